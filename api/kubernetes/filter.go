@@ -5,6 +5,8 @@ import (
 	"statusbay/api/httpparameters"
 	"strconv"
 	"strings"
+
+	"github.com/gorilla/mux"
 )
 
 type FilterApplications struct {
@@ -21,6 +23,8 @@ type FilterApplications struct {
 	From          int
 	To            int
 	Distinct      bool
+	Rollback      *bool
+	Superseded    *bool
 }
 
 // FilterApplication Filter application by specific filters
@@ -40,6 +44,18 @@ func FilterApplication(req *http.Request) FilterApplications {
 	to, _ := strconv.Atoi(httpparameters.QueryParamWithDefault(req, "to", "0"))
 	distinct, _ := strconv.ParseBool(httpparameters.QueryParamWithDefault(req, "distinct", "false"))
 
+	// Without rollback filter, both rollbacks and regular applies are returned
+	var rollback *bool
+	if isRollback, err := strconv.ParseBool(httpparameters.QueryParamWithDefault(req, "rollback", "")); err == nil {
+		rollback = &isRollback
+	}
+
+	// Without superseded filter, the applies that were replaced by a newer apply are returned with the other applies
+	var superseded *bool
+	if isSuperseded, err := strconv.ParseBool(httpparameters.QueryParamWithDefault(req, "superseded", "")); err == nil {
+		superseded = &isSuperseded
+	}
+
 	return FilterApplications{
 		Limit:         limit,
 		Offset:        offset,
@@ -54,5 +70,38 @@ func FilterApplication(req *http.Request) FilterApplications {
 		From:          from,
 		To:            to,
 		Distinct:      distinct,
+		Rollback:      rollback,
+		Superseded:    superseded,
+	}
+}
+
+type FilterScaleEvents struct {
+	Offset     int
+	Limit      int
+	Name       string
+	Clusters   []string
+	Namespaces []string
+	From       int
+	To         int
+}
+
+// FilterScaleEvent Filter the scaling history of an application by specific filters
+func FilterScaleEvent(req *http.Request) FilterScaleEvents {
+
+	offset, _ := strconv.Atoi(httpparameters.QueryParamWithDefault(req, "offset", "0"))
+	limit, _ := strconv.Atoi(httpparameters.QueryParamWithDefault(req, "limit", "20"))
+	cluster := httpparameters.QueryParamWithDefault(req, "cluster", "")
+	namespace := httpparameters.QueryParamWithDefault(req, "namespace", "")
+	from, _ := strconv.Atoi(httpparameters.QueryParamWithDefault(req, "from", "0"))
+	to, _ := strconv.Atoi(httpparameters.QueryParamWithDefault(req, "to", "0"))
+
+	return FilterScaleEvents{
+		Offset:     offset,
+		Limit:      limit,
+		Name:       mux.Vars(req)["name"],
+		Clusters:   strings.Split(cluster, ","),
+		Namespaces: strings.Split(namespace, ","),
+		From:       from,
+		To:         to,
 	}
 }
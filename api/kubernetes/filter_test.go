@@ -60,3 +60,49 @@ func TestFilterApplication(t *testing.T) {
 	})
 
 }
+
+func TestFilterApplicationRollback(t *testing.T) {
+
+	testCases := []struct {
+		query    string
+		expected *bool
+	}{
+		{"", nil},
+		{"?rollback=true", func() *bool { v := true; return &v }()},
+		{"?rollback=false", func() *bool { v := false; return &v }()},
+		{"?rollback=invalid", nil},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.query, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", fmt.Sprintf("127.0.0.1%s", test.query), nil)
+			filters := kubernetes.FilterApplication(req)
+			if !reflect.DeepEqual(filters.Rollback, test.expected) {
+				t.Fatalf("unexpected rollback filter, got %v expected %v", filters.Rollback, test.expected)
+			}
+		})
+	}
+}
+
+func TestFilterApplicationSuperseded(t *testing.T) {
+
+	testCases := []struct {
+		query    string
+		expected *bool
+	}{
+		{"", nil},
+		{"?superseded=true", func() *bool { v := true; return &v }()},
+		{"?superseded=false", func() *bool { v := false; return &v }()},
+		{"?superseded=invalid", nil},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.query, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", fmt.Sprintf("127.0.0.1%s", test.query), nil)
+			filters := kubernetes.FilterApplication(req)
+			if !reflect.DeepEqual(filters.Superseded, test.expected) {
+				t.Fatalf("unexpected superseded filter, got %v expected %v", filters.Superseded, test.expected)
+			}
+		})
+	}
+}
@@ -27,6 +27,8 @@ func MarkApplicationDeploymentEvents(appDeployment *ResponseDeploymentData, even
 
 		services(deployment.Services, eventMarksConfig)
 
+		horizontalPodAutoscalers(deployment.HorizontalPodAutoscalers, eventMarksConfig)
+
 	}
 
 	for _, daemonset := range appDeployment.Resources.Daemonsets {
@@ -53,6 +55,41 @@ func MarkApplicationDeploymentEvents(appDeployment *ResponseDeploymentData, even
 
 		services(statefulset.Services, eventMarksConfig)
 
+		horizontalPodAutoscalers(statefulset.HorizontalPodAutoscalers, eventMarksConfig)
+
+	}
+
+	for _, job := range appDeployment.Resources.Jobs {
+
+		for i, dep := range job.Events {
+			eventDescription := eventmark.MarkEvent(dep.Message, eventMarksConfig.Job)
+			job.Events[i].MarkDescriptions = eventDescription
+		}
+
+		pods(job.Pods, eventMarksConfig)
+
+	}
+
+	for _, customResource := range appDeployment.Resources.CustomResources {
+
+		for i, dep := range customResource.Events {
+			eventDescription := eventmark.MarkEvent(dep.Message, eventMarksConfig.CustomResource)
+			customResource.Events[i].MarkDescriptions = eventDescription
+		}
+
+		pods(customResource.Pods, eventMarksConfig)
+
+		services(customResource.Services, eventMarksConfig)
+
+	}
+
+	for _, trafficSwitch := range appDeployment.Resources.TrafficSwitches {
+
+		for i, dep := range trafficSwitch.Events {
+			eventDescription := eventmark.MarkEvent(dep.Message, eventMarksConfig.Service)
+			trafficSwitch.Events[i].MarkDescriptions = eventDescription
+		}
+
 	}
 }
 
@@ -85,3 +122,15 @@ func services(service map[string]ResponseServicesData, events config.KubernetesM
 	}
 
 }
+
+// horizontalPodAutoscalers will mark horizontal pod autoscaler messages from horizontal pod autoscaler event section
+func horizontalPodAutoscalers(hpas map[string]ResponseHorizontalPodAutoscalerData, events config.KubernetesMarksEvents) {
+
+	for _, hpa := range hpas {
+		for i, event := range hpa.Events {
+			eventDescription := eventmark.MarkEvent(event.Message, events.Hpa)
+			hpa.Events[i].MarkDescriptions = eventDescription
+		}
+	}
+
+}
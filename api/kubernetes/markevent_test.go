@@ -31,6 +31,9 @@ func TestMarkApplicationDeploymentEvents(t *testing.T) {
 	searchStatefulsetEvents := []config.EventMarksConfig{
 		{Pattern: "statefulset error", Descriptions: []string{"error 1"}},
 	}
+	searchHpaEvents := []config.EventMarksConfig{
+		{Pattern: "unable to get metrics", Descriptions: []string{"hpa error 1"}},
+	}
 
 	// messages content
 	podEvent := map[string]ResponseDeploymenPod{
@@ -57,6 +60,15 @@ func TestMarkApplicationDeploymentEvents(t *testing.T) {
 		},
 	}
 
+	hpa := map[string]ResponseHorizontalPodAutoscalerData{
+		"hpa": {
+			Events: []ResponseEventMessages{
+				{Message: "failed to get cpu utilization: unable to get metrics for resource cpu"},
+				{Message: "New size: 4"},
+			},
+		},
+	}
+
 	applyData := ResponseDeploymentData{
 		Resources: ResponseResourcesData{
 			Statefulsets: map[string]StatefulsetDataResponse{
@@ -64,8 +76,9 @@ func TestMarkApplicationDeploymentEvents(t *testing.T) {
 					Events: []ResponseEventMessages{
 						{Message: "statefulset error"},
 					},
-					Pods:     podEvent,
-					Services: service,
+					Pods:                     podEvent,
+					Services:                 service,
+					HorizontalPodAutoscalers: hpa,
 				},
 			},
 			Daemonsets: map[string]DaemonsetDataResponse{
@@ -92,7 +105,8 @@ func TestMarkApplicationDeploymentEvents(t *testing.T) {
 							},
 						},
 					},
-					Services: service,
+					Services:                 service,
+					HorizontalPodAutoscalers: hpa,
 				},
 			},
 		},
@@ -106,6 +120,7 @@ func TestMarkApplicationDeploymentEvents(t *testing.T) {
 		Service:     searchServiceEvents,
 		Demonset:    searchDaemonsetEvents,
 		Statefulset: searchStatefulsetEvents,
+		Hpa:         searchHpaEvents,
 	}
 	MarkApplicationDeploymentEvents(&applyData, eventsConfig)
 
@@ -149,6 +164,13 @@ func TestMarkApplicationDeploymentEvents(t *testing.T) {
 			},
 			1,
 		},
+		{
+			"deployment hpa",
+			func(d ResponseDeploymentData) []ResponseEventMessages {
+				return d.Resources.Deployments["deployment"].HorizontalPodAutoscalers["hpa"].Events
+			},
+			1,
+		},
 		{
 			"daemonset",
 			func(d ResponseDeploymentData) []ResponseEventMessages {
@@ -205,6 +227,13 @@ func TestMarkApplicationDeploymentEvents(t *testing.T) {
 			},
 			2,
 		},
+		{
+			"statefulset hpa",
+			func(d ResponseDeploymentData) []ResponseEventMessages {
+				return d.Resources.Statefulsets["statefulset"].HorizontalPodAutoscalers["hpa"].Events
+			},
+			1,
+		},
 	}
 
 	for _, ct := range testCases {
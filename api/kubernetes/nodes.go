@@ -0,0 +1,80 @@
+package kubernetes
+
+import (
+	"sort"
+)
+
+const (
+	// daemonsetNodeReady is the rollout state of a node that its daemonset pod is ready
+	daemonsetNodeReady = "ready"
+
+	// daemonsetNodePending is the rollout state of a node that its daemonset pod didn't start yet
+	daemonsetNodePending = "pending"
+
+	// daemonsetNodeFailing is the rollout state of a node that its daemonset pod is failing
+	daemonsetNodeFailing = "failing"
+)
+
+// daemonsetNodeStateRank orders the nodes that are stuck first
+var daemonsetNodeStateRank = map[string]int{
+	daemonsetNodeFailing: 0,
+	daemonsetNodePending: 1,
+	daemonsetNodeReady:   2,
+}
+
+// DaemonsetNodes returns the per node rollout of the apply daemonsets, filtered by the given states when the list
+// is not empty. The failing and pending nodes are first, then the nodes that were not updated
+func DaemonsetNodes(resources ResponseResourcesData, states []string) ([]ResponseDaemonsetNodeRow, ResponseDaemonsetNodesSummary) {
+	rows := []ResponseDaemonsetNodeRow{}
+	summary := ResponseDaemonsetNodesSummary{}
+
+	for daemonsetName, daemonset := range resources.Daemonsets {
+		for nodeName, node := range daemonset.Nodes {
+			summary.Total++
+			if node.Updated {
+				summary.Updated++
+			}
+			switch node.State {
+			case daemonsetNodeReady:
+				summary.Ready++
+			case daemonsetNodePending:
+				summary.Pending++
+			case daemonsetNodeFailing:
+				summary.Failing++
+			}
+
+			if len(states) > 0 && !containsString(states, node.State) {
+				continue
+			}
+			rows = append(rows, ResponseDaemonsetNodeRow{
+				Daemonset: daemonsetName,
+				Node:      nodeName,
+				Rollout:   node,
+			})
+		}
+	}
+
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Rollout.State != rows[j].Rollout.State {
+			return daemonsetNodeStateRank[rows[i].Rollout.State] < daemonsetNodeStateRank[rows[j].Rollout.State]
+		}
+		if rows[i].Rollout.Updated != rows[j].Rollout.Updated {
+			return !rows[i].Rollout.Updated
+		}
+		if rows[i].Daemonset != rows[j].Daemonset {
+			return rows[i].Daemonset < rows[j].Daemonset
+		}
+		return rows[i].Node < rows[j].Node
+	})
+	return rows, summary
+}
+
+// containsString returns true when the value exists in the list
+func containsString(values []string, value string) bool {
+	for _, existing := range values {
+		if existing == value {
+			return true
+		}
+	}
+	return false
+}
@@ -19,6 +19,7 @@ type ResponseKubernetesApplications struct {
 	Namespace string `json:"Namespace"`
 	DeployBy  string `json:"DeployBy"`
 	Time      int64  `json:"Time"`
+	Rollback  bool   `json:"Rollback"`
 }
 
 type ResponseMetaData struct {
@@ -29,13 +30,77 @@ type ResponseMetaData struct {
 	DesiredState int32             `json:"DesiredState"`
 	Alerts       []ResponseAlerts  `json:"Alerts"`
 	Metrics      []ResponseMetrics `json:"Metrics"`
+
+	SuccessCriteria *ResponseSuccessCriteria `json:"SuccessCriteria"`
+}
+
+// ResponseSuccessCriteria describe when the pods of a resource are considered as available for the apply to succeed
+type ResponseSuccessCriteria struct {
+	MinReadySeconds     int64    `json:"MinReadySeconds"`
+	MinAvailablePercent int32    `json:"MinAvailablePercent"`
+	IgnoreContainers    []string `json:"IgnoreContainers"`
+	MaxRestarts         *int32   `json:"MaxRestarts"`
 }
 
 type ResponseDeploymenPod struct {
 	Phase             *string                            `json:"Phase"`
 	CreationTimestamp time.Time                          `json:"CreationTimestamp"`
+	NodeName          string                             `json:"NodeName"`
+	PodIP             string                             `json:"PodIP"`
+	QOSClass          string                             `json:"QOSClass"`
 	Events            []ResponseEventMessages            `json:"Events"`
 	PVC               map[string][]ResponseEventMessages `json:"Pvcs"`
+	Logs              map[string]ResponseContainerLogs   `json:"Logs"`
+	Timeline          *ResponsePodTimeline               `json:"Timeline"`
+	Containers        []ResponsePodContainerStatus       `json:"Containers"`
+}
+
+// ResponsePodContainerStatus describes the latest state of an init, regular or ephemeral pod container
+type ResponsePodContainerStatus struct {
+	Name         string `json:"Name"`
+	Type         string `json:"Type"`
+	Image        string `json:"Image"`
+	ImageID      string `json:"ImageID"`
+	State        string `json:"State"`
+	Reason       string `json:"Reason"`
+	Message      string `json:"Message"`
+	ExitCode     int32  `json:"ExitCode"`
+	RestartCount int32  `json:"RestartCount"`
+	Ready        bool   `json:"Ready"`
+	StartedAt    int64  `json:"StartedAt"`
+
+	LastTermination *ResponseContainerTermination `json:"LastTermination"`
+}
+
+// ResponseContainerTermination describes how the previous run of a restarted container ended
+type ResponseContainerTermination struct {
+	Reason     string `json:"Reason"`
+	ExitCode   int32  `json:"ExitCode"`
+	Signal     int32  `json:"Signal"`
+	StartedAt  int64  `json:"StartedAt"`
+	FinishedAt int64  `json:"FinishedAt"`
+}
+
+// ResponsePodTimeline holds the times (unix nano) that the pod reached each readiness step, 0 when the step was not reached
+type ResponsePodTimeline struct {
+	Created         int64 `json:"Created"`
+	Scheduled       int64 `json:"Scheduled"`
+	ImagePulled     int64 `json:"ImagePulled"`
+	Initialized     int64 `json:"Initialized"`
+	ContainersReady int64 `json:"ContainersReady"`
+	Ready           int64 `json:"Ready"`
+	Terminated      int64 `json:"Terminated"`
+}
+
+// ResponseContainerLogs is the redacted log tail of a container that terminated with an error or is crash-looping
+type ResponseContainerLogs struct {
+	Reason       string `json:"Reason"`
+	ExitCode     int32  `json:"ExitCode"`
+	RestartCount int32  `json:"RestartCount"`
+	Current      string `json:"Current"`
+	Previous     string `json:"Previous"`
+	Error        string `json:"Error"`
+	Time         int64  `json:"Time"`
 }
 
 type ResponseEventMessages struct {
@@ -43,6 +108,11 @@ type ResponseEventMessages struct {
 	Time                int64    `json:"Time"`
 	Action              string   `json:"Action"`
 	ReportingController string   `json:"ReportingController"`
+	Reason              string   `json:"Reason"`
+	Type                string   `json:"Type"`
+	Count               int32    `json:"Count"`
+	FirstTimestamp      int64    `json:"FirstTimestamp"`
+	LastTimestamp       int64    `json:"LastTimestamp"`
 	MarkDescriptions    []string `json:"MarkDescriptions"`
 }
 
@@ -52,8 +122,54 @@ type ResponseReplicaset struct {
 }
 
 type ResponseServicesData struct {
+	Events          []ResponseEventMessages         `json:"Events"`
+	Endpoints       []ResponseEndpointsReadiness    `json:"Endpoints"`
+	Ingresses       map[string]ResponseIngressData  `json:"Ingresses"`
+	SelectorChanges []ResponseServiceSelectorChange `json:"SelectorChanges"`
+}
+
+// ResponseServiceSelectorChange describes a change of the service selector and the workload that the service targets after the change
+type ResponseServiceSelectorChange struct {
+	Time             int64             `json:"Time"`
+	PreviousSelector map[string]string `json:"PreviousSelector"`
+	Selector         map[string]string `json:"Selector"`
+	Workload         string            `json:"Workload"`
+	Pods             []string          `json:"Pods"`
+	EndpointsFlipped int64             `json:"EndpointsFlipped"`
+}
+
+// ResponseEndpointsReadiness is a sample of the service endpoints ready and not ready addresses counts
+type ResponseEndpointsReadiness struct {
+	Time     int64 `json:"Time"`
+	Ready    int   `json:"Ready"`
+	NotReady int   `json:"NotReady"`
+}
+
+// ResponseIngressData describes an ingress that routes to the service
+type ResponseIngressData struct {
 	Events []ResponseEventMessages `json:"Events"`
 }
+
+// ResponseHorizontalPodAutoscalerData describes a horizontal pod autoscaler that scales the applied resource
+type ResponseHorizontalPodAutoscalerData struct {
+	MinReplicas     *int32                                     `json:"MinReplicas"`
+	MaxReplicas     int32                                      `json:"MaxReplicas"`
+	CurrentReplicas int32                                      `json:"CurrentReplicas"`
+	DesiredReplicas int32                                      `json:"DesiredReplicas"`
+	LastScaleTime   int64                                      `json:"LastScaleTime"`
+	Conditions      []ResponseHorizontalPodAutoscalerCondition `json:"Conditions"`
+	Events          []ResponseEventMessages                    `json:"Events"`
+}
+
+// ResponseHorizontalPodAutoscalerCondition describes the latest state of a horizontal pod autoscaler condition
+type ResponseHorizontalPodAutoscalerCondition struct {
+	Type               string `json:"Type"`
+	Status             string `json:"Status"`
+	Reason             string `json:"Reason"`
+	Message            string `json:"Message"`
+	LastTransitionTime int64  `json:"LastTransitionTime"`
+}
+
 type ResponseMetricsQuery struct {
 	Query    string `json:"Query"`
 	Title    string `json:"Title"`
@@ -85,14 +201,30 @@ type DeploymentDataResponse struct {
 	Replicaset map[string]ResponseReplicaset   `json:"Replicaset"`
 	Status     ResponseDeploymentStatus        `json:"Status"`
 	Services   map[string]ResponseServicesData `json:"Services"`
+
+	HorizontalPodAutoscalers map[string]ResponseHorizontalPodAutoscalerData `json:"HorizontalPodAutoscalers"`
 }
 
 type DaemonsetDataResponse struct {
-	Metadata ResponseMetaData                `json:"MetaData"`
-	Events   []ResponseEventMessages         `json:"Events"`
-	Pods     map[string]ResponseDeploymenPod `json:"Pods"`
-	Status   ResponseDeploymentStatus        `json:"Status"`
-	Services map[string]ResponseServicesData `json:"Services"`
+	Metadata       ResponseMetaData                 `json:"MetaData"`
+	Events         []ResponseEventMessages          `json:"Events"`
+	Pods           map[string]ResponseDeploymenPod  `json:"Pods"`
+	Status         ResponseDeploymentStatus         `json:"Status"`
+	Services       map[string]ResponseServicesData  `json:"Services"`
+	UpdateStrategy string                           `json:"UpdateStrategy"`
+	Nodes          map[string]ResponseDaemonsetNode `json:"Nodes"`
+}
+
+// ResponseDaemonsetNode describes the rollout of a daemonset on a single node
+type ResponseDaemonsetNode struct {
+	Pod        string   `json:"Pod"`
+	Updated    bool     `json:"Updated"`
+	State      string   `json:"State"`
+	Reason     string   `json:"Reason"`
+	Time       int64    `json:"Time"`
+	Cordoned   bool     `json:"Cordoned"`
+	Conditions []string `json:"Conditions"`
+	Taints     []string `json:"Taints"`
 }
 
 type StatefulsetDataResponse struct {
@@ -101,16 +233,99 @@ type StatefulsetDataResponse struct {
 	Pods        map[string]ResponseDeploymenPod `json:"Pods"`
 	Status      ResponseDeploymentStatus        `json:"Status"`
 	Services    map[string]ResponseServicesData `json:"Services"`
+
+	UpdateStrategy string `json:"UpdateStrategy"`
+	Partition      int32  `json:"Partition"`
+
+	HorizontalPodAutoscalers map[string]ResponseHorizontalPodAutoscalerData `json:"HorizontalPodAutoscalers"`
+}
+
+type ResponseJobStatus struct {
+	Active     int32               `json:"Active"`
+	Succeeded  int32               `json:"Succeeded"`
+	Failed     int32               `json:"Failed"`
+	Conditions []ResponseCondition `json:"Conditions"`
+}
+
+type JobDataResponse struct {
+	Metadata     ResponseMetaData                `json:"MetaData"`
+	Events       []ResponseEventMessages         `json:"Events"`
+	Pods         map[string]ResponseDeploymenPod `json:"Pods"`
+	Status       ResponseJobStatus               `json:"Status"`
+	BackoffLimit int32                           `json:"BackoffLimit"`
+	WorkQueue    bool                            `json:"WorkQueue"`
+}
+
+type CustomResourceDataResponse struct {
+	Metadata ResponseMetaData                `json:"MetaData"`
+	Kind     string                          `json:"Kind"`
+	Phase    string                          `json:"Phase"`
+	Status   map[string]interface{}          `json:"Status"`
+	Events   []ResponseEventMessages         `json:"Events"`
+	Pods     map[string]ResponseDeploymenPod `json:"Pods"`
+	Services map[string]ResponseServicesData `json:"Services"`
+}
+
+// TrafficSwitchDataResponse describes a service selector change that was applied on its own
+type TrafficSwitchDataResponse struct {
+	Metadata  ResponseMetaData              `json:"MetaData"`
+	Switch    ResponseServiceSelectorChange `json:"Switch"`
+	Events    []ResponseEventMessages       `json:"Events"`
+	Endpoints []ResponseEndpointsReadiness  `json:"Endpoints"`
 }
 
 type ResponseResourcesData struct {
-	Deployments  map[string]DeploymentDataResponse  `json:"Deployments"`
-	Daemonsets   map[string]DaemonsetDataResponse   `json:"Daemonsets"`
-	Statefulsets map[string]StatefulsetDataResponse `json:"Statefulsets"`
+	Deployments     map[string]DeploymentDataResponse     `json:"Deployments"`
+	Daemonsets      map[string]DaemonsetDataResponse      `json:"Daemonsets"`
+	Statefulsets    map[string]StatefulsetDataResponse    `json:"Statefulsets"`
+	Jobs            map[string]JobDataResponse            `json:"Jobs"`
+	CustomResources map[string]CustomResourceDataResponse `json:"CustomResources"`
+	TrafficSwitches map[string]TrafficSwitchDataResponse  `json:"TrafficSwitches"`
+}
+
+// ResponseWatchHealth describes the health of the apply watches
+type ResponseWatchHealth struct {
+	Restarts        int    `json:"Restarts"`
+	Overflows       int    `json:"Overflows"`
+	Incomplete      bool   `json:"Incomplete"`
+	LastError       string `json:"LastError"`
+	LastRestartTime int64  `json:"LastRestartTime"`
 }
 
 type ResponseDeploymentData struct {
-	Resources ResponseResourcesData `json:"Resources"`
+	Resources     ResponseResourcesData  `json:"Resources"`
+	WatchHealth   *ResponseWatchHealth   `json:"WatchHealth"`
+	Rollback      *ResponseRollback      `json:"Rollback"`
+	SpecDiffs     []ResponseSpecDiff     `json:"SpecDiffs"`
+	FailureCauses []ResponseFailureCause `json:"FailureCauses"`
+	RolloutPhases []ResponseRolloutPhase `json:"RolloutPhases"`
+	Supersedes    string                 `json:"Supersedes"`
+	SupersededBy  string                 `json:"SupersededBy"`
+}
+
+// ResponseRolloutPhase describes how long the apply took to reach a rollout milestone
+type ResponseRolloutPhase struct {
+	Name            string  `json:"Name"`
+	Start           int64   `json:"Start"`
+	End             int64   `json:"End"`
+	DurationSeconds float64 `json:"DurationSeconds"`
+}
+
+// ResponseFailureCause describes a detected cause of a failed apply, ranked from the most likely root cause
+type ResponseFailureCause struct {
+	Reason      string   `json:"Reason"`
+	Description string   `json:"Description"`
+	Details     []string `json:"Details"`
+	Message     string   `json:"Message"`
+	Pods        []string `json:"Pods"`
+	Resources   []string `json:"Resources"`
+}
+
+// ResponseRollback describes an apply that restored the pod template of an earlier revision
+type ResponseRollback struct {
+	Kind         string `json:"Kind"`
+	ResourceName string `json:"ResourceName"`
+	Revision     int64  `json:"Revision"`
 }
 
 type ResponseKubernetesDeployment struct {
@@ -134,6 +349,109 @@ type ResponseMetrics struct {
 	Provider string `json:"Provider"`
 }
 
+// ResponseKubernetesScaleEvent describes a scale of an application resource without a spec change
+type ResponseKubernetesScaleEvent struct {
+	Cluster      string `json:"Cluster"`
+	Namespace    string `json:"Namespace"`
+	Kind         string `json:"Kind"`
+	ResourceName string `json:"ResourceName"`
+	FromReplicas int32  `json:"FromReplicas"`
+	ToReplicas   int32  `json:"ToReplicas"`
+	Time         int64  `json:"Time"`
+}
+
+// ResponseSpecDiff describes the changes of a resource spec against the previous version
+type ResponseSpecDiff struct {
+	Kind         string               `json:"Kind"`
+	ResourceName string               `json:"ResourceName"`
+	Changes      []ResponseSpecChange `json:"Changes"`
+}
+
+// ResponseSpecChange describes a single change of a resource spec, the environment variables values are redacted
+type ResponseSpecChange struct {
+	Type      string `json:"Type"`
+	Action    string `json:"Action"`
+	Container string `json:"Container,omitempty"`
+	Name      string `json:"Name"`
+	Previous  string `json:"Previous"`
+	Current   string `json:"Current"`
+}
+
+// ResponseKubernetesSpecDiff describes the spec diff of an apply
+type ResponseKubernetesSpecDiff struct {
+	Name      string             `json:"Name"`
+	Cluster   string             `json:"Cluster"`
+	Namespace string             `json:"Namespace"`
+	Time      int64              `json:"Time"`
+	SpecDiffs []ResponseSpecDiff `json:"SpecDiffs"`
+}
+
+// ResponsePodTimelineRow is a row of the apply timeline, the readiness steps of a single pod
+type ResponsePodTimelineRow struct {
+	Kind         string              `json:"Kind"`
+	ResourceName string              `json:"ResourceName"`
+	Pod          string              `json:"Pod"`
+	Timeline     ResponsePodTimeline `json:"Timeline"`
+}
+
+// ResponseKubernetesTimeline describes the rollout phases and the pods readiness steps of an apply
+type ResponseKubernetesTimeline struct {
+	Name      string                   `json:"Name"`
+	Cluster   string                   `json:"Cluster"`
+	Namespace string                   `json:"Namespace"`
+	Time      int64                    `json:"Time"`
+	Phases    []ResponseRolloutPhase   `json:"Phases"`
+	Pods      []ResponsePodTimelineRow `json:"Pods"`
+}
+
+// ResponseWarningEvent is a warning event of one of the apply objects
+type ResponseWarningEvent struct {
+	Kind           string `json:"Kind"`
+	ResourceName   string `json:"ResourceName"`
+	Object         string `json:"Object"`
+	ObjectName     string `json:"ObjectName"`
+	Reason         string `json:"Reason"`
+	Message        string `json:"Message"`
+	Count          int32  `json:"Count"`
+	FirstTimestamp int64  `json:"FirstTimestamp"`
+	LastTimestamp  int64  `json:"LastTimestamp"`
+}
+
+// ResponseKubernetesWarnings describes the warning events of an apply
+type ResponseKubernetesWarnings struct {
+	Name      string                 `json:"Name"`
+	Cluster   string                 `json:"Cluster"`
+	Namespace string                 `json:"Namespace"`
+	Time      int64                  `json:"Time"`
+	Warnings  []ResponseWarningEvent `json:"Warnings"`
+}
+
+// ResponseDaemonsetNodeRow is the rollout state of a daemonset on a single node
+type ResponseDaemonsetNodeRow struct {
+	Daemonset string                `json:"Daemonset"`
+	Node      string                `json:"Node"`
+	Rollout   ResponseDaemonsetNode `json:"Rollout"`
+}
+
+// ResponseDaemonsetNodesSummary counts the nodes of the apply daemonsets by their rollout state
+type ResponseDaemonsetNodesSummary struct {
+	Total   int `json:"Total"`
+	Updated int `json:"Updated"`
+	Ready   int `json:"Ready"`
+	Pending int `json:"Pending"`
+	Failing int `json:"Failing"`
+}
+
+// ResponseKubernetesDaemonsetNodes describes the per node rollout of the apply daemonsets
+type ResponseKubernetesDaemonsetNodes struct {
+	Name      string                        `json:"Name"`
+	Cluster   string                        `json:"Cluster"`
+	Namespace string                        `json:"Namespace"`
+	Time      int64                         `json:"Time"`
+	Summary   ResponseDaemonsetNodesSummary `json:"Summary"`
+	Nodes     []ResponseDaemonsetNodeRow    `json:"Nodes"`
+}
+
 // END Kubernetes deployment response
 
 type PeriodsResponse struct {
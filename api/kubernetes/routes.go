@@ -5,9 +5,11 @@ import (
 	"errors"
 	"net/http"
 	"net/url"
+	"sort"
 	"statusbay/api/httpresponse"
 	"statusbay/config"
 	"statusbay/state"
+	"strings"
 
 	"github.com/gorilla/mux"
 	log "github.com/sirupsen/logrus"
@@ -34,7 +36,12 @@ func NewKubernetesRoutes(storage Storage, router *mux.Router, eventsConfig confi
 func (kr *RouterKubernetesManager) bindEndpoints() {
 	kr.router.HandleFunc("/api/v1/kubernetes/applications", kr.Applications).Methods("GET")
 	kr.router.HandleFunc("/api/v1/kubernetes/applications/values/{column}", kr.ApplicationsColumnValues).Methods("GET")
+	kr.router.HandleFunc("/api/v1/kubernetes/applications/{name}/scales", kr.ScaleEvents).Methods("GET")
 	kr.router.HandleFunc("/api/v1/kubernetes/application/{apply_id}", kr.GetDeployment).Methods("GET")
+	kr.router.HandleFunc("/api/v1/kubernetes/application/{apply_id}/diff", kr.GetSpecDiff).Methods("GET")
+	kr.router.HandleFunc("/api/v1/kubernetes/application/{apply_id}/timeline", kr.GetTimeline).Methods("GET")
+	kr.router.HandleFunc("/api/v1/kubernetes/application/{apply_id}/warnings", kr.GetWarnings).Methods("GET")
+	kr.router.HandleFunc("/api/v1/kubernetes/application/{apply_id}/nodes", kr.GetDaemonsetNodes).Methods("GET")
 }
 
 //Applications returns a list of applied application.
@@ -78,6 +85,7 @@ func (route *RouterKubernetesManager) Applications(resp http.ResponseWriter, req
 			DeployBy:  row.DeployBy,
 			Status:    row.Status,
 			Time:      row.Time,
+			Rollback:  row.Rollback,
 		})
 
 	}
@@ -129,6 +137,34 @@ func (route *RouterKubernetesManager) ApplicationsColumnValues(resp http.Respons
 	httpresponse.JSONWrite(resp, http.StatusOK, values)
 }
 
+//ScaleEvents returns the scaling history of an application.
+func (route *RouterKubernetesManager) ScaleEvents(resp http.ResponseWriter, req *http.Request) {
+
+	queryFilter := FilterScaleEvent(req)
+
+	rows, err := route.storage.ScaleEvents(queryFilter)
+	if err != nil {
+		log.WithError(err).WithField("name", queryFilter.Name).Error("could not return scale events")
+		httpresponse.JSONWrite(resp, http.StatusNotFound, httpresponse.HTTPErrorResponse{Error: "Could not return scale events"})
+		return
+	}
+
+	response := []ResponseKubernetesScaleEvent{}
+	for _, row := range *rows {
+		response = append(response, ResponseKubernetesScaleEvent{
+			Cluster:      row.Cluster,
+			Namespace:    row.Namespace,
+			Kind:         row.Kind,
+			ResourceName: row.ResourceName,
+			FromReplicas: row.FromReplicas,
+			ToReplicas:   row.ToReplicas,
+			Time:         row.Time,
+		})
+	}
+
+	httpresponse.JSONWrite(resp, http.StatusOK, response)
+}
+
 //GetDeployment returns a specific deployment details.
 func (route *RouterKubernetesManager) GetDeployment(resp http.ResponseWriter, req *http.Request) {
 
@@ -166,3 +202,165 @@ func (route *RouterKubernetesManager) GetDeployment(resp http.ResponseWriter, re
 	httpresponse.JSONWrite(resp, http.StatusOK, response)
 
 }
+
+// loadApplyDetails returns the apply of the request apply id and its parsed details. When the apply was not found or
+// its details could not be parsed, the error response is written and false is returned
+func (route *RouterKubernetesManager) loadApplyDetails(resp http.ResponseWriter, req *http.Request) (state.TableKubernetes, ResponseDeploymentData, bool) {
+
+	params := mux.Vars(req)
+	applyID := params["apply_id"]
+
+	var details ResponseDeploymentData
+	deployment, err := route.storage.GetDeployment(applyID)
+	if err != nil {
+		log.WithField("apply_id", applyID).Error("deployment not found")
+		httpresponse.JSONError(resp, http.StatusNotFound, errors.New("Deployment not found"))
+		return deployment, details, false
+	}
+
+	err = json.Unmarshal([]byte(deployment.Details), &details)
+	if err != nil {
+		log.WithError(err).WithField("apply_id", applyID).Error("could not parse deployment details")
+		httpresponse.JSONError(resp, http.StatusNotFound, errors.New("Could not parse deployment detail"))
+		return deployment, details, false
+	}
+	return deployment, details, true
+}
+
+//GetSpecDiff returns the spec changes of a specific deployment against the previous version.
+func (route *RouterKubernetesManager) GetSpecDiff(resp http.ResponseWriter, req *http.Request) {
+
+	deployment, details, found := route.loadApplyDetails(resp, req)
+	if !found {
+		return
+	}
+
+	// Applies that were saved before the spec diff was recorded don't have a diff
+	specDiffs := details.SpecDiffs
+	if specDiffs == nil {
+		specDiffs = []ResponseSpecDiff{}
+	}
+
+	response := ResponseKubernetesSpecDiff{
+		Name:      deployment.Name,
+		Cluster:   deployment.Cluster,
+		Namespace: deployment.Namespace,
+		Time:      deployment.Time,
+		SpecDiffs: specDiffs,
+	}
+
+	httpresponse.JSONWrite(resp, http.StatusOK, response)
+}
+
+//GetTimeline returns the rollout phases and the readiness steps of each pod of a specific deployment.
+func (route *RouterKubernetesManager) GetTimeline(resp http.ResponseWriter, req *http.Request) {
+
+	deployment, details, found := route.loadApplyDetails(resp, req)
+	if !found {
+		return
+	}
+
+	// Applies that were saved before the timeline was recorded don't have rollout phases
+	phases := details.RolloutPhases
+	if phases == nil {
+		phases = []ResponseRolloutPhase{}
+	}
+
+	response := ResponseKubernetesTimeline{
+		Name:      deployment.Name,
+		Cluster:   deployment.Cluster,
+		Namespace: deployment.Namespace,
+		Time:      deployment.Time,
+		Phases:    phases,
+		Pods:      timelineRows(details.Resources),
+	}
+
+	httpresponse.JSONWrite(resp, http.StatusOK, response)
+}
+
+//GetWarnings returns the warning events of a specific deployment, the latest warning first.
+func (route *RouterKubernetesManager) GetWarnings(resp http.ResponseWriter, req *http.Request) {
+
+	deployment, details, found := route.loadApplyDetails(resp, req)
+	if !found {
+		return
+	}
+
+	response := ResponseKubernetesWarnings{
+		Name:      deployment.Name,
+		Cluster:   deployment.Cluster,
+		Namespace: deployment.Namespace,
+		Time:      deployment.Time,
+		Warnings:  WarningEvents(details.Resources),
+	}
+
+	httpresponse.JSONWrite(resp, http.StatusOK, response)
+}
+
+//GetDaemonsetNodes returns the per node rollout of the daemonsets of a specific deployment, the stuck nodes first.
+func (route *RouterKubernetesManager) GetDaemonsetNodes(resp http.ResponseWriter, req *http.Request) {
+
+	deployment, details, found := route.loadApplyDetails(resp, req)
+	if !found {
+		return
+	}
+
+	states := []string{}
+	if state := req.URL.Query().Get("state"); state != "" {
+		states = strings.Split(state, ",")
+	}
+	nodes, summary := DaemonsetNodes(details.Resources, states)
+
+	response := ResponseKubernetesDaemonsetNodes{
+		Name:      deployment.Name,
+		Cluster:   deployment.Cluster,
+		Namespace: deployment.Namespace,
+		Time:      deployment.Time,
+		Summary:   summary,
+		Nodes:     nodes,
+	}
+
+	httpresponse.JSONWrite(resp, http.StatusOK, response)
+}
+
+// timelineRows returns the pods timeline of all the apply resources, sorted by the pod creation time
+func timelineRows(resources ResponseResourcesData) []ResponsePodTimelineRow {
+	rows := []ResponsePodTimelineRow{}
+	addPods := func(kind, resourceName string, pods map[string]ResponseDeploymenPod) {
+		for podName, pod := range pods {
+			if pod.Timeline == nil {
+				continue
+			}
+			rows = append(rows, ResponsePodTimelineRow{
+				Kind:         kind,
+				ResourceName: resourceName,
+				Pod:          podName,
+				Timeline:     *pod.Timeline,
+			})
+		}
+	}
+
+	for name, deployment := range resources.Deployments {
+		addPods("deployment", name, deployment.Pods)
+	}
+	for name, daemonset := range resources.Daemonsets {
+		addPods("daemonset", name, daemonset.Pods)
+	}
+	for name, statefulset := range resources.Statefulsets {
+		addPods("statefulset", name, statefulset.Pods)
+	}
+	for name, job := range resources.Jobs {
+		addPods("job", name, job.Pods)
+	}
+	for name, customResource := range resources.CustomResources {
+		addPods(strings.ToLower(customResource.Kind), name, customResource.Pods)
+	}
+
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Timeline.Created != rows[j].Timeline.Created {
+			return rows[i].Timeline.Created < rows[j].Timeline.Created
+		}
+		return rows[i].Pod < rows[j].Pod
+	})
+	return rows
+}
@@ -112,3 +112,231 @@ func TestApplicationsFiltersData(t *testing.T) {
 	}
 
 }
+
+func TestScaleEvents(t *testing.T) {
+	var wg sync.WaitGroup
+	ctx := context.Background()
+
+	ms := MockServer(t, "", nil, nil)
+	ms.api.BindEndpoints()
+	ms.api.Serve(ctx, &wg)
+
+	testsResponseCount := []struct {
+		endpoint              string
+		expectedStatusCode    int
+		expectedCountResponse int
+	}{
+		{"/api/v1/kubernetes/applications/foo/scales", http.StatusOK, 2},
+		{"/api/v1/kubernetes/applications/foo-1/scales", http.StatusOK, 0},
+	}
+
+	for _, test := range testsResponseCount {
+		t.Run(test.endpoint, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			req, err := http.NewRequest("GET", test.endpoint, nil)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			ms.api.Router().ServeHTTP(rr, req)
+			if rr.Code != test.expectedStatusCode {
+				t.Fatalf("unexpected status code: got %d want %d", rr.Code, test.expectedStatusCode)
+			}
+
+			response := []kubernetes.ResponseKubernetesScaleEvent{}
+			body, err := ioutil.ReadAll(rr.Body)
+			err = json.Unmarshal(body, &response)
+			if len(response) != test.expectedCountResponse {
+				t.Fatalf("unexpected scale events length, got %d expected %d", len(response), test.expectedCountResponse)
+			}
+		})
+	}
+}
+
+func TestSpecDiff(t *testing.T) {
+	var wg sync.WaitGroup
+	ctx := context.Background()
+
+	ms := MockServer(t, "", nil, nil)
+	ms.api.BindEndpoints()
+	ms.api.Serve(ctx, &wg)
+
+	testsResponseCount := []struct {
+		endpoint              string
+		expectedStatusCode    int
+		expectedCountResponse int
+	}{
+		{"/api/v1/kubernetes/application/c60c45dc08b369ec8a4ee89bcf37c96eaa1b81cb/diff", http.StatusOK, 2},
+		{"/api/v1/kubernetes/application/not-exists/diff", http.StatusNotFound, 0},
+	}
+
+	for _, test := range testsResponseCount {
+		t.Run(test.endpoint, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			req, err := http.NewRequest("GET", test.endpoint, nil)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			ms.api.Router().ServeHTTP(rr, req)
+			if rr.Code != test.expectedStatusCode {
+				t.Fatalf("unexpected status code: got %d want %d", rr.Code, test.expectedStatusCode)
+			}
+
+			response := kubernetes.ResponseKubernetesSpecDiff{}
+			body, err := ioutil.ReadAll(rr.Body)
+			err = json.Unmarshal(body, &response)
+			changes := 0
+			for _, diff := range response.SpecDiffs {
+				changes += len(diff.Changes)
+			}
+			if changes != test.expectedCountResponse {
+				t.Fatalf("unexpected spec changes length, got %d expected %d", changes, test.expectedCountResponse)
+			}
+		})
+	}
+}
+
+func TestTimeline(t *testing.T) {
+	var wg sync.WaitGroup
+	ctx := context.Background()
+
+	ms := MockServer(t, "", nil, nil)
+	ms.api.BindEndpoints()
+	ms.api.Serve(ctx, &wg)
+
+	testsResponseCount := []struct {
+		endpoint           string
+		expectedStatusCode int
+		expectedPhases     int
+		expectedPods       []string
+	}{
+		{"/api/v1/kubernetes/application/c60c45dc08b369ec8a4ee89bcf37c96eaa1b81cb/timeline", http.StatusOK, 2, []string{"foo-1", "foo-2"}},
+		{"/api/v1/kubernetes/application/not-exists/timeline", http.StatusNotFound, 0, []string{}},
+	}
+
+	for _, test := range testsResponseCount {
+		t.Run(test.endpoint, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			req, err := http.NewRequest("GET", test.endpoint, nil)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			ms.api.Router().ServeHTTP(rr, req)
+			if rr.Code != test.expectedStatusCode {
+				t.Fatalf("unexpected status code: got %d want %d", rr.Code, test.expectedStatusCode)
+			}
+
+			response := kubernetes.ResponseKubernetesTimeline{}
+			body, err := ioutil.ReadAll(rr.Body)
+			err = json.Unmarshal(body, &response)
+			if len(response.Phases) != test.expectedPhases {
+				t.Fatalf("unexpected rollout phases length, got %d expected %d", len(response.Phases), test.expectedPhases)
+			}
+			if len(response.Pods) != len(test.expectedPods) {
+				t.Fatalf("unexpected pods length, got %d expected %d", len(response.Pods), len(test.expectedPods))
+			}
+			for i, pod := range response.Pods {
+				if pod.Pod != test.expectedPods[i] {
+					t.Fatalf("unexpected pod in position %d, got %s expected %s", i, pod.Pod, test.expectedPods[i])
+				}
+			}
+		})
+	}
+}
+
+func TestWarnings(t *testing.T) {
+	var wg sync.WaitGroup
+	ctx := context.Background()
+
+	ms := MockServer(t, "", nil, nil)
+	ms.api.BindEndpoints()
+	ms.api.Serve(ctx, &wg)
+
+	testsResponseCount := []struct {
+		endpoint           string
+		expectedStatusCode int
+		expectedObjects    []string
+	}{
+		{"/api/v1/kubernetes/application/c60c45dc08b369ec8a4ee89bcf37c96eaa1b81cb/warnings", http.StatusOK, []string{"foo-2", "data"}},
+		{"/api/v1/kubernetes/application/not-exists/warnings", http.StatusNotFound, []string{}},
+	}
+
+	for _, test := range testsResponseCount {
+		t.Run(test.endpoint, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			req, err := http.NewRequest("GET", test.endpoint, nil)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			ms.api.Router().ServeHTTP(rr, req)
+			if rr.Code != test.expectedStatusCode {
+				t.Fatalf("unexpected status code: got %d want %d", rr.Code, test.expectedStatusCode)
+			}
+
+			response := kubernetes.ResponseKubernetesWarnings{}
+			body, err := ioutil.ReadAll(rr.Body)
+			err = json.Unmarshal(body, &response)
+			if len(response.Warnings) != len(test.expectedObjects) {
+				t.Fatalf("unexpected warnings length, got %d expected %d", len(response.Warnings), len(test.expectedObjects))
+			}
+			for i, warning := range response.Warnings {
+				if warning.ObjectName != test.expectedObjects[i] {
+					t.Fatalf("unexpected warning object in position %d, got %s expected %s", i, warning.ObjectName, test.expectedObjects[i])
+				}
+			}
+		})
+	}
+}
+
+func TestDaemonsetNodes(t *testing.T) {
+	var wg sync.WaitGroup
+	ctx := context.Background()
+
+	ms := MockServer(t, "", nil, nil)
+	ms.api.BindEndpoints()
+	ms.api.Serve(ctx, &wg)
+
+	testsResponseCount := []struct {
+		endpoint           string
+		expectedStatusCode int
+		expectedTotal      int
+		expectedNodes      []string
+	}{
+		{"/api/v1/kubernetes/application/c60c45dc08b369ec8a4ee89bcf37c96eaa1b81cb/nodes", http.StatusOK, 4, []string{"node-d", "node-b", "node-c", "node-a"}},
+		{"/api/v1/kubernetes/application/c60c45dc08b369ec8a4ee89bcf37c96eaa1b81cb/nodes?state=pending,failing", http.StatusOK, 4, []string{"node-d", "node-b"}},
+		{"/api/v1/kubernetes/application/not-exists/nodes", http.StatusNotFound, 0, []string{}},
+	}
+
+	for _, test := range testsResponseCount {
+		t.Run(test.endpoint, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			req, err := http.NewRequest("GET", test.endpoint, nil)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			ms.api.Router().ServeHTTP(rr, req)
+			if rr.Code != test.expectedStatusCode {
+				t.Fatalf("unexpected status code: got %d want %d", rr.Code, test.expectedStatusCode)
+			}
+
+			response := kubernetes.ResponseKubernetesDaemonsetNodes{}
+			body, err := ioutil.ReadAll(rr.Body)
+			err = json.Unmarshal(body, &response)
+			if response.Summary.Total != test.expectedTotal {
+				t.Fatalf("unexpected nodes total, got %d expected %d", response.Summary.Total, test.expectedTotal)
+			}
+			if len(response.Nodes) != len(test.expectedNodes) {
+				t.Fatalf("unexpected nodes length, got %d expected %d", len(response.Nodes), len(test.expectedNodes))
+			}
+			for i, node := range response.Nodes {
+				if node.Node != test.expectedNodes[i] {
+					t.Fatalf("unexpected node in position %d, got %s expected %s", i, node.Node, test.expectedNodes[i])
+				}
+			}
+		})
+	}
+}
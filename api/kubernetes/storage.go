@@ -13,6 +13,7 @@ type Storage interface {
 	ApplicationsCount(queryFillter FilterApplications) (int64, error)
 	GetDeployment(applyID string) (state.TableKubernetes, error)
 	GetUniqueFieldValues(tableName, columnName string) ([]string, error)
+	ScaleEvents(queryFilter FilterScaleEvents) (*[]state.TableScaleEvents, error)
 }
 
 type MySQLStorage struct {
@@ -98,6 +99,44 @@ func (my *MySQLStorage) GetDeployment(applyID string) (state.TableKubernetes, er
 
 }
 
+// ScaleEvents returns the scaling history of an application, the latest scale events first
+func (my *MySQLStorage) ScaleEvents(queryFilter FilterScaleEvents) (*[]state.TableScaleEvents, error) {
+
+	table := &[]state.TableScaleEvents{}
+	queryBuilder := my.client.DB.Offset(queryFilter.Offset).Limit(queryFilter.Limit).Where(&state.TableScaleEvents{Name: queryFilter.Name})
+
+	clusters := []string{}
+	for _, cluster := range queryFilter.Clusters {
+		if cluster != "" {
+			clusters = append(clusters, cluster)
+		}
+	}
+	if len(clusters) > 0 {
+		queryBuilder = queryBuilder.Where("cluster IN (?)", clusters)
+	}
+
+	namespaces := []string{}
+	for _, namespace := range queryFilter.Namespaces {
+		if namespace != "" {
+			namespaces = append(namespaces, namespace)
+		}
+	}
+	if len(namespaces) > 0 {
+		queryBuilder = queryBuilder.Where("namespace IN (?)", namespaces)
+	}
+
+	// We only support a case where we have both filter of From and To
+	if queryFilter.From != 0 && queryFilter.To != 0 {
+		queryBuilder = queryBuilder.Where("time >= ? and time <= ?", queryFilter.From, queryFilter.To)
+	}
+
+	if err := queryBuilder.Order("time desc").Find(table).Error; err != nil {
+		my.logger.WithError(err).WithField("name", queryFilter.Name).Error("could not fetch scale events")
+		return nil, err
+	}
+	return table, nil
+}
+
 func (my *MySQLStorage) builderApplications(queryFillter FilterApplications) *gorm.DB {
 
 	queryBuilder := my.client.DB.Offset(queryFillter.Offset).Limit(queryFillter.Limit)
@@ -151,6 +190,18 @@ func (my *MySQLStorage) builderApplications(queryFillter FilterApplications) *go
 		queryBuilder = queryBuilder.Where("time >= ? and time <= ?", queryFillter.From, queryFillter.To)
 	}
 
+	if queryFillter.Rollback != nil {
+		queryBuilder = queryBuilder.Where("rollback = ?", *queryFillter.Rollback)
+	}
+
+	if queryFillter.Superseded != nil {
+		if *queryFillter.Superseded {
+			queryBuilder = queryBuilder.Where("status = ?", state.StatusSuperseded)
+		} else {
+			queryBuilder = queryBuilder.Where("status <> ?", state.StatusSuperseded)
+		}
+	}
+
 	if len(queryFillter.Statuses) > 0 {
 
 		for i, status := range queryFillter.Statuses {
@@ -0,0 +1,130 @@
+package kubernetes
+
+import (
+	"sort"
+	"strings"
+)
+
+// eventTypeWarning is the type of kubernetes warning events
+const eventTypeWarning = "Warning"
+
+// warningsCollector collects the warning events of the apply resources
+type warningsCollector struct {
+	kind         string
+	resourceName string
+	warnings     []ResponseWarningEvent
+}
+
+// WarningEvents returns the warning events of all the apply objects, the latest warning first
+func WarningEvents(resources ResponseResourcesData) []ResponseWarningEvent {
+	wc := &warningsCollector{warnings: []ResponseWarningEvent{}}
+
+	for name, deployment := range resources.Deployments {
+		wc.setResource("deployment", name)
+		wc.add("deployment", name, deployment.Events)
+		for replicasetName, replicaset := range deployment.Replicaset {
+			wc.add("replicaset", replicasetName, replicaset.Events)
+		}
+		wc.addPods(deployment.Pods)
+		wc.addServices(deployment.Services)
+		wc.addHorizontalPodAutoscalers(deployment.HorizontalPodAutoscalers)
+	}
+	for name, daemonset := range resources.Daemonsets {
+		wc.setResource("daemonset", name)
+		wc.add("daemonset", name, daemonset.Events)
+		wc.addPods(daemonset.Pods)
+		wc.addServices(daemonset.Services)
+	}
+	for name, statefulset := range resources.Statefulsets {
+		wc.setResource("statefulset", name)
+		wc.add("statefulset", name, statefulset.Events)
+		wc.addPods(statefulset.Pods)
+		wc.addServices(statefulset.Services)
+		wc.addHorizontalPodAutoscalers(statefulset.HorizontalPodAutoscalers)
+	}
+	for name, job := range resources.Jobs {
+		wc.setResource("job", name)
+		wc.add("job", name, job.Events)
+		wc.addPods(job.Pods)
+	}
+	for name, customResource := range resources.CustomResources {
+		kind := strings.ToLower(customResource.Kind)
+		wc.setResource(kind, name)
+		wc.add(kind, name, customResource.Events)
+		wc.addPods(customResource.Pods)
+		wc.addServices(customResource.Services)
+	}
+	for name, trafficSwitch := range resources.TrafficSwitches {
+		wc.setResource("service", name)
+		wc.add("service", name, trafficSwitch.Events)
+	}
+
+	sort.Slice(wc.warnings, func(i, j int) bool {
+		first, second := wc.warnings[i], wc.warnings[j]
+		if first.LastTimestamp != second.LastTimestamp {
+			return first.LastTimestamp > second.LastTimestamp
+		}
+		if first.ObjectName != second.ObjectName {
+			return first.ObjectName < second.ObjectName
+		}
+		return first.Message < second.Message
+	})
+	return wc.warnings
+}
+
+// setResource sets the apply resource of the next collected warnings
+func (wc *warningsCollector) setResource(kind, name string) {
+	wc.kind = kind
+	wc.resourceName = name
+}
+
+// addPods adds the warnings of the pods and of the pods persistent volume claims
+func (wc *warningsCollector) addPods(pods map[string]ResponseDeploymenPod) {
+	for podName, pod := range pods {
+		wc.add("pod", podName, pod.Events)
+		for pvcName, events := range pod.PVC {
+			wc.add("pvc", pvcName, events)
+		}
+	}
+}
+
+// addServices adds the warnings of the services and of the ingresses that route to them
+func (wc *warningsCollector) addServices(services map[string]ResponseServicesData) {
+	for serviceName, service := range services {
+		wc.add("service", serviceName, service.Events)
+		for ingressName, ingress := range service.Ingresses {
+			wc.add("ingress", ingressName, ingress.Events)
+		}
+	}
+}
+
+// addHorizontalPodAutoscalers adds the warnings of the horizontal pod autoscalers
+func (wc *warningsCollector) addHorizontalPodAutoscalers(hpas map[string]ResponseHorizontalPodAutoscalerData) {
+	for hpaName, hpa := range hpas {
+		wc.add("hpa", hpaName, hpa.Events)
+	}
+}
+
+// add adds the warning events of an object
+func (wc *warningsCollector) add(object, objectName string, events []ResponseEventMessages) {
+	for _, event := range events {
+		if event.Type != eventTypeWarning {
+			continue
+		}
+		lastTimestamp := event.LastTimestamp
+		if lastTimestamp == 0 {
+			lastTimestamp = event.Time
+		}
+		wc.warnings = append(wc.warnings, ResponseWarningEvent{
+			Kind:           wc.kind,
+			ResourceName:   wc.resourceName,
+			Object:         object,
+			ObjectName:     objectName,
+			Reason:         event.Reason,
+			Message:        event.Message,
+			Count:          event.Count,
+			FirstTimestamp: event.FirstTimestamp,
+			LastTimestamp:  lastTimestamp,
+		})
+	}
+}
@@ -1,16 +1,22 @@
 package testutil
 
 import (
+	"errors"
 	"statusbay/api/kubernetes"
 	"statusbay/state"
 )
 
 var (
 	responseTable = []state.TableKubernetes{
-		{ApplyId: "c60c45dc08b369ec8a4ee89bcf37c96eaa1b81cb", Name: "foo", Cluster: "cluster1", Namespace: "foo-namespace", Status: "running", Time: 123, DeployBy: "foo@example.com"},
+		{ApplyId: "c60c45dc08b369ec8a4ee89bcf37c96eaa1b81cb", Name: "foo", Cluster: "cluster1", Namespace: "foo-namespace", Status: "running", Time: 123, DeployBy: "foo@example.com", Details: `{"SpecDiffs":[{"Kind":"deployment","ResourceName":"foo","Changes":[{"Type":"image","Action":"modified","Container":"foo","Name":"image","Previous":"foo:1","Current":"foo:2"},{"Type":"env","Action":"added","Container":"foo","Name":"TOKEN","Previous":"","Current":"<redacted>"}]}],"RolloutPhases":[{"Name":"first_pod_scheduled","Start":1000000000,"End":2000000000,"DurationSeconds":1},{"Name":"first_pod_ready","Start":1000000000,"End":5000000000,"DurationSeconds":4}],"Resources":{"Deployments":{"foo":{"Pods":{"foo-2":{"Events":[{"Message":"Back-off restarting failed container","Reason":"BackOff","Type":"Warning","Count":5,"FirstTimestamp":3000000000,"LastTimestamp":9000000000},{"Message":"Started container foo","Reason":"Started","Type":"Normal","Count":1}],"Timeline":{"Created":1500000000,"Scheduled":2000000000,"Ready":5000000000}},"foo-1":{"Pvcs":{"data":[{"Message":"waiting for a volume to be created","Reason":"ExternalProvisioning","Type":"Warning","Count":1,"LastTimestamp":4000000000}]},"Timeline":{"Created":1200000000,"Scheduled":2000000000}},"foo-old":{}}}},"Daemonsets":{"agent":{"Nodes":{"node-a":{"Pod":"agent-a","Updated":true,"State":"ready"},"node-b":{"Pod":"agent-b","Updated":true,"State":"pending","Reason":"Unschedulable","Cordoned":true,"Conditions":["DiskPressure"],"Taints":["node.kubernetes.io/disk-pressure:NoSchedule"]},"node-c":{"Pod":"agent-c","Updated":false,"State":"ready"},"node-d":{"Pod":"agent-d","Updated":true,"State":"failing","Reason":"CrashLoopBackOff"}}}}}}`},
 		{ApplyId: "cbd69b781769cbf090662f46dd3bbef10f3103c2", Name: "foo", Cluster: "cluster1", Namespace: "foo-namespace", Status: "successful", Time: 1234, DeployBy: "foo@example.com"},
 		{ApplyId: "asdmken3rnuiweu423ihndscsdfalwelk2223usd", Name: "foo-1", Cluster: "cluster1", Namespace: "foo-namespace", Status: "faild", Time: 1234, DeployBy: "foo@example.com"},
 	}
+
+	scaleEventsTable = []state.TableScaleEvents{
+		{Name: "foo", Cluster: "cluster1", Namespace: "foo-namespace", Kind: "deployment", ResourceName: "foo", FromReplicas: 2, ToReplicas: 4, Time: 1235},
+		{Name: "foo", Cluster: "cluster1", Namespace: "foo-namespace", Kind: "deployment", ResourceName: "foo", FromReplicas: 4, ToReplicas: 2, Time: 1236},
+	}
 )
 
 type MockStorage struct {
@@ -30,10 +36,25 @@ func (m *MockStorage) ApplicationsCount(queryFillter kubernetes.FilterApplicatio
 }
 
 func (m *MockStorage) GetDeployment(applyID string) (state.TableKubernetes, error) {
-	return responseTable[0], nil
+	for _, row := range responseTable {
+		if row.ApplyId == applyID {
+			return row, nil
+		}
+	}
+	return state.TableKubernetes{}, errors.New("deployment not found")
 }
 
 func (m *MockStorage) GetUniqueFieldValues(tableName, columnName string) ([]string, error) {
 	values := []string{"foo", "foo1"}
 	return values, nil
 }
+
+func (m *MockStorage) ScaleEvents(queryFilter kubernetes.FilterScaleEvents) (*[]state.TableScaleEvents, error) {
+	rows := []state.TableScaleEvents{}
+	for _, row := range scaleEventsTable {
+		if row.Name == queryFilter.Name {
+			rows = append(rows, row)
+		}
+	}
+	return &rows, nil
+}
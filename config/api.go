@@ -53,8 +53,12 @@ type KubernetesMarksEvents struct {
 	Deployment  []EventMarksConfig `yaml:"deployment"`
 	Demonset    []EventMarksConfig `yaml:"demonset"`
 	Statefulset []EventMarksConfig `yaml:"statefulset"`
+	Job         []EventMarksConfig `yaml:"job"`
 	Service     []EventMarksConfig `yaml:"service"`
 	Pvc         []EventMarksConfig `yaml:"pvc"`
+	Hpa         []EventMarksConfig `yaml:"hpa"`
+
+	CustomResource []EventMarksConfig `yaml:"custom_resource"`
 }
 
 // API is holds all application configuration
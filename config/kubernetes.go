@@ -1,15 +1,26 @@
 package config
 
 import (
+	"fmt"
 	"io/ioutil"
+	"os"
 	notifierCommon "statusbay/notifiers/common"
 	notifierLoader "statusbay/notifiers/load"
 	"statusbay/state"
+	watcherCommon "statusbay/watcher/kubernetes/common"
 	"time"
 
 	"gopkg.in/yaml.v2"
 )
 
+const (
+	_defaultLeaseName          = "statusbay-watcher"
+	_defaultLeaseNamespace     = "default"
+	_defaultLeaseDuration      = time.Second * 15
+	_defaultLeaseRenewDeadline = time.Second * 10
+	_defaultLeaseRetryPeriod   = time.Second * 2
+)
+
 type DashboardConfig struct {
 	Service string `yaml:"service"`
 }
@@ -30,8 +41,80 @@ type KubernetesApplies struct {
 	// CheckFinishDelay defind time to wait until starting check the status of the apply
 	CheckFinishDelay time.Duration `yaml:"check_finish_delay"`
 
+	// CheckFinishInterval defines how often the apply status is checked after the check finish delay. Defaults to 2s
+	CheckFinishInterval time.Duration `yaml:"check_finish_interval"`
+
 	// CollectDataAfterApplyFinish defind how many time to continue collect apply events
 	CollectDataAfterApplyFinish time.Duration `yaml:"collect_data_after_apply_finish"`
+
+	// NotifyScaling sends a notification when a deployment or statefulset is scaled without a spec change
+	NotifyScaling bool `yaml:"notify_scaling"`
+}
+
+// KubernetesInformers configuration
+type KubernetesInformers struct {
+	// ResyncPeriod defines how often the informers resend all the cached resources to the watchers. 0 disables the resync
+	ResyncPeriod time.Duration `yaml:"resync_period"`
+}
+
+// KubernetesLeaderElection configuration
+type KubernetesLeaderElection struct {
+	// Enabled runs the watcher only on the replica that holds the lease, the other replicas are kept in standby
+	Enabled bool `yaml:"enabled"`
+
+	// Namespace and LeaseName of the lease object
+	Namespace string `yaml:"namespace"`
+	LeaseName string `yaml:"lease_name"`
+
+	// Identity of the replica. Default is the hostname (pod name)
+	Identity string `yaml:"identity"`
+
+	// LeaseDuration defines how long the standby replicas wait before taking over a lease that was not renewed
+	LeaseDuration time.Duration `yaml:"lease_duration"`
+
+	// RenewDeadline defines how long the leader retries to renew the lease before it gives up the leadership
+	RenewDeadline time.Duration `yaml:"renew_deadline"`
+
+	// RetryPeriod defines the time between the lease acquire/renew retries
+	RetryPeriod time.Duration `yaml:"retry_period"`
+}
+
+// setDefaults fills the leader election fields that were not configured
+func (le *KubernetesLeaderElection) setDefaults() {
+	if le.LeaseName == "" {
+		le.LeaseName = _defaultLeaseName
+	}
+	if le.Namespace == "" {
+		le.Namespace = _defaultLeaseNamespace
+	}
+	if le.Identity == "" {
+		le.Identity, _ = os.Hostname()
+	}
+	if le.LeaseDuration == 0 {
+		le.LeaseDuration = _defaultLeaseDuration
+	}
+	if le.RenewDeadline == 0 {
+		le.RenewDeadline = _defaultLeaseRenewDeadline
+	}
+	if le.RetryPeriod == 0 {
+		le.RetryPeriod = _defaultLeaseRetryPeriod
+	}
+}
+
+// KubernetesCluster configuration
+type KubernetesCluster struct {
+	// Name of the cluster, the applies of the cluster are tagged with this name
+	Name string `yaml:"name"`
+
+	// Kubeconfig path and Context to use for connecting the cluster. Default context is the kubeconfig current context
+	Kubeconfig string `yaml:"kubeconfig"`
+	Context    string `yaml:"context"`
+
+	// InCluster uses the pod service account credentials, the kubeconfig settings are ignored
+	InCluster bool `yaml:"in_cluster"`
+
+	// Namespaces to watch with namespace scoped permissions. Default is the watcher namespaces list
+	Namespaces []string `yaml:"namespaces"`
 }
 
 // EventMarksConfig is defined how the mark event will look
@@ -42,12 +125,20 @@ type EventMarksConfig struct {
 
 // Kubernetes is holds all application configuration
 type Kubernetes struct {
-	ClusterName     string                      `yaml:"cluster_name"`
-	Log             LogConfig                   `yaml:"log"`
-	MySQL           *state.MySQLConfig          `yaml:"mysql"`
-	NotifierConfigs notifierCommon.ConfigByName `yaml:"notifiers"`
-	UI              *UIConfig                   `yaml:"ui"`
-	Applies         *KubernetesApplies          `yaml:"applies"`
+	ClusterName     string                               `yaml:"cluster_name"`
+	Log             LogConfig                            `yaml:"log"`
+	MySQL           *state.MySQLConfig                   `yaml:"mysql"`
+	NotifierConfigs notifierCommon.ConfigByName          `yaml:"notifiers"`
+	UI              *UIConfig                            `yaml:"ui"`
+	Applies         *KubernetesApplies                   `yaml:"applies"`
+	Informers       KubernetesInformers                  `yaml:"informers"`
+	LeaderElection  KubernetesLeaderElection             `yaml:"leader_election"`
+	Clusters        []KubernetesCluster                  `yaml:"clusters"`
+	Namespaces      []string                             `yaml:"namespaces"`
+	Filters         watcherCommon.FilterConfig           `yaml:"filters"`
+	CustomResources []watcherCommon.CustomResourceConfig `yaml:"custom_resources"`
+	PodLogs         watcherCommon.PodLogsConfig          `yaml:"pod_logs"`
+	Events          watcherCommon.EventsConfig           `yaml:"events"`
 
 	Telemetry MetricsConfig `yaml:"telemetry"`
 
@@ -80,6 +171,37 @@ func LoadKubernetesConfig(path string) (config Kubernetes, err error) {
 	if err = yaml.Unmarshal(data, &config); err != nil {
 		return
 	}
+	config.LeaderElection.setDefaults()
+	if err = config.validateClusters(); err != nil {
+		return
+	}
+	err = config.validateCustomResources()
 
 	return
 }
+
+// validateCustomResources checks that the field paths of the custom resources can be parsed, so an unsupported
+// path fails on startup instead of never matching
+func (k *Kubernetes) validateCustomResources() error {
+	for _, resource := range k.CustomResources {
+		if err := resource.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateClusters checks that each cluster has a unique name, the name is used to identify the cluster applies
+func (k *Kubernetes) validateClusters() error {
+	names := map[string]bool{}
+	for i, cluster := range k.Clusters {
+		if cluster.Name == "" {
+			return fmt.Errorf("cluster name is missing in cluster number %d", i+1)
+		}
+		if names[cluster.Name] {
+			return fmt.Errorf("cluster name %s is duplicated", cluster.Name)
+		}
+		names[cluster.Name] = true
+	}
+	return nil
+}
@@ -42,5 +42,19 @@ func TestKubernetes(t *testing.T) {
 		}
 		t.Log(data)
 	})
+	t.Run("duplicate_clusters", func(t *testing.T) {
+		_, err := config.LoadKubernetesConfig(fmt.Sprintf("%s/testutil/mock/duplicate-clusters-config.yaml", currentFolderPath))
+
+		if err == nil {
+			t.Fatalf("expected error on duplicate cluster names")
+		}
+	})
+	t.Run("invalid_custom_resource_field_path", func(t *testing.T) {
+		_, err := config.LoadKubernetesConfig(fmt.Sprintf("%s/testutil/mock/invalid-custom-resource-config.yaml", currentFolderPath))
+
+		if err == nil {
+			t.Fatalf("expected error on invalid custom resource field path")
+		}
+	})
 
 }
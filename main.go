@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"sort"
 	"statusbay/api"
 	"statusbay/api/alerts"
 	apiKubernetes "statusbay/api/kubernetes"
@@ -18,9 +19,11 @@ import (
 	"statusbay/visibility"
 	kuberneteswatcher "statusbay/watcher/kubernetes"
 	"statusbay/watcher/kubernetes/client"
+	"sync"
 	"time"
 
 	log "github.com/sirupsen/logrus"
+	"k8s.io/client-go/kubernetes"
 )
 
 const (
@@ -61,7 +64,7 @@ func main() {
 	case ModeAPI:
 		runner = startAPIServer(ctx, configPath, eventsPath)
 	case KubernetesWatcher:
-		runner = startKubernetesWatcher(ctx, configPath, kubeconfig, apiserverHost)
+		runner = startKubernetesWatcher(ctx, cancelFn, configPath, kubeconfig, apiserverHost)
 	default:
 		flag.Usage()
 		os.Exit(1)
@@ -71,12 +74,16 @@ func main() {
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, os.Interrupt)
 
-	<-stop // block until we are requested to stop
+	// block until we are requested to stop, or the watcher stopped itself after it lost the leader lease
+	select {
+	case <-stop:
+	case <-ctx.Done():
+	}
 	runner.StopFunc(cancelFn)
 
 }
 
-func startKubernetesWatcher(ctx context.Context, configPath, kubeconfig, apiserverHost string) *serverutil.Runner {
+func startKubernetesWatcher(ctx context.Context, cancelFn context.CancelFunc, configPath, kubeconfig, apiserverHost string) *serverutil.Runner {
 
 	version.NewVersion(ctx, "wacher_kubernetes", 12*time.Hour)
 
@@ -95,13 +102,44 @@ func startKubernetesWatcher(ctx context.Context, configPath, kubeconfig, apiserv
 	//Setup logging
 	visibility.SetupLogging(watcherConfig.Log.Level, watcherConfig.Log.GelfAddress, "wacher_kubernetes")
 
-	// Init kubernetes client
-	kubernetesClientManager, err := client.NewClientManager(kubeconfig, apiserverHost)
-	if err != nil {
-		log.WithError(err).Panic("failed to initialize Kubernetes client")
-		os.Exit(1)
+	// Without clusters list, a single cluster is watched with the command line client flags
+	clusterConfigs := watcherConfig.Clusters
+	if len(clusterConfigs) == 0 {
+		clusterConfigs = []config.KubernetesCluster{{Name: watcherConfig.ClusterName, Kubeconfig: kubeconfig}}
+	}
+
+	// Init kubernetes clients
+	clusters := []kubernetesCluster{}
+	for _, clusterConfig := range clusterConfigs {
+		kubeconfigPath, serverHost, kubeContext := clusterConfig.Kubeconfig, "", clusterConfig.Context
+		if len(watcherConfig.Clusters) == 0 {
+			serverHost = apiserverHost
+		}
+		if clusterConfig.InCluster {
+			kubeconfigPath, kubeContext = "", ""
+		}
+		kubernetesClientManager, err := client.NewContextClientManager(kubeconfigPath, serverHost, kubeContext)
+		if err != nil {
+			log.WithError(err).WithField("cluster", clusterConfig.Name).Panic("failed to initialize Kubernetes client")
+			os.Exit(1)
+		}
+		kubernetesClientset := kubernetesClientManager.GetInsecureClient()
+
+		// Without namespaces list, the whole cluster is watched and cluster wide permissions are required
+		namespaces := clusterConfig.Namespaces
+		if len(namespaces) == 0 {
+			namespaces = watcherConfig.Namespaces
+		}
+
+		//Informer manager
+		informerManager := kuberneteswatcher.NewInformerManager(kubernetesClientset, kubernetesClientManager.GetDynamicClient(), watcherConfig.Informers.ResyncPeriod, namespaces)
+
+		clusters = append(clusters, kubernetesCluster{
+			name:            clusterConfig.Name,
+			clientset:       kubernetesClientset,
+			informerManager: informerManager,
+		})
 	}
-	kubernetesClientset := kubernetesClientManager.GetInsecureClient()
 
 	// Init mysql storage
 	mysqlManager := state.NewMysqlClient(watcherConfig.MySQL)
@@ -114,41 +152,50 @@ func startKubernetesWatcher(ctx context.Context, configPath, kubeconfig, apiserv
 		os.Exit(1)
 	}
 
-	// Init Reporter
-	reporter := kuberneteswatcher.NewReporter(notifiers)
-
-	//Registry manager
-	registryManager := kuberneteswatcher.NewRegistryManager(watcherConfig.Applies.SaveInterval, watcherConfig.Applies.CheckFinishDelay, watcherConfig.Applies.CollectDataAfterApplyFinish, mysql, reporter, watcherConfig.ClusterName)
-	runningApplies := registryManager.LoadRunningApplies()
-	//Event manager
-	eventManager := kuberneteswatcher.NewEventsManager(kubernetesClientset)
-
-	//Service manager
-	serviceManager := kuberneteswatcher.NewServiceManager(kubernetesClientset, eventManager)
-
-	//Pvc manager
-	pvcManager := kuberneteswatcher.NewPvcManager(kubernetesClientset, eventManager)
-
-	//Pods manager
-	podsManager := kuberneteswatcher.NewPodsManager(kubernetesClientset, eventManager, pvcManager)
-
-	//Replicaset manager
-	replicasetManager := kuberneteswatcher.NewReplicasetManager(kubernetesClientset, eventManager, podsManager)
-
-	//Deployment manager
-	deploymentManager := kuberneteswatcher.NewDeploymentManager(kubernetesClientset, eventManager, registryManager, replicasetManager, serviceManager, runningApplies, watcherConfig.Applies.MaxApplyTime)
-
-	// ControllerRevision Manager
-	controllerRevisionManager := kuberneteswatcher.NewControllerRevisionManager(kubernetesClientset, podsManager)
+	// Filters of the tracked resources
+	applyFilter, err := kuberneteswatcher.NewApplyFilter(watcherConfig.Filters)
+	if err != nil {
+		log.WithError(err).Panic("invalid filters configuration")
+		os.Exit(1)
+	}
 
-	// Daemonset manager
-	daemonsetManager := kuberneteswatcher.NewDaemonsetManager(kubernetesClientset, eventManager, registryManager, serviceManager, controllerRevisionManager, runningApplies, watcherConfig.Applies.MaxApplyTime)
+	// Init Reporter, shared by all the clusters
+	reporter := kuberneteswatcher.NewReporter(notifiers)
 
-	//Statefulset manager
-	statefulsetManager := kuberneteswatcher.NewStatefulsetManager(kubernetesClientset, eventManager, registryManager, serviceManager, controllerRevisionManager, runningApplies, watcherConfig.Applies.MaxApplyTime)
+	// The watcher servers are created only on the active replica, the running applies are loaded from the storage
+	// each time the servers are created so a standby replica continues the applies of the previous leader
+	watcherServers := func() []serverutil.Server {
+		// The servers of each cluster are started in their own goroutine, so a cluster that can't be reached
+		// doesn't block the other clusters
+		servers := []serverutil.Server{}
+		for _, cluster := range clusters {
+			servers = append(servers, serverutil.NewGroup(
+				clusterSyncServer{cluster: cluster},
+				clusterWatcherServer{watcherConfig: watcherConfig, cluster: cluster, storage: mysql, reporter: reporter, applyFilter: applyFilter},
+			))
+		}
+		return servers
+	}
 
-	servers := []serverutil.Server{
-		eventManager, podsManager, pvcManager, deploymentManager, daemonsetManager, statefulsetManager, replicasetManager, registryManager, serviceManager, reporter,
+	servers := []serverutil.Server{reporter}
+	informerManagers := []*kuberneteswatcher.InformerManager{}
+	for _, cluster := range clusters {
+		servers = append(servers, cluster.informerManager)
+		informerManagers = append(informerManagers, cluster.informerManager)
+	}
+	if watcherConfig.LeaderElection.Enabled {
+		//Leader election manager, the informers of all the clusters are warmed up and the lease is held in the first cluster
+		leaderElectionManager := kuberneteswatcher.NewLeaderElectionManager(informerManagers, clusters[0].clientset, kuberneteswatcher.LeaderElectionConfig{
+			Identity:      watcherConfig.LeaderElection.Identity,
+			Namespace:     watcherConfig.LeaderElection.Namespace,
+			Name:          watcherConfig.LeaderElection.LeaseName,
+			LeaseDuration: watcherConfig.LeaderElection.LeaseDuration,
+			RenewDeadline: watcherConfig.LeaderElection.RenewDeadline,
+			RetryPeriod:   watcherConfig.LeaderElection.RetryPeriod,
+		}, watcherServers, cancelFn)
+		servers = append(servers, leaderElectionManager)
+	} else {
+		servers = append(servers, watcherServers()...)
 	}
 
 	// Run a list of backround process for the server
@@ -206,3 +253,111 @@ func startAPIServer(ctx context.Context, configPath string, eventsPath string) *
 	return serverutil.RunAll(ctx, servers)
 
 }
+
+// kubernetesCluster holds the clients of a watched cluster
+type kubernetesCluster struct {
+	name            string
+	clientset       kubernetes.Interface
+	informerManager *kuberneteswatcher.InformerManager
+}
+
+// newClusterServers creates the watcher servers of a single cluster. All the clusters share the same storage, reporter and filters
+func newClusterServers(watcherConfig config.Kubernetes, cluster kubernetesCluster, storage kuberneteswatcher.Storage, reporter *kuberneteswatcher.ReporterManager, applyFilter *kuberneteswatcher.ApplyFilter) []serverutil.Server {
+	informerManager := cluster.informerManager
+
+	//Registry manager
+	registryManager := kuberneteswatcher.NewRegistryManager(watcherConfig.Applies.SaveInterval, watcherConfig.Applies.CheckFinishDelay, watcherConfig.Applies.CheckFinishInterval, watcherConfig.Applies.CollectDataAfterApplyFinish, storage, reporter, cluster.name, applyFilter, watcherConfig.Applies.NotifyScaling)
+	runningApplies := registryManager.LoadRunningApplies()
+
+	//Event manager
+	eventManager := kuberneteswatcher.NewEventsManager(informerManager, watcherConfig.Events)
+
+	//Service manager
+	serviceManager := kuberneteswatcher.NewServiceManager(informerManager, eventManager)
+
+	//Horizontal pod autoscaler manager
+	hpaManager := kuberneteswatcher.NewHorizontalPodAutoscalerManager(informerManager, eventManager)
+
+	//Pvc manager
+	pvcManager := kuberneteswatcher.NewPvcManager(informerManager, eventManager)
+
+	//Pods manager
+	podsManager := kuberneteswatcher.NewPodsManager(informerManager, eventManager, pvcManager, cluster.clientset, watcherConfig.PodLogs)
+
+	//Replicaset manager
+	replicasetManager := kuberneteswatcher.NewReplicasetManager(informerManager, eventManager, podsManager)
+
+	//Deployment manager
+	deploymentManager := kuberneteswatcher.NewDeploymentManager(informerManager, eventManager, registryManager, replicasetManager, serviceManager, hpaManager, runningApplies, watcherConfig.Applies.MaxApplyTime)
+
+	// ControllerRevision Manager
+	controllerRevisionManager := kuberneteswatcher.NewControllerRevisionManager(informerManager, podsManager)
+
+	// Daemonset manager
+	daemonsetManager := kuberneteswatcher.NewDaemonsetManager(informerManager, eventManager, registryManager, serviceManager, controllerRevisionManager, cluster.clientset, runningApplies, watcherConfig.Applies.MaxApplyTime)
+
+	//Statefulset manager
+	statefulsetManager := kuberneteswatcher.NewStatefulsetManager(informerManager, eventManager, registryManager, serviceManager, hpaManager, controllerRevisionManager, runningApplies, watcherConfig.Applies.MaxApplyTime)
+
+	//Job manager
+	jobManager := kuberneteswatcher.NewJobManager(informerManager, eventManager, registryManager, podsManager, runningApplies, watcherConfig.Applies.MaxApplyTime)
+
+	//Custom resource manager
+	customResourceManager := kuberneteswatcher.NewCustomResourceManager(informerManager, eventManager, registryManager, podsManager, serviceManager, watcherConfig.CustomResources, runningApplies, watcherConfig.Applies.MaxApplyTime)
+
+	//Traffic switch manager
+	trafficSwitchManager := kuberneteswatcher.NewTrafficSwitchManager(informerManager, eventManager, registryManager, runningApplies, watcherConfig.Applies.MaxApplyTime)
+
+	return []serverutil.Server{
+		registryManager, eventManager, podsManager, pvcManager, deploymentManager, daemonsetManager, statefulsetManager, jobManager, customResourceManager, trafficSwitchManager, replicasetManager, serviceManager, hpaManager,
+	}
+}
+
+// clusterWatcherServer creates and starts the watcher servers of a cluster. It follows the cluster sync server in the
+// cluster group, so the running applies of the cluster are loaded only after its informer caches were synced
+type clusterWatcherServer struct {
+	watcherConfig config.Kubernetes
+	cluster       kubernetesCluster
+	storage       kuberneteswatcher.Storage
+	reporter      *kuberneteswatcher.ReporterManager
+	applyFilter   *kuberneteswatcher.ApplyFilter
+}
+
+// Serve creates the watcher servers of the cluster and starts them
+func (cws clusterWatcherServer) Serve(ctx context.Context, wg *sync.WaitGroup) {
+	for _, server := range newClusterServers(cws.watcherConfig, cws.cluster, cws.storage, cws.reporter, cws.applyFilter) {
+		server.Serve(ctx, wg)
+	}
+}
+
+// clusterSyncServer syncs the informer caches of the cluster before its watchers are started, and reports a
+// cluster that can't be reached or watched instead of blocking without a log
+type clusterSyncServer struct {
+	cluster kubernetesCluster
+}
+
+// Serve waits until the informer caches of the cluster are synced or the cache sync timeout passed. It blocks on
+// purpose, the servers that follow it in the cluster group are started only after the sync
+func (cs clusterSyncServer) Serve(ctx context.Context, wg *sync.WaitGroup) {
+	wg.Add(1)
+	defer wg.Done()
+
+	if err := cs.cluster.informerManager.WarmUp(); err != nil {
+		log.WithError(err).WithField("cluster", cs.cluster.name).Error("cluster informers were not synced, the cluster watchers keep retrying")
+	}
+
+	// One summary line of the namespaces that are not tracked until the watcher is restarted
+	unwatched := map[string][]string{}
+	for resource, namespaces := range cs.cluster.informerManager.UnwatchedNamespaces() {
+		for namespace := range namespaces {
+			unwatched[resource] = append(unwatched[resource], namespace)
+		}
+		sort.Strings(unwatched[resource])
+	}
+	if len(unwatched) > 0 {
+		log.WithFields(log.Fields{
+			"cluster":              cs.cluster.name,
+			"unwatched_namespaces": unwatched,
+		}).Warn("cluster namespaces could not be watched, their resources are not tracked")
+	}
+}
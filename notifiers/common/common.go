@@ -21,5 +21,6 @@ type Notifier interface {
 	ReportStarted(message common.DeploymentReport)
 	ReportDeleted(message common.DeploymentReport)
 	ReportEnded(message common.DeploymentReport)
+	ReportScaled(message common.ScaleReport)
 	Serve(ctx context.Context, wg *sync.WaitGroup)
 }
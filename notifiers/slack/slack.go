@@ -58,6 +58,10 @@ func (sl *Manager) LoadConfig(notifierConfig common.NotifierConfig) (err error)
 		if newConfig.MessageTemplates[deleted] != nil {
 			sl.config.MessageTemplates[deleted] = newConfig.MessageTemplates[deleted]
 		}
+
+		if newConfig.MessageTemplates[scaled] != nil {
+			sl.config.MessageTemplates[scaled] = newConfig.MessageTemplates[scaled]
+		}
 	}
 
 	// validate config
@@ -120,6 +124,31 @@ func (sl *Manager) sendToAll(stage ReportStage, message watcherCommon.Deployment
 					},
 				},
 			}
+			if message.RollbackRevision > 0 {
+				attachment.Fields = append(attachment.Fields, slackApi.AttachmentField{
+					Title: "Rollback",
+					Value: fmt.Sprintf("Rollback to revision %d", message.RollbackRevision),
+					Short: true,
+				})
+			}
+			if len(message.FailureCauses) > 0 {
+				attachment.Fields = append(attachment.Fields, slackApi.AttachmentField{
+					Title: "Failure causes",
+					Value: failureCausesSummary(message.FailureCauses),
+				})
+			}
+			if message.SupersededBy != "" {
+				attachment.Fields = append(attachment.Fields, slackApi.AttachmentField{
+					Title: "Superseded",
+					Value: fmt.Sprintf("A newer apply was started, <%s/%s|view the new apply>", slackBaseURL, message.SupersededBy),
+				})
+			}
+			if message.DataIncomplete {
+				attachment.Fields = append(attachment.Fields, slackApi.AttachmentField{
+					Title: "Data",
+					Value: "Some of the watches were interrupted, the report may be incomplete",
+				})
+			}
 			sl.send(toChannel, attachment, message.LogEntry)
 
 		} else {
@@ -146,7 +175,7 @@ func (sl *Manager) ReportEnded(message watcherCommon.DeploymentReport) {
 	switch message.Status {
 	case watcherCommon.ApplySuccessful:
 		color = green
-	case watcherCommon.ApplyCanceled:
+	case watcherCommon.ApplyCanceled, watcherCommon.ApplyStatusSuperseded, watcherCommon.ApplyStatusAwaitingPodDeletion:
 		color = yellow
 	case watcherCommon.ApplyStatusFailed:
 		color = red
@@ -155,6 +184,49 @@ func (sl *Manager) ReportEnded(message watcherCommon.DeploymentReport) {
 	sl.sendToAll(ended, message, color)
 }
 
+// ReportScaled sends a deployment scaled report
+func (sl *Manager) ReportScaled(message watcherCommon.ScaleReport) {
+	for _, to := range distinct(append(message.To, sl.config.DefaultChannels...)) {
+		if to == "" {
+			continue
+		}
+		toChannel, err := sl.GetChannelId(to)
+		if err != nil {
+			message.LogEntry.WithField("to", to).Debug("slack id not found")
+			continue
+		}
+		attachment := slackApi.Attachment{
+			Title:   sl.config.MessageTemplates[scaled].Title,
+			Pretext: sl.config.MessageTemplates[scaled].Pretext,
+			Text:    sl.config.MessageTemplates[scaled].Text,
+			Color:   string(blue),
+			Fields: []slackApi.AttachmentField{
+				{
+					Title: "Application",
+					Value: message.Name,
+					Short: true,
+				},
+				{
+					Title: "Cluster",
+					Value: message.ClusterName,
+					Short: true,
+				},
+				{
+					Title: "Resource",
+					Value: fmt.Sprintf("%s/%s", message.Kind, message.ResourceName),
+					Short: true,
+				},
+				{
+					Title: "Replicas",
+					Value: fmt.Sprintf("%d -> %d", message.FromReplicas, message.ToReplicas),
+					Short: true,
+				},
+			},
+		}
+		sl.send(toChannel, attachment, message.LogEntry)
+	}
+}
+
 // Serve will periodically check slack for a change in the list of existing users
 func (sl *Manager) Serve(ctx context.Context, wg *sync.WaitGroup) {
 	wg.Add(1)
@@ -246,3 +318,19 @@ func replacePlaceholders(input, status, link, deployedBy string) string {
 			common.LinkPlaceholder, link),
 		common.DeployedByPlaceholder, deployedBy)
 }
+
+// failureCausesSummary returns a line per failure cause with the affected pods count
+func failureCausesSummary(causes []watcherCommon.FailureCause) string {
+	lines := []string{}
+	for _, cause := range causes {
+		line := fmt.Sprintf("%s: %s", cause.Reason, cause.Description)
+		if len(cause.Details) > 0 {
+			line = fmt.Sprintf("%s (%s)", line, strings.Join(cause.Details, ", "))
+		}
+		if len(cause.Pods) > 0 {
+			line = fmt.Sprintf("%s - %d pods", line, len(cause.Pods))
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -222,6 +222,20 @@ func TestDistinct(t *testing.T) {
 	})
 }
 
+func TestFailureCausesSummary(t *testing.T) {
+	t.Run("a line per failure cause", func(t *testing.T) {
+		causes := []watcherCommon.FailureCause{
+			{Reason: "Unschedulable", Description: "Pods could not be scheduled on any node", Details: []string{"insufficient cpu"}, Pods: []string{"foo-1", "foo-2"}},
+			{Reason: "QuotaExceeded", Description: "The namespace resource quota was exceeded"},
+		}
+		expected := "Unschedulable: Pods could not be scheduled on any node (insufficient cpu) - 2 pods\nQuotaExceeded: The namespace resource quota was exceeded"
+
+		if result := failureCausesSummary(causes); result != expected {
+			t.Errorf("expected result `%v` to match `%v`", result, expected)
+		}
+	})
+}
+
 func TestUpdateUsers(t *testing.T) {
 	t.Run("unable to get users from slack api, existing list remains the same", func(t *testing.T) {
 		mockClient := &MockApiClient{
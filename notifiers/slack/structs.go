@@ -17,6 +17,9 @@ var defaultMessageConfig = map[ReportStage]*Message{
 		Pretext: "Deployment deleted {deployed_by}",
 		Text:    "<{link}|Click here> to view the StatusBay report",
 	},
+	scaled: {
+		Pretext: "Kubernetes deployment scaled",
+	},
 }
 
 type ReportStage string
@@ -25,6 +28,7 @@ const (
 	started ReportStage = "beginning_message"
 	ended   ReportStage = "end_message"
 	deleted ReportStage = "deleted_message"
+	scaled  ReportStage = "scaled_message"
 )
 
 type MessageColor string
@@ -55,6 +55,10 @@ func (*NotifierMock) ReportEnded(watcherCommon.DeploymentReport) {
 	panic("implement me")
 }
 
+func (*NotifierMock) ReportScaled(watcherCommon.ScaleReport) {
+	panic("implement me")
+}
+
 func (*NotifierMock) Serve(ctx context.Context, wg *sync.WaitGroup) {
 	wg.Add(1)
 
@@ -33,8 +33,41 @@ func RunAll(ctx context.Context, servers []Server) *Runner {
 
 }
 
+// Group serves a list of servers one after the other in its own goroutine, so a server that blocks while it is
+// started doesn't delay the servers that are not in the group
+type Group struct {
+	servers []Server
+}
+
+// NewGroup creates a group of servers that are started in the given order
+func NewGroup(servers ...Server) *Group {
+	return &Group{
+		servers: servers,
+	}
+}
+
+// Serve starts the servers of the group in the background
+func (g *Group) Serve(ctx context.Context, wg *sync.WaitGroup) {
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+		for _, server := range g.servers {
+			if server == nil {
+				continue
+			}
+			server.Serve(ctx, wg)
+		}
+	}()
+}
+
 // StopFunc will stop all registered servers in reverse order from how they were registered
 func (r *Runner) StopFunc(cancelFn context.CancelFunc) {
 	cancelFn()
 	r.wg.Wait()
 }
+
+// Wait blocks until all the registered servers were stopped
+func (r *Runner) Wait() {
+	r.wg.Wait()
+}
@@ -58,3 +58,43 @@ func TestServe(t *testing.T) {
 	}
 
 }
+
+type blockingServer struct {
+	unblock chan struct{}
+}
+
+func (b *blockingServer) Serve(ctx context.Context, wg *sync.WaitGroup) {
+	<-b.unblock
+}
+
+func TestGroup(t *testing.T) {
+
+	blocking := &blockingServer{unblock: make(chan struct{})}
+	blocked := MockServeStruct()
+	other := MockServeStruct()
+
+	ctx, cancelFn := context.WithCancel(context.Background())
+	runner := serverutil.RunAll(ctx, []serverutil.Server{
+		serverutil.NewGroup(blocking, blocked),
+		serverutil.NewGroup(other),
+	})
+	time.Sleep(time.Millisecond * 200)
+
+	if !other.init {
+		t.Fatalf("the servers of a group were delayed by a blocking server of another group")
+	}
+	if blocked.init {
+		t.Fatalf("unexpected server init before the previous server of the group was started")
+	}
+
+	close(blocking.unblock)
+	time.Sleep(time.Millisecond * 200)
+	if !blocked.init {
+		t.Fatalf("the server was not started after the previous server of the group was started")
+	}
+
+	runner.StopFunc(cancelFn)
+	if !blocked.isStop || !other.isStop {
+		t.Fatalf("the group servers were not stopped")
+	}
+}
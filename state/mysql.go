@@ -16,6 +16,10 @@ type MySQLManager struct {
 	DB *gorm.DB
 }
 
+// StatusSuperseded is the status of an apply that was replaced by a newer apply before it finished, shared by the
+// watcher that saves the applies and the api that filters them
+const StatusSuperseded = "superseded"
+
 // TableKubernetes define deployment table schema
 type TableKubernetes struct {
 	ApplyId   string `gorm:"unique_index;not null"`
@@ -26,6 +30,7 @@ type TableKubernetes struct {
 	Time      int64  `gorm:"not null"`
 	DeployBy  string `gorm:"not DeployBy"`
 	Details   string `gorm:"not null;type:json"`
+	Rollback  bool   `gorm:"not null;default:false"`
 }
 
 // TableName set deployment name table
@@ -37,6 +42,10 @@ func (u *TableKubernetes) TableName() string {
 type TableDeploymentsHash struct {
 	Deployment string `gorm:"not null;primary_key:yes"`
 	Hash       uint64 `gorm:"not null"`
+
+	// HashScheme is the version of the fields that the hash was computed from, 0 for hashes that were saved before
+	// the scheme was versioned
+	HashScheme int `gorm:"not null;default:0"`
 }
 
 // TableName set deployment hash name
@@ -44,6 +53,46 @@ func (u *TableDeploymentsHash) TableName() string {
 	return "last_deployment_version"
 }
 
+// TableDeploymentsReplicas define the last replicas count of a resource
+type TableDeploymentsReplicas struct {
+	Deployment string `gorm:"not null;primary_key:yes"`
+	Replicas   int32  `gorm:"not null"`
+}
+
+// TableName set deployment replicas table name
+func (u *TableDeploymentsReplicas) TableName() string {
+	return "last_deployment_replicas"
+}
+
+// TableScaleEvents define the scaling history of the applications
+type TableScaleEvents struct {
+	ID           uint   `gorm:"primary_key"`
+	Name         string `gorm:"not null;index"`
+	Cluster      string `gorm:"not null"`
+	Namespace    string `gorm:"not null"`
+	Kind         string `gorm:"not null"`
+	ResourceName string `gorm:"not null"`
+	FromReplicas int32  `gorm:"not null"`
+	ToReplicas   int32  `gorm:"not null"`
+	Time         int64  `gorm:"not null"`
+}
+
+// TableName set scale events table name
+func (u *TableScaleEvents) TableName() string {
+	return "kubernetes_scale_events"
+}
+
+// TableResourceVersion define the last resource version that was handled by a resource watcher
+type TableResourceVersion struct {
+	Resource        string `gorm:"not null;primary_key:yes"`
+	ResourceVersion string `gorm:"not null"`
+}
+
+// TableName set resource version table name
+func (u *TableResourceVersion) TableName() string {
+	return "last_resource_version"
+}
+
 // open will create a new DB connection
 func open(username, password, dns, schema string, port int) (*gorm.DB, error) {
 	return gorm.Open("mysql", fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8&parseTime=True&loc=Local", username, password, dns, port, schema))
@@ -87,6 +136,9 @@ func NewMysqlClient(config *MySQLConfig) *MySQLManager {
 func (my *MySQLManager) Migration() {
 	my.DB.AutoMigrate(&TableKubernetes{})
 	my.DB.AutoMigrate(&TableDeploymentsHash{})
+	my.DB.AutoMigrate(&TableResourceVersion{})
+	my.DB.AutoMigrate(&TableDeploymentsReplicas{})
+	my.DB.AutoMigrate(&TableScaleEvents{})
 }
 
 // MySQLConfig client configuration
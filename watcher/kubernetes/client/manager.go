@@ -5,6 +5,7 @@ import (
 
 	log "github.com/sirupsen/logrus"
 
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
@@ -14,6 +15,7 @@ import (
 // ClientManagerDescriber descrive client managet interface
 type ClientManagerDescriber interface {
 	GetInsecureClient() kubernetes.Interface
+	GetDynamicClient() dynamic.Interface
 }
 
 const (
@@ -37,10 +39,12 @@ var Version = "UNKNOWN"
 type clientManager struct {
 	kubeConfigPath  string
 	apiserverHost   string
+	kubeContext     string
 	inClusterConfig *rest.Config
 	insecureConfig  *rest.Config
 
 	insecureClient kubernetes.Interface
+	dynamicClient  dynamic.Interface
 }
 
 // GetInsecureClient return the kubernetes client instance
@@ -48,9 +52,14 @@ func (cm *clientManager) GetInsecureClient() kubernetes.Interface {
 	return cm.insecureClient
 }
 
+// GetDynamicClient return the kubernetes dynamic client instance
+func (cm *clientManager) GetDynamicClient() dynamic.Interface {
+	return cm.dynamicClient
+}
+
 // initInClusterConfig create kubernetes rest config instance
 func (cm *clientManager) initInClusterConfig() error {
-	if len(cm.apiserverHost) > 0 || len(cm.kubeConfigPath) > 0 {
+	if len(cm.apiserverHost) > 0 || len(cm.kubeConfigPath) > 0 || len(cm.kubeContext) > 0 {
 		return nil
 	}
 
@@ -65,7 +74,7 @@ func (cm *clientManager) initInClusterConfig() error {
 
 // initInsecureConfig create kubernetes client config
 func (cm *clientManager) initInsecureConfig() {
-	cfg, err := cm.buildConfigFromFlags(cm.apiserverHost, cm.kubeConfigPath)
+	cfg, err := cm.buildConfigFromFlags(cm.apiserverHost, cm.kubeConfigPath, cm.kubeContext)
 	if err != nil {
 		panic(err)
 	}
@@ -83,11 +92,16 @@ func (cm *clientManager) initConfig(cfg *rest.Config) {
 }
 
 // buildConfigFromFlags return the rest kubernetes config.
-func (cm *clientManager) buildConfigFromFlags(apiserverHost, kubeConfigPath string) (*rest.Config, error) {
-	if len(kubeConfigPath) > 0 || len(apiserverHost) > 0 {
+func (cm *clientManager) buildConfigFromFlags(apiserverHost, kubeConfigPath, kubeContext string) (*rest.Config, error) {
+	if len(kubeConfigPath) > 0 || len(apiserverHost) > 0 || len(kubeContext) > 0 {
+		loadingRules := &clientcmd.ClientConfigLoadingRules{ExplicitPath: kubeConfigPath}
+		// context without kubeconfig path is taken from the default kubeconfig files
+		if len(kubeConfigPath) == 0 && len(kubeContext) > 0 {
+			loadingRules = clientcmd.NewDefaultClientConfigLoadingRules()
+		}
 		return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
-			&clientcmd.ClientConfigLoadingRules{ExplicitPath: kubeConfigPath},
-			&clientcmd.ConfigOverrides{ClusterInfo: api.Cluster{Server: apiserverHost}}).ClientConfig()
+			loadingRules,
+			&clientcmd.ConfigOverrides{ClusterInfo: api.Cluster{Server: apiserverHost}, CurrentContext: kubeContext}).ClientConfig()
 	}
 
 	if cm.inClusterConfig != nil {
@@ -106,6 +120,13 @@ func (cm *clientManager) initInsecureClients() error {
 	}
 
 	cm.insecureClient = k8sClient
+
+	dynamicClient, err := dynamic.NewForConfig(cm.insecureConfig)
+	if err != nil {
+		return err
+	}
+
+	cm.dynamicClient = dynamicClient
 	return nil
 }
 
@@ -121,15 +142,23 @@ func (cm *clientManager) init() error {
 
 // NewClientManager creates client manager with given kubeConfigPath and apiserverHost parameters.
 func NewClientManager(kubeConfigPath, apiserverHost string) (ClientManagerDescriber, error) {
+	return NewContextClientManager(kubeConfigPath, apiserverHost, "")
+}
+
+// NewContextClientManager creates client manager with given kubeConfigPath, apiserverHost and kubeconfig context parameters.
+// When all the parameters are empty, the in-cluster configuration is used
+func NewContextClientManager(kubeConfigPath, apiserverHost, kubeContext string) (ClientManagerDescriber, error) {
 
 	log.WithFields(log.Fields{
 		"config_path": kubeConfigPath,
 		"server_host": apiserverHost,
+		"context":     kubeContext,
 	}).Info("setting up kubernetes client manager")
 
 	result := &clientManager{
 		kubeConfigPath: kubeConfigPath,
 		apiserverHost:  apiserverHost,
+		kubeContext:    kubeContext,
 	}
 
 	err := result.init()
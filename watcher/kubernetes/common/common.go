@@ -1,8 +1,14 @@
 package common
 
 import (
+	"fmt"
+	"statusbay/state"
+	"strings"
+	"time"
+
 	log "github.com/sirupsen/logrus"
 	eventwatch "k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/util/jsonpath"
 )
 
 //DeploymentStatus defined the status of the deployment
@@ -23,6 +29,13 @@ const (
 
 	// ApplyCanceled when statusbay stop watch
 	ApplyCanceled DeploymentStatus = "cancelled"
+
+	// ApplyStatusSuperseded when a new version of the apply resources was applied before the apply finished
+	ApplyStatusSuperseded DeploymentStatus = state.StatusSuperseded
+
+	// ApplyStatusAwaitingPodDeletion when the resources use the OnDelete update strategy and their pods were not deleted
+	// yet, the pods are replaced only after they are deleted manually
+	ApplyStatusAwaitingPodDeletion DeploymentStatus = "awaiting_pod_deletion"
 )
 
 // DeploymentStatusDescription are the various descriptions of the states a deployment can be in.
@@ -39,8 +52,29 @@ const (
 	// ApplyStatusDescriptionProgressDeadline progress deadline ended
 	ApplyStatusDescriptionProgressDeadline DeploymentStatusDescription = "Failed due to progress deadline"
 
+	// ApplyStatusDescriptionBackoffLimit job failed more times than the job backoff limit
+	ApplyStatusDescriptionBackoffLimit DeploymentStatusDescription = "Failed due to job backoff limit"
+
+	// ApplyStatusDescriptionJobDeadline job was active longer than the job active deadline
+	ApplyStatusDescriptionJobDeadline DeploymentStatusDescription = "Failed due to job active deadline"
+
+	// ApplyStatusDescriptionCustomResourceFailed custom resource status matched the failed rule
+	ApplyStatusDescriptionCustomResourceFailed DeploymentStatusDescription = "Failed due to custom resource status"
+
+	// ApplyStatusDescriptionSuccessCriteria a container restarted more than the success criteria max restarts
+	ApplyStatusDescriptionSuccessCriteria DeploymentStatusDescription = "Failed due to success criteria"
+
 	// ApplyStatusDescriptionCanceled description when apply canceld
 	ApplyStatusDescriptionCanceled DeploymentStatusDescription = "Deployment canceld"
+
+	// ApplyStatusDescriptionSuperseded description when apply was replaced by a newer apply
+	ApplyStatusDescriptionSuperseded DeploymentStatusDescription = "Superseded by a newer apply"
+
+	// ApplyStatusDescriptionPartitioned statefulset pods at or above the rollout partition were updated
+	ApplyStatusDescriptionPartitioned DeploymentStatusDescription = "Partitioned rollout completed successfully"
+
+	// ApplyStatusDescriptionAwaitingPodDeletion description when the apply waits for the OnDelete pods to be deleted
+	ApplyStatusDescriptionAwaitingPodDeletion DeploymentStatusDescription = "Awaiting manual pod deletion"
 )
 
 // DeploymentReport defined deployment reporter message
@@ -65,8 +99,183 @@ type DeploymentReport struct {
 
 	// ClusterName of the apply
 	ClusterName string
+
+	// DataIncomplete is true when some of the apply watches were interrupted, so the report may miss some changes
+	DataIncomplete bool
+
+	// RollbackRevision is the revision that the apply was rolled back to, 0 when the apply is not a rollback
+	RollbackRevision int64
+
+	// FailureCauses are the detected causes of a failed apply, ranked from the most likely root cause
+	FailureCauses []FailureCause
+
+	// SupersededBy is the URI of the apply that replaced a superseded apply, empty when the apply was not superseded
+	SupersededBy string
+}
+
+// FailureCause describes a detected cause of a failed apply, and the pods that were affected by it
+type FailureCause struct {
+	// Reason is the cause identifier, for example: ImagePullBackOff
+	Reason string `json:"Reason"`
+
+	// Description is a human readable description of the cause
+	Description string `json:"Description"`
+
+	// Details are the specific conditions of the cause, for example the unschedulable pods insufficient resources
+	Details []string `json:"Details"`
+
+	// Message is the latest event message that matched the cause
+	Message string `json:"Message"`
+
+	// Pods that were affected by the cause
+	Pods []string `json:"Pods"`
+
+	// Resources that reported the cause without a pod, formatted as <kind>/<name>
+	Resources []string `json:"Resources"`
+}
+
+// ScaleReport defined scaling reporter message
+type ScaleReport struct {
+	// To is a  list of channels/username to send message to
+	To []string
+
+	// Name of the application
+	Name string
+
+	// Kind and ResourceName of the scaled resource
+	Kind         string
+	ResourceName string
+
+	// FromReplicas and ToReplicas are the replicas count before and after the scale
+	FromReplicas int32
+	ToReplicas   int32
+
+	// LogEntry is the application logger
+	LogEntry log.Entry
+
+	// ClusterName of the application
+	ClusterName string
 }
 
 func IsSupportedEventType(eventType eventwatch.EventType) bool {
 	return (eventType == eventwatch.Modified || eventType == eventwatch.Added || eventType == eventwatch.Deleted)
 }
+
+// CustomResourceStatusRule describes when a custom resource reached a specific state.
+// The rule matches when the value of FieldPath is one of Values, or when the status condition
+// with ConditionType has the ConditionStatus value
+type CustomResourceStatusRule struct {
+	// FieldPath is a JSONPath expression in the resource object, for example: {.status.phase} or
+	// {.status.conditions[?(@.type=="Ready")].status}. A dot separated path without braces, such as status.phase, is supported as well
+	FieldPath string `yaml:"field_path"`
+
+	// Values of FieldPath that match the rule
+	Values []string `yaml:"values"`
+
+	// ConditionType is the type of the condition in status.conditions
+	ConditionType string `yaml:"condition_type"`
+
+	// ConditionStatus is the expected condition status. default: True
+	ConditionStatus string `yaml:"condition_status"`
+}
+
+// CustomResourceConfig describes a custom resource that should be tracked as an apply
+type CustomResourceConfig struct {
+	Group    string `yaml:"group"`
+	Version  string `yaml:"version"`
+	Resource string `yaml:"resource"`
+
+	// Kind of the resource, used for filtering the resource events
+	Kind string `yaml:"kind"`
+
+	// PodSelectorPath is a JSONPath expression of the labels of the resource pods. default: {.spec.selector.matchLabels}
+	PodSelectorPath string `yaml:"pod_selector_path"`
+
+	Progressing CustomResourceStatusRule `yaml:"progressing"`
+	Succeeded   CustomResourceStatusRule `yaml:"succeeded"`
+	Failed      CustomResourceStatusRule `yaml:"failed"`
+}
+
+// Validate checks that the field paths of the custom resource are valid JSONPath expressions
+func (crc CustomResourceConfig) Validate() error {
+	paths := []struct {
+		name string
+		path string
+	}{
+		{"pod_selector_path", crc.PodSelectorPath},
+		{"progressing.field_path", crc.Progressing.FieldPath},
+		{"succeeded.field_path", crc.Succeeded.FieldPath},
+		{"failed.field_path", crc.Failed.FieldPath},
+	}
+	for _, fieldPath := range paths {
+		if fieldPath.path == "" {
+			continue
+		}
+		if _, err := ParseFieldPath(fieldPath.path); err != nil {
+			return fmt.Errorf("custom resource %s.%s has invalid %s %q: %s", crc.Resource, crc.Group, fieldPath.name, fieldPath.path, err)
+		}
+	}
+	return nil
+}
+
+// ParseFieldPath parses the JSONPath field path of a custom resource. A path without braces is treated as a dot
+// separated path, for example status.phase is parsed as {.status.phase}. Missing fields are not an error
+func ParseFieldPath(path string) (*jsonpath.JSONPath, error) {
+	if !strings.HasPrefix(strings.TrimSpace(path), "{") {
+		path = fmt.Sprintf("{.%s}", strings.TrimPrefix(path, "."))
+	}
+	parser := jsonpath.New("field_path").AllowMissingKeys(true)
+	if err := parser.Parse(path); err != nil {
+		return nil, err
+	}
+	return parser, nil
+}
+
+// NamespaceFilterConfig describes the namespaces include and exclude lists, glob patterns are supported
+type NamespaceFilterConfig struct {
+	// Include list of namespaces to track. Empty list includes all the namespaces
+	Include []string `yaml:"include"`
+
+	// Exclude list of namespaces that are never tracked, even when included
+	Exclude []string `yaml:"exclude"`
+}
+
+// FilterConfig describes which resources are tracked as applies
+type FilterConfig struct {
+	Namespaces NamespaceFilterConfig `yaml:"namespaces"`
+
+	// LabelSelector the resource labels should match, e.g: "team=core,tier!=cache"
+	LabelSelector string `yaml:"label_selector"`
+
+	// OptIn tracks only resources with the statusbay.io/enabled: "true" annotation
+	OptIn bool `yaml:"opt_in"`
+
+	// Kinds enables or disables tracking per resource kind (deployment, daemonset, statefulset, job or the custom resource <resource>.<group>).
+	// Kinds that are not listed are tracked
+	Kinds map[string]bool `yaml:"kinds"`
+}
+
+// PodLogsConfig describes the log tails that are captured for failing containers
+type PodLogsConfig struct {
+	// Enabled fetches the logs of containers that terminated with a non-zero exit code or are crash-looping.
+	// Requires get permission on the pods/log resource
+	Enabled bool `yaml:"enabled"`
+
+	// TailLines is the number of lines to fetch from the end of the logs. default: 50
+	TailLines int64 `yaml:"tail_lines"`
+
+	// LimitBytes is the max size of each log tail, the end of the tail is kept. default: 8192
+	LimitBytes int64 `yaml:"limit_bytes"`
+
+	// RedactPatterns are regular expressions that are replaced in the logs, in addition to the built-in secret patterns
+	RedactPatterns []string `yaml:"redact_patterns"`
+}
+
+// EventsConfig describes which kubernetes events are related to the apply
+type EventsConfig struct {
+	// RelevanceWindow is the max age of an event that can't be matched to its object uid. default: 30s
+	RelevanceWindow time.Duration `yaml:"relevance_window"`
+
+	// KindRelevanceWindows overrides the relevance window by the kind of the event object, for example Pod: 2m
+	KindRelevanceWindows map[string]time.Duration `yaml:"kind_relevance_windows"`
+}
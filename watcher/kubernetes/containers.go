@@ -0,0 +1,148 @@
+package kuberneteswatcher
+
+import (
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// ContainerTypeInit is an init container, init containers run one after the other before the pod containers start
+	ContainerTypeInit = "init"
+
+	// ContainerTypeApp is a regular container of the pod
+	ContainerTypeApp = "container"
+
+	// ContainerTypeEphemeral is a debug container that was added to a running pod
+	ContainerTypeEphemeral = "ephemeral"
+
+	// ContainerStateWaiting the container didn't start yet or is waiting to be restarted
+	ContainerStateWaiting = "waiting"
+
+	// ContainerStateRunning the container is running
+	ContainerStateRunning = "running"
+
+	// ContainerStateTerminated the container exited
+	ContainerStateTerminated = "terminated"
+)
+
+// PodContainerStatus describes the latest state of a pod container
+type PodContainerStatus struct {
+	Name         string `json:"Name"`
+	Type         string `json:"Type"`
+	Image        string `json:"Image"`
+	ImageID      string `json:"ImageID"`
+	State        string `json:"State"`
+	Reason       string `json:"Reason"`
+	Message      string `json:"Message"`
+	ExitCode     int32  `json:"ExitCode"`
+	RestartCount int32  `json:"RestartCount"`
+	Ready        bool   `json:"Ready"`
+
+	// StartedAt is the time (unix nano) that the running container started, 0 when the container is not running
+	StartedAt int64 `json:"StartedAt"`
+
+	// LastTermination describes the previous run of a restarted container, nil when the container was not restarted
+	LastTermination *ContainerTermination `json:"LastTermination"`
+}
+
+// ContainerTermination describes how a container run ended
+type ContainerTermination struct {
+	Reason     string `json:"Reason"`
+	ExitCode   int32  `json:"ExitCode"`
+	Signal     int32  `json:"Signal"`
+	StartedAt  int64  `json:"StartedAt"`
+	FinishedAt int64  `json:"FinishedAt"`
+}
+
+// podContainerStatuses returns the statuses of the init containers in their run order, then the containers and
+// the ephemeral containers
+func podContainerStatuses(pod *v1.Pod) []PodContainerStatus {
+	statuses := []PodContainerStatus{}
+	for _, container := range pod.Status.InitContainerStatuses {
+		statuses = append(statuses, newPodContainerStatus(ContainerTypeInit, container))
+	}
+	for _, container := range pod.Status.ContainerStatuses {
+		statuses = append(statuses, newPodContainerStatus(ContainerTypeApp, container))
+	}
+	for _, container := range pod.Status.EphemeralContainerStatuses {
+		statuses = append(statuses, newPodContainerStatus(ContainerTypeEphemeral, container))
+	}
+	return statuses
+}
+
+// newPodContainerStatus creates the status of a single container
+func newPodContainerStatus(containerType string, container v1.ContainerStatus) PodContainerStatus {
+	status := PodContainerStatus{
+		Name:         container.Name,
+		Type:         containerType,
+		Image:        container.Image,
+		ImageID:      container.ImageID,
+		RestartCount: container.RestartCount,
+		Ready:        container.Ready,
+	}
+
+	switch {
+	case container.State.Waiting != nil:
+		status.State = ContainerStateWaiting
+		status.Reason = container.State.Waiting.Reason
+		status.Message = container.State.Waiting.Message
+	case container.State.Running != nil:
+		status.State = ContainerStateRunning
+		status.StartedAt = optionalUnixNano(container.State.Running.StartedAt)
+	case container.State.Terminated != nil:
+		status.State = ContainerStateTerminated
+		status.Reason = container.State.Terminated.Reason
+		status.Message = container.State.Terminated.Message
+		status.ExitCode = container.State.Terminated.ExitCode
+	}
+
+	if terminated := container.LastTerminationState.Terminated; terminated != nil {
+		status.LastTermination = &ContainerTermination{
+			Reason:     terminated.Reason,
+			ExitCode:   terminated.ExitCode,
+			Signal:     terminated.Signal,
+			StartedAt:  optionalUnixNano(terminated.StartedAt),
+			FinishedAt: optionalUnixNano(terminated.FinishedAt),
+		}
+	}
+	return status
+}
+
+// optionalUnixNano returns the time in unix nano, 0 when the time is not set
+func optionalUnixNano(t metaV1.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+// initContainersStatus returns the pod status when the pod is blocked by an init container, the status describes
+// the blocking init container, for example: Init:CrashLoopBackOff (migrations 1/2)
+func initContainersStatus(pod *v1.Pod) (string, bool) {
+	total := len(pod.Spec.InitContainers)
+	if total < len(pod.Status.InitContainerStatuses) {
+		total = len(pod.Status.InitContainerStatuses)
+	}
+	for i, container := range pod.Status.InitContainerStatuses {
+		step := fmt.Sprintf("%s %d/%d", container.Name, i+1, total)
+		switch {
+		case container.State.Terminated != nil && container.State.Terminated.ExitCode == 0:
+			continue
+		case container.State.Terminated != nil:
+			reason := container.State.Terminated.Reason
+			if reason == "" {
+				reason = fmt.Sprintf("ExitCode:%d", container.State.Terminated.ExitCode)
+			}
+			return fmt.Sprintf("Init:%s (%s)", reason, step), true
+		case container.State.Running != nil:
+			return fmt.Sprintf("Init:Running (%s)", step), true
+		case container.State.Waiting != nil && container.State.Waiting.Reason != "":
+			return fmt.Sprintf("Init:%s (%s)", container.State.Waiting.Reason, step), true
+		default:
+			return fmt.Sprintf("Init:Waiting (%s)", step), true
+		}
+	}
+	return "", false
+}
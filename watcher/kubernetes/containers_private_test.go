@@ -0,0 +1,81 @@
+package kuberneteswatcher
+
+import (
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestInitContainersStatus(t *testing.T) {
+
+	completed := v1.ContainerState{Terminated: &v1.ContainerStateTerminated{Reason: "Completed"}}
+
+	testCases := []struct {
+		name            string
+		statuses        []v1.ContainerStatus
+		expectedStatus  string
+		expectedBlocked bool
+	}{
+		{"no_init_containers", []v1.ContainerStatus{}, "", false},
+		{"completed", []v1.ContainerStatus{{Name: "config", State: completed}, {Name: "migrations", State: completed}}, "", false},
+		{"running", []v1.ContainerStatus{{Name: "config", State: completed}, {Name: "migrations", State: v1.ContainerState{Running: &v1.ContainerStateRunning{}}}}, "Init:Running (migrations 2/2)", true},
+		{"failed", []v1.ContainerStatus{{Name: "config", State: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{Reason: "Error", ExitCode: 1}}}, {Name: "migrations"}}, "Init:Error (config 1/2)", true},
+		{"failed_without_reason", []v1.ContainerStatus{{Name: "config", State: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{ExitCode: 2}}}}, "Init:ExitCode:2 (config 1/1)", true},
+		{"image_pull", []v1.ContainerStatus{{Name: "config", State: v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: "ImagePullBackOff"}}}}, "Init:ImagePullBackOff (config 1/1)", true},
+		{"not_started", []v1.ContainerStatus{{Name: "config"}}, "Init:Waiting (config 1/1)", true},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			pod := &v1.Pod{Status: v1.PodStatus{InitContainerStatuses: test.statuses}}
+			status, blocked := initContainersStatus(pod)
+			if blocked != test.expectedBlocked {
+				t.Fatalf("unexpected blocked value, got %t expected %t", blocked, test.expectedBlocked)
+			}
+			if status != test.expectedStatus {
+				t.Fatalf("unexpected status, got %q expected %q", status, test.expectedStatus)
+			}
+		})
+	}
+}
+
+func TestNewPodContainerStatus(t *testing.T) {
+
+	finished := metaV1.NewTime(time.Unix(1000, 0))
+	container := v1.ContainerStatus{
+		Name:         "nginx",
+		Image:        "nginx:1.17",
+		ImageID:      "docker-pullable://nginx@sha256:aaaa",
+		RestartCount: 3,
+		State:        v1.ContainerState{Running: &v1.ContainerStateRunning{}},
+		LastTerminationState: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{
+			Reason:     "OOMKilled",
+			ExitCode:   137,
+			FinishedAt: finished,
+		}},
+	}
+
+	status := newPodContainerStatus(ContainerTypeApp, container)
+	if status.State != ContainerStateRunning || status.ExitCode != 0 {
+		t.Fatalf("unexpected container state, got %s (%d) expected %s (0)", status.State, status.ExitCode, ContainerStateRunning)
+	}
+	if status.ImageID != container.ImageID || status.RestartCount != 3 {
+		t.Fatalf("unexpected container image id or restart count, got %+v", status)
+	}
+	if status.LastTermination == nil {
+		t.Fatalf("expected the container last termination")
+	}
+	if status.LastTermination.Reason != "OOMKilled" || status.LastTermination.ExitCode != 137 {
+		t.Fatalf("unexpected last termination, got %+v", *status.LastTermination)
+	}
+	if status.LastTermination.FinishedAt != finished.UnixNano() || status.LastTermination.StartedAt != 0 {
+		t.Fatalf("unexpected last termination times, got %+v", *status.LastTermination)
+	}
+
+	status = newPodContainerStatus(ContainerTypeApp, v1.ContainerStatus{Name: "nginx"})
+	if status.LastTermination != nil {
+		t.Fatalf("unexpected last termination of a container that was not restarted, got %+v", *status.LastTermination)
+	}
+}
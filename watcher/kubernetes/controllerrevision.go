@@ -4,14 +4,16 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	backoff "github.com/cenkalti/backoff/v4"
 	log "github.com/sirupsen/logrus"
 	appsV1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
 	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
-	"k8s.io/client-go/kubernetes"
+	"k8s.io/apimachinery/pkg/types"
 )
 
 //BackoffParams parameters
@@ -32,29 +34,56 @@ func NewBackOffParams() *BackoffParams {
 
 //ControllerRevision Main interface
 type ControllerRevision interface {
-	WatchControllerRevisionPods(ctx context.Context, logEntry log.Entry, registryData RegistryData, resourceGeneration int64, controllerRevisionlabels map[string]string, controllerRevisionHashlabelKey string, controllerRevisionPodLabelValuePerfix string, namespace string) error
-	WatchControllerRevisionPodsRetry(ctx context.Context, logEntry log.Entry, registryData RegistryData, resourceGeneration int64, controllerRevisionlabels map[string]string, controllerRevisionHashlabelKey string, controllerRevisionPodLabelValuePerfix string, namespace string, backOffParams *BackoffParams) error
+	WatchControllerRevisionPods(ctx context.Context, logEntry log.Entry, registryData RegistryData, watchHealth *WatchHealth, resourceGeneration int64, controllerRevisionlabels map[string]string, controllerRevisionHashlabelKey string, controllerRevisionPodLabelValuePerfix string, namespace string) error
+	WatchControllerRevisionPodsRetry(ctx context.Context, logEntry log.Entry, registryData RegistryData, watchHealth *WatchHealth, resourceGeneration int64, controllerRevisionlabels map[string]string, controllerRevisionHashlabelKey string, controllerRevisionPodLabelValuePerfix string, namespace string, backOffParams *BackoffParams) error
+	GetRollbackRevision(namespace string, ownerUID types.UID, controllerRevisionlabels map[string]string, template v1.PodTemplateSpec) (int64, bool)
+	RecordRevisions(namespace string, ownerUID types.UID, controllerRevisionlabels map[string]string, template v1.PodTemplateSpec)
+	ForgetRevisions(ownerUID types.UID)
+	GetPreviousTemplate(namespace string, ownerUID types.UID, controllerRevisionlabels map[string]string, template v1.PodTemplateSpec) (*v1.PodTemplateSpec, bool)
 }
 
 //ControllerRevisionManager Manager to interfact with Kubernetes kind
 type ControllerRevisionManager struct {
-	// Kubernetes client
-	client kubernetes.Interface
+	// informerManager lists the controller revisions from the informers cache
+	informerManager *InformerManager
 
 	// to find pods related
 	podsManager *PodsManager
+
+	// revisions holds the controller revisions of each owner as they were seen before its next apply
+	revisions map[types.UID]*revisionsRecord
+	lock      *sync.Mutex
 }
 
 // NewControllerRevisionManager create new instance of controllerRevision manager
-func NewControllerRevisionManager(client kubernetes.Interface, podsManager *PodsManager) *ControllerRevisionManager {
+func NewControllerRevisionManager(informerManager *InformerManager, podsManager *PodsManager) *ControllerRevisionManager {
 	return &ControllerRevisionManager{
-		client:      client,
-		podsManager: podsManager,
+		informerManager: informerManager,
+		podsManager:     podsManager,
+		revisions:       make(map[types.UID]*revisionsRecord),
+		lock:            &sync.Mutex{},
 	}
 }
 
+// listRevisions returns the controller revisions in the namespace that match the labels
+func (cr *ControllerRevisionManager) listRevisions(namespace string, controllerRevisionlabels map[string]string) ([]appsV1.ControllerRevision, error) {
+	objects, err := cr.informerManager.List(controllerRevisionsResource, namespace, metaV1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(controllerRevisionlabels).String()})
+	if err != nil {
+		return nil, err
+	}
+
+	revisions := []appsV1.ControllerRevision{}
+	for _, obj := range objects {
+		if revision, ok := obj.(*appsV1.ControllerRevision); ok {
+			revisions = append(revisions, *revision)
+		}
+	}
+	return revisions, nil
+}
+
 // WatchControllerRevisionPodsRetry perform exponential backoff retry on WatchControllerRevisionPods
-func (cr *ControllerRevisionManager) WatchControllerRevisionPodsRetry(ctx context.Context, logEntry log.Entry, registryData RegistryData, resourceGeneration int64, controllerRevisionlabels map[string]string, controllerRevisionHashlabelKey string, controllerRevisionPodLabelValuePerfix string, namespace string, backOff *BackoffParams) error {
+func (cr *ControllerRevisionManager) WatchControllerRevisionPodsRetry(ctx context.Context, logEntry log.Entry, registryData RegistryData, watchHealth *WatchHealth, resourceGeneration int64, controllerRevisionlabels map[string]string, controllerRevisionHashlabelKey string, controllerRevisionPodLabelValuePerfix string, namespace string, backOff *BackoffParams) error {
 	defaultParams := NewBackOffParams()
 	if backOff != nil {
 		defaultParams = backOff
@@ -66,7 +95,7 @@ func (cr *ControllerRevisionManager) WatchControllerRevisionPodsRetry(ctx contex
 	ticker := backoff.NewTicker(b)
 	var err error
 	for range ticker.C {
-		if err = cr.WatchControllerRevisionPods(ctx, logEntry, registryData, resourceGeneration, controllerRevisionlabels, controllerRevisionHashlabelKey, controllerRevisionPodLabelValuePerfix, namespace); err != nil {
+		if err = cr.WatchControllerRevisionPods(ctx, logEntry, registryData, watchHealth, resourceGeneration, controllerRevisionlabels, controllerRevisionHashlabelKey, controllerRevisionPodLabelValuePerfix, namespace); err != nil {
 			logEntry.Warn("retrying backoff")
 			continue
 		}
@@ -81,14 +110,70 @@ func (cr *ControllerRevisionManager) WatchControllerRevisionPodsRetry(ctx contex
 	return err
 }
 
+// GetRollbackRevision returns the revision that the resource was rolled back to, when the pod template matches
+// a controller revision that was older before the apply. The revisions are recorded again for the next apply
+func (cr *ControllerRevisionManager) GetRollbackRevision(namespace string, ownerUID types.UID, controllerRevisionlabels map[string]string, template v1.PodTemplateSpec) (int64, bool) {
+	revisions, err := cr.listRevisions(namespace, controllerRevisionlabels)
+	if err != nil {
+		log.WithError(err).WithField("namespace", namespace).Warn("could not list revisions, rollback detection is skipped")
+		return 0, false
+	}
+
+	cr.lock.Lock()
+	defer cr.lock.Unlock()
+	revision, isRollback := controllerRevisionRollbackRevision(ownerUID, template, revisions, cr.revisions[ownerUID])
+	cr.revisions[ownerUID] = newRevisionsRecord(ownerUID, template, revisions)
+	return revision, isRollback
+}
+
+// RecordRevisions records the controller revisions of the resource when they were not recorded yet, so the revision
+// numbers before the next apply are known even when the controller already bumped the number of a reused revision
+func (cr *ControllerRevisionManager) RecordRevisions(namespace string, ownerUID types.UID, controllerRevisionlabels map[string]string, template v1.PodTemplateSpec) {
+	cr.lock.Lock()
+	_, recorded := cr.revisions[ownerUID]
+	cr.lock.Unlock()
+	if recorded {
+		return
+	}
+
+	revisions, err := cr.listRevisions(namespace, controllerRevisionlabels)
+	if err != nil {
+		log.WithError(err).WithField("namespace", namespace).Warn("could not list revisions, the revisions are not recorded")
+		return
+	}
+
+	cr.lock.Lock()
+	defer cr.lock.Unlock()
+	if _, recorded := cr.revisions[ownerUID]; !recorded {
+		cr.revisions[ownerUID] = newRevisionsRecord(ownerUID, template, revisions)
+	}
+}
+
+// ForgetRevisions removes the recorded controller revisions of a deleted resource
+func (cr *ControllerRevisionManager) ForgetRevisions(ownerUID types.UID) {
+	cr.lock.Lock()
+	defer cr.lock.Unlock()
+	delete(cr.revisions, ownerUID)
+}
+
+// GetPreviousTemplate returns the pod template of the latest controller revision of the resource, when it doesn't match
+// the current pod template
+func (cr *ControllerRevisionManager) GetPreviousTemplate(namespace string, ownerUID types.UID, controllerRevisionlabels map[string]string, template v1.PodTemplateSpec) (*v1.PodTemplateSpec, bool) {
+	revisions, err := cr.listRevisions(namespace, controllerRevisionlabels)
+	if err != nil {
+		log.WithError(err).WithField("namespace", namespace).Warn("could not list revisions, spec diff is skipped")
+		return nil, false
+	}
+	return previousControllerRevisionTemplate(ownerUID, template, revisions)
+}
+
 // WatchControllerRevisionPods finds the correct pods to watch
 // 1. search a controllerrevision resource that is related to (statefulset or daemonset) using the version id and labels.
 // 2. once found, extract the controller-revision-hash value and look for pods with this annotation
 // 3. watch those pods.
-func (cr *ControllerRevisionManager) WatchControllerRevisionPods(ctx context.Context, logEntry log.Entry, registryData RegistryData, resourceGeneration int64, controllerRevisionlabels map[string]string, controllerRevisionHashlabelKey string, controllerRevisionPodLabelValuePerfix string, namespace string) error {
+func (cr *ControllerRevisionManager) WatchControllerRevisionPods(ctx context.Context, logEntry log.Entry, registryData RegistryData, watchHealth *WatchHealth, resourceGeneration int64, controllerRevisionlabels map[string]string, controllerRevisionHashlabelKey string, controllerRevisionPodLabelValuePerfix string, namespace string) error {
 	// find controller revision that fits the resource version`
-	revisions, err := cr.client.AppsV1().ControllerRevisions(namespace).List(metaV1.ListOptions{
-		LabelSelector: labels.SelectorFromSet(controllerRevisionlabels).String()})
+	revisions, err := cr.listRevisions(namespace, controllerRevisionlabels)
 
 	if err != nil {
 		logEntry.WithError(err).WithFields(log.Fields{
@@ -103,7 +188,7 @@ func (cr *ControllerRevisionManager) WatchControllerRevisionPods(ctx context.Con
 	})
 
 	// Get the revision hash inside controller revision
-	for _, revision := range revisions.Items {
+	for _, revision := range revisions {
 		if revision.Revision == resourceGeneration {
 
 			revisionLog.Info("Searching for controllerRevisionHash from the controllerRevisionHashlabelKey")
@@ -135,6 +220,7 @@ func (cr *ControllerRevisionManager) WatchControllerRevisionPods(ctx context.Con
 				Namespace:    namespace,
 				Ctx:          ctx,
 				LogEntry:     logEntry,
+				WatchHealth:  watchHealth,
 			}
 			return nil
 		}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	kuberneteswatcher "statusbay/watcher/kubernetes"
+	"statusbay/watcher/kubernetes/common"
 	"sync"
 
 	log "github.com/sirupsen/logrus"
@@ -11,6 +12,7 @@ import (
 	v1 "k8s.io/api/core/v1"
 	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/fake"
 )
 
@@ -31,9 +33,9 @@ func NewControllerRevisionManagerMock(client *fake.Clientset, podsManager *kuber
 	var wg *sync.WaitGroup
 	ctx := context.Background()
 	if podManager == nil {
-		eventManager := kuberneteswatcher.NewEventsManager(client)
+		eventManager := kuberneteswatcher.NewEventsManager(NewInformerManagerMock(client), common.EventsConfig{})
 		pvcManager := NewPvcManagerMock(client)
-		podManager = kuberneteswatcher.NewPodsManager(client, eventManager, pvcManager)
+		podManager = kuberneteswatcher.NewPodsManager(NewInformerManagerMock(client), eventManager, pvcManager, client, common.PodLogsConfig{})
 		podManager.Serve(ctx, wg)
 		eventManager.Serve(ctx, wg)
 	}
@@ -55,12 +57,12 @@ func stringInMap(str string, dict map[string]string) bool {
 }
 
 // WatchControllerRevisionPods dummy interface.
-func (mcr *MockControllerRevisionManager) WatchControllerRevisionPods(ctx context.Context, logEntry log.Entry, registryData kuberneteswatcher.RegistryData, resourceGeneration int64, controllerRevisionlabels map[string]string, controllerRevisionHashlabelKey string, controllerRevisionPodLabelValuePerfix string, namespace string) error {
+func (mcr *MockControllerRevisionManager) WatchControllerRevisionPods(ctx context.Context, logEntry log.Entry, registryData kuberneteswatcher.RegistryData, watchHealth *kuberneteswatcher.WatchHealth, resourceGeneration int64, controllerRevisionlabels map[string]string, controllerRevisionHashlabelKey string, controllerRevisionPodLabelValuePerfix string, namespace string) error {
 	return mcr.Error
 }
 
 // WatchControllerRevisionPodsRetry Implement a check in the interface to check whether a  controllerRevisionHashlabelKey is valid.
-func (mcr *MockControllerRevisionManager) WatchControllerRevisionPodsRetry(ctx context.Context, logEntry log.Entry, registryData kuberneteswatcher.RegistryData, resourceGeneration int64, controllerRevisionlabels map[string]string, controllerRevisionHashlabelKey string, controllerRevisionPodLabelValuePerfix string, namespace string, backOffParams *kuberneteswatcher.BackoffParams) error {
+func (mcr *MockControllerRevisionManager) WatchControllerRevisionPodsRetry(ctx context.Context, logEntry log.Entry, registryData kuberneteswatcher.RegistryData, watchHealth *kuberneteswatcher.WatchHealth, resourceGeneration int64, controllerRevisionlabels map[string]string, controllerRevisionHashlabelKey string, controllerRevisionPodLabelValuePerfix string, namespace string, backOffParams *kuberneteswatcher.BackoffParams) error {
 	// expectedOptionsOfControllerRevisionHashlabelKey in a Map
 	crhlk := map[string]string{"daemonset": appsV1.DefaultDaemonSetUniqueLabelKey, "statefulset": "controller.kubernetes.io/hash"}
 	if !stringInMap(controllerRevisionHashlabelKey, crhlk) {
@@ -72,6 +74,24 @@ func (mcr *MockControllerRevisionManager) WatchControllerRevisionPodsRetry(ctx c
 	return mcr.Error
 }
 
+// GetRollbackRevision dummy interface.
+func (mcr *MockControllerRevisionManager) GetRollbackRevision(namespace string, ownerUID types.UID, controllerRevisionlabels map[string]string, template v1.PodTemplateSpec) (int64, bool) {
+	return 0, false
+}
+
+// RecordRevisions dummy interface.
+func (mcr *MockControllerRevisionManager) RecordRevisions(namespace string, ownerUID types.UID, controllerRevisionlabels map[string]string, template v1.PodTemplateSpec) {
+}
+
+// ForgetRevisions dummy interface.
+func (mcr *MockControllerRevisionManager) ForgetRevisions(ownerUID types.UID) {
+}
+
+// GetPreviousTemplate dummy interface.
+func (mcr *MockControllerRevisionManager) GetPreviousTemplate(namespace string, ownerUID types.UID, controllerRevisionlabels map[string]string, template v1.PodTemplateSpec) (*v1.PodTemplateSpec, bool) {
+	return nil, false
+}
+
 // createControllerRevisionMock will create a mock a ControllerRevision Object.
 func createControllerRevisionMock(client *fake.Clientset, name string, namespace string, controllerRevisionHash string, controllerRevisionHashlabelKey string, labels map[string]string) *appsV1.ControllerRevision {
 	revision := &appsV1.ControllerRevision{
@@ -0,0 +1,459 @@
+package kuberneteswatcher
+
+import (
+	"context"
+	"fmt"
+	"statusbay/watcher/kubernetes/common"
+	"sync"
+	"time"
+
+	"github.com/mitchellh/hashstructure"
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	// CustomResourcePhaseProgressing when the resource status matched the progressing rule
+	CustomResourcePhaseProgressing = "progressing"
+
+	// CustomResourcePhaseSucceeded when the resource status matched the succeeded rule
+	CustomResourcePhaseSucceeded = "succeeded"
+
+	// CustomResourcePhaseFailed when the resource status matched the failed rule
+	CustomResourcePhaseFailed = "failed"
+
+	// defaultPodSelectorPath is the label selector path of most of the workload resources
+	defaultPodSelectorPath = "{.spec.selector.matchLabels}"
+
+	// defaultConditionStatus is the expected condition status when the rule not define one
+	defaultConditionStatus = "True"
+)
+
+// CustomResourceManager defined custom resources manager struct
+type CustomResourceManager struct {
+	// Informer manager will be owner to watch the custom resources
+	informerManager *InformerManager
+
+	// Event manager will be owner to start watch on custom resource events
+	eventManager *EventsManager
+
+	// Registry manager will be owner to manage the running / new custom resource applies
+	registryManager *RegistryManager
+
+	// Pods manager will watch the pods that selected by the custom resource
+	podsManager *PodsManager
+
+	// Will triggered when custom resource watch started
+	serviceManager *ServiceManager
+
+	// List of custom resources to watch
+	resources []common.CustomResourceConfig
+
+	// Max watch time
+	maxDeploymentTime int64
+
+	// Initial Running Applies to load on start
+	initialRunningApplies []*RegistryRow
+}
+
+// NewCustomResourceManager creates a new instance to manage the configured custom resources
+func NewCustomResourceManager(informerManager *InformerManager, eventManager *EventsManager, registryManager *RegistryManager, podsManager *PodsManager, serviceManager *ServiceManager, resources []common.CustomResourceConfig, runningApplies []*RegistryRow, maxDeploymentTime time.Duration) *CustomResourceManager {
+	return &CustomResourceManager{
+		informerManager:       informerManager,
+		eventManager:          eventManager,
+		registryManager:       registryManager,
+		podsManager:           podsManager,
+		serviceManager:        serviceManager,
+		resources:             resources,
+		maxDeploymentTime:     int64(maxDeploymentTime.Seconds()),
+		initialRunningApplies: runningApplies,
+	}
+}
+
+// Serve will start listening on custom resources requests
+func (crm *CustomResourceManager) Serve(ctx context.Context, wg *sync.WaitGroup) {
+	wg.Add(1)
+
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				log.Warn("custom resource manager has been shut down")
+				wg.Done()
+				return
+			}
+		}
+	}()
+
+	// Continue watching on running custom resources from storage state
+	runningApps := crm.initialRunningApplies
+	log.WithField("running_apps", len(runningApps)).Debug("loaded running applications in custom resource manager")
+	for _, application := range runningApps {
+		app := application
+		for resourceName, customResourceData := range application.DBSchema.Resources.CustomResources {
+			crData := customResourceData
+			resourceConfig, found := crm.getResourceConfig(crData.Group, crData.Version, crData.Resource)
+			if !found {
+				app.Log().Logger.WithFields(log.Fields{
+					"name":     crData.GetName(),
+					"resource": crData.Resource,
+				}).Warn("custom resource is not configured anymore, skipping loaded running resource")
+				continue
+			}
+			listOptions := metaV1.ListOptions{
+				FieldSelector: fields.OneTermEqualSelector("metadata.name", resourceName).String(),
+			}
+			app.Log().Logger.WithField("name", crData.GetName()).Debug("begining watching loaded running custom resource")
+			go func(app *RegistryRow, crData *CustomResourceData, resourceConfig common.CustomResourceConfig, listOptions metaV1.ListOptions) {
+				crm.watchCustomResource(app.ctx, app.cancelFn, app.Log(), app.DBSchema.WatchHealth, crData, resourceConfig, listOptions, crData.Metadata.Namespace, crData.ProgressDeadlineSeconds)
+			}(app, crData, resourceConfig, listOptions)
+		}
+	}
+	// we dont need that anymore
+	crm.initialRunningApplies = nil
+
+	// The informer of a custom resource is synced only when its definition is installed in the cluster,
+	// so each custom resource is watched without blocking the others
+	for _, resourceConfig := range crm.resources {
+		go crm.watchCustomResources(ctx, resourceConfig)
+	}
+}
+
+// watchCustomResources start watch on all the resources of the given custom resource config
+func (crm *CustomResourceManager) watchCustomResources(ctx context.Context, resourceConfig common.CustomResourceConfig) {
+	gvr := getGroupVersionResource(resourceConfig)
+	resourceLog := log.WithField("resource", gvr.String())
+
+	// Resume from the last handled resource version, so changes that were applied while the watcher was down are not missed
+	resourceVersion := crm.registryManager.LoadResourceVersion(gvr.GroupResource().String())
+	if resourceVersion == "" {
+		resourceVersion, _ = crm.informerManager.ResourceVersion(gvr)
+	}
+	watchListOptions := metaV1.ListOptions{ResourceVersion: resourceVersion}
+	watcher := crm.informerManager.ResumableWatch(ctx, *resourceLog, nil, gvr, "", watchListOptions)
+	go func() {
+		resourceLog.WithField("resource_version", resourceVersion).Info("custom resources watcher started")
+		for {
+			select {
+			case event, watch := <-watcher:
+				if !watch {
+					resourceLog.WithField("list_options", watchListOptions.String()).Info("custom resources watcher was stopped, channel was closed")
+					return
+				}
+				resource, ok := event.Object.(*unstructured.Unstructured)
+				if !ok {
+					resourceLog.WithField("object", event.Object).Warn("failed to parse custom resource watcher data")
+					continue
+				}
+				crm.registryManager.UpdateResourceVersion(gvr.GroupResource().String(), resource.GetResourceVersion())
+
+				resourceLog.WithFields(log.Fields{
+					"name":      resource.GetName(),
+					"namespace": resource.GetNamespace(),
+				}).Debug("custom resource event detected")
+				applicationName := GetApplicationName(resource.GetAnnotations(), resource.GetName())
+
+				if !common.IsSupportedEventType(event.Type) {
+					resourceLog.WithFields(log.Fields{
+						"event_type":  event.Type,
+						"application": applicationName,
+					}).Info("event type not supported")
+					continue
+				}
+
+				hash, _ := hashstructure.Hash(resource.Object["spec"], nil)
+				apply := ApplyEvent{
+					Event:           fmt.Sprintf("%v", event.Type),
+					ApplyName:       applicationName,
+					ResourceName:    resource.GetName(),
+					Namespace:       resource.GetNamespace(),
+					Kind:            gvr.GroupResource().String(),
+					Hash:            hash,
+					Annotations:     resource.GetAnnotations(),
+					Labels:          resource.GetLabels(),
+					UID:             resource.GetUID(),
+					ResourceVersion: resource.GetResourceVersion(),
+				}
+
+				appRegistry := crm.registryManager.NewApplyEvent(apply)
+				if appRegistry == nil {
+					continue
+				}
+
+				crLog := appRegistry.Log()
+				crLog.WithField("event", event.Type).Info("adding custom resource to apply registry")
+
+				registryApply := crm.AddNewCustomResource(apply, appRegistry, resourceConfig)
+
+				listOptions := metaV1.ListOptions{
+					FieldSelector: fields.OneTermEqualSelector("metadata.name", resource.GetName()).String(),
+				}
+
+				go crm.watchCustomResource(
+					appRegistry.ctx,
+					appRegistry.cancelFn,
+					crLog,
+					appRegistry.DBSchema.WatchHealth,
+					registryApply,
+					resourceConfig,
+					listOptions,
+					resource.GetNamespace(),
+					GetProgressDeadlineApply(resource.GetAnnotations(), crm.maxDeploymentTime))
+
+			case <-ctx.Done():
+				resourceLog.Warn("custom resources watcher was stopped, got ctx done signal")
+				return
+			}
+		}
+	}()
+}
+
+// watchCustomResource will watch a specific custom resource and its related resources (events + pods + services)
+func (crm *CustomResourceManager) watchCustomResource(ctx context.Context, cancelFn context.CancelFunc, lg log.Entry, health *WatchHealth, registryData *CustomResourceData, resourceConfig common.CustomResourceConfig, listOptions metaV1.ListOptions, namespace string, maxWatchTime int64) {
+
+	crLog := lg.WithFields(log.Fields{
+		"custom_resource_name": registryData.GetName(),
+		"custom_resource_kind": resourceConfig.Kind,
+	})
+	crLog.Info("start watching custom resource")
+	crLog.WithField("list_option", listOptions.String()).Debug("list option for custom resource filtering")
+
+	watcher := crm.informerManager.ResumableWatch(ctx, *crLog, health, getGroupVersionResource(resourceConfig), namespace, listOptions)
+	firstInit := true
+	for {
+		select {
+		case event, watch := <-watcher:
+			if !watch {
+				crLog.Warn("custom resource watcher was stopped, channel was closed")
+				cancelFn()
+				return
+			}
+			resource, isOk := event.Object.(*unstructured.Unstructured)
+			if !isOk {
+				crLog.WithField("object", event.Object).Warn("failed to parse custom resource watcher data")
+				continue
+			}
+			if firstInit {
+				firstInit = false
+				eventListOptions := metaV1.ListOptions{FieldSelector: labels.SelectorFromSet(map[string]string{
+					"involvedObject.name": resource.GetName(),
+					"involvedObject.kind": resourceConfig.Kind,
+				}).String(),
+					TimeoutSeconds: &maxWatchTime,
+				}
+
+				// Start watching on Events of the custom resource
+				crm.watchEvents(ctx, *crLog, health, registryData, eventListOptions, registryData.Metadata.objectReference(resourceConfig.Kind), namespace)
+
+				podLabels := getCustomResourcePodLabels(resource, resourceConfig.PodSelectorPath)
+				if len(podLabels) > 0 {
+					// Start watching on the pods that selected by the custom resource
+					crm.podsManager.Watch <- WatchData{
+						ListOptions:  metaV1.ListOptions{LabelSelector: labels.SelectorFromSet(podLabels).String()},
+						RegistryData: registryData,
+						Namespace:    namespace,
+						Ctx:          ctx,
+						LogEntry:     *crLog,
+						WatchHealth:  health,
+					}
+
+					// start service watch
+					crm.serviceManager.Watch <- WatchData{
+						ListOptions:  metaV1.ListOptions{TimeoutSeconds: &maxWatchTime, LabelSelector: labels.SelectorFromSet(podLabels).String()},
+						RegistryData: registryData,
+						Namespace:    namespace,
+						Ctx:          ctx,
+						LogEntry:     *crLog,
+						WatchHealth:  health,
+					}
+				} else {
+					crLog.WithField("pod_selector_path", resourceConfig.PodSelectorPath).Warn("custom resource pod selector not found, can't start watch on pods")
+				}
+			}
+			registryData.UpdateApplyStatus(resource, resourceConfig)
+		case <-ctx.Done():
+			crLog.Debug("custom resource watcher was stopped, got ctx done signal")
+			return
+		}
+	}
+}
+
+// watchEvents will watch for events related to the custom resource
+func (crm *CustomResourceManager) watchEvents(ctx context.Context, lg log.Entry, health *WatchHealth, registryData *CustomResourceData, listOptions metaV1.ListOptions, involvedObject v1.ObjectReference, namespace string) {
+
+	lg.Info("started the event watcher on custom resource events")
+	watchData := WatchEvents{
+		ListOptions:       listOptions,
+		Namespace:         namespace,
+		Ctx:               ctx,
+		LogEntry:          lg,
+		InvolvedObject:    involvedObject,
+		ApplyCreationTime: health.applyCreation(),
+	}
+
+	eventChan := crm.eventManager.Watch(watchData)
+	go func() {
+		for {
+			select {
+			case event := <-eventChan:
+				registryData.UpdateCustomResourceEvents(event)
+			case <-ctx.Done():
+				lg.Info("stopped the event watcher on custom resource events")
+				return
+			}
+		}
+	}()
+}
+
+// AddNewCustomResource add a new custom resource under application settings
+func (crm *CustomResourceManager) AddNewCustomResource(data ApplyEvent, applicationRegistry *RegistryRow, resourceConfig common.CustomResourceConfig) *CustomResourceData {
+
+	log := applicationRegistry.Log()
+	crd := &CustomResourceData{
+		Metadata: MetaData{
+			Name:            data.ApplyName,
+			Namespace:       data.Namespace,
+			Annotations:     data.Annotations,
+			Labels:          data.Labels,
+			UID:             data.UID,
+			ResourceVersion: data.ResourceVersion,
+			Metrics:         GetMetricsDataFromAnnotations(data.Annotations),
+			Alerts:          GetAlertsDataFromAnnotations(data.Annotations),
+		},
+		Group:                   resourceConfig.Group,
+		Version:                 resourceConfig.Version,
+		Resource:                resourceConfig.Resource,
+		Kind:                    resourceConfig.Kind,
+		Events:                  make([]EventMessages, 0),
+		Pods:                    make(map[string]DeploymenPod, 0),
+		Services:                make(map[string]ServicesData, 0),
+		ProgressDeadlineSeconds: GetProgressDeadlineApply(data.Annotations, crm.maxDeploymentTime),
+	}
+	applicationRegistry.DBSchema.Resources.CustomResources[data.ResourceName] = crd
+
+	log.WithField("kind", resourceConfig.Kind).Info("custom resource was associated to the application")
+
+	return crd
+
+}
+
+// getResourceConfig returns the configuration of the given custom resource
+func (crm *CustomResourceManager) getResourceConfig(group, version, resource string) (common.CustomResourceConfig, bool) {
+	for _, resourceConfig := range crm.resources {
+		if resourceConfig.Group == group && resourceConfig.Version == version && resourceConfig.Resource == resource {
+			return resourceConfig, true
+		}
+	}
+	return common.CustomResourceConfig{}, false
+}
+
+// getGroupVersionResource returns the GVR of the custom resource configuration
+func getGroupVersionResource(resourceConfig common.CustomResourceConfig) schema.GroupVersionResource {
+	return schema.GroupVersionResource{
+		Group:    resourceConfig.Group,
+		Version:  resourceConfig.Version,
+		Resource: resourceConfig.Resource,
+	}
+}
+
+// getCustomResourcePodLabels returns the label set that selects the custom resource pods
+func getCustomResourcePodLabels(resource *unstructured.Unstructured, podSelectorPath string) map[string]string {
+	if podSelectorPath == "" {
+		podSelectorPath = defaultPodSelectorPath
+	}
+	values, err := getFieldPathValues(resource, podSelectorPath)
+	if err != nil || len(values) == 0 {
+		return nil
+	}
+	selector, ok := values[0].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	podLabels := map[string]string{}
+	for key, value := range selector {
+		label, ok := value.(string)
+		if !ok {
+			return nil
+		}
+		podLabels[key] = label
+	}
+	return podLabels
+}
+
+// GetCustomResourcePhase returns the phase of the custom resource by the configured status rules.
+// The failed rule is checked first, then the succeeded and the progressing rules
+func GetCustomResourcePhase(resource *unstructured.Unstructured, resourceConfig common.CustomResourceConfig) string {
+	switch {
+	case matchStatusRule(resource, resourceConfig.Failed):
+		return CustomResourcePhaseFailed
+	case matchStatusRule(resource, resourceConfig.Succeeded):
+		return CustomResourcePhaseSucceeded
+	case matchStatusRule(resource, resourceConfig.Progressing):
+		return CustomResourcePhaseProgressing
+	}
+	return ""
+}
+
+// matchStatusRule checks if the custom resource matches the given status rule
+func matchStatusRule(resource *unstructured.Unstructured, rule common.CustomResourceStatusRule) bool {
+
+	if rule.FieldPath != "" {
+		values, err := getFieldPathValues(resource, rule.FieldPath)
+		if err == nil {
+			for _, value := range values {
+				for _, expected := range rule.Values {
+					if fmt.Sprintf("%v", value) == expected {
+						return true
+					}
+				}
+			}
+		}
+	}
+
+	if rule.ConditionType != "" {
+		expectedStatus := rule.ConditionStatus
+		if expectedStatus == "" {
+			expectedStatus = defaultConditionStatus
+		}
+		conditions, found, err := unstructured.NestedSlice(resource.Object, "status", "conditions")
+		if err != nil || !found {
+			return false
+		}
+		for _, condition := range conditions {
+			conditionData, ok := condition.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if conditionData["type"] == rule.ConditionType && conditionData["status"] == expectedStatus {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// getFieldPathValues returns the values that the JSONPath field path selects in the custom resource.
+// The field path is parsed on each call, the parsed JSONPath can't be shared between the watchers
+func getFieldPathValues(resource *unstructured.Unstructured, fieldPath string) ([]interface{}, error) {
+	parser, err := common.ParseFieldPath(fieldPath)
+	if err != nil {
+		return nil, err
+	}
+	results, err := parser.FindResults(resource.Object)
+	if err != nil {
+		return nil, err
+	}
+	values := []interface{}{}
+	for _, result := range results {
+		for _, value := range result {
+			values = append(values, value.Interface())
+		}
+	}
+	return values, nil
+}
@@ -0,0 +1,220 @@
+package kuberneteswatcher_test
+
+import (
+	"context"
+	kuberneteswatcher "statusbay/watcher/kubernetes"
+	"statusbay/watcher/kubernetes/common"
+	"statusbay/watcher/kubernetes/testutil"
+	"sync"
+	"testing"
+	"time"
+
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicFake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+var rolloutResourceConfig = common.CustomResourceConfig{
+	Group:    "argoproj.io",
+	Version:  "v1alpha1",
+	Resource: "rollouts",
+	Kind:     "Rollout",
+	Progressing: common.CustomResourceStatusRule{
+		FieldPath: "status.phase",
+		Values:    []string{"Progressing"},
+	},
+	Succeeded: common.CustomResourceStatusRule{
+		FieldPath: "status.phase",
+		Values:    []string{"Healthy"},
+	},
+	Failed: common.CustomResourceStatusRule{
+		ConditionType:   "Aborted",
+		ConditionStatus: "True",
+	},
+}
+
+func createRolloutMock(client *dynamicFake.FakeDynamicClient, name string, namespace string, phase string) *unstructured.Unstructured {
+	rollout := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "argoproj.io/v1alpha1",
+			"kind":       "Rollout",
+			"metadata": map[string]interface{}{
+				"name":      name,
+				"namespace": namespace,
+				"annotations": map[string]interface{}{
+					"statusbay.io/report-deploy-by":          "foo@example.com",
+					"statusbay.io/progress-deadline-seconds": "30",
+				},
+			},
+			"spec": map[string]interface{}{
+				"replicas": int64(1),
+				"selector": map[string]interface{}{
+					"matchLabels": map[string]interface{}{
+						"app": name,
+					},
+				},
+			},
+			"status": map[string]interface{}{
+				"phase": phase,
+			},
+		},
+	}
+	gvr := schema.GroupVersionResource{Group: "argoproj.io", Version: "v1alpha1", Resource: "rollouts"}
+	rollout, _ = client.Resource(gvr).Namespace(namespace).Create(rollout, metaV1.CreateOptions{})
+	return rollout
+}
+
+func NewCustomResourceManagerMock(client *fake.Clientset, dynamicClient *dynamicFake.FakeDynamicClient) (*kuberneteswatcher.CustomResourceManager, *testutil.MockStorage) {
+	maxDeploymentTime, _ := time.ParseDuration("10m")
+	eventManager := NewEventsMock(client)
+	registryManager, storage := NewRegistryMock()
+	serviceManager := NewServiceManagerMockMock(client)
+	pvcManager := NewPvcManagerMock(client)
+	podManager := kuberneteswatcher.NewPodsManager(NewInformerManagerMock(client), eventManager, pvcManager, client, common.PodLogsConfig{})
+	runningApplies := registryManager.LoadRunningApplies()
+	informerManager := kuberneteswatcher.NewInformerManager(client, dynamicClient, 0, nil)
+	customResourceManager := kuberneteswatcher.NewCustomResourceManager(informerManager, eventManager, registryManager, podManager, serviceManager, []common.CustomResourceConfig{rolloutResourceConfig}, runningApplies, maxDeploymentTime)
+
+	var wg sync.WaitGroup
+	ctx := context.Background()
+
+	informerManager.Serve(ctx, &wg)
+	eventManager.Serve(ctx, &wg)
+	serviceManager.Serve(ctx, &wg)
+	podManager.Serve(ctx, &wg)
+	customResourceManager.Serve(ctx, &wg)
+
+	return customResourceManager, storage
+}
+
+func TestCustomResourceWatch(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	dynamicClient := dynamicFake.NewSimpleDynamicClient(runtime.NewScheme())
+	_, storage := NewCustomResourceManagerMock(client, dynamicClient)
+	namespace := "rollouts"
+
+	rollout := createRolloutMock(dynamicClient, "checkout", namespace, "Progressing")
+	time.Sleep(time.Second)
+
+	// The per apply watcher gets only the changes after it was started
+	gvr := schema.GroupVersionResource{Group: "argoproj.io", Version: "v1alpha1", Resource: "rollouts"}
+	unstructured.SetNestedField(rollout.Object, "Healthy", "status", "phase")
+	dynamicClient.Resource(gvr).Namespace(namespace).Update(rollout, metaV1.UpdateOptions{})
+	time.Sleep(time.Second * 2)
+
+	application := storage.MockWriteDeployment["1"]
+
+	t.Run("custom_resource_schema_data", func(t *testing.T) {
+		if application.Schema.Application != "checkout" {
+			t.Fatalf("unexpected application name, got %s expected %s", application.Schema.Application, "checkout")
+		}
+		customResource, found := application.Schema.Resources.CustomResources["checkout"]
+		if !found {
+			t.Fatalf("custom resource not found in application resources")
+		}
+		if customResource.Kind != "Rollout" {
+			t.Fatalf("unexpected custom resource kind, got %s expected %s", customResource.Kind, "Rollout")
+		}
+		if customResource.ProgressDeadlineSeconds != 30 {
+			t.Fatalf("unexpected values for ProgressDeadline field, got %d expected %d", customResource.ProgressDeadlineSeconds, 30)
+		}
+	})
+
+	t.Run("custom_resource_phase", func(t *testing.T) {
+		phase := application.Schema.Resources.CustomResources["checkout"].Phase
+		if phase != kuberneteswatcher.CustomResourcePhaseSucceeded {
+			t.Fatalf("unexpected custom resource phase, got %s expected %s", phase, kuberneteswatcher.CustomResourcePhaseSucceeded)
+		}
+	})
+}
+
+func TestGetCustomResourcePhase(t *testing.T) {
+
+	testCases := []struct {
+		name          string
+		status        map[string]interface{}
+		expectedPhase string
+	}{
+		{"unknown", map[string]interface{}{}, ""},
+		{"progressing", map[string]interface{}{"phase": "Progressing"}, kuberneteswatcher.CustomResourcePhaseProgressing},
+		{"succeeded", map[string]interface{}{"phase": "Healthy"}, kuberneteswatcher.CustomResourcePhaseSucceeded},
+		{"failed_condition", map[string]interface{}{
+			"phase": "Progressing",
+			"conditions": []interface{}{
+				map[string]interface{}{"type": "Aborted", "status": "True"},
+			},
+		}, kuberneteswatcher.CustomResourcePhaseFailed},
+		{"condition_not_matched", map[string]interface{}{
+			"conditions": []interface{}{
+				map[string]interface{}{"type": "Aborted", "status": "False"},
+			},
+		}, ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resource := &unstructured.Unstructured{Object: map[string]interface{}{"status": tc.status}}
+			phase := kuberneteswatcher.GetCustomResourcePhase(resource, rolloutResourceConfig)
+			if phase != tc.expectedPhase {
+				t.Fatalf("unexpected custom resource phase, got %s expected %s", phase, tc.expectedPhase)
+			}
+		})
+	}
+}
+
+func TestGetCustomResourcePhaseJSONPath(t *testing.T) {
+
+	resourceConfig := common.CustomResourceConfig{
+		Progressing: common.CustomResourceStatusRule{
+			FieldPath: `{.status.conditions[?(@.type=="Progressing")].status}`,
+			Values:    []string{"True"},
+		},
+		Succeeded: common.CustomResourceStatusRule{
+			FieldPath: `{.status.conditions[?(@.type=="Ready")].status}`,
+			Values:    []string{"True"},
+		},
+		Failed: common.CustomResourceStatusRule{
+			FieldPath: "{.status.replicaStatuses[*].phase}",
+			Values:    []string{"Degraded"},
+		},
+	}
+
+	testCases := []struct {
+		name          string
+		status        map[string]interface{}
+		expectedPhase string
+	}{
+		{"missing_fields", map[string]interface{}{}, ""},
+		{"filter_progressing", map[string]interface{}{
+			"conditions": []interface{}{
+				map[string]interface{}{"type": "Progressing", "status": "True"},
+				map[string]interface{}{"type": "Ready", "status": "False"},
+			},
+		}, kuberneteswatcher.CustomResourcePhaseProgressing},
+		{"filter_succeeded", map[string]interface{}{
+			"conditions": []interface{}{
+				map[string]interface{}{"type": "Progressing", "status": "False"},
+				map[string]interface{}{"type": "Ready", "status": "True"},
+			},
+		}, kuberneteswatcher.CustomResourcePhaseSucceeded},
+		{"array_wildcard_failed", map[string]interface{}{
+			"replicaStatuses": []interface{}{
+				map[string]interface{}{"phase": "Healthy"},
+				map[string]interface{}{"phase": "Degraded"},
+			},
+		}, kuberneteswatcher.CustomResourcePhaseFailed},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resource := &unstructured.Unstructured{Object: map[string]interface{}{"status": tc.status}}
+			phase := kuberneteswatcher.GetCustomResourcePhase(resource, resourceConfig)
+			if phase != tc.expectedPhase {
+				t.Fatalf("unexpected custom resource phase, got %s expected %s", phase, tc.expectedPhase)
+			}
+		})
+	}
+}
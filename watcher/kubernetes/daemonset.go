@@ -10,14 +10,16 @@ import (
 	"github.com/mitchellh/hashstructure"
 	log "github.com/sirupsen/logrus"
 	appsV1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
 	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	eventwatch "k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/kubernetes"
 )
 
 type DaemonsetManager struct {
-	// Kubernetes client
-	client kubernetes.Interface
+	// Informer manager will be owner to watch the Kubernetes resources
+	informerManager *InformerManager
 
 	// Event manager will be owner to start watch on deployment events
 	eventManager *EventsManager
@@ -30,6 +32,10 @@ type DaemonsetManager struct {
 
 	//
 	controllerRevManager ControllerRevision
+
+	// Kubernetes client, used to get the nodes of the daemonset pods that are not ready
+	client kubernetes.Interface
+
 	// Max watch time
 	maxDeploymentTime int64
 
@@ -38,13 +44,14 @@ type DaemonsetManager struct {
 }
 
 //NewDaemonsetManager  create new instance to manage damonset related things
-func NewDaemonsetManager(kubernetesClientset kubernetes.Interface, eventManager *EventsManager, registryManager *RegistryManager, serviceManager *ServiceManager, controllerRevisionManager ControllerRevision, runningApplies []*RegistryRow, maxDeploymentTime time.Duration) *DaemonsetManager {
+func NewDaemonsetManager(informerManager *InformerManager, eventManager *EventsManager, registryManager *RegistryManager, serviceManager *ServiceManager, controllerRevisionManager ControllerRevision, client kubernetes.Interface, runningApplies []*RegistryRow, maxDeploymentTime time.Duration) *DaemonsetManager {
 	return &DaemonsetManager{
-		client:                kubernetesClientset,
+		informerManager:       informerManager,
 		eventManager:          eventManager,
 		registryManager:       registryManager,
 		serviceManager:        serviceManager,
 		controllerRevManager:  controllerRevisionManager,
+		client:                client,
 		maxDeploymentTime:     int64(maxDeploymentTime.Seconds()),
 		initialRunningApplies: runningApplies,
 	}
@@ -79,6 +86,7 @@ func (dsm *DaemonsetManager) Serve(ctx context.Context, wg *sync.WaitGroup) {
 					app.ctx,
 					app.cancelFn,
 					app.Log(),
+					app.DBSchema.WatchHealth,
 					dsData,
 					listOptions,
 					dsData.Metadata.Namespace,
@@ -94,21 +102,20 @@ func (dsm *DaemonsetManager) Serve(ctx context.Context, wg *sync.WaitGroup) {
 }
 
 func (dsm *DaemonsetManager) watchDaemonsets(ctx context.Context) {
-	daemonsetsList, _ := dsm.client.AppsV1().DaemonSets("").List(metaV1.ListOptions{})
-	daemonsetWatchListOptions := metaV1.ListOptions{ResourceVersion: daemonsetsList.GetResourceVersion()}
-	watcher, err := dsm.client.AppsV1().DaemonSets("").Watch(daemonsetWatchListOptions)
-	if err != nil {
-		log.WithError(err).WithField("list_option", daemonsetWatchListOptions.String()).Error("could not start a watcher on daemonsets")
-		return
+	// Resume from the last handled resource version, so changes that were applied while the watcher was down are not missed
+	resourceVersion := dsm.registryManager.LoadResourceVersion(daemonsetsResource.GroupResource().String())
+	if resourceVersion == "" {
+		resourceVersion, _ = dsm.informerManager.ResourceVersion(daemonsetsResource)
 	}
+	daemonsetWatchListOptions := metaV1.ListOptions{ResourceVersion: resourceVersion}
+	watcher := dsm.informerManager.ResumableWatch(ctx, *log.WithField("resource", daemonsetsResource.String()), nil, daemonsetsResource, "", daemonsetWatchListOptions)
 	go func() {
-		log.WithField("resource_version", daemonsetsList.GetResourceVersion()).Info("daemonsets watcher was started")
+		log.WithField("resource_version", resourceVersion).Info("daemonsets watcher was started")
 		for {
 			select {
-			case event, watch := <-watcher.ResultChan():
+			case event, watch := <-watcher:
 				if !watch {
-					log.WithField("list_options", daemonsetWatchListOptions.String()).Info("daemonsets watcher was stopped. Reopen the channel")
-					dsm.watchDaemonsets(ctx)
+					log.WithField("list_options", daemonsetWatchListOptions.String()).Info("daemonsets watcher was stopped, channel was closed")
 					return
 				}
 				daemonset, ok := event.Object.(*appsV1.DaemonSet)
@@ -116,6 +123,7 @@ func (dsm *DaemonsetManager) watchDaemonsets(ctx context.Context) {
 					log.WithField("object", event.Object).Warn("failed to parse daemonset watcher data")
 					continue
 				}
+				dsm.registryManager.UpdateResourceVersion(daemonsetsResource.GroupResource().String(), daemonset.GetResourceVersion())
 
 				log.WithFields(log.Fields{
 					"name":      daemonset.GetName(),
@@ -127,24 +135,43 @@ func (dsm *DaemonsetManager) watchDaemonsets(ctx context.Context) {
 
 					hash, _ := hashstructure.Hash(daemonset.Spec, nil)
 					apply := ApplyEvent{
-						Event:        fmt.Sprintf("%v", event.Type),
-						ApplyName:    daemonsetName,
-						ResourceName: daemonset.GetName(),
-						Namespace:    daemonset.GetNamespace(),
-						Kind:         "daemonset",
-						Hash:         hash,
-						Annotations:  daemonset.GetAnnotations(),
-						Labels:       daemonset.GetLabels(),
+						Event:           fmt.Sprintf("%v", event.Type),
+						ApplyName:       daemonsetName,
+						ResourceName:    daemonset.GetName(),
+						Namespace:       daemonset.GetNamespace(),
+						Kind:            "daemonset",
+						Hash:            hash,
+						Annotations:     daemonset.GetAnnotations(),
+						Labels:          daemonset.GetLabels(),
+						UID:             daemonset.GetUID(),
+						ResourceVersion: daemonset.GetResourceVersion(),
+					}
+
+					if event.Type == eventwatch.Deleted {
+						dsm.controllerRevManager.ForgetRevisions(daemonset.GetUID())
 					}
 
 					appRegistry := dsm.registryManager.NewApplyEvent(apply)
 					if appRegistry == nil {
+						// The revisions are recorded before the next apply, a rollback bumps the number of the reused revision
+						if event.Type != eventwatch.Deleted && daemonset.Spec.Selector != nil {
+							dsm.controllerRevManager.RecordRevisions(daemonset.GetNamespace(), daemonset.GetUID(), daemonset.Spec.Selector.MatchLabels, daemonset.Spec.Template)
+						}
 						continue
 					}
 					daemonsetLog := appRegistry.Log()
 					daemonsetLog.WithField("event", event.Type).Info("adding demonset to apply registry")
 
-					registryApply := dsm.AddNewDaemonset(apply, appRegistry, daemonset.Status.DesiredNumberScheduled)
+					registryApply := dsm.AddNewDaemonset(apply, appRegistry, daemonset.Status.DesiredNumberScheduled, getDaemonsetUpdateStrategy(daemonset))
+
+					if event.Type != eventwatch.Deleted && daemonset.Spec.Selector != nil {
+						if revision, isRollback := dsm.controllerRevManager.GetRollbackRevision(daemonset.GetNamespace(), daemonset.GetUID(), daemonset.Spec.Selector.MatchLabels, daemonset.Spec.Template); isRollback {
+							appRegistry.SetRollback("daemonset", daemonset.GetName(), revision)
+						}
+						if previous, found := dsm.controllerRevManager.GetPreviousTemplate(daemonset.GetNamespace(), daemonset.GetUID(), daemonset.Spec.Selector.MatchLabels, daemonset.Spec.Template); found {
+							appRegistry.AddSpecChanges("daemonset", daemonset.GetName(), podTemplateSpecChanges(*previous, daemonset.Spec.Template))
+						}
+					}
 
 					daemonsetWatchListOptions := metaV1.ListOptions{
 						LabelSelector: labels.SelectorFromSet(daemonset.GetLabels()).String()}
@@ -153,6 +180,7 @@ func (dsm *DaemonsetManager) watchDaemonsets(ctx context.Context) {
 						appRegistry.ctx,
 						appRegistry.cancelFn,
 						daemonsetLog,
+						appRegistry.DBSchema.WatchHealth,
 						registryApply,
 						daemonsetWatchListOptions,
 						daemonset.GetNamespace(),
@@ -166,7 +194,6 @@ func (dsm *DaemonsetManager) watchDaemonsets(ctx context.Context) {
 				}
 			case <-ctx.Done():
 				log.Warn("Daemonset watch was stopped. Got ctx done signal")
-				watcher.Stop()
 				return
 			}
 		}
@@ -174,21 +201,17 @@ func (dsm *DaemonsetManager) watchDaemonsets(ctx context.Context) {
 }
 
 // watchDaemonset will watch a specific daemonset and its related resources (controller revision + pods)
-func (dsm *DaemonsetManager) watchDaemonset(ctx context.Context, cancelFn context.CancelFunc, lg log.Entry, daemonsetData *DaemonsetData, listOptions metaV1.ListOptions, namespace string, maxWatchTime int64) {
+func (dsm *DaemonsetManager) watchDaemonset(ctx context.Context, cancelFn context.CancelFunc, lg log.Entry, health *WatchHealth, daemonsetData *DaemonsetData, listOptions metaV1.ListOptions, namespace string, maxWatchTime int64) {
 
 	daemonsetLog := lg.WithField("daemonset_name", daemonsetData.GetName())
 	daemonsetLog.Info("Starting watch on Daemonset")
 	daemonsetLog.WithField("list_option", listOptions.String()).Debug("list option for daemonset filtering")
 
-	watcher, err := dsm.client.AppsV1().DaemonSets(namespace).Watch(listOptions)
-	if err != nil {
-		daemonsetLog.WithError(err).Error("could not start watch on daemonset")
-		return
-	}
+	watcher := dsm.informerManager.ResumableWatch(ctx, *daemonsetLog, health, daemonsetsResource, namespace, listOptions)
 	firstInit := true
 	for {
 		select {
-		case event, watch := <-watcher.ResultChan():
+		case event, watch := <-watcher:
 			if !watch {
 				daemonsetLog.Warn("daemonset watcher was stopped, channel was closed")
 				cancelFn()
@@ -207,10 +230,10 @@ func (dsm *DaemonsetManager) watchDaemonset(ctx context.Context, cancelFn contex
 				}).String(),
 					TimeoutSeconds: &maxWatchTime,
 				}
-				dsm.watchEvents(ctx, *daemonsetLog, daemonsetData, eventListOptions, namespace)
+				dsm.watchEvents(ctx, *daemonsetLog, health, daemonsetData, eventListOptions, daemonsetData.Metadata.objectReference("DaemonSet"), namespace)
 
 				// start pods watch
-				dsm.controllerRevManager.WatchControllerRevisionPodsRetry(ctx, *daemonsetLog, daemonsetData,
+				dsm.controllerRevManager.WatchControllerRevisionPodsRetry(ctx, *daemonsetLog, daemonsetData, health,
 					daemonset.ObjectMeta.Generation,
 					daemonset.Spec.Selector.MatchLabels,
 					appsV1.DefaultDaemonSetUniqueLabelKey,
@@ -218,6 +241,9 @@ func (dsm *DaemonsetManager) watchDaemonset(ctx context.Context, cancelFn contex
 					namespace,
 					nil)
 
+				// start the per node rollout watch
+				dsm.watchNodes(ctx, *daemonsetLog, health, daemonsetData, daemonset.Spec.Selector.MatchLabels, namespace, daemonsetTemplateGeneration(daemonset))
+
 				// start service watch
 				dsm.serviceManager.Watch <- WatchData{
 					ListOptions:  metaV1.ListOptions{TimeoutSeconds: &maxWatchTime, LabelSelector: labels.SelectorFromSet(daemonset.Spec.Selector.MatchLabels).String()},
@@ -225,26 +251,28 @@ func (dsm *DaemonsetManager) watchDaemonset(ctx context.Context, cancelFn contex
 					Namespace:    daemonset.Namespace,
 					Ctx:          ctx,
 					LogEntry:     *daemonsetLog,
+					WatchHealth:  health,
 				}
 			}
 			daemonsetData.UpdateApplyStatus(daemonset.Status)
 		case <-ctx.Done():
 			daemonsetLog.Debug("daemonset watcher was stopped, got ctx done signal")
-			watcher.Stop()
 			return
 		}
 	}
 }
 
 // watchEvents will watch for events related to the Daemonset Resource
-func (dsm *DaemonsetManager) watchEvents(ctx context.Context, lg log.Entry, daemonsetData *DaemonsetData, listOptions metaV1.ListOptions, namespace string) {
+func (dsm *DaemonsetManager) watchEvents(ctx context.Context, lg log.Entry, health *WatchHealth, daemonsetData *DaemonsetData, listOptions metaV1.ListOptions, involvedObject v1.ObjectReference, namespace string) {
 	lg.Info("initializing the event watcher on daemonset events")
 
 	watchData := WatchEvents{
-		ListOptions: listOptions,
-		Namespace:   namespace,
-		Ctx:         ctx,
-		LogEntry:    lg,
+		ListOptions:       listOptions,
+		Namespace:         namespace,
+		Ctx:               ctx,
+		LogEntry:          lg,
+		InvolvedObject:    involvedObject,
+		ApplyCreationTime: health.applyCreation(),
 	}
 	eventChan := dsm.eventManager.Watch(watchData)
 	go func() {
@@ -261,22 +289,29 @@ func (dsm *DaemonsetManager) watchEvents(ctx context.Context, lg log.Entry, daem
 }
 
 // AddNewDaemonset add new daemonset under application
-func (dsm *DaemonsetManager) AddNewDaemonset(data ApplyEvent, applicationRegistry *RegistryRow, desiredState int32) *DaemonsetData {
+func (dsm *DaemonsetManager) AddNewDaemonset(data ApplyEvent, applicationRegistry *RegistryRow, desiredState int32, updateStrategy string) *DaemonsetData {
 
 	log := applicationRegistry.Log()
 	dd := &DaemonsetData{
 		Metadata: MetaData{
-			Name:         data.ApplyName,
-			Namespace:    data.Namespace,
-			Annotations:  data.Annotations,
-			Labels:       data.Labels,
-			Metrics:      GetMetricsDataFromAnnotations(data.Annotations),
-			Alerts:       GetAlertsDataFromAnnotations(data.Annotations),
-			DesiredState: desiredState,
+			Name:            data.ApplyName,
+			Namespace:       data.Namespace,
+			Annotations:     data.Annotations,
+			Labels:          data.Labels,
+			UID:             data.UID,
+			ResourceVersion: data.ResourceVersion,
+			Metrics:         GetMetricsDataFromAnnotations(data.Annotations),
+			Alerts:          GetAlertsDataFromAnnotations(data.Annotations),
+			SuccessCriteria: GetSuccessCriteriaFromAnnotations(data.Annotations),
+			DesiredState:    desiredState,
 		},
+		// The status is updated by the daemonset watch, until then the apply isn't finished without the desired pods
+		Status:                  appsV1.DaemonSetStatus{DesiredNumberScheduled: desiredState},
 		Pods:                    make(map[string]DeploymenPod, 0),
 		Services:                make(map[string]ServicesData, 0),
 		ProgressDeadlineSeconds: GetProgressDeadlineApply(data.Annotations, dsm.maxDeploymentTime),
+		UpdateStrategy:          updateStrategy,
+		Nodes:                   make(map[string]DaemonsetNode, 0),
 	}
 	applicationRegistry.DBSchema.Resources.Daemonsets[data.ResourceName] = dd
 
@@ -284,3 +319,11 @@ func (dsm *DaemonsetManager) AddNewDaemonset(data ApplyEvent, applicationRegistr
 
 	return dd
 }
+
+// getDaemonsetUpdateStrategy returns the update strategy type of the daemonset, RollingUpdate when it is not set
+func getDaemonsetUpdateStrategy(daemonset *appsV1.DaemonSet) string {
+	if daemonset.Spec.UpdateStrategy.Type == "" {
+		return string(appsV1.RollingUpdateDaemonSetStrategyType)
+	}
+	return string(daemonset.Spec.UpdateStrategy.Type)
+}
@@ -62,10 +62,10 @@ func NewDaemonSetManagerMock(client *fake.Clientset) (*kuberneteswatcher.Daemons
 	registryManager, storage := NewRegistryMock()
 	serviceManager := NewServiceManagerMockMock(client)
 	pvcManager := NewPvcManagerMock(client)
-	podManager := kuberneteswatcher.NewPodsManager(client, eventManager, pvcManager)
+	podManager := kuberneteswatcher.NewPodsManager(NewInformerManagerMock(client), eventManager, pvcManager, client, common.PodLogsConfig{})
 	controllerRevisionManager := NewControllerRevisionManagerMock(client, podManager)
 	runningApplies := registryManager.LoadRunningApplies()
-	daemonsetManager := kuberneteswatcher.NewDaemonsetManager(client, eventManager, registryManager, serviceManager, controllerRevisionManager, runningApplies, maxDeploymentTime)
+	daemonsetManager := kuberneteswatcher.NewDaemonsetManager(NewInformerManagerMock(client), eventManager, registryManager, serviceManager, controllerRevisionManager, client, runningApplies, maxDeploymentTime)
 
 	var wg sync.WaitGroup
 	ctx := context.Background()
@@ -130,6 +130,18 @@ func TestDaemonsetWatch(t *testing.T) {
 	revision.Revision = daemonsetObj.ObjectMeta.Generation
 	client.AppsV1().ControllerRevisions(namespace).Create(revision)
 
+	// create a pending pod of the daemonset on a cordoned node
+	client.CoreV1().Nodes().Create(&v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}, Spec: v1.NodeSpec{Unschedulable: true}})
+	client.CoreV1().Pods(namespace).Create(&v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-daemonset-node-1",
+			Namespace: namespace,
+			Labels:    map[string]string{"app": "application", "pod-template-generation": "0"},
+		},
+		Spec:   v1.PodSpec{NodeName: "node-1"},
+		Status: v1.PodStatus{Phase: v1.PodPending},
+	})
+
 	time.Sleep(time.Second)
 	// create matchin pods to the revision hash
 
@@ -160,9 +172,86 @@ func TestDaemonsetWatch(t *testing.T) {
 		}
 	})
 
+	t.Run("daemonset_nodes", func(t *testing.T) {
+		node, found := application.Schema.Resources.Daemonsets["test-daemonset"].Nodes["node-1"]
+		if !found {
+			t.Fatalf("node not found in daemonset nodes")
+		}
+		if node.Pod != "test-daemonset-node-1" {
+			t.Fatalf("unexpected node pod, got %s expected %s", node.Pod, "test-daemonset-node-1")
+		}
+		if node.State != kuberneteswatcher.DaemonsetNodePending {
+			t.Fatalf("unexpected node state, got %s expected %s", node.State, kuberneteswatcher.DaemonsetNodePending)
+		}
+		if !node.Updated {
+			t.Fatalf("expected the node pod to be updated")
+		}
+		if !node.Cordoned {
+			t.Fatalf("expected the node to be cordoned")
+		}
+	})
+
 	// t.Run("service", func(t *testing.T) {
 	// 	if len(daemonsetData.Services) != 1 {
 	// 		t.Fatalf("unexpected service count, got %d expected %d", len(daemonsetData.Services), 1)
 	// 	}
 	// })
 }
+
+func createMockDaemonsetData(registryManager *kuberneteswatcher.RegistryManager, registryRow *kuberneteswatcher.RegistryRow, applyEvent kuberneteswatcher.ApplyEvent, progressDeadlineSeconds string, updateStrategy string) *kuberneteswatcher.DaemonsetData {
+
+	maxDeploymentTime, _ := time.ParseDuration(progressDeadlineSeconds)
+	client := fake.NewSimpleClientset()
+	eventManager := NewEventsMock(client)
+	serviceManager := NewServiceManagerMockMock(client)
+	pvcManager := NewPvcManagerMock(client)
+	podManager := kuberneteswatcher.NewPodsManager(NewInformerManagerMock(client), eventManager, pvcManager, client, common.PodLogsConfig{})
+	controllerRevisionManager := NewControllerRevisionManagerMock(client, podManager)
+	daemonsetManager := kuberneteswatcher.NewDaemonsetManager(NewInformerManagerMock(client), eventManager, registryManager, serviceManager, controllerRevisionManager, client, registryManager.LoadRunningApplies(), maxDeploymentTime)
+
+	return daemonsetManager.AddNewDaemonset(applyEvent, registryRow, 3, updateStrategy)
+}
+
+func TestDaemonsetFinish(t *testing.T) {
+
+	testCases := []struct {
+		name                string
+		progressDeadline    string
+		updateStrategy      appsV1.DaemonSetUpdateStrategyType
+		status              appsV1.DaemonSetStatus
+		expectedStatus      common.DeploymentStatus
+		expectedDescription common.DeploymentStatusDescription
+	}{
+		{"on_delete", "1s", appsV1.OnDeleteDaemonSetStrategyType, appsV1.DaemonSetStatus{DesiredNumberScheduled: 3, CurrentNumberScheduled: 3, NumberReady: 3}, common.ApplyStatusAwaitingPodDeletion, common.ApplyStatusDescriptionAwaitingPodDeletion},
+		{"on_delete_updated", "10m", appsV1.OnDeleteDaemonSetStrategyType, appsV1.DaemonSetStatus{DesiredNumberScheduled: 3, CurrentNumberScheduled: 3, NumberReady: 3, UpdatedNumberScheduled: 3}, common.ApplySuccessful, common.ApplyStatusDescriptionSuccessful},
+		{"on_delete_updated_not_ready", "1s", appsV1.OnDeleteDaemonSetStrategyType, appsV1.DaemonSetStatus{DesiredNumberScheduled: 3, CurrentNumberScheduled: 2, NumberReady: 2, UpdatedNumberScheduled: 3}, common.ApplyStatusFailed, common.ApplyStatusDescriptionProgressDeadline},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			registry, storage := NewRegistryMock()
+			registryRow := registry.NewApplication("application", "default", map[string]string{}, common.ApplyStatusRunning)
+
+			apply := kuberneteswatcher.ApplyEvent{
+				Event:        "create",
+				ApplyName:    "application",
+				ResourceName: "application",
+				Namespace:    "default",
+				Kind:         "daemonset",
+				Hash:         1234,
+				Annotations:  map[string]string{},
+				Labels:       map[string]string{},
+			}
+			data := createMockDaemonsetData(registry, registryRow, apply, tc.progressDeadline, string(tc.updateStrategy))
+			data.UpdateApplyStatus(tc.status)
+
+			time.Sleep(time.Second * 4)
+			if storage.MockWriteDeployment["1"].Status != tc.expectedStatus {
+				t.Fatalf("unexpected daemonset apply status, got %s expected %s", storage.MockWriteDeployment["1"].Status, tc.expectedStatus)
+			}
+			if storage.MockWriteDeployment["1"].Schema.DeploymentDescription != tc.expectedDescription {
+				t.Fatalf("unexpected daemonset apply description, got %s expected %s", storage.MockWriteDeployment["1"].Schema.DeploymentDescription, tc.expectedDescription)
+			}
+		})
+	}
+}
@@ -0,0 +1,200 @@
+package kuberneteswatcher
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	appsV1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	eventwatch "k8s.io/apimachinery/pkg/watch"
+)
+
+const (
+	// DaemonsetNodeReady the daemonset pod of the node is ready
+	DaemonsetNodeReady = "ready"
+
+	// DaemonsetNodePending the daemonset pod of the node was not scheduled yet or its containers didn't start yet
+	DaemonsetNodePending = "pending"
+
+	// DaemonsetNodeFailing the daemonset pod of the node failed or one of its containers is crash-looping
+	DaemonsetNodeFailing = "failing"
+
+	// podTemplateGenerationLabel is the pod label of the daemonset template generation that created the pod
+	podTemplateGenerationLabel = "pod-template-generation"
+
+	// nodeNotReadyCondition is reported when the node ready condition is not true
+	nodeNotReadyCondition = "NotReady"
+)
+
+// nodePressureConditions are the node conditions that prevent pods from being scheduled or running when they are true
+var nodePressureConditions = []v1.NodeConditionType{v1.NodeMemoryPressure, v1.NodeDiskPressure, v1.NodePIDPressure, v1.NodeNetworkUnavailable}
+
+// DaemonsetNode describes the rollout of a daemonset on a single node
+type DaemonsetNode struct {
+	Pod     string `json:"Pod"`
+	Updated bool   `json:"Updated"`
+	State   string `json:"State"`
+	Reason  string `json:"Reason"`
+	Time    int64  `json:"Time"`
+
+	// The node details explain why the pod is not scheduled or not ready, they are collected only when the pod is not ready
+	Cordoned   bool     `json:"Cordoned"`
+	Conditions []string `json:"Conditions"`
+	Taints     []string `json:"Taints"`
+}
+
+// watchNodes watches all the pods of the daemonset, old and updated, and records the rollout state of each node
+func (dsm *DaemonsetManager) watchNodes(ctx context.Context, lg log.Entry, health *WatchHealth, daemonsetData *DaemonsetData, selector map[string]string, namespace string, templateGeneration string) {
+	lg.WithField("template_generation", templateGeneration).Info("started the nodes watcher of daemonset")
+	listOptions := metaV1.ListOptions{LabelSelector: labels.SelectorFromSet(selector).String()}
+	watcher := dsm.informerManager.ResumableWatch(ctx, lg, health, podsResource, namespace, listOptions)
+	go func() {
+		for {
+			select {
+			case event, watch := <-watcher:
+				if !watch {
+					lg.Info("daemonset nodes watcher was stopped, channel was closed")
+					return
+				}
+				pod, ok := event.Object.(*v1.Pod)
+				if !ok {
+					lg.WithField("object", event.Object).Warn("failed to parse daemonset pod watcher data")
+					continue
+				}
+				nodeName := podNodeName(pod)
+				if nodeName == "" {
+					continue
+				}
+				if event.Type == eventwatch.Deleted {
+					daemonsetData.DeleteNodePod(nodeName, pod.GetName())
+					continue
+				}
+
+				node := newDaemonsetNode(pod, templateGeneration)
+				if node.State != DaemonsetNodeReady {
+					dsm.setNodeDetails(lg, &node, nodeName)
+				}
+				daemonsetData.UpdateNode(nodeName, node)
+			case <-ctx.Done():
+				lg.Info("stopped the nodes watcher of daemonset")
+				return
+			}
+		}
+	}()
+}
+
+// setNodeDetails sets the node conditions, taints and cordon state that may explain why the pod is not ready
+func (dsm *DaemonsetManager) setNodeDetails(lg log.Entry, daemonsetNode *DaemonsetNode, nodeName string) {
+	node, err := dsm.client.CoreV1().Nodes().Get(nodeName, metaV1.GetOptions{})
+	if err != nil {
+		lg.WithError(err).WithField("node", nodeName).Debug("could not get the daemonset pod node")
+		return
+	}
+	daemonsetNode.Cordoned = node.Spec.Unschedulable
+	daemonsetNode.Conditions = nodeConditions(node)
+	daemonsetNode.Taints = nodeTaints(node)
+}
+
+// newDaemonsetNode creates the rollout state of the node from its daemonset pod
+func newDaemonsetNode(pod *v1.Pod, templateGeneration string) DaemonsetNode {
+	state, reason := daemonsetPodState(pod)
+	return DaemonsetNode{
+		Pod:        pod.GetName(),
+		Updated:    pod.GetLabels()[podTemplateGenerationLabel] == templateGeneration,
+		State:      state,
+		Reason:     reason,
+		Time:       time.Now().UnixNano(),
+		Conditions: []string{},
+		Taints:     []string{},
+	}
+}
+
+// daemonsetPodState returns the state of the pod and the reason of a pod that is not ready
+func daemonsetPodState(pod *v1.Pod) (string, string) {
+	if pod.Status.Phase == v1.PodFailed {
+		return DaemonsetNodeFailing, pod.Status.Reason
+	}
+	containers := append(append([]v1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+	for _, container := range containers {
+		if isFailingContainer(container) {
+			return DaemonsetNodeFailing, newContainerLogs(container).Reason
+		}
+	}
+
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == v1.PodReady && condition.Status == v1.ConditionTrue {
+			return DaemonsetNodeReady, ""
+		}
+		if condition.Type == v1.PodScheduled && condition.Status == v1.ConditionFalse {
+			return DaemonsetNodePending, condition.Reason
+		}
+	}
+	for _, container := range containers {
+		if container.State.Waiting != nil && container.State.Waiting.Reason != "" {
+			return DaemonsetNodePending, container.State.Waiting.Reason
+		}
+	}
+	return DaemonsetNodePending, string(pod.Status.Phase)
+}
+
+// podNodeName returns the node of the daemonset pod. A pod that was not scheduled yet is bound to its node by
+// the daemonset controller with a required node affinity on the node name
+func podNodeName(pod *v1.Pod) string {
+	if pod.Spec.NodeName != "" {
+		return pod.Spec.NodeName
+	}
+	if pod.Spec.Affinity == nil || pod.Spec.Affinity.NodeAffinity == nil || pod.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil {
+		return ""
+	}
+	for _, term := range pod.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms {
+		for _, field := range term.MatchFields {
+			if field.Key == "metadata.name" && field.Operator == v1.NodeSelectorOpIn && len(field.Values) == 1 {
+				return field.Values[0]
+			}
+		}
+	}
+	return ""
+}
+
+// daemonsetTemplateGeneration returns the template generation that the updated pods are labeled with
+func daemonsetTemplateGeneration(daemonset *appsV1.DaemonSet) string {
+	if generation, found := daemonset.GetAnnotations()[appsV1.DeprecatedTemplateGeneration]; found {
+		return generation
+	}
+	return strconv.FormatInt(daemonset.GetGeneration(), 10)
+}
+
+// nodeConditions returns the node pressure conditions that are true, and NotReady when the node is not ready
+func nodeConditions(node *v1.Node) []string {
+	conditions := []string{}
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == v1.NodeReady && condition.Status != v1.ConditionTrue {
+			conditions = append(conditions, nodeNotReadyCondition)
+			continue
+		}
+		for _, pressure := range nodePressureConditions {
+			if condition.Type == pressure && condition.Status == v1.ConditionTrue {
+				conditions = append(conditions, string(condition.Type))
+			}
+		}
+	}
+	return conditions
+}
+
+// nodeTaints returns the node taints in the kubectl format, for example: dedicated=gpu:NoSchedule
+func nodeTaints(node *v1.Node) []string {
+	taints := []string{}
+	for _, taint := range node.Spec.Taints {
+		if taint.Value == "" {
+			taints = append(taints, fmt.Sprintf("%s:%s", taint.Key, taint.Effect))
+			continue
+		}
+		taints = append(taints, fmt.Sprintf("%s=%s:%s", taint.Key, taint.Value, taint.Effect))
+	}
+	return taints
+}
@@ -0,0 +1,106 @@
+package kuberneteswatcher
+
+import (
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestDaemonsetPodState(t *testing.T) {
+
+	testCases := []struct {
+		name           string
+		status         v1.PodStatus
+		expectedState  string
+		expectedReason string
+	}{
+		{"ready", v1.PodStatus{Phase: v1.PodRunning, Conditions: []v1.PodCondition{{Type: v1.PodReady, Status: v1.ConditionTrue}}}, DaemonsetNodeReady, ""},
+		{"unschedulable", v1.PodStatus{Phase: v1.PodPending, Conditions: []v1.PodCondition{{Type: v1.PodScheduled, Status: v1.ConditionFalse, Reason: "Unschedulable"}}}, DaemonsetNodePending, "Unschedulable"},
+		{"container_creating", v1.PodStatus{Phase: v1.PodPending, ContainerStatuses: []v1.ContainerStatus{{State: v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: "ContainerCreating"}}}}}, DaemonsetNodePending, "ContainerCreating"},
+		{"crash_loop", v1.PodStatus{Phase: v1.PodRunning, ContainerStatuses: []v1.ContainerStatus{{State: v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}}}}}, DaemonsetNodeFailing, "CrashLoopBackOff"},
+		{"failed_init_container", v1.PodStatus{Phase: v1.PodPending, InitContainerStatuses: []v1.ContainerStatus{{State: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{Reason: "Error", ExitCode: 1}}}}}, DaemonsetNodeFailing, "Error"},
+		{"evicted", v1.PodStatus{Phase: v1.PodFailed, Reason: "Evicted"}, DaemonsetNodeFailing, "Evicted"},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			state, reason := daemonsetPodState(&v1.Pod{Status: test.status})
+			if state != test.expectedState {
+				t.Fatalf("unexpected state, got %s expected %s", state, test.expectedState)
+			}
+			if reason != test.expectedReason {
+				t.Fatalf("unexpected reason, got %q expected %q", reason, test.expectedReason)
+			}
+		})
+	}
+}
+
+func TestPodNodeName(t *testing.T) {
+
+	affinity := &v1.Affinity{NodeAffinity: &v1.NodeAffinity{RequiredDuringSchedulingIgnoredDuringExecution: &v1.NodeSelector{
+		NodeSelectorTerms: []v1.NodeSelectorTerm{{MatchFields: []v1.NodeSelectorRequirement{{Key: "metadata.name", Operator: v1.NodeSelectorOpIn, Values: []string{"node-2"}}}}},
+	}}}
+
+	testCases := []struct {
+		name     string
+		spec     v1.PodSpec
+		expected string
+	}{
+		{"scheduled", v1.PodSpec{NodeName: "node-1", Affinity: affinity}, "node-1"},
+		{"not_scheduled", v1.PodSpec{Affinity: affinity}, "node-2"},
+		{"without_node", v1.PodSpec{}, ""},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			if nodeName := podNodeName(&v1.Pod{Spec: test.spec}); nodeName != test.expected {
+				t.Fatalf("unexpected node name, got %q expected %q", nodeName, test.expected)
+			}
+		})
+	}
+}
+
+func TestNodeConditionsAndTaints(t *testing.T) {
+
+	node := &v1.Node{
+		Spec: v1.NodeSpec{Taints: []v1.Taint{
+			{Key: "dedicated", Value: "gpu", Effect: v1.TaintEffectNoSchedule},
+			{Key: "node.kubernetes.io/unreachable", Effect: v1.TaintEffectNoExecute},
+		}},
+		Status: v1.NodeStatus{Conditions: []v1.NodeCondition{
+			{Type: v1.NodeReady, Status: v1.ConditionUnknown},
+			{Type: v1.NodeMemoryPressure, Status: v1.ConditionTrue},
+			{Type: v1.NodeDiskPressure, Status: v1.ConditionFalse},
+		}},
+	}
+
+	if conditions := nodeConditions(node); !reflect.DeepEqual(conditions, []string{"NotReady", "MemoryPressure"}) {
+		t.Fatalf("unexpected node conditions, got %v", conditions)
+	}
+	if taints := nodeTaints(node); !reflect.DeepEqual(taints, []string{"dedicated=gpu:NoSchedule", "node.kubernetes.io/unreachable:NoExecute"}) {
+		t.Fatalf("unexpected node taints, got %v", taints)
+	}
+}
+
+func TestDaemonsetUpdateNode(t *testing.T) {
+
+	daemonset := &DaemonsetData{}
+	daemonset.UpdateNode("node-1", DaemonsetNode{Pod: "agent-new", Updated: true, State: DaemonsetNodePending})
+
+	// The old pod of the node is still terminating
+	daemonset.UpdateNode("node-1", DaemonsetNode{Pod: "agent-old", Updated: false, State: DaemonsetNodeReady})
+	if daemonset.Nodes["node-1"].Pod != "agent-new" {
+		t.Fatalf("unexpected node pod, got %s expected %s", daemonset.Nodes["node-1"].Pod, "agent-new")
+	}
+
+	daemonset.DeleteNodePod("node-1", "agent-old")
+	if _, found := daemonset.Nodes["node-1"]; !found {
+		t.Fatalf("the node was removed by the deletion of an old pod")
+	}
+
+	daemonset.DeleteNodePod("node-1", "agent-new")
+	if _, found := daemonset.Nodes["node-1"]; found {
+		t.Fatalf("the node was not removed after its pod was deleted")
+	}
+}
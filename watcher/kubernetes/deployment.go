@@ -11,16 +11,17 @@ import (
 	log "github.com/sirupsen/logrus"
 
 	appsV1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
 	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
-	"k8s.io/client-go/kubernetes"
+	eventwatch "k8s.io/apimachinery/pkg/watch"
 )
 
 // DeploymentManager defined deployment struct
 type DeploymentManager struct {
 
-	// Kubernetes client
-	client kubernetes.Interface
+	// Informer manager will be owner to watch the Kubernetes resources
+	informerManager *InformerManager
 
 	// Event manager will be owner to start watch on deployment events
 	eventManager *EventsManager
@@ -34,6 +35,9 @@ type DeploymentManager struct {
 	// Will triggered when deployment watch started
 	serviceManager *ServiceManager
 
+	// Will triggered when deployment watch started
+	hpaManager *HorizontalPodAutoscalerManager
+
 	// Max watch time
 	maxDeploymentTime int64
 
@@ -42,13 +46,14 @@ type DeploymentManager struct {
 }
 
 // NewDeploymentManager create new deployment instance
-func NewDeploymentManager(kubernetesClientset kubernetes.Interface, eventManager *EventsManager, registryManager *RegistryManager, replicaset *ReplicaSetManager, serviceManager *ServiceManager, runningApplies []*RegistryRow, maxDeploymentTime time.Duration) *DeploymentManager {
+func NewDeploymentManager(informerManager *InformerManager, eventManager *EventsManager, registryManager *RegistryManager, replicaset *ReplicaSetManager, serviceManager *ServiceManager, hpaManager *HorizontalPodAutoscalerManager, runningApplies []*RegistryRow, maxDeploymentTime time.Duration) *DeploymentManager {
 	return &DeploymentManager{
-		client:                kubernetesClientset,
+		informerManager:       informerManager,
 		eventManager:          eventManager,
 		registryManager:       registryManager,
 		replicaset:            replicaset,
 		serviceManager:        serviceManager,
+		hpaManager:            hpaManager,
 		maxDeploymentTime:     int64(maxDeploymentTime.Seconds()),
 		initialRunningApplies: runningApplies,
 	}
@@ -79,7 +84,7 @@ func (dm *DeploymentManager) Serve(ctx context.Context, wg *sync.WaitGroup) {
 			deploymentWatchListOptions := metaV1.ListOptions{LabelSelector: labels.SelectorFromSet(deploymentData.Deployment.Labels).String()}
 			app.Log().Logger.WithField("name", depData.GetName()).Debug("begining watching loaded running deployment")
 			go func(app *RegistryRow, depData *DeploymentData, listOptions metaV1.ListOptions) {
-				dm.watchDeployment(app.ctx, app.cancelFn, app.Log(), depData, listOptions, depData.Deployment.Namespace, depData.ProgressDeadlineSeconds)
+				dm.watchDeployment(app.ctx, app.cancelFn, app.Log(), app.DBSchema.WatchHealth, depData, listOptions, depData.Deployment.Namespace, depData.ProgressDeadlineSeconds)
 			}(app, depData, deploymentWatchListOptions)
 		}
 	}
@@ -91,25 +96,21 @@ func (dm *DeploymentManager) Serve(ctx context.Context, wg *sync.WaitGroup) {
 // watchDeployments start watch on all Kubernetes deployments
 func (dm *DeploymentManager) watchDeployments(ctx context.Context) {
 
-	deploymentList, _ := dm.client.AppsV1().Deployments("").List(metaV1.ListOptions{})
-	deploymentWatchListOptions := metaV1.ListOptions{ResourceVersion: deploymentList.GetResourceVersion()}
-	watcher, err := dm.client.AppsV1().Deployments("").Watch(deploymentWatchListOptions)
-
-	if err != nil {
-		log.WithError(err).WithField("list_option", deploymentWatchListOptions.String()).Error("could not start deployments watcher")
-
-		return
+	// Resume from the last handled resource version, so changes that were applied while the watcher was down are not missed
+	resourceVersion := dm.registryManager.LoadResourceVersion(deploymentsResource.GroupResource().String())
+	if resourceVersion == "" {
+		resourceVersion, _ = dm.informerManager.ResourceVersion(deploymentsResource)
 	}
-
+	deploymentWatchListOptions := metaV1.ListOptions{ResourceVersion: resourceVersion}
+	watcher := dm.informerManager.ResumableWatch(ctx, *log.WithField("resource", deploymentsResource.String()), nil, deploymentsResource, "", deploymentWatchListOptions)
 	go func() {
-		log.WithField("resource_version", deploymentList.GetResourceVersion()).Info("starting deployments watcher")
+		log.WithField("resource_version", resourceVersion).Info("starting deployments watcher")
 		for {
 			select {
-			case event, watch := <-watcher.ResultChan():
+			case event, watch := <-watcher:
 
 				if !watch {
-					log.WithField("list_options", deploymentWatchListOptions.String()).Info("deployments watcher was stopped, reopening the channel")
-					dm.watchDeployments(ctx)
+					log.WithField("list_options", deploymentWatchListOptions.String()).Info("deployments watcher was stopped, channel was closed")
 					return
 				}
 
@@ -118,6 +119,7 @@ func (dm *DeploymentManager) watchDeployments(ctx context.Context) {
 					log.WithField("object", event.Object).Warn("failed to parse deployment watcher data")
 					continue
 				}
+				dm.registryManager.UpdateResourceVersion(deploymentsResource.GroupResource().String(), deployment.GetResourceVersion())
 
 				log.WithFields(log.Fields{
 					"name":      deployment.GetName(),
@@ -127,16 +129,25 @@ func (dm *DeploymentManager) watchDeployments(ctx context.Context) {
 
 				if common.IsSupportedEventType(event.Type) {
 
-					hash, _ := hashstructure.Hash(deployment.Spec, nil)
+					// The replicas are excluded from the hash, a scale without a spec change is not a new apply
+					spec := deployment.Spec
+					spec.Replicas = nil
+					hash, _ := hashstructure.Hash(spec, nil)
+					// Versions that were saved before the replicas were excluded are compared with the full spec hash
+					legacyHash, _ := hashstructure.Hash(deployment.Spec, nil)
 					apply := ApplyEvent{
-						Event:        fmt.Sprintf("%v", event.Type),
-						ApplyName:    deploymentName,
-						ResourceName: deployment.GetName(),
-						Namespace:    deployment.GetNamespace(),
-						Kind:         "deployment",
-						Hash:         hash,
-						Annotations:  deployment.GetAnnotations(),
-						Labels:       deployment.GetLabels(),
+						Event:           fmt.Sprintf("%v", event.Type),
+						ApplyName:       deploymentName,
+						ResourceName:    deployment.GetName(),
+						Namespace:       deployment.GetNamespace(),
+						Kind:            "deployment",
+						Hash:            hash,
+						LegacyHash:      legacyHash,
+						Annotations:     deployment.GetAnnotations(),
+						Labels:          deployment.GetLabels(),
+						UID:             deployment.GetUID(),
+						ResourceVersion: deployment.GetResourceVersion(),
+						Replicas:        deployment.Spec.Replicas,
 					}
 
 					applicationRegistry := dm.registryManager.NewApplyEvent(apply)
@@ -148,6 +159,20 @@ func (dm *DeploymentManager) watchDeployments(ctx context.Context) {
 
 					registryDeployment := dm.AddNewDeployment(apply, applicationRegistry, *deployment.Spec.Replicas)
 
+					if event.Type != eventwatch.Deleted {
+						replicasets, err := dm.listReplicasets(deployment)
+						if err != nil {
+							deploymentLog.WithError(err).Warn("could not list replicasets, rollback detection and spec diff are skipped")
+						} else {
+							if revision, isRollback := replicaSetRollbackRevision(deployment, replicasets); isRollback {
+								applicationRegistry.SetRollback("deployment", deployment.GetName(), revision)
+							}
+							if previous, found := previousReplicaSetTemplate(deployment, replicasets); found {
+								applicationRegistry.AddSpecChanges("deployment", deployment.GetName(), podTemplateSpecChanges(*previous, deployment.Spec.Template))
+							}
+						}
+					}
+
 					deploymentWatchListOptions := metaV1.ListOptions{LabelSelector: labels.SelectorFromSet(deployment.GetLabels()).String()}
 
 					maxWatchTime := dm.maxDeploymentTime
@@ -156,6 +181,7 @@ func (dm *DeploymentManager) watchDeployments(ctx context.Context) {
 						applicationRegistry.ctx,
 						applicationRegistry.cancelFn,
 						deploymentLog,
+						applicationRegistry.DBSchema.WatchHealth,
 						registryDeployment,
 						deploymentWatchListOptions,
 						deployment.GetNamespace(),
@@ -170,7 +196,6 @@ func (dm *DeploymentManager) watchDeployments(ctx context.Context) {
 
 			case <-ctx.Done():
 				log.Warn("deployment watch was stopped, got ctx done signal")
-				watcher.Stop()
 				return
 
 			}
@@ -182,23 +207,19 @@ func (dm *DeploymentManager) watchDeployments(ctx context.Context) {
 }
 
 //watchDeployment will watch on one running deployment
-func (dm *DeploymentManager) watchDeployment(ctx context.Context, cancelFn context.CancelFunc, lg log.Entry, registryDeployment *DeploymentData, listOptions metaV1.ListOptions, namespace string, maxWatchTime int64) {
+func (dm *DeploymentManager) watchDeployment(ctx context.Context, cancelFn context.CancelFunc, lg log.Entry, health *WatchHealth, registryDeployment *DeploymentData, listOptions metaV1.ListOptions, namespace string, maxWatchTime int64) {
 
 	deploymentLog := lg.WithField("deployment_name", registryDeployment.GetName())
 	deploymentLog.Info("initializing deployments watcher")
 	deploymentLog.WithField("list_option", listOptions.String()).Debug("list option for deployment filtering")
 
-	watcher, err := dm.client.AppsV1().Deployments(namespace).Watch(listOptions)
-	if err != nil {
-		deploymentLog.WithError(err).Error("could not start deployments watcher")
-		return
-	}
+	watcher := dm.informerManager.ResumableWatch(ctx, *deploymentLog, health, deploymentsResource, namespace, listOptions)
 
 	firstInit := true
 
 	for {
 		select {
-		case event, watch := <-watcher.ResultChan():
+		case event, watch := <-watcher:
 			if !watch {
 				deploymentLog.Warn("deployment watcher was stopped, channel was closed")
 				cancelFn()
@@ -221,7 +242,7 @@ func (dm *DeploymentManager) watchDeployment(ctx context.Context, cancelFn conte
 					TimeoutSeconds: &maxWatchTime,
 					// ResourceVersion: deployment.ResourceVersion,
 				}
-				dm.watchEvents(ctx, *deploymentLog, registryDeployment, eventListOptions, namespace)
+				dm.watchEvents(ctx, *deploymentLog, health, registryDeployment, eventListOptions, registryDeployment.Deployment.objectReference("Deployment"), namespace)
 				//Starting replicaset watch
 				dm.replicaset.Watch <- WatchReplica{
 					DesiredReplicas: *deployment.Spec.Replicas,
@@ -230,6 +251,7 @@ func (dm *DeploymentManager) watchDeployment(ctx context.Context, cancelFn conte
 					Namespace:       deployment.Namespace,
 					Ctx:             ctx,
 					LogEntry:        *deploymentLog,
+					WatchHealth:     health,
 				}
 
 				dm.serviceManager.Watch <- WatchData{
@@ -238,6 +260,18 @@ func (dm *DeploymentManager) watchDeployment(ctx context.Context, cancelFn conte
 					Namespace:    deployment.Namespace,
 					Ctx:          ctx,
 					LogEntry:     *deploymentLog,
+					WatchHealth:  health,
+				}
+
+				dm.hpaManager.Watch <- WatchHorizontalPodAutoscaler{
+					ListOptions:  metaV1.ListOptions{TimeoutSeconds: &maxWatchTime},
+					RegistryData: registryDeployment,
+					Namespace:    deployment.Namespace,
+					TargetKind:   "Deployment",
+					TargetName:   deployment.GetName(),
+					Ctx:          ctx,
+					LogEntry:     *deploymentLog,
+					WatchHealth:  health,
 				}
 			}
 
@@ -245,7 +279,6 @@ func (dm *DeploymentManager) watchDeployment(ctx context.Context, cancelFn conte
 
 		case <-ctx.Done():
 			deploymentLog.Debug("deployment watcher was stopped, got ctx done signal")
-			watcher.Stop()
 			return
 
 		}
@@ -254,14 +287,16 @@ func (dm *DeploymentManager) watchDeployment(ctx context.Context, cancelFn conte
 }
 
 // watchEvents will start watch on deployment event messages changes
-func (dm *DeploymentManager) watchEvents(ctx context.Context, lg log.Entry, registryDeployment *DeploymentData, listOptions metaV1.ListOptions, namespace string) {
+func (dm *DeploymentManager) watchEvents(ctx context.Context, lg log.Entry, health *WatchHealth, registryDeployment *DeploymentData, listOptions metaV1.ListOptions, involvedObject v1.ObjectReference, namespace string) {
 	lg.Info("initializing events watcher")
 
 	watchData := WatchEvents{
-		ListOptions: listOptions,
-		Namespace:   namespace,
-		Ctx:         ctx,
-		LogEntry:    lg,
+		ListOptions:       listOptions,
+		Namespace:         namespace,
+		Ctx:               ctx,
+		LogEntry:          lg,
+		InvolvedObject:    involvedObject,
+		ApplyCreationTime: health.applyCreation(),
 	}
 
 	eventChan := dm.eventManager.Watch(watchData)
@@ -279,24 +314,50 @@ func (dm *DeploymentManager) watchEvents(ctx context.Context, lg log.Entry, regi
 	}()
 }
 
+// listReplicasets returns the cached replicasets that match the deployment selector
+func (dm *DeploymentManager) listReplicasets(deployment *appsV1.Deployment) ([]*appsV1.ReplicaSet, error) {
+	replicasets := []*appsV1.ReplicaSet{}
+	if deployment.Spec.Selector == nil {
+		return replicasets, nil
+	}
+
+	objects, err := dm.informerManager.List(replicasetsResource, deployment.GetNamespace(), metaV1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(deployment.Spec.Selector.MatchLabels).String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, obj := range objects {
+		if replicaset, ok := obj.(*appsV1.ReplicaSet); ok {
+			replicasets = append(replicasets, replicaset)
+		}
+	}
+	return replicasets, nil
+}
+
 // AddNewDeployment add new deployment under application
 func (dm *DeploymentManager) AddNewDeployment(data ApplyEvent, applicationRegistry *RegistryRow, desiredState int32) *DeploymentData {
 
 	log := applicationRegistry.Log()
 	dd := &DeploymentData{
 		Deployment: MetaData{
-			Name:         data.ApplyName,
-			Namespace:    data.Namespace,
-			Annotations:  data.Annotations,
-			Labels:       data.Labels,
-			Metrics:      GetMetricsDataFromAnnotations(data.Annotations),
-			Alerts:       GetAlertsDataFromAnnotations(data.Annotations),
-			DesiredState: desiredState,
+			Name:            data.ApplyName,
+			Namespace:       data.Namespace,
+			Annotations:     data.Annotations,
+			Labels:          data.Labels,
+			UID:             data.UID,
+			ResourceVersion: data.ResourceVersion,
+			Metrics:         GetMetricsDataFromAnnotations(data.Annotations),
+			Alerts:          GetAlertsDataFromAnnotations(data.Annotations),
+			SuccessCriteria: GetSuccessCriteriaFromAnnotations(data.Annotations),
+			DesiredState:    desiredState,
 		},
-		Pods:                    make(map[string]DeploymenPod, 0),
-		Replicaset:              make(map[string]Replicaset, 0),
-		Services:                make(map[string]ServicesData, 0),
-		ProgressDeadlineSeconds: GetProgressDeadlineApply(data.Annotations, dm.maxDeploymentTime),
+		Pods:                     make(map[string]DeploymenPod, 0),
+		Replicaset:               make(map[string]Replicaset, 0),
+		Services:                 make(map[string]ServicesData, 0),
+		HorizontalPodAutoscalers: make(map[string]HorizontalPodAutoscalerData, 0),
+		ProgressDeadlineSeconds:  GetProgressDeadlineApply(data.Annotations, dm.maxDeploymentTime),
 	}
 	applicationRegistry.DBSchema.Resources.Deployments[data.ResourceName] = dd
 
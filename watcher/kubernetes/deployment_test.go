@@ -24,7 +24,8 @@ func createMockDeploymentData(registryManager *kuberneteswatcher.RegistryManager
 	eventManager := NewEventsMock(client)
 	replicasetManager := NewReplicasetMock(client)
 	serviceManager := NewServiceManagerMockMock(client)
-	deploymentManager := kuberneteswatcher.NewDeploymentManager(client, eventManager, registryManager, replicasetManager, serviceManager, runningApplies, maxDeploymentTime)
+	hpaManager := NewHorizontalPodAutoscalerManagerMock(client)
+	deploymentManager := kuberneteswatcher.NewDeploymentManager(NewInformerManagerMock(client), eventManager, registryManager, replicasetManager, serviceManager, hpaManager, runningApplies, maxDeploymentTime)
 
 	return deploymentManager.AddNewDeployment(applyEvent, registryRow, 3)
 
@@ -97,13 +98,15 @@ func NewDeploymentManagerMock(client *fake.Clientset) (*kuberneteswatcher.Deploy
 	runningApplies := registryManager.LoadRunningApplies()
 	replicasetManager := NewReplicasetMock(client)
 	serviceManager := NewServiceManagerMockMock(client)
-	deploymentManager := kuberneteswatcher.NewDeploymentManager(client, eventManager, registryManager, replicasetManager, serviceManager, runningApplies, maxDeploymentTime)
+	hpaManager := NewHorizontalPodAutoscalerManagerMock(client)
+	deploymentManager := kuberneteswatcher.NewDeploymentManager(NewInformerManagerMock(client), eventManager, registryManager, replicasetManager, serviceManager, hpaManager, runningApplies, maxDeploymentTime)
 
 	var wg sync.WaitGroup
 	ctx := context.Background()
 
 	deploymentManager.Serve(ctx, &wg)
 	serviceManager.Serve(ctx, &wg)
+	hpaManager.Serve(ctx, &wg)
 	replicasetManager.Serve(ctx, &wg)
 	return deploymentManager, storage
 
@@ -154,7 +157,7 @@ func TestDeploymentsWatch(t *testing.T) {
 	replicaset.Status.Replicas = 2
 	client.AppsV1().ReplicaSets(namespace).Update(replicaset)
 	client.CoreV1().Services(namespace).Create(svc)
-	event1 := &v1.Event{Message: "message", ObjectMeta: metaV1.ObjectMeta{Name: "a", CreationTimestamp: metaV1.Time{Time: time.Now()}}}
+	event1 := &v1.Event{Message: "message", ObjectMeta: metaV1.ObjectMeta{Name: "a", CreationTimestamp: metaV1.Time{Time: time.Now()}}, InvolvedObject: v1.ObjectReference{Kind: "Deployment", Name: "test-deployment"}}
 	client.CoreV1().Events(namespace).Create(event1)
 
 	time.Sleep(time.Second)
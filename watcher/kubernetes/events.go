@@ -2,6 +2,8 @@ package kuberneteswatcher
 
 import (
 	"context"
+	"statusbay/watcher/kubernetes/common"
+	"strings"
 	"sync"
 	"time"
 
@@ -9,9 +11,11 @@ import (
 
 	v1 "k8s.io/api/core/v1"
 	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/client-go/kubernetes"
 )
 
+// defaultEventsRelevanceWindow is the default max age of an event that can't be matched to the tracked object uid
+const defaultEventsRelevanceWindow = 30 * time.Second
+
 // WatchEvents struct
 type WatchEvents struct {
 
@@ -26,17 +30,44 @@ type WatchEvents struct {
 
 	// LogEntry for write application logs
 	LogEntry log.Entry
+
+	// WatchHealth of the apply, updated when the events watch is interrupted
+	WatchHealth *WatchHealth
+
+	// InvolvedObject is the tracked object of the events. Events of an object with the same name and a different uid
+	// are ignored, and events of an object with the same uid are related to the apply
+	InvolvedObject v1.ObjectReference
+
+	// ApplyCreationTime is the creation time of the apply. Events of the tracked object that were last seen before it
+	// belong to an earlier apply of the object, unless they reference the tracked resource version
+	ApplyCreationTime time.Time
 }
 
 // EventsManager defined pods manager struct
 type EventsManager struct {
-	client kubernetes.Interface
+	informerManager *InformerManager
+
+	// relevanceWindow is the max age of an event that can't be matched to the tracked object uid, kindRelevanceWindows
+	// overrides it by the lower case object kind
+	relevanceWindow      time.Duration
+	kindRelevanceWindows map[string]time.Duration
 }
 
 // NewEventsManager create new pods instance
-func NewEventsManager(kubernetesClientset kubernetes.Interface) *EventsManager {
+func NewEventsManager(informerManager *InformerManager, eventsConfig common.EventsConfig) *EventsManager {
+	relevanceWindow := eventsConfig.RelevanceWindow
+	if relevanceWindow <= 0 {
+		relevanceWindow = defaultEventsRelevanceWindow
+	}
+	kindRelevanceWindows := map[string]time.Duration{}
+	for kind, window := range eventsConfig.KindRelevanceWindows {
+		kindRelevanceWindows[strings.ToLower(kind)] = window
+	}
+
 	return &EventsManager{
-		client: kubernetesClientset,
+		informerManager:      informerManager,
+		relevanceWindow:      relevanceWindow,
+		kindRelevanceWindows: kindRelevanceWindows,
 	}
 }
 
@@ -65,14 +96,13 @@ func (em *EventsManager) Watch(watchData WatchEvents) <-chan EventMessages {
 	watchData.LogEntry.WithField("list_option", watchData.ListOptions.String()).Debug("events watcher started")
 
 	go func() {
-		watcher, err := em.client.CoreV1().Events("").Watch(watchData.ListOptions)
-		if err != nil {
-			watchData.LogEntry.WithError(err).Error("failed to watch on events")
-			return
-		}
+		// occurrences holds the count of each event object that was sent, so only new occurrences of a repeated
+		// event are sent when the event is updated or received again after the watch was resumed
+		occurrences := map[string]int32{}
+		watcher := em.informerManager.ResumableWatch(watchData.Ctx, watchData.LogEntry, watchData.WatchHealth, eventsResource, watchData.Namespace, watchData.ListOptions)
 		for {
 			select {
-			case event, watch := <-watcher.ResultChan():
+			case event, watch := <-watcher:
 				if !watch {
 					watchData.LogEntry.WithField("timeout", watchData.ListOptions.TimeoutSeconds).Warn("stop watching on events, got timeout")
 					return
@@ -83,26 +113,30 @@ func (em *EventsManager) Watch(watchData WatchEvents) <-chan EventMessages {
 					watchData.LogEntry.Warn("failed to parse event object")
 					continue
 				}
-				diff := time.Now().Sub(eventData.GetCreationTimestamp().Time).Seconds()
-				// TODO:: move to configuration settup
-				if diff < 30 {
-					responses <- EventMessages{
-						Message:             eventData.Message,
-						Time:                eventData.GetCreationTimestamp().Time.UnixNano(),
-						Action:              eventData.Action,
-						ReportingController: eventData.ReportingController,
+				if em.isRelated(watchData.InvolvedObject, watchData.ApplyCreationTime, eventData) {
+					key := string(eventData.GetUID())
+					if key == "" {
+						key = eventData.GetName()
+					}
+					count := eventCount(eventData)
+					previous := occurrences[key]
+					if count <= previous {
+						watchData.LogEntry.WithField("Message", eventData.Message).Debug("the event occurrences were already received")
+						continue
 					}
+					occurrences[key] = count
+					responses <- newEventMessages(eventData, count-previous)
 				} else {
 					watchData.LogEntry.WithFields(log.Fields{
-						"Message": eventData.Message,
-						"time":    eventData.GetCreationTimestamp(),
-						"object":  event.Object,
-					}).Debug("the event to old, and not related to the current apply")
+						"Message":          eventData.Message,
+						"time":             eventLastSeen(eventData),
+						"uid":              eventData.InvolvedObject.UID,
+						"resource_version": eventData.InvolvedObject.ResourceVersion,
+					}).Debug("the event is not related to the current apply")
 				}
 
 			case <-watchData.Ctx.Done():
 				watchData.LogEntry.Debug("stop events watch, got ctx done signal")
-				watcher.Stop()
 				return
 			}
 		}
@@ -110,3 +144,134 @@ func (em *EventsManager) Watch(watchData WatchEvents) <-chan EventMessages {
 	return responses
 
 }
+
+// isRelated returns true when the event is related to the apply. An event of an object with a different uid than the
+// tracked object is not related, and an event of the tracked object is related when it references the tracked object
+// version or was last seen after the apply was created. The event age is compared with the apply creation time and not
+// with the current time, so old events of the apply are still related when they are replayed after a watcher restart.
+// Only events without a uid are matched by the relevance window of the object kind
+func (em *EventsManager) isRelated(tracked v1.ObjectReference, applyCreationTime time.Time, eventData *v1.Event) bool {
+	involved := eventData.InvolvedObject
+	if tracked.UID != "" && involved.UID != "" {
+		if tracked.UID != involved.UID {
+			return false
+		}
+		if involved.ResourceVersion != "" && involved.ResourceVersion == tracked.ResourceVersion {
+			return true
+		}
+		return applyCreationTime.IsZero() || !eventLastSeen(eventData).Before(applyCreationTime)
+	}
+
+	kind := tracked.Kind
+	if kind == "" {
+		kind = involved.Kind
+	}
+	return time.Since(eventLastSeen(eventData)) < em.kindRelevanceWindow(kind)
+}
+
+// kindRelevanceWindow returns the relevance window of the object kind
+func (em *EventsManager) kindRelevanceWindow(kind string) time.Duration {
+	if window, found := em.kindRelevanceWindows[strings.ToLower(kind)]; found {
+		return window
+	}
+	return em.relevanceWindow
+}
+
+// eventCount returns the number of occurrences of the event
+func eventCount(eventData *v1.Event) int32 {
+	if eventData.Count > 0 {
+		return eventData.Count
+	}
+	return 1
+}
+
+// eventLastSeen returns the time of the last occurrence of the event
+func eventLastSeen(eventData *v1.Event) time.Time {
+	if !eventData.LastTimestamp.IsZero() {
+		return eventData.LastTimestamp.Time
+	}
+	return eventData.GetCreationTimestamp().Time
+}
+
+// newEventMessages converts a kubernetes event to an event message with the given number of new occurrences
+func newEventMessages(eventData *v1.Event, count int32) EventMessages {
+	created := eventData.GetCreationTimestamp().Time.UnixNano()
+	firstSeen := created
+	if !eventData.FirstTimestamp.IsZero() {
+		firstSeen = eventData.FirstTimestamp.UnixNano()
+	}
+	return EventMessages{
+		Message:             eventData.Message,
+		Time:                created,
+		Action:              eventData.Action,
+		ReportingController: eventData.ReportingController,
+		Reason:              eventData.Reason,
+		Type:                eventData.Type,
+		Count:               count,
+		FirstTimestamp:      firstSeen,
+		LastTimestamp:       eventLastSeen(eventData).UnixNano(),
+	}
+}
+
+// appendEvent adds the event to the events list, an event with the same type, reason and message of an existing
+// event is merged into the existing event
+func appendEvent(events []EventMessages, event EventMessages) []EventMessages {
+	if event.Count <= 0 {
+		event.Count = 1
+	}
+	if event.FirstTimestamp == 0 {
+		event.FirstTimestamp = event.Time
+	}
+	if event.LastTimestamp == 0 {
+		event.LastTimestamp = event.Time
+	}
+
+	for i := range events {
+		existing := &events[i]
+		if existing.Type != event.Type || existing.Reason != event.Reason || existing.Message != event.Message {
+			continue
+		}
+		if existing.Count <= 0 {
+			existing.Count = 1
+		}
+		existing.Count += event.Count
+		if existing.FirstTimestamp == 0 || event.FirstTimestamp < existing.FirstTimestamp {
+			existing.FirstTimestamp = event.FirstTimestamp
+		}
+		if event.LastTimestamp > existing.LastTimestamp {
+			existing.LastTimestamp = event.LastTimestamp
+		}
+		return events
+	}
+	return append(events, event)
+}
+
+// lastSeen returns the time of the last occurrence of the event
+func (em EventMessages) lastSeen() int64 {
+	if em.LastTimestamp > em.Time {
+		return em.LastTimestamp
+	}
+	return em.Time
+}
+
+// objectReference returns the reference of the applied resource version, events of the resource are matched to it
+func (md MetaData) objectReference(kind string) v1.ObjectReference {
+	return v1.ObjectReference{
+		Kind:            kind,
+		Name:            md.Name,
+		Namespace:       md.Namespace,
+		UID:             md.UID,
+		ResourceVersion: md.ResourceVersion,
+	}
+}
+
+// newObjectReference returns the reference of an object that was discovered by the apply watchers
+func newObjectReference(kind string, object metaV1.Object) v1.ObjectReference {
+	return v1.ObjectReference{
+		Kind:            kind,
+		Name:            object.GetName(),
+		Namespace:       object.GetNamespace(),
+		UID:             object.GetUID(),
+		ResourceVersion: object.GetResourceVersion(),
+	}
+}
@@ -2,10 +2,14 @@ package kuberneteswatcher_test
 
 import (
 	"context"
+	"reflect"
+	"sort"
+	"sync"
 	"testing"
 	"time"
 
 	kuberneteswatcher "statusbay/watcher/kubernetes"
+	"statusbay/watcher/kubernetes/common"
 
 	log "github.com/sirupsen/logrus"
 	v1 "k8s.io/api/core/v1"
@@ -15,7 +19,7 @@ import (
 
 func NewEventsMock(client *fake.Clientset) *kuberneteswatcher.EventsManager {
 
-	eventManager := kuberneteswatcher.NewEventsManager(client)
+	eventManager := kuberneteswatcher.NewEventsManager(NewInformerManagerMock(client), common.EventsConfig{})
 
 	return eventManager
 }
@@ -96,3 +100,199 @@ func TestWatchMark(t *testing.T) {
 	}
 
 }
+
+func TestWatchRepeatedEvent(t *testing.T) {
+	client := fake.NewSimpleClientset()
+
+	eventManager := NewEventsMock(client)
+
+	lg := log.WithField("test", "TestWatchRepeatedEvent")
+	ctx := context.Background()
+
+	watchData := kuberneteswatcher.WatchEvents{
+		ListOptions: metav1.ListOptions{},
+		Namespace:   "default",
+		Ctx:         ctx,
+		LogEntry:    *lg,
+	}
+
+	eventChan := eventManager.Watch(watchData)
+	time.Sleep(time.Second)
+
+	created := time.Now().Add(-time.Minute)
+	event := &v1.Event{
+		Message:        "Back-off restarting failed container",
+		Reason:         "BackOff",
+		Type:           v1.EventTypeWarning,
+		Count:          1,
+		FirstTimestamp: metav1.Time{Time: created},
+		LastTimestamp:  metav1.Time{Time: time.Now()},
+		ObjectMeta:     metav1.ObjectMeta{Name: "a", CreationTimestamp: metav1.Time{Time: created}},
+	}
+	client.CoreV1().Events("default").Create(event)
+	received := <-eventChan
+
+	if received.Count != 1 || received.Reason != "BackOff" || received.Type != v1.EventTypeWarning {
+		t.Fatalf("unexpected event, got %+v", received)
+	}
+
+	// The event was created a minute ago, the update is sent because of its last occurrence
+	event.Count = 4
+	event.LastTimestamp = metav1.Time{Time: time.Now()}
+	client.CoreV1().Events("default").Update(event)
+	received = <-eventChan
+
+	if received.Count != 3 {
+		t.Fatalf("unexpected new occurrences count, got %d expected %d", received.Count, 3)
+	}
+	if received.FirstTimestamp != created.UnixNano() {
+		t.Fatalf("unexpected event first timestamp, got %d expected %d", received.FirstTimestamp, created.UnixNano())
+	}
+}
+
+func TestWatchRelatedEvents(t *testing.T) {
+	client := fake.NewSimpleClientset()
+
+	eventManager := NewEventsMock(client)
+
+	lg := log.WithField("test", "TestWatchRelatedEvents")
+	ctx := context.Background()
+
+	watchData := kuberneteswatcher.WatchEvents{
+		ListOptions:       metav1.ListOptions{},
+		Namespace:         "default",
+		Ctx:               ctx,
+		LogEntry:          *lg,
+		InvolvedObject:    v1.ObjectReference{Kind: "Deployment", UID: "uid-1", ResourceVersion: "100"},
+		ApplyCreationTime: time.Now().Add(-time.Minute * 30),
+	}
+
+	eventChan := eventManager.Watch(watchData)
+
+	received := []string{}
+	var mutex sync.Mutex
+	go func() {
+		for {
+			select {
+			case event := <-eventChan:
+				mutex.Lock()
+				received = append(received, event.Message)
+				mutex.Unlock()
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	hourAgo := metav1.Time{Time: time.Now().Add(-time.Hour)}
+	events := []*v1.Event{
+		// The event of the tracked version is related even though it was last seen before the apply was created
+		{Message: "same version", InvolvedObject: v1.ObjectReference{Kind: "Deployment", UID: "uid-1", ResourceVersion: "100"}, ObjectMeta: metav1.ObjectMeta{Name: "a", CreationTimestamp: hourAgo}},
+		// The event of a deleted object with the same name is not related
+		{Message: "other uid", InvolvedObject: v1.ObjectReference{Kind: "Deployment", UID: "uid-0", ResourceVersion: "200"}, ObjectMeta: metav1.ObjectMeta{Name: "b", CreationTimestamp: metav1.Time{Time: time.Now()}}},
+		// The events of other versions that were last seen before the apply was created belong to an earlier apply
+		{Message: "previous version", InvolvedObject: v1.ObjectReference{Kind: "Deployment", UID: "uid-1", ResourceVersion: "90"}, ObjectMeta: metav1.ObjectMeta{Name: "c", CreationTimestamp: hourAgo}},
+		{Message: "other version", InvolvedObject: v1.ObjectReference{Kind: "Deployment", UID: "uid-1", ResourceVersion: "110"}, ObjectMeta: metav1.ObjectMeta{Name: "d", CreationTimestamp: hourAgo}},
+		// Resource versions are not ordered, the event of another version that was last seen after the apply was created is related
+		{Message: "recent other version", InvolvedObject: v1.ObjectReference{Kind: "Deployment", UID: "uid-1", ResourceVersion: "120"}, ObjectMeta: metav1.ObjectMeta{Name: "e", CreationTimestamp: metav1.Time{Time: time.Now()}}},
+	}
+
+	time.Sleep(time.Second)
+	for _, event := range events {
+		client.CoreV1().Events("default").Create(event)
+	}
+	time.Sleep(time.Second)
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	expected := []string{"same version", "recent other version"}
+	sort.Strings(received)
+	sort.Strings(expected)
+	if !reflect.DeepEqual(received, expected) {
+		t.Fatalf("unexpected related events, got %v expected %v", received, expected)
+	}
+}
+
+func TestWatchReplayedPodEvents(t *testing.T) {
+	client := fake.NewSimpleClientset()
+
+	eventManager := NewEventsMock(client)
+
+	lg := log.WithField("test", "TestWatchReplayedPodEvents")
+	ctx, cancelFn := context.WithCancel(context.Background())
+	defer cancelFn()
+
+	applyCreationTime := time.Now().Add(-time.Hour * 2)
+	pod := v1.ObjectReference{Kind: "Pod", UID: "pod-uid", ResourceVersion: "100"}
+	hourAgo := metav1.Time{Time: time.Now().Add(-time.Hour)}
+	threeHoursAgo := metav1.Time{Time: time.Now().Add(-time.Hour * 3)}
+
+	// The events were created before the watcher was restarted, and are older than the relevance window
+	events := []*v1.Event{
+		{Message: "pod event", InvolvedObject: v1.ObjectReference{Kind: "Pod", UID: "pod-uid", ResourceVersion: "120"}, ObjectMeta: metav1.ObjectMeta{Name: "a", CreationTimestamp: hourAgo}, LastTimestamp: hourAgo},
+		{Message: "earlier apply pod event", InvolvedObject: v1.ObjectReference{Kind: "Pod", UID: "pod-uid", ResourceVersion: "90"}, ObjectMeta: metav1.ObjectMeta{Name: "b", CreationTimestamp: threeHoursAgo}, LastTimestamp: threeHoursAgo},
+		{Message: "other pod event", InvolvedObject: v1.ObjectReference{Kind: "Pod", UID: "other-pod-uid", ResourceVersion: "120"}, ObjectMeta: metav1.ObjectMeta{Name: "c", CreationTimestamp: hourAgo}, LastTimestamp: hourAgo},
+	}
+	for _, event := range events {
+		client.CoreV1().Events("default").Create(event)
+	}
+
+	eventChan := eventManager.Watch(kuberneteswatcher.WatchEvents{
+		ListOptions:       metav1.ListOptions{},
+		Namespace:         "default",
+		Ctx:               ctx,
+		LogEntry:          *lg,
+		InvolvedObject:    pod,
+		ApplyCreationTime: applyCreationTime,
+	})
+
+	received := []string{}
+	timeout := time.After(time.Second * 2)
+	for done := false; !done; {
+		select {
+		case event := <-eventChan:
+			received = append(received, event.Message)
+		case <-timeout:
+			done = true
+		}
+	}
+
+	expected := []string{"pod event"}
+	if !reflect.DeepEqual(received, expected) {
+		t.Fatalf("unexpected replayed events, got %v expected %v", received, expected)
+	}
+}
+
+func TestWatchKindRelevanceWindow(t *testing.T) {
+	client := fake.NewSimpleClientset()
+
+	eventManager := kuberneteswatcher.NewEventsManager(NewInformerManagerMock(client), common.EventsConfig{
+		KindRelevanceWindows: map[string]time.Duration{"Pod": 2 * time.Hour},
+	})
+
+	lg := log.WithField("test", "TestWatchKindRelevanceWindow")
+	ctx := context.Background()
+
+	watchData := kuberneteswatcher.WatchEvents{
+		ListOptions:    metav1.ListOptions{},
+		Namespace:      "default",
+		Ctx:            ctx,
+		LogEntry:       *lg,
+		InvolvedObject: v1.ObjectReference{Kind: "Pod"},
+	}
+
+	eventChan := eventManager.Watch(watchData)
+	time.Sleep(time.Second)
+
+	event := &v1.Event{Message: "pulling image", ObjectMeta: metav1.ObjectMeta{Name: "a", CreationTimestamp: metav1.Time{Time: time.Now().Add(-time.Hour)}}}
+	client.CoreV1().Events("default").Create(event)
+
+	select {
+	case received := <-eventChan:
+		if received.Message != "pulling image" {
+			t.Fatalf("unexpected event message, got %s expected %s", received.Message, "pulling image")
+		}
+	case <-time.After(time.Second * 2):
+		t.Fatalf("the pod event within the pod relevance window was not received")
+	}
+}
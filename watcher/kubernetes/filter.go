@@ -0,0 +1,79 @@
+package kuberneteswatcher
+
+import (
+	"fmt"
+	"path"
+	"statusbay/watcher/kubernetes/common"
+	"strconv"
+
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// ApplyFilter decides which resources are tracked as applies
+type ApplyFilter struct {
+	config        common.FilterConfig
+	labelSelector labels.Selector
+}
+
+// NewApplyFilter creates new apply filter from the filters configuration
+func NewApplyFilter(config common.FilterConfig) (*ApplyFilter, error) {
+
+	for _, pattern := range append(append([]string{}, config.Namespaces.Include...), config.Namespaces.Exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid namespace pattern %s: %v", pattern, err)
+		}
+	}
+
+	labelSelector, err := labels.Parse(config.LabelSelector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid label selector %s: %v", config.LabelSelector, err)
+	}
+
+	return &ApplyFilter{
+		config:        config,
+		labelSelector: labelSelector,
+	}, nil
+}
+
+// Match returns true when the apply should be tracked, otherwise returns the reason that the apply was filtered.
+// A nil filter tracks all the applies
+func (af *ApplyFilter) Match(apply ApplyEvent) (bool, string) {
+	if af == nil {
+		return true, ""
+	}
+
+	if enabled, found := af.config.Kinds[apply.Kind]; found && !enabled {
+		return false, "kind is disabled"
+	}
+
+	if len(af.config.Namespaces.Include) > 0 && !matchNamespace(af.config.Namespaces.Include, apply.Namespace) {
+		return false, "namespace is not included"
+	}
+
+	if matchNamespace(af.config.Namespaces.Exclude, apply.Namespace) {
+		return false, "namespace is excluded"
+	}
+
+	if !af.labelSelector.Matches(labels.Set(apply.Labels)) {
+		return false, "labels don't match the label selector"
+	}
+
+	if af.config.OptIn {
+		enabled, _ := strconv.ParseBool(GetMetadata(apply.Annotations, fmt.Sprintf("%s/%s", annotationPrefix, annotationEnabled)))
+		if !enabled {
+			return false, "resource is not opted in"
+		}
+	}
+
+	return true, ""
+}
+
+// matchNamespace checks if the namespace matches one of the glob patterns
+func matchNamespace(patterns []string, namespace string) bool {
+	for _, pattern := range patterns {
+		if match, _ := path.Match(pattern, namespace); match {
+			return true
+		}
+	}
+	return false
+}
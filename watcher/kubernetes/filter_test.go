@@ -0,0 +1,63 @@
+package kuberneteswatcher_test
+
+import (
+	kuberneteswatcher "statusbay/watcher/kubernetes"
+	"statusbay/watcher/kubernetes/common"
+	"testing"
+)
+
+func TestApplyFilter(t *testing.T) {
+
+	filter, err := kuberneteswatcher.NewApplyFilter(common.FilterConfig{
+		Namespaces: common.NamespaceFilterConfig{
+			Include: []string{"team-*", "default"},
+			Exclude: []string{"team-sandbox-*"},
+		},
+		LabelSelector: "tier!=cache",
+		Kinds: map[string]bool{
+			"daemonset": false,
+			"job":       true,
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	optInFilter, err := kuberneteswatcher.NewApplyFilter(common.FilterConfig{OptIn: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	testsCases := []struct {
+		name     string
+		filter   *kuberneteswatcher.ApplyFilter
+		apply    kuberneteswatcher.ApplyEvent
+		expected bool
+	}{
+		{"included_namespace", filter, kuberneteswatcher.ApplyEvent{Namespace: "team-core", Kind: "deployment"}, true},
+		{"not_included_namespace", filter, kuberneteswatcher.ApplyEvent{Namespace: "kube-system", Kind: "deployment"}, false},
+		{"excluded_namespace", filter, kuberneteswatcher.ApplyEvent{Namespace: "team-sandbox-1", Kind: "deployment"}, false},
+		{"disabled_kind", filter, kuberneteswatcher.ApplyEvent{Namespace: "default", Kind: "daemonset"}, false},
+		{"enabled_kind", filter, kuberneteswatcher.ApplyEvent{Namespace: "default", Kind: "job"}, true},
+		{"label_selector", filter, kuberneteswatcher.ApplyEvent{Namespace: "default", Kind: "deployment", Labels: map[string]string{"tier": "cache"}}, false},
+		{"opt_in", optInFilter, kuberneteswatcher.ApplyEvent{Namespace: "default", Kind: "deployment", Annotations: map[string]string{"statusbay.io/enabled": "true"}}, true},
+		{"not_opt_in", optInFilter, kuberneteswatcher.ApplyEvent{Namespace: "default", Kind: "deployment", Annotations: map[string]string{}}, false},
+		{"nil_filter", nil, kuberneteswatcher.ApplyEvent{Namespace: "kube-system", Kind: "deployment"}, true},
+	}
+
+	for _, test := range testsCases {
+		t.Run(test.name, func(t *testing.T) {
+			match, reason := test.filter.Match(test.apply)
+			if match != test.expected {
+				t.Fatalf("unexpected filter match, got %t expected %t (reason: %s)", match, test.expected, reason)
+			}
+		})
+	}
+
+	t.Run("invalid_label_selector", func(t *testing.T) {
+		_, err := kuberneteswatcher.NewApplyFilter(common.FilterConfig{LabelSelector: "tier in cache"})
+		if err == nil {
+			t.Fatalf("expected error on invalid label selector")
+		}
+	})
+}
@@ -0,0 +1,186 @@
+package kuberneteswatcher
+
+import (
+	"context"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+
+	autoscalingV2beta2 "k8s.io/api/autoscaling/v2beta2"
+	v1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	eventwatch "k8s.io/apimachinery/pkg/watch"
+)
+
+// HorizontalPodAutoscalerRegistryData is the registry data of a resource that can be scaled by horizontal pod autoscalers
+type HorizontalPodAutoscalerRegistryData interface {
+	UpdateHorizontalPodAutoscaler(hpa *autoscalingV2beta2.HorizontalPodAutoscaler)
+	UpdateHorizontalPodAutoscalerEvents(name string, event EventMessages) error
+}
+
+// WatchHorizontalPodAutoscaler holds the data to be sent to the horizontal pod autoscaler Watch channel. Only the
+// horizontal pod autoscalers that their scale target is the given kind and name are tracked
+type WatchHorizontalPodAutoscaler struct {
+	Ctx          context.Context
+	ListOptions  metaV1.ListOptions
+	RegistryData HorizontalPodAutoscalerRegistryData
+	Namespace    string
+	TargetKind   string
+	TargetName   string
+	LogEntry     log.Entry
+	WatchHealth  *WatchHealth
+}
+
+// HorizontalPodAutoscalerManager manages the horizontal pod autoscalers of the applied resources
+type HorizontalPodAutoscalerManager struct {
+	eventManager    *EventsManager
+	informerManager *InformerManager
+	Watch           chan WatchHorizontalPodAutoscaler
+}
+
+// NewHorizontalPodAutoscalerManager creates a new horizontal pod autoscaler manager
+func NewHorizontalPodAutoscalerManager(informerManager *InformerManager, eventManager *EventsManager) *HorizontalPodAutoscalerManager {
+	return &HorizontalPodAutoscalerManager{
+		informerManager: informerManager,
+		eventManager:    eventManager,
+
+		Watch: make(chan WatchHorizontalPodAutoscaler),
+	}
+}
+
+// Serve will start listening on horizontal pod autoscaler requests
+func (hm *HorizontalPodAutoscalerManager) Serve(ctx context.Context, wg *sync.WaitGroup) {
+	wg.Add(1)
+
+	go func() {
+		for {
+			select {
+			case data := <-hm.Watch:
+				hm.watch(data)
+			case <-ctx.Done():
+				log.Warn("horizontal pod autoscaler manager has been shut down")
+				wg.Done()
+				return
+			}
+		}
+	}()
+
+}
+
+// watch will start watch on the horizontal pod autoscalers of the scale target
+func (hm *HorizontalPodAutoscalerManager) watch(watchData WatchHorizontalPodAutoscaler) {
+
+	go func() {
+
+		watchLog := watchData.LogEntry.WithFields(log.Fields{
+			"target_kind": watchData.TargetKind,
+			"target_name": watchData.TargetName,
+		})
+		watchLog.Info("start watching horizontal pod autoscalers")
+
+		watcher := hm.informerManager.ResumableWatch(watchData.Ctx, *watchLog, watchData.WatchHealth, horizontalPodAutoscalersResource, watchData.Namespace, watchData.ListOptions)
+		firstInit := map[string]bool{}
+
+		for {
+			select {
+			case event, watch := <-watcher:
+				if !watch {
+					watchLog.Warn("horizontal pod autoscaler watcher was stopped, channel was closed")
+					return
+				}
+				if event.Type == eventwatch.Deleted {
+					continue
+				}
+				hpa, isOk := event.Object.(*autoscalingV2beta2.HorizontalPodAutoscaler)
+				if !isOk {
+					watchLog.WithField("object", event.Object).Warn("failed to parse horizontal pod autoscaler watcher data")
+					continue
+				}
+				if !isScaleTarget(hpa, watchData.TargetKind, watchData.TargetName) {
+					continue
+				}
+
+				watchData.RegistryData.UpdateHorizontalPodAutoscaler(hpa)
+				if _, found := firstInit[hpa.GetName()]; !found {
+					firstInit[hpa.GetName()] = true
+					hpaLog := watchLog.WithField("hpa", hpa.GetName())
+					hpaLog.Info("horizontal pod autoscaler of the resource was found")
+					eventListOptions := metaV1.ListOptions{FieldSelector: labels.SelectorFromSet(map[string]string{
+						"involvedObject.name": hpa.GetName(),
+						"involvedObject.kind": "HorizontalPodAutoscaler",
+					}).String(),
+					}
+					hm.watchEvents(watchData.Ctx, *hpaLog, watchData.WatchHealth, watchData.RegistryData, eventListOptions, newObjectReference("HorizontalPodAutoscaler", hpa), hpa.GetName(), watchData.Namespace)
+				}
+
+			case <-watchData.Ctx.Done():
+				watchLog.Debug("horizontal pod autoscaler watcher was stopped, got ctx done signal")
+				return
+			}
+		}
+
+	}()
+
+}
+
+// watchEvents will start watch on horizontal pod autoscaler event messages changes
+func (hm *HorizontalPodAutoscalerManager) watchEvents(ctx context.Context, lg log.Entry, health *WatchHealth, registryData HorizontalPodAutoscalerRegistryData, listOptions metaV1.ListOptions, involvedObject v1.ObjectReference, hpaName, namespace string) {
+
+	lg.Info("initializing the event watcher on horizontal pod autoscaler events")
+
+	watchData := WatchEvents{
+		ListOptions:       listOptions,
+		Namespace:         namespace,
+		Ctx:               ctx,
+		LogEntry:          lg,
+		WatchHealth:       health,
+		InvolvedObject:    involvedObject,
+		ApplyCreationTime: health.applyCreation(),
+	}
+
+	eventChan := hm.eventManager.Watch(watchData)
+	go func() {
+
+		for {
+			select {
+			case event := <-eventChan:
+				registryData.UpdateHorizontalPodAutoscalerEvents(hpaName, event)
+			case <-ctx.Done():
+				lg.Info("stop watching on horizontal pod autoscaler events")
+				return
+			}
+		}
+	}()
+}
+
+// isScaleTarget returns true when the horizontal pod autoscaler scales the given resource
+func isScaleTarget(hpa *autoscalingV2beta2.HorizontalPodAutoscaler, kind, name string) bool {
+	return hpa.Spec.ScaleTargetRef.Kind == kind && hpa.Spec.ScaleTargetRef.Name == name
+}
+
+// newHorizontalPodAutoscalerData creates the registry data of a horizontal pod autoscaler from its spec and status,
+// the collected events are kept
+func newHorizontalPodAutoscalerData(hpa *autoscalingV2beta2.HorizontalPodAutoscaler, events *[]EventMessages) HorizontalPodAutoscalerData {
+	data := HorizontalPodAutoscalerData{
+		MinReplicas:     hpa.Spec.MinReplicas,
+		MaxReplicas:     hpa.Spec.MaxReplicas,
+		CurrentReplicas: hpa.Status.CurrentReplicas,
+		DesiredReplicas: hpa.Status.DesiredReplicas,
+		Conditions:      []HorizontalPodAutoscalerCondition{},
+		Events:          events,
+	}
+	if hpa.Status.LastScaleTime != nil {
+		data.LastScaleTime = optionalUnixNano(*hpa.Status.LastScaleTime)
+	}
+	for _, condition := range hpa.Status.Conditions {
+		data.Conditions = append(data.Conditions, HorizontalPodAutoscalerCondition{
+			Type:               string(condition.Type),
+			Status:             string(condition.Status),
+			Reason:             condition.Reason,
+			Message:            condition.Message,
+			LastTransitionTime: optionalUnixNano(condition.LastTransitionTime),
+		})
+	}
+	return data
+}
@@ -0,0 +1,127 @@
+package kuberneteswatcher_test
+
+import (
+	"context"
+	kuberneteswatcher "statusbay/watcher/kubernetes"
+	"statusbay/watcher/kubernetes/common"
+	"sync"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	autoscalingV2beta2 "k8s.io/api/autoscaling/v2beta2"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func createHorizontalPodAutoscalerMock(client *fake.Clientset, name, namespace, targetKind, targetName string) *autoscalingV2beta2.HorizontalPodAutoscaler {
+	minReplicas := int32(2)
+	hpa := &autoscalingV2beta2.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: autoscalingV2beta2.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: autoscalingV2beta2.CrossVersionObjectReference{
+				Kind:       targetKind,
+				Name:       targetName,
+				APIVersion: "apps/v1",
+			},
+			MinReplicas: &minReplicas,
+			MaxReplicas: 10,
+		},
+		Status: autoscalingV2beta2.HorizontalPodAutoscalerStatus{
+			CurrentReplicas: 2,
+			DesiredReplicas: 4,
+			Conditions: []autoscalingV2beta2.HorizontalPodAutoscalerCondition{
+				{
+					Type:    autoscalingV2beta2.ScalingActive,
+					Status:  v1.ConditionFalse,
+					Reason:  "FailedGetResourceMetric",
+					Message: "the HPA was unable to compute the replica count: unable to get metrics for resource cpu",
+				},
+			},
+		},
+	}
+
+	hpa, _ = client.AutoscalingV2beta2().HorizontalPodAutoscalers(namespace).Create(hpa)
+	return hpa
+}
+
+func NewHorizontalPodAutoscalerManagerMock(client *fake.Clientset) *kuberneteswatcher.HorizontalPodAutoscalerManager {
+
+	eventManager := NewEventsMock(client)
+	return kuberneteswatcher.NewHorizontalPodAutoscalerManager(NewInformerManagerMock(client), eventManager)
+
+}
+
+func TestHorizontalPodAutoscalerWatch(t *testing.T) {
+	registry, storageMock := NewRegistryMock()
+
+	registryRow := registry.NewApplication("nginx", "default", map[string]string{}, common.ApplyStatusRunning)
+	namespace := "default"
+	apply := kuberneteswatcher.ApplyEvent{
+		Event:        "create",
+		ApplyName:    "nginx-deployment",
+		ResourceName: "resourceName",
+		Namespace:    namespace,
+		Kind:         "deployment",
+		Hash:         1234,
+		Annotations:  map[string]string{},
+		Labels:       map[string]string{},
+	}
+
+	registryDeploymentData := createMockDeploymentData(registry, registryRow, apply, "10m")
+	lg := log.WithField("test", "TestHorizontalPodAutoscalerWatch")
+	ctx := context.Background()
+
+	client := fake.NewSimpleClientset()
+
+	hpaManager := NewHorizontalPodAutoscalerManagerMock(client)
+
+	var wg sync.WaitGroup
+
+	hpaManager.Serve(ctx, &wg)
+
+	hpaManager.Watch <- kuberneteswatcher.WatchHorizontalPodAutoscaler{
+		ListOptions:  metav1.ListOptions{},
+		RegistryData: registryDeploymentData,
+		Namespace:    namespace,
+		TargetKind:   "Deployment",
+		TargetName:   "nginx-deployment",
+		Ctx:          ctx,
+		LogEntry:     *lg,
+	}
+
+	time.Sleep(time.Second)
+	createHorizontalPodAutoscalerMock(client, "nginx-hpa", namespace, "Deployment", "nginx-deployment")
+	createHorizontalPodAutoscalerMock(client, "other-hpa", namespace, "Deployment", "other-deployment")
+	time.Sleep(time.Second)
+
+	event := &v1.Event{Message: "New size: 4; reason: cpu resource utilization (percentage of request) above target", Reason: "SuccessfulRescale", InvolvedObject: v1.ObjectReference{Kind: "HorizontalPodAutoscaler", Name: "nginx-hpa"}, ObjectMeta: metav1.ObjectMeta{CreationTimestamp: metav1.Time{Time: time.Now()}}}
+	client.CoreV1().Events(namespace).Create(event)
+
+	time.Sleep(time.Second * 2)
+
+	deployment := storageMock.MockWriteDeployment["1"].Schema.Resources.Deployments["resourceName"]
+
+	if len(deployment.HorizontalPodAutoscalers) != 1 {
+		t.Fatalf("unexpected horizontal pod autoscalers count, got %d expected %d", len(deployment.HorizontalPodAutoscalers), 1)
+	}
+
+	hpa, found := deployment.HorizontalPodAutoscalers["nginx-hpa"]
+	if !found {
+		t.Fatalf("horizontal pod autoscaler nginx-hpa was not found")
+	}
+	if hpa.CurrentReplicas != 2 || hpa.DesiredReplicas != 4 || hpa.MaxReplicas != 10 || *hpa.MinReplicas != 2 {
+		t.Fatalf("unexpected horizontal pod autoscaler replicas, got %+v", hpa)
+	}
+	if len(hpa.Conditions) != 1 || hpa.Conditions[0].Reason != "FailedGetResourceMetric" {
+		t.Fatalf("unexpected horizontal pod autoscaler conditions, got %+v", hpa.Conditions)
+	}
+	if len(*hpa.Events) != 1 || (*hpa.Events)[0].Reason != "SuccessfulRescale" {
+		t.Fatalf("unexpected horizontal pod autoscaler events, got %+v", *hpa.Events)
+	}
+}
@@ -0,0 +1,938 @@
+package kuberneteswatcher
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	appsV1 "k8s.io/api/apps/v1"
+	autoscalingV2beta2 "k8s.io/api/autoscaling/v2beta2"
+	batchV1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	networkingV1beta1 "k8s.io/api/networking/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	eventwatch "k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+var (
+	podsResource                     = v1.SchemeGroupVersion.WithResource("pods")
+	eventsResource                   = v1.SchemeGroupVersion.WithResource("events")
+	servicesResource                 = v1.SchemeGroupVersion.WithResource("services")
+	persistentVolumeClaimsResource   = v1.SchemeGroupVersion.WithResource("persistentvolumeclaims")
+	deploymentsResource              = appsV1.SchemeGroupVersion.WithResource("deployments")
+	replicasetsResource              = appsV1.SchemeGroupVersion.WithResource("replicasets")
+	daemonsetsResource               = appsV1.SchemeGroupVersion.WithResource("daemonsets")
+	statefulsetsResource             = appsV1.SchemeGroupVersion.WithResource("statefulsets")
+	controllerRevisionsResource      = appsV1.SchemeGroupVersion.WithResource("controllerrevisions")
+	jobsResource                     = batchV1.SchemeGroupVersion.WithResource("jobs")
+	horizontalPodAutoscalersResource = autoscalingV2beta2.SchemeGroupVersion.WithResource("horizontalpodautoscalers")
+	endpointsResource                = v1.SchemeGroupVersion.WithResource("endpoints")
+	ingressesResource                = networkingV1beta1.SchemeGroupVersion.WithResource("ingresses")
+
+	// errInformerNotSynced returned when the informer cache could not be synced
+	errInformerNotSynced = errors.New("informer cache was not synced")
+
+	// errWatchClosed returned when the watch channel was closed before the watch timeout
+	errWatchClosed = errors.New("watch channel was closed")
+
+	// errListTimeout returned when the resources could not be listed before the cache sync timeout
+	errListTimeout = errors.New("resources could not be listed before the cache sync timeout")
+
+	// errNoWatchableNamespace returned when none of the configured namespaces can be watched
+	errNoWatchableNamespace = errors.New("none of the namespaces can be watched")
+
+	// errWatchOverflow returned when the watch dropped changes because its consumer was too slow
+	errWatchOverflow = errors.New("watch queue overflowed, changes were dropped")
+)
+
+const (
+	// watchRetryInitialBackoff is the delay before the first retry of an interrupted watch
+	watchRetryInitialBackoff = time.Second
+
+	// watchRetryMaxBackoff is the max delay between the retries of an interrupted watch
+	watchRetryMaxBackoff = time.Second * 30
+
+	// informerCacheSyncTimeout is the max time to wait for the first list of an informer, so a cluster that can't be
+	// reached doesn't block its watchers forever
+	informerCacheSyncTimeout = time.Minute * 2
+
+	// informerWatcherQueueSize is the max count of the informer changes that are queued for a watcher that didn't
+	// receive them yet. The objects that are replayed from the cache when the watch starts are not counted
+	informerWatcherQueueSize = 1000
+)
+
+// sharedInformer holds the informers of a single resource type, a cluster wide informer or an informer per
+// watched namespace, and the active watchers that receive the informers changes
+type sharedInformer struct {
+	informers map[string]cache.SharedIndexInformer
+
+	// watchers are indexed by their namespace and then by their label selector, so a change is matched only against
+	// the watchers of its namespace and each label selector is matched once. Watchers of all the namespaces are
+	// indexed by an empty namespace
+	watchers map[string]map[string]map[*informerWatcher]bool
+	lock     *sync.RWMutex
+}
+
+// InformerManager holds one shared informer per resource type, and dispatch the informer changes to the
+// apply watchers by the watchers namespace, labels and fields selectors.
+// When a list of namespaces is given, the resources are watched with an informer per namespace, so only
+// namespace scoped permissions are required
+type InformerManager struct {
+	client        kubernetes.Interface
+	dynamicClient dynamic.Interface
+	resyncPeriod  time.Duration
+	namespaces    []string
+
+	// cacheSyncTimeout is the max time to wait until the resources can be listed and the informer cache is synced
+	cacheSyncTimeout time.Duration
+
+	// watcherQueueSize is the max count of the changes that are queued for a single watcher
+	watcherQueueSize int
+
+	// watchRetryInitialBackoff and watchRetryMaxBackoff are the delays between the retries of an interrupted watch
+	watchRetryInitialBackoff time.Duration
+	watchRetryMaxBackoff     time.Duration
+
+	informers           map[schema.GroupVersionResource]*sharedInformer
+	unwatchedNamespaces map[schema.GroupVersionResource]map[string]error
+	lock                *sync.Mutex
+	stopCh              chan struct{}
+	stopOnce            *sync.Once
+}
+
+// NewInformerManager creates new shared informers manager. Empty namespaces list watches the whole cluster
+func NewInformerManager(kubernetesClientset kubernetes.Interface, dynamicClient dynamic.Interface, resyncPeriod time.Duration, namespaces []string) *InformerManager {
+	return &InformerManager{
+		client:                   kubernetesClientset,
+		dynamicClient:            dynamicClient,
+		resyncPeriod:             resyncPeriod,
+		namespaces:               namespaces,
+		cacheSyncTimeout:         informerCacheSyncTimeout,
+		watcherQueueSize:         informerWatcherQueueSize,
+		watchRetryInitialBackoff: watchRetryInitialBackoff,
+		watchRetryMaxBackoff:     watchRetryMaxBackoff,
+		informers:                make(map[schema.GroupVersionResource]*sharedInformer),
+		unwatchedNamespaces:      make(map[schema.GroupVersionResource]map[string]error),
+		lock:                     &sync.Mutex{},
+		stopCh:                   make(chan struct{}),
+		stopOnce:                 &sync.Once{},
+	}
+}
+
+// Serve will stop all the running informers when the context is done
+func (im *InformerManager) Serve(ctx context.Context, wg *sync.WaitGroup) {
+	wg.Add(1)
+
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				log.Warn("informer manager has been shut down")
+				im.stopOnce.Do(func() { close(im.stopCh) })
+				wg.Done()
+				return
+			}
+		}
+	}()
+
+}
+
+// Start starts the informers of the given resources and waits until their caches are synced
+func (im *InformerManager) Start(resources ...schema.GroupVersionResource) error {
+	for _, resource := range resources {
+		if _, err := im.getInformer(resource); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WarmUp starts the informers of all the built-in watched resources, so their caches are
+// already synced when the watchers are started
+func (im *InformerManager) WarmUp() error {
+	return im.Start(podsResource, eventsResource, servicesResource, persistentVolumeClaimsResource, deploymentsResource, replicasetsResource, daemonsetsResource, statefulsetsResource, controllerRevisionsResource, jobsResource, horizontalPodAutoscalersResource, endpointsResource, ingressesResource)
+}
+
+// ResourceVersion returns the last resource version that the informers of the resource were synced with.
+// With an informer per namespace, the version of the first synced namespace is returned. A watch that
+// starts from a version that one of the informers is synced with doesn't replay the informers cache
+func (im *InformerManager) ResourceVersion(resource schema.GroupVersionResource) (string, error) {
+	shared, err := im.getInformer(resource)
+	if err != nil {
+		return "", err
+	}
+	namespaces := []string{}
+	for namespace := range shared.informers {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+	for _, namespace := range namespaces {
+		if resourceVersion := shared.informers[namespace].LastSyncResourceVersion(); resourceVersion != "" {
+			return resourceVersion, nil
+		}
+	}
+	return "", nil
+}
+
+// UnwatchedNamespaces returns the namespaces that could not be watched per resource, with the watch error
+func (im *InformerManager) UnwatchedNamespaces() map[string]map[string]error {
+	im.lock.Lock()
+	defer im.lock.Unlock()
+
+	unwatched := map[string]map[string]error{}
+	for resource, namespaces := range im.unwatchedNamespaces {
+		unwatched[resource.String()] = map[string]error{}
+		for namespace, err := range namespaces {
+			unwatched[resource.String()][namespace] = err
+		}
+	}
+	return unwatched
+}
+
+// Watch returns a watcher on the resource changes that match the list options.
+// When the list options don't include resource version, all the matching objects in the informer
+// cache are sent as added events (same as watch without resource version on the API server).
+// Resource versions are opaque, so when the informers are not synced with the given resource version,
+// all the matching objects are sent except the objects that still have the given resource version.
+func (im *InformerManager) Watch(resource schema.GroupVersionResource, namespace string, options metaV1.ListOptions) (eventwatch.Interface, error) {
+	return im.watch(resource, namespace, options)
+}
+
+// watch returns an informer watcher on the resource changes that match the list options
+func (im *InformerManager) watch(resource schema.GroupVersionResource, namespace string, options metaV1.ListOptions) (*informerWatcher, error) {
+
+	labelSelector, err := labels.Parse(options.LabelSelector)
+	if err != nil {
+		return nil, err
+	}
+	fieldSelector, err := fields.ParseSelector(options.FieldSelector)
+	if err != nil {
+		return nil, err
+	}
+
+	shared, err := im.getInformer(resource)
+	if err != nil {
+		return nil, err
+	}
+
+	watcher := newInformerWatcher(namespace, labelSelector, fieldSelector)
+	watcher.queueSize = im.watcherQueueSize
+	watcher.onStop = func() {
+		shared.lock.Lock()
+		shared.removeWatcher(watcher)
+		shared.lock.Unlock()
+	}
+
+	// The watcher is registered while holding the dispatch lock, so changes are not
+	// dispatched while the cache replay is collected
+	shared.lock.Lock()
+	if !shared.isSyncedWith(options.ResourceVersion) {
+		for _, informer := range shared.informers {
+			for _, obj := range informer.GetStore().List() {
+				if !watcher.matches(obj) || !isChangedSince(obj, options.ResourceVersion) {
+					continue
+				}
+				watcher.replay(eventwatch.Event{Type: eventwatch.Added, Object: obj.(runtime.Object)})
+			}
+		}
+	}
+	shared.addWatcher(watcher)
+	shared.lock.Unlock()
+
+	if options.TimeoutSeconds != nil {
+		time.AfterFunc(time.Duration(*options.TimeoutSeconds)*time.Second, watcher.Stop)
+	}
+
+	go watcher.run()
+	return watcher, nil
+}
+
+// List returns the cached objects of the resource that match the namespace and the list options selectors
+func (im *InformerManager) List(resource schema.GroupVersionResource, namespace string, options metaV1.ListOptions) ([]runtime.Object, error) {
+
+	labelSelector, err := labels.Parse(options.LabelSelector)
+	if err != nil {
+		return nil, err
+	}
+	fieldSelector, err := fields.ParseSelector(options.FieldSelector)
+	if err != nil {
+		return nil, err
+	}
+
+	shared, err := im.getInformer(resource)
+	if err != nil {
+		return nil, err
+	}
+
+	matcher := newInformerWatcher(namespace, labelSelector, fieldSelector)
+	objects := []runtime.Object{}
+	for _, informer := range shared.informers {
+		for _, obj := range informer.GetStore().List() {
+			if matcher.matches(obj) {
+				objects = append(objects, obj.(runtime.Object))
+			}
+		}
+	}
+	return objects, nil
+}
+
+// ResumableWatch returns the resource changes that match the list options, the returned channel is closed
+// only when the context is done or when the watch timeout (TimeoutSeconds) was reached.
+// When the watch is closed or fails, it is re-established with backoff from the last seen resource version.
+// Every interruption is recorded in the given watch health, including the watches that dropped changes
+// because the consumer was too slow. The dropped changes are replayed from the informers cache on the next watch
+func (im *InformerManager) ResumableWatch(ctx context.Context, lg log.Entry, health *WatchHealth, resource schema.GroupVersionResource, namespace string, options metaV1.ListOptions) <-chan eventwatch.Event {
+
+	results := make(chan eventwatch.Event)
+
+	var deadline time.Time
+	if options.TimeoutSeconds != nil {
+		deadline = time.Now().Add(time.Duration(*options.TimeoutSeconds) * time.Second)
+	}
+
+	go func() {
+		defer close(results)
+
+		resourceVersion := options.ResourceVersion
+		backoff := im.watchRetryInitialBackoff
+		for {
+			watchOptions := options
+			watchOptions.ResourceVersion = resourceVersion
+			if !deadline.IsZero() {
+				remaining := int64(time.Until(deadline).Seconds())
+				if remaining <= 0 {
+					return
+				}
+				watchOptions.TimeoutSeconds = &remaining
+			}
+
+			watcher, err := im.watch(resource, namespace, watchOptions)
+			if err == nil {
+				err = forwardWatchEvents(ctx, watcher, results, &resourceVersion, &backoff, im.watchRetryInitialBackoff)
+				if watcher.isOverflowed() {
+					err = errWatchOverflow
+					health.watchOverflowed()
+				}
+			}
+
+			if ctx.Err() != nil || im.isStopped() {
+				return
+			}
+			if err == errWatchClosed && !deadline.IsZero() && !time.Now().Before(deadline) {
+				return
+			}
+
+			watchLog := lg.WithError(err).WithFields(log.Fields{
+				"resource":         resource.Resource,
+				"resource_version": resourceVersion,
+				"backoff":          backoff,
+			})
+			watchLog.Warn("watch was interrupted, re-establishing the watch")
+			health.watchRestarted(err)
+
+			select {
+			case <-time.After(backoff):
+			case <-ctx.Done():
+				return
+			}
+			backoff = backoff * 2
+			if backoff > im.watchRetryMaxBackoff {
+				backoff = im.watchRetryMaxBackoff
+			}
+		}
+	}()
+
+	return results
+}
+
+// forwardWatchEvents sends the watch changes to the results channel, and keeps the last seen resource version.
+// The backoff is reset to the initial backoff once the watch delivers a change
+func forwardWatchEvents(ctx context.Context, watcher eventwatch.Interface, results chan<- eventwatch.Event, resourceVersion *string, backoff *time.Duration, initialBackoff time.Duration) error {
+	defer watcher.Stop()
+	for {
+		select {
+		case event, ok := <-watcher.ResultChan():
+			if !ok {
+				return errWatchClosed
+			}
+			if event.Type == eventwatch.Error {
+				return apierrors.FromObject(event.Object)
+			}
+			if accessor, err := meta.Accessor(event.Object); err == nil && accessor.GetResourceVersion() != "" {
+				*resourceVersion = accessor.GetResourceVersion()
+			}
+			*backoff = initialBackoff
+
+			select {
+			case results <- event:
+			case <-ctx.Done():
+				return nil
+			}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// isStopped returns true when the informer manager was shut down
+func (im *InformerManager) isStopped() bool {
+	select {
+	case <-im.stopCh:
+		return true
+	default:
+		return false
+	}
+}
+
+// getInformer returns the shared informer of the resource, the informer is created and started on the first request
+func (im *InformerManager) getInformer(resource schema.GroupVersionResource) (*sharedInformer, error) {
+	im.lock.Lock()
+	shared, found := im.informers[resource]
+	if !found {
+		shared = &sharedInformer{
+			informers: make(map[string]cache.SharedIndexInformer),
+			watchers:  make(map[string]map[string]map[*informerWatcher]bool),
+			lock:      &sync.RWMutex{},
+		}
+
+		if len(im.namespaces) == 0 {
+			if err := im.canWatch(resource, ""); err != nil {
+				im.lock.Unlock()
+				log.WithError(err).WithField("resource", resource.String()).Error("resource could not be watched")
+				return nil, err
+			}
+			shared.informers[""] = im.newInformer(resource, "")
+		} else {
+			for _, namespace := range im.namespaces {
+				if err := im.canWatch(resource, namespace); err != nil {
+					log.WithError(err).WithFields(log.Fields{
+						"resource":  resource.String(),
+						"namespace": namespace,
+					}).Error("namespace could not be watched, the namespace resources are not tracked")
+					if im.unwatchedNamespaces[resource] == nil {
+						im.unwatchedNamespaces[resource] = map[string]error{}
+					}
+					im.unwatchedNamespaces[resource][namespace] = err
+					continue
+				}
+				shared.informers[namespace] = im.newInformer(resource, namespace)
+			}
+			if len(shared.informers) == 0 {
+				im.lock.Unlock()
+				return nil, errNoWatchableNamespace
+			}
+		}
+
+		for namespace, informer := range shared.informers {
+			informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+				AddFunc: func(obj interface{}) {
+					shared.dispatch(eventwatch.Added, obj)
+				},
+				UpdateFunc: func(oldObj, newObj interface{}) {
+					shared.dispatch(eventwatch.Modified, newObj)
+				},
+				DeleteFunc: func(obj interface{}) {
+					if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+						obj = tombstone.Obj
+					}
+					shared.dispatch(eventwatch.Deleted, obj)
+				},
+			})
+			log.WithFields(log.Fields{
+				"resource":  resource.String(),
+				"namespace": namespace,
+			}).Info("starting shared informer")
+			go informer.Run(im.stopCh)
+		}
+		im.informers[resource] = shared
+	}
+	im.lock.Unlock()
+
+	if !im.waitForCacheSync(shared.informers) {
+		log.WithFields(log.Fields{
+			"resource": resource.String(),
+			"timeout":  im.cacheSyncTimeout,
+		}).Error("informer cache was not synced")
+		return nil, errInformerNotSynced
+	}
+	return shared, nil
+}
+
+// waitForCacheSync waits until the caches of the informers are synced. Returns false when the informer manager
+// was stopped or the cache sync timeout passed
+func (im *InformerManager) waitForCacheSync(informers map[string]cache.SharedIndexInformer) bool {
+	stopCh := make(chan struct{})
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-im.stopCh:
+		case <-time.After(im.cacheSyncTimeout):
+		case <-done:
+			return
+		}
+		close(stopCh)
+	}()
+
+	for _, informer := range informers {
+		if !cache.WaitForCacheSync(stopCh, informer.HasSynced) {
+			return false
+		}
+	}
+	return true
+}
+
+// canWatch checks that the resources of the namespace can be listed, so an informer without permissions or of a
+// cluster that can't be reached doesn't block the cache sync forever
+func (im *InformerManager) canWatch(resource schema.GroupVersionResource, namespace string) error {
+	listWatch := im.newListWatch(resource, namespace)
+	result := make(chan error, 1)
+	go func() {
+		_, err := listWatch.List(metaV1.ListOptions{Limit: 1})
+		result <- err
+	}()
+
+	select {
+	case err := <-result:
+		return err
+	case <-time.After(im.cacheSyncTimeout):
+		return errListTimeout
+	case <-im.stopCh:
+		return errInformerNotSynced
+	}
+}
+
+// newInformer creates an informer for the given resource, empty namespace creates a cluster wide informer
+func (im *InformerManager) newInformer(resource schema.GroupVersionResource, namespace string) cache.SharedIndexInformer {
+	listWatch := im.newListWatch(resource, namespace)
+	return cache.NewSharedIndexInformer(listWatch, im.objectType(resource), im.resyncPeriod, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+}
+
+// objectType returns the object type that the informer of the resource holds
+func (im *InformerManager) objectType(resource schema.GroupVersionResource) runtime.Object {
+	switch resource {
+	case podsResource:
+		return &v1.Pod{}
+	case eventsResource:
+		return &v1.Event{}
+	case servicesResource:
+		return &v1.Service{}
+	case persistentVolumeClaimsResource:
+		return &v1.PersistentVolumeClaim{}
+	case deploymentsResource:
+		return &appsV1.Deployment{}
+	case replicasetsResource:
+		return &appsV1.ReplicaSet{}
+	case daemonsetsResource:
+		return &appsV1.DaemonSet{}
+	case statefulsetsResource:
+		return &appsV1.StatefulSet{}
+	case controllerRevisionsResource:
+		return &appsV1.ControllerRevision{}
+	case jobsResource:
+		return &batchV1.Job{}
+	case horizontalPodAutoscalersResource:
+		return &autoscalingV2beta2.HorizontalPodAutoscaler{}
+	case endpointsResource:
+		return &v1.Endpoints{}
+	case ingressesResource:
+		return &networkingV1beta1.Ingress{}
+	default:
+		return &unstructured.Unstructured{}
+	}
+}
+
+// newListWatch creates the list and watch functions of the resource in the given namespace, empty namespace lists all the namespaces
+func (im *InformerManager) newListWatch(resource schema.GroupVersionResource, namespace string) *cache.ListWatch {
+
+	var listFunc cache.ListFunc
+	var watchFunc cache.WatchFunc
+
+	switch resource {
+	case podsResource:
+		listFunc = func(options metaV1.ListOptions) (runtime.Object, error) {
+			return im.client.CoreV1().Pods(namespace).List(options)
+		}
+		watchFunc = func(options metaV1.ListOptions) (eventwatch.Interface, error) {
+			return im.client.CoreV1().Pods(namespace).Watch(options)
+		}
+	case eventsResource:
+		listFunc = func(options metaV1.ListOptions) (runtime.Object, error) {
+			return im.client.CoreV1().Events(namespace).List(options)
+		}
+		watchFunc = func(options metaV1.ListOptions) (eventwatch.Interface, error) {
+			return im.client.CoreV1().Events(namespace).Watch(options)
+		}
+	case servicesResource:
+		listFunc = func(options metaV1.ListOptions) (runtime.Object, error) {
+			return im.client.CoreV1().Services(namespace).List(options)
+		}
+		watchFunc = func(options metaV1.ListOptions) (eventwatch.Interface, error) {
+			return im.client.CoreV1().Services(namespace).Watch(options)
+		}
+	case persistentVolumeClaimsResource:
+		listFunc = func(options metaV1.ListOptions) (runtime.Object, error) {
+			return im.client.CoreV1().PersistentVolumeClaims(namespace).List(options)
+		}
+		watchFunc = func(options metaV1.ListOptions) (eventwatch.Interface, error) {
+			return im.client.CoreV1().PersistentVolumeClaims(namespace).Watch(options)
+		}
+	case deploymentsResource:
+		listFunc = func(options metaV1.ListOptions) (runtime.Object, error) {
+			return im.client.AppsV1().Deployments(namespace).List(options)
+		}
+		watchFunc = func(options metaV1.ListOptions) (eventwatch.Interface, error) {
+			return im.client.AppsV1().Deployments(namespace).Watch(options)
+		}
+	case replicasetsResource:
+		listFunc = func(options metaV1.ListOptions) (runtime.Object, error) {
+			return im.client.AppsV1().ReplicaSets(namespace).List(options)
+		}
+		watchFunc = func(options metaV1.ListOptions) (eventwatch.Interface, error) {
+			return im.client.AppsV1().ReplicaSets(namespace).Watch(options)
+		}
+	case daemonsetsResource:
+		listFunc = func(options metaV1.ListOptions) (runtime.Object, error) {
+			return im.client.AppsV1().DaemonSets(namespace).List(options)
+		}
+		watchFunc = func(options metaV1.ListOptions) (eventwatch.Interface, error) {
+			return im.client.AppsV1().DaemonSets(namespace).Watch(options)
+		}
+	case statefulsetsResource:
+		listFunc = func(options metaV1.ListOptions) (runtime.Object, error) {
+			return im.client.AppsV1().StatefulSets(namespace).List(options)
+		}
+		watchFunc = func(options metaV1.ListOptions) (eventwatch.Interface, error) {
+			return im.client.AppsV1().StatefulSets(namespace).Watch(options)
+		}
+	case controllerRevisionsResource:
+		listFunc = func(options metaV1.ListOptions) (runtime.Object, error) {
+			return im.client.AppsV1().ControllerRevisions(namespace).List(options)
+		}
+		watchFunc = func(options metaV1.ListOptions) (eventwatch.Interface, error) {
+			return im.client.AppsV1().ControllerRevisions(namespace).Watch(options)
+		}
+	case jobsResource:
+		listFunc = func(options metaV1.ListOptions) (runtime.Object, error) {
+			return im.client.BatchV1().Jobs(namespace).List(options)
+		}
+		watchFunc = func(options metaV1.ListOptions) (eventwatch.Interface, error) {
+			return im.client.BatchV1().Jobs(namespace).Watch(options)
+		}
+	case horizontalPodAutoscalersResource:
+		listFunc = func(options metaV1.ListOptions) (runtime.Object, error) {
+			return im.client.AutoscalingV2beta2().HorizontalPodAutoscalers(namespace).List(options)
+		}
+		watchFunc = func(options metaV1.ListOptions) (eventwatch.Interface, error) {
+			return im.client.AutoscalingV2beta2().HorizontalPodAutoscalers(namespace).Watch(options)
+		}
+	case endpointsResource:
+		listFunc = func(options metaV1.ListOptions) (runtime.Object, error) {
+			return im.client.CoreV1().Endpoints(namespace).List(options)
+		}
+		watchFunc = func(options metaV1.ListOptions) (eventwatch.Interface, error) {
+			return im.client.CoreV1().Endpoints(namespace).Watch(options)
+		}
+	case ingressesResource:
+		listFunc = func(options metaV1.ListOptions) (runtime.Object, error) {
+			return im.client.NetworkingV1beta1().Ingresses(namespace).List(options)
+		}
+		watchFunc = func(options metaV1.ListOptions) (eventwatch.Interface, error) {
+			return im.client.NetworkingV1beta1().Ingresses(namespace).Watch(options)
+		}
+	default:
+		// Any other resource (custom resources) is watched with the dynamic client
+		listFunc = func(options metaV1.ListOptions) (runtime.Object, error) {
+			return im.dynamicClient.Resource(resource).Namespace(namespace).List(options)
+		}
+		watchFunc = func(options metaV1.ListOptions) (eventwatch.Interface, error) {
+			return im.dynamicClient.Resource(resource).Namespace(namespace).Watch(options)
+		}
+	}
+
+	return &cache.ListWatch{
+		ListFunc: listFunc,
+		WatchFunc: func(options metaV1.ListOptions) (eventwatch.Interface, error) {
+			// Bookmarks keep the informer resource version fresh, so a watch restart doesn't require a full relist
+			options.AllowWatchBookmarks = true
+			return watchFunc(options)
+		},
+	}
+}
+
+// isSyncedWith checks if one of the informers was synced with the given resource version
+func (si *sharedInformer) isSyncedWith(resourceVersion string) bool {
+	if resourceVersion == "" {
+		return false
+	}
+	for _, informer := range si.informers {
+		if informer.LastSyncResourceVersion() == resourceVersion {
+			return true
+		}
+	}
+	return false
+}
+
+// addWatcher adds the watcher to the watchers index, the shared informer lock must be held
+func (si *sharedInformer) addWatcher(watcher *informerWatcher) {
+	selectors, found := si.watchers[watcher.namespace]
+	if !found {
+		selectors = make(map[string]map[*informerWatcher]bool)
+		si.watchers[watcher.namespace] = selectors
+	}
+	selector := watcher.labelSelector.String()
+	if _, found := selectors[selector]; !found {
+		selectors[selector] = make(map[*informerWatcher]bool)
+	}
+	selectors[selector][watcher] = true
+}
+
+// removeWatcher removes the watcher from the watchers index, the shared informer lock must be held
+func (si *sharedInformer) removeWatcher(watcher *informerWatcher) {
+	selectors, found := si.watchers[watcher.namespace]
+	if !found {
+		return
+	}
+	selector := watcher.labelSelector.String()
+	delete(selectors[selector], watcher)
+	if len(selectors[selector]) == 0 {
+		delete(selectors, selector)
+	}
+	if len(selectors) == 0 {
+		delete(si.watchers, watcher.namespace)
+	}
+}
+
+// listWatchers returns all the active watchers, the shared informer lock must be held
+func (si *sharedInformer) listWatchers() []*informerWatcher {
+	watchers := []*informerWatcher{}
+	for _, selectors := range si.watchers {
+		for _, selectorWatchers := range selectors {
+			for watcher := range selectorWatchers {
+				watchers = append(watchers, watcher)
+			}
+		}
+	}
+	return watchers
+}
+
+// dispatch sends the informer change to all the matching watchers. Only the watchers of the object namespace and of
+// all the namespaces are matched, and the watchers that share a label selector are skipped together
+func (si *sharedInformer) dispatch(eventType eventwatch.EventType, obj interface{}) {
+	runtimeObj, ok := obj.(runtime.Object)
+	if !ok {
+		log.WithField("object", obj).Warn("failed to dispatch informer object")
+		return
+	}
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		log.WithError(err).WithField("object", obj).Warn("failed to dispatch informer object")
+		return
+	}
+	event := eventwatch.Event{Type: eventType, Object: runtimeObj}
+	objectLabels := labels.Set(accessor.GetLabels())
+	var objectFields fields.Set
+
+	namespaces := []string{""}
+	if namespace := accessor.GetNamespace(); namespace != "" {
+		namespaces = append(namespaces, namespace)
+	}
+
+	si.lock.RLock()
+	defer si.lock.RUnlock()
+	for _, namespace := range namespaces {
+		for _, selectorWatchers := range si.watchers[namespace] {
+			for watcher := range selectorWatchers {
+				// All the watchers of the group have the same label selector
+				if !watcher.labelSelector.Matches(objectLabels) {
+					break
+				}
+				if objectFields == nil {
+					objectFields = getObjectFields(obj, accessor)
+				}
+				if watcher.fieldSelector.Matches(objectFields) {
+					watcher.add(event)
+				}
+			}
+		}
+	}
+}
+
+// informerWatcher implements watch.Interface on top of the shared informer changes.
+// Changes are queued up to the queue size, so a slow watcher never blocks the informer dispatch.
+// When the queue is full, the change is dropped and the watcher is stopped
+type informerWatcher struct {
+	namespace     string
+	labelSelector labels.Selector
+	fieldSelector fields.Selector
+
+	queue      []eventwatch.Event
+	queueSize  int
+	replaying  int
+	overflowed bool
+	result     chan eventwatch.Event
+	done       chan struct{}
+	stopped    bool
+	cond       *sync.Cond
+	once       *sync.Once
+	onStop     func()
+}
+
+// newInformerWatcher creates new informer watcher
+func newInformerWatcher(namespace string, labelSelector labels.Selector, fieldSelector fields.Selector) *informerWatcher {
+	return &informerWatcher{
+		namespace:     namespace,
+		labelSelector: labelSelector,
+		fieldSelector: fieldSelector,
+		queue:         []eventwatch.Event{},
+		queueSize:     informerWatcherQueueSize,
+		result:        make(chan eventwatch.Event),
+		done:          make(chan struct{}),
+		cond:          sync.NewCond(&sync.Mutex{}),
+		once:          &sync.Once{},
+	}
+}
+
+// ResultChan returns the watcher changes channel
+func (iw *informerWatcher) ResultChan() <-chan eventwatch.Event {
+	return iw.result
+}
+
+// Stop stops the watcher and closes the result channel
+func (iw *informerWatcher) Stop() {
+	iw.once.Do(func() {
+		if iw.onStop != nil {
+			iw.onStop()
+		}
+		iw.cond.L.Lock()
+		iw.stopped = true
+		iw.cond.L.Unlock()
+		iw.cond.Broadcast()
+		close(iw.done)
+	})
+}
+
+// add queues a new change for the watcher. When the queue is full the change is dropped and the watcher
+// is stopped, so the consumer can re-establish the watch from the informers cache
+func (iw *informerWatcher) add(event eventwatch.Event) {
+	iw.cond.L.Lock()
+	defer iw.cond.L.Unlock()
+	if iw.stopped {
+		return
+	}
+	if len(iw.queue)-iw.replaying >= iw.queueSize {
+		iw.overflowed = true
+		iw.stopped = true
+		iw.cond.Signal()
+		// The changes are dispatched while holding the shared informer lock, that is required to stop the watcher
+		go iw.Stop()
+		return
+	}
+	iw.queue = append(iw.queue, event)
+	iw.cond.Signal()
+}
+
+// replay queues an object from the informer cache, the replayed objects are not limited by the queue size
+func (iw *informerWatcher) replay(event eventwatch.Event) {
+	iw.cond.L.Lock()
+	defer iw.cond.L.Unlock()
+	iw.queue = append(iw.queue, event)
+	iw.replaying++
+}
+
+// isOverflowed returns true when the watcher dropped changes because its queue was full
+func (iw *informerWatcher) isOverflowed() bool {
+	iw.cond.L.Lock()
+	defer iw.cond.L.Unlock()
+	return iw.overflowed
+}
+
+// run sends the queued changes to the result channel until the watcher is stopped
+func (iw *informerWatcher) run() {
+	defer close(iw.result)
+	for {
+		iw.cond.L.Lock()
+		for len(iw.queue) == 0 && !iw.stopped {
+			iw.cond.Wait()
+		}
+		if iw.stopped {
+			iw.cond.L.Unlock()
+			return
+		}
+		event := iw.queue[0]
+		iw.queue = iw.queue[1:]
+		if iw.replaying > 0 {
+			iw.replaying--
+		}
+		iw.cond.L.Unlock()
+
+		select {
+		case iw.result <- event:
+		case <-iw.done:
+			return
+		}
+	}
+}
+
+// matches checks if the object matches the watcher namespace, labels and fields selectors
+func (iw *informerWatcher) matches(obj interface{}) bool {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return false
+	}
+	if iw.namespace != "" && accessor.GetNamespace() != iw.namespace {
+		return false
+	}
+	if !iw.labelSelector.Matches(labels.Set(accessor.GetLabels())) {
+		return false
+	}
+	return iw.fieldSelector.Matches(getObjectFields(obj, accessor))
+}
+
+// getObjectFields returns the object fields that can be used in a field selector
+func getObjectFields(obj interface{}, accessor metaV1.Object) fields.Set {
+	objectFields := fields.Set{
+		"metadata.name":      accessor.GetName(),
+		"metadata.namespace": accessor.GetNamespace(),
+		"metadata.uid":       string(accessor.GetUID()),
+	}
+	if owner := metaV1.GetControllerOf(accessor); owner != nil {
+		objectFields["metadata.ownerReferences.uid"] = string(owner.UID)
+	}
+	if event, ok := obj.(*v1.Event); ok {
+		objectFields["involvedObject.kind"] = event.InvolvedObject.Kind
+		objectFields["involvedObject.name"] = event.InvolvedObject.Name
+		objectFields["involvedObject.namespace"] = event.InvolvedObject.Namespace
+		objectFields["involvedObject.uid"] = string(event.InvolvedObject.UID)
+		objectFields["reason"] = event.Reason
+		objectFields["type"] = event.Type
+	}
+	return objectFields
+}
+
+// isChangedSince checks if the object may have been changed since the given resource version. Resource versions
+// are opaque, so only an object that still has the given resource version is known to be unchanged.
+// Empty resource version means that all the objects were changed
+func isChangedSince(obj interface{}, resourceVersion string) bool {
+	if resourceVersion == "" {
+		return true
+	}
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return false
+	}
+	return accessor.GetResourceVersion() != resourceVersion
+}
@@ -0,0 +1,274 @@
+package kuberneteswatcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	eventwatch "k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func newInformerManagerMock(ctx context.Context, client *fake.Clientset) *InformerManager {
+	informerManager := NewInformerManager(client, nil, 0, nil)
+	// Interrupted watches are retried quickly, so the tests don't wait for the production backoff
+	informerManager.watchRetryInitialBackoff = time.Millisecond * 10
+	informerManager.watchRetryMaxBackoff = time.Millisecond * 100
+	var wg sync.WaitGroup
+	informerManager.Serve(ctx, &wg)
+	return informerManager
+}
+
+func createPodWithVersionMock(client *fake.Clientset, name, resourceVersion string) {
+	pod := &v1.Pod{
+		ObjectMeta: metaV1.ObjectMeta{
+			Name:            name,
+			Namespace:       "default",
+			ResourceVersion: resourceVersion,
+		},
+	}
+	client.CoreV1().Pods("default").Create(pod)
+}
+
+func TestInformerWatchResourceVersion(t *testing.T) {
+	ctx, cancelFn := context.WithCancel(context.Background())
+	defer cancelFn()
+	client := fake.NewSimpleClientset()
+	informerManager := newInformerManagerMock(ctx, client)
+
+	// The informer is synced with the list resource version
+	client.PrependReactor("list", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, &v1.PodList{
+			ListMeta: metaV1.ListMeta{ResourceVersion: "9"},
+			Items: []v1.Pod{
+				{ObjectMeta: metaV1.ObjectMeta{Name: "handled", Namespace: "default", ResourceVersion: "3"}},
+				{ObjectMeta: metaV1.ObjectMeta{Name: "changed", Namespace: "default", ResourceVersion: "8"}},
+			},
+		}, nil
+	})
+	informerManager.Start(podsResource)
+
+	testCases := []struct {
+		name            string
+		resourceVersion string
+		expected        []string
+	}{
+		{"synced_version", "9", []string{}},
+		{"handled_version", "3", []string{"changed"}},
+		{"unknown_version", "5", []string{"changed", "handled"}},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			watcher, err := informerManager.Watch(podsResource, "default", metaV1.ListOptions{ResourceVersion: test.resourceVersion})
+			if err != nil {
+				t.Fatalf("unexpected error when starting the watch, got %s", err)
+			}
+			defer watcher.Stop()
+
+			replayed := []string{}
+			for {
+				select {
+				case event := <-watcher.ResultChan():
+					replayed = append(replayed, event.Object.(*v1.Pod).GetName())
+					continue
+				case <-time.After(time.Millisecond * 200):
+				}
+				break
+			}
+			sort.Strings(replayed)
+			if !reflect.DeepEqual(replayed, test.expected) {
+				t.Fatalf("unexpected replayed pods, got %v expected %v", replayed, test.expected)
+			}
+		})
+	}
+}
+
+func TestResumableWatchReconnect(t *testing.T) {
+	ctx, cancelFn := context.WithCancel(context.Background())
+	defer cancelFn()
+	client := fake.NewSimpleClientset()
+	informerManager := newInformerManagerMock(ctx, client)
+	health := &WatchHealth{}
+
+	results := informerManager.ResumableWatch(ctx, *log.WithField("test", "TestResumableWatchReconnect"), health, podsResource, "default", metaV1.ListOptions{})
+	time.Sleep(time.Millisecond * 200)
+
+	// Interrupt all the running watchers of the pods informer
+	informerManager.lock.Lock()
+	shared := informerManager.informers[podsResource]
+	informerManager.lock.Unlock()
+	shared.lock.RLock()
+	watchers := shared.listWatchers()
+	shared.lock.RUnlock()
+	for _, watcher := range watchers {
+		watcher.Stop()
+	}
+
+	time.Sleep(informerManager.watchRetryInitialBackoff + time.Millisecond*200)
+	createPodWithVersionMock(client, "nginx", "10")
+
+	t.Run("watch_resumed", func(t *testing.T) {
+		select {
+		case event, ok := <-results:
+			if !ok {
+				t.Fatalf("unexpected closed watch channel")
+			}
+			pod := event.Object.(*v1.Pod)
+			if pod.GetName() != "nginx" {
+				t.Fatalf("unexpected pod, got %s expected %s", pod.GetName(), "nginx")
+			}
+		case <-time.After(time.Second * 2):
+			t.Fatalf("the watch was not resumed")
+		}
+	})
+
+	t.Run("watch_health", func(t *testing.T) {
+		if health.Restarts != 1 {
+			t.Fatalf("unexpected watch restarts, got %d expected %d", health.Restarts, 1)
+		}
+		if !health.IsIncomplete() {
+			t.Fatalf("expected the watch health to be incomplete")
+		}
+	})
+}
+
+func TestResumableWatchOverflow(t *testing.T) {
+	ctx, cancelFn := context.WithCancel(context.Background())
+	defer cancelFn()
+	client := fake.NewSimpleClientset()
+	informerManager := newInformerManagerMock(ctx, client)
+	informerManager.watcherQueueSize = 2
+	health := &WatchHealth{}
+
+	results := informerManager.ResumableWatch(ctx, *log.WithField("test", "TestResumableWatchOverflow"), health, podsResource, "default", metaV1.ListOptions{})
+	time.Sleep(time.Millisecond * 200)
+
+	// The changes are not received while they are created, so the watch queue overflows
+	expected := []string{"nginx-1", "nginx-2", "nginx-3", "nginx-4", "nginx-5"}
+	for i, name := range expected {
+		createPodWithVersionMock(client, name, fmt.Sprintf("%d", i+1))
+	}
+	time.Sleep(time.Millisecond * 200)
+
+	t.Run("dropped_changes_replayed", func(t *testing.T) {
+		received := map[string]bool{}
+		timeout := time.After(time.Second * 5)
+		for len(received) < len(expected) {
+			select {
+			case event, ok := <-results:
+				if !ok {
+					t.Fatalf("unexpected closed watch channel")
+				}
+				received[event.Object.(*v1.Pod).GetName()] = true
+			case <-timeout:
+				t.Fatalf("unexpected received pods, got %v expected %v", received, expected)
+			}
+		}
+	})
+
+	t.Run("watch_health", func(t *testing.T) {
+		if health.Overflows != 1 {
+			t.Fatalf("unexpected watch overflows, got %d expected %d", health.Overflows, 1)
+		}
+		if !health.IsIncomplete() {
+			t.Fatalf("expected the watch health to be incomplete")
+		}
+	})
+}
+
+func TestInformerCacheSyncTimeout(t *testing.T) {
+
+	t.Run("cluster_wide_forbidden", func(t *testing.T) {
+		client := fake.NewSimpleClientset()
+		client.PrependReactor("list", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			return true, nil, apierrors.NewForbidden(podsResource.GroupResource(), "", errors.New("namespace scoped role"))
+		})
+		ctx, cancelFn := context.WithCancel(context.Background())
+		defer cancelFn()
+		informerManager := newInformerManagerMock(ctx, client)
+
+		if _, err := informerManager.getInformer(podsResource); !apierrors.IsForbidden(err) {
+			t.Fatalf("unexpected error, got %v expected forbidden", err)
+		}
+	})
+
+	t.Run("cache_not_synced", func(t *testing.T) {
+		client := fake.NewSimpleClientset()
+		// Only the list of the permissions check succeeds, the informer list keeps failing
+		var lists int32
+		client.PrependReactor("list", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			if atomic.AddInt32(&lists, 1) == 1 {
+				return false, nil, nil
+			}
+			return true, nil, errors.New("connection refused")
+		})
+		ctx, cancelFn := context.WithCancel(context.Background())
+		defer cancelFn()
+		informerManager := newInformerManagerMock(ctx, client)
+		informerManager.cacheSyncTimeout = time.Millisecond * 500
+
+		started := time.Now()
+		if _, err := informerManager.getInformer(podsResource); err != errInformerNotSynced {
+			t.Fatalf("unexpected error, got %v expected %s", err, errInformerNotSynced)
+		}
+		if time.Since(started) > time.Second*5 {
+			t.Fatalf("the cache sync was not bounded by the timeout, took %s", time.Since(started))
+		}
+	})
+}
+
+func TestInformerDispatchIndex(t *testing.T) {
+	shared := &sharedInformer{
+		watchers: make(map[string]map[string]map[*informerWatcher]bool),
+		lock:     &sync.RWMutex{},
+	}
+	newWatcher := func(namespace, labelSelector string) *informerWatcher {
+		selector, _ := labels.Parse(labelSelector)
+		watcher := newInformerWatcher(namespace, selector, fields.Everything())
+		shared.addWatcher(watcher)
+		return watcher
+	}
+	watchers := map[string]*informerWatcher{
+		"all_namespaces":  newWatcher("", ""),
+		"same_selector_1": newWatcher("default", "app=nginx"),
+		"same_selector_2": newWatcher("default", "app=nginx"),
+		"other_selector":  newWatcher("default", "app=redis"),
+		"other_namespace": newWatcher("kube-system", "app=nginx"),
+	}
+
+	shared.dispatch(eventwatch.Added, &v1.Pod{ObjectMeta: metaV1.ObjectMeta{Name: "nginx", Namespace: "default", Labels: map[string]string{"app": "nginx"}}})
+
+	received := []string{}
+	for name, watcher := range watchers {
+		if len(watcher.queue) > 0 {
+			received = append(received, name)
+		}
+	}
+	sort.Strings(received)
+	expected := []string{"all_namespaces", "same_selector_1", "same_selector_2"}
+	if !reflect.DeepEqual(received, expected) {
+		t.Fatalf("unexpected watchers that received the change, got %v expected %v", received, expected)
+	}
+
+	for _, watcher := range watchers {
+		shared.removeWatcher(watcher)
+	}
+	if len(shared.watchers) != 0 {
+		t.Fatalf("unexpected watchers index after all the watchers were removed, got %v", shared.watchers)
+	}
+}
@@ -0,0 +1,172 @@
+package kuberneteswatcher_test
+
+import (
+	"context"
+	"errors"
+	kuberneteswatcher "statusbay/watcher/kubernetes"
+	"sync"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+var (
+	informerManagersMock     = map[*fake.Clientset]*kuberneteswatcher.InformerManager{}
+	informerManagersMockLock = &sync.Mutex{}
+)
+
+// NewInformerManagerMock returns a started informer manager of the fake client. All the managers
+// that were created with the same fake client share the same informers
+func NewInformerManagerMock(client *fake.Clientset) *kuberneteswatcher.InformerManager {
+	informerManagersMockLock.Lock()
+	defer informerManagersMockLock.Unlock()
+
+	if informerManager, found := informerManagersMock[client]; found {
+		return informerManager
+	}
+
+	informerManager := kuberneteswatcher.NewInformerManager(client, nil, 0, nil)
+	var wg sync.WaitGroup
+	informerManager.Serve(context.Background(), &wg)
+	informerManagersMock[client] = informerManager
+	return informerManager
+}
+
+func createInformerPodMock(client *fake.Clientset, name, namespace string, labels map[string]string) {
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    labels,
+		},
+	}
+	client.CoreV1().Pods(namespace).Create(pod)
+}
+
+func readWatchEvents(watcher watch.Interface, wait time.Duration) []watch.Event {
+	events := []watch.Event{}
+	for {
+		select {
+		case event, ok := <-watcher.ResultChan():
+			if !ok {
+				return events
+			}
+			events = append(events, event)
+		case <-time.After(wait):
+			return events
+		}
+	}
+}
+
+func TestInformerWatchSelectors(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	informerManager := NewInformerManagerMock(client)
+
+	createInformerPodMock(client, "nginx-1", "default", map[string]string{"app": "nginx"})
+
+	watcher, err := informerManager.Watch(v1.SchemeGroupVersion.WithResource("pods"), "default", metav1.ListOptions{LabelSelector: "app=nginx"})
+	if err != nil {
+		t.Fatalf("unexpected error when starting the watch, got %s", err)
+	}
+	defer watcher.Stop()
+	time.Sleep(time.Millisecond * 100)
+
+	createInformerPodMock(client, "nginx-2", "default", map[string]string{"app": "nginx"})
+	createInformerPodMock(client, "redis-1", "default", map[string]string{"app": "redis"})
+	createInformerPodMock(client, "nginx-3", "other", map[string]string{"app": "nginx"})
+
+	events := readWatchEvents(watcher, time.Second)
+
+	t.Run("events_count", func(t *testing.T) {
+		if len(events) != 2 {
+			t.Fatalf("unexpected count of watch events, got %d expected %d", len(events), 2)
+		}
+	})
+
+	t.Run("cache_replay", func(t *testing.T) {
+		pod := events[0].Object.(*v1.Pod)
+		if events[0].Type != watch.Added || pod.Name != "nginx-1" {
+			t.Fatalf("unexpected first watch event, got %s %s expected %s %s", events[0].Type, pod.Name, watch.Added, "nginx-1")
+		}
+	})
+
+	t.Run("field_selector", func(t *testing.T) {
+		fieldWatcher, err := informerManager.Watch(v1.SchemeGroupVersion.WithResource("pods"), "", metav1.ListOptions{FieldSelector: "metadata.name=nginx-3"})
+		if err != nil {
+			t.Fatalf("unexpected error when starting the watch, got %s", err)
+		}
+		defer fieldWatcher.Stop()
+		fieldEvents := readWatchEvents(fieldWatcher, time.Millisecond*500)
+		if len(fieldEvents) != 1 {
+			t.Fatalf("unexpected count of watch events, got %d expected %d", len(fieldEvents), 1)
+		}
+	})
+}
+
+func TestInformerWatchTimeout(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	informerManager := NewInformerManagerMock(client)
+
+	timeout := int64(1)
+	watcher, err := informerManager.Watch(v1.SchemeGroupVersion.WithResource("pods"), "default", metav1.ListOptions{TimeoutSeconds: &timeout})
+	if err != nil {
+		t.Fatalf("unexpected error when starting the watch, got %s", err)
+	}
+
+	select {
+	case _, ok := <-watcher.ResultChan():
+		if ok {
+			t.Fatalf("unexpected watch event, expected the watch to be closed")
+		}
+	case <-time.After(time.Second * 3):
+		t.Fatalf("the watch was not closed after the timeout")
+	}
+}
+
+func TestNamespacedInformerManager(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("list", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetNamespace() == "forbidden" {
+			return true, nil, apierrors.NewForbidden(schema.GroupResource{Resource: "pods"}, "", errors.New("namespace scoped role"))
+		}
+		return false, nil, nil
+	})
+
+	createInformerPodMock(client, "watched", "team-a", map[string]string{})
+	createInformerPodMock(client, "other-namespace", "team-b", map[string]string{})
+
+	informerManager := kuberneteswatcher.NewInformerManager(client, nil, 0, []string{"team-a", "forbidden"})
+	var wg sync.WaitGroup
+	informerManager.Serve(context.Background(), &wg)
+
+	watcher, err := informerManager.Watch(v1.SchemeGroupVersion.WithResource("pods"), "", metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error when starting the watch, got %s", err)
+	}
+	defer watcher.Stop()
+
+	t.Run("watched_namespaces", func(t *testing.T) {
+		events := readWatchEvents(watcher, time.Millisecond*200)
+		if len(events) != 1 {
+			t.Fatalf("unexpected events count, got %d expected %d", len(events), 1)
+		}
+		if name := events[0].Object.(*v1.Pod).GetName(); name != "watched" {
+			t.Fatalf("unexpected pod, got %s expected %s", name, "watched")
+		}
+	})
+
+	t.Run("unwatched_namespaces", func(t *testing.T) {
+		unwatched := informerManager.UnwatchedNamespaces()["/v1, Resource=pods"]
+		if _, found := unwatched["forbidden"]; !found || len(unwatched) != 1 {
+			t.Fatalf("unexpected unwatched namespaces, got %v", unwatched)
+		}
+	})
+}
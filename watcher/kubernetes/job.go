@@ -0,0 +1,346 @@
+package kuberneteswatcher
+
+import (
+	"context"
+	"fmt"
+	"statusbay/watcher/kubernetes/common"
+	"sync"
+	"time"
+
+	"github.com/mitchellh/hashstructure"
+	log "github.com/sirupsen/logrus"
+	batchV1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	eventwatch "k8s.io/apimachinery/pkg/watch"
+)
+
+const (
+	// defaultJobCompletions is the Kubernetes default of job spec.completions
+	defaultJobCompletions = int32(1)
+
+	// defaultJobBackoffLimit is the Kubernetes default of job spec.backoffLimit
+	defaultJobBackoffLimit = int32(6)
+
+	// jobDeadlineExceededReason is the failed condition reason of a job that was active longer than its active deadline
+	jobDeadlineExceededReason = "DeadlineExceeded"
+)
+
+// JobManager defined job manager struct
+type JobManager struct {
+	// Informer manager will be owner to watch the Kubernetes resources
+	informerManager *InformerManager
+
+	// Event manager will be owner to start watch on job events
+	eventManager *EventsManager
+
+	// Registry manager will be owner to manage the running / new job applies
+	registryManager *RegistryManager
+
+	// Pods manager will watch the pods that created by the job
+	podsManager *PodsManager
+
+	// Max watch time
+	maxDeploymentTime int64
+
+	// Initial Running Applies to load on start
+	initialRunningApplies []*RegistryRow
+}
+
+// NewJobManager creates a new instance to manage job related resources
+func NewJobManager(informerManager *InformerManager, eventManager *EventsManager, registryManager *RegistryManager, podsManager *PodsManager, runningApplies []*RegistryRow, maxDeploymentTime time.Duration) *JobManager {
+	return &JobManager{
+		informerManager:       informerManager,
+		eventManager:          eventManager,
+		registryManager:       registryManager,
+		podsManager:           podsManager,
+		maxDeploymentTime:     int64(maxDeploymentTime.Seconds()),
+		initialRunningApplies: runningApplies,
+	}
+}
+
+// Serve will start listening on job requests
+func (jm *JobManager) Serve(ctx context.Context, wg *sync.WaitGroup) {
+	wg.Add(1)
+
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				log.Warn("job manager has been shut down")
+				wg.Done()
+				return
+			}
+		}
+	}()
+
+	// Continue watching on running jobs from storage state
+	runningJobApps := jm.initialRunningApplies
+	log.WithField("running_apps", len(runningJobApps)).Debug("loaded running applications in job manager")
+	for _, application := range runningJobApps {
+		app := application
+		for resourceName, jobData := range application.DBSchema.Resources.Jobs {
+			jData := jobData
+			jobWatchListOptions := metaV1.ListOptions{
+				FieldSelector: fields.OneTermEqualSelector("metadata.name", resourceName).String(),
+			}
+			app.Log().Logger.WithField("name", jData.GetName()).Debug("begining watching loaded running job")
+			go func(app *RegistryRow, jData *JobData, listOptions metaV1.ListOptions) {
+				jm.watchJob(app.ctx, app.cancelFn, app.Log(), app.DBSchema.WatchHealth, jData, listOptions, jData.Metadata.Namespace, jData.ProgressDeadlineSeconds)
+			}(app, jData, jobWatchListOptions)
+		}
+	}
+	// we dont need that anymore
+	jm.initialRunningApplies = nil
+	jm.watchJobs(ctx)
+
+}
+
+// watchJobs start watch on all Kubernetes jobs
+func (jm *JobManager) watchJobs(ctx context.Context) {
+	// Resume from the last handled resource version, so changes that were applied while the watcher was down are not missed
+	resourceVersion := jm.registryManager.LoadResourceVersion(jobsResource.GroupResource().String())
+	if resourceVersion == "" {
+		resourceVersion, _ = jm.informerManager.ResourceVersion(jobsResource)
+	}
+	jobWatchListOptions := metaV1.ListOptions{ResourceVersion: resourceVersion}
+	watcher := jm.informerManager.ResumableWatch(ctx, *log.WithField("resource", jobsResource.String()), nil, jobsResource, "", jobWatchListOptions)
+	go func() {
+		log.WithField("resource_version", resourceVersion).Info("jobs watcher started")
+		for {
+			select {
+			case event, watch := <-watcher:
+				if !watch {
+					log.WithField("list_options", jobWatchListOptions.String()).Info("jobs watcher was stopped, channel was closed")
+					return
+				}
+				job, ok := event.Object.(*batchV1.Job)
+				if !ok {
+					log.WithField("object", event.Object).Warn("failed to parse job watcher data")
+					continue
+				}
+				jm.registryManager.UpdateResourceVersion(jobsResource.GroupResource().String(), job.GetResourceVersion())
+
+				log.WithFields(log.Fields{
+					"name":      job.GetName(),
+					"namespace": job.GetNamespace(),
+				}).Debug("job event detected")
+				jobName := GetApplicationName(job.GetAnnotations(), getJobOwnerName(job))
+
+				if !common.IsSupportedEventType(event.Type) {
+					log.WithFields(log.Fields{
+						"event_type": event.Type,
+						"job":        jobName,
+					}).Info("event type not supported")
+					continue
+				}
+
+				// Finished jobs are deleted by the ttl controller or by the cronjob history limit,
+				// those deletions are not applies, so we only clear the job version history
+				if event.Type == eventwatch.Deleted && isJobCompleted(job) {
+					jm.registryManager.deleteAppliedVersion(job.GetName(), job.GetNamespace(), "job")
+					continue
+				}
+
+				hash, _ := hashstructure.Hash(job.Spec, nil)
+				apply := ApplyEvent{
+					Event:           fmt.Sprintf("%v", event.Type),
+					ApplyName:       jobName,
+					ResourceName:    job.GetName(),
+					Namespace:       job.GetNamespace(),
+					Kind:            "job",
+					Hash:            hash,
+					Annotations:     job.GetAnnotations(),
+					Labels:          job.GetLabels(),
+					UID:             job.GetUID(),
+					ResourceVersion: job.GetResourceVersion(),
+				}
+
+				appRegistry := jm.registryManager.NewApplyEvent(apply)
+				if appRegistry == nil {
+					continue
+				}
+
+				jobLog := appRegistry.Log()
+				jobLog.WithField("event", event.Type).Info("adding job to apply registry")
+
+				registryApply := jm.AddNewJob(apply, appRegistry, getJobCompletions(job), getJobBackoffLimit(job), isWorkQueueJob(job))
+
+				jobWatchListOptions := metaV1.ListOptions{
+					FieldSelector: fields.OneTermEqualSelector("metadata.name", job.GetName()).String(),
+				}
+
+				go jm.watchJob(
+					appRegistry.ctx,
+					appRegistry.cancelFn,
+					jobLog,
+					appRegistry.DBSchema.WatchHealth,
+					registryApply,
+					jobWatchListOptions,
+					job.GetNamespace(),
+					GetProgressDeadlineApply(job.GetAnnotations(), jm.maxDeploymentTime))
+
+			case <-ctx.Done():
+				log.Warn("job watcher was stopped, got ctx done signal")
+				return
+			}
+		}
+	}()
+}
+
+// watchJob will watch a specific job and its related resources (events + pods)
+func (jm *JobManager) watchJob(ctx context.Context, cancelFn context.CancelFunc, lg log.Entry, health *WatchHealth, registryJob *JobData, listOptions metaV1.ListOptions, namespace string, maxWatchTime int64) {
+
+	jobLog := lg.WithField("job_name", registryJob.GetName())
+	jobLog.Info("start watching job")
+	jobLog.WithField("list_option", listOptions.String()).Debug("list option for job filtering")
+
+	watcher := jm.informerManager.ResumableWatch(ctx, *jobLog, health, jobsResource, namespace, listOptions)
+	firstInit := true
+	for {
+		select {
+		case event, watch := <-watcher:
+			if !watch {
+				jobLog.Warn("job watcher was stopped, channel was closed")
+				cancelFn()
+				return
+			}
+			job, isOk := event.Object.(*batchV1.Job)
+			if !isOk {
+				jobLog.WithField("object", event.Object).Warn("failed to parse job watcher data")
+				continue
+			}
+			if firstInit {
+				firstInit = false
+				eventListOptions := metaV1.ListOptions{FieldSelector: labels.SelectorFromSet(map[string]string{
+					"involvedObject.name": job.GetName(),
+					"involvedObject.kind": "Job",
+				}).String(),
+					TimeoutSeconds: &maxWatchTime,
+				}
+
+				// Start watching on Events of job
+				jm.watchEvents(ctx, *jobLog, health, registryJob, eventListOptions, registryJob.Metadata.objectReference("Job"), namespace)
+
+				// Start watching on the pods that were created by the job
+				if job.Spec.Selector != nil {
+					jm.podsManager.Watch <- WatchData{
+						ListOptions:  metaV1.ListOptions{LabelSelector: labels.SelectorFromSet(job.Spec.Selector.MatchLabels).String()},
+						RegistryData: registryJob,
+						Namespace:    namespace,
+						Ctx:          ctx,
+						LogEntry:     *jobLog,
+						WatchHealth:  health,
+					}
+				} else {
+					jobLog.Warn("job selector not found, can't start watch on pods")
+				}
+			}
+			registryJob.UpdateApplyStatus(job.Status)
+		case <-ctx.Done():
+			jobLog.Debug("job watcher was stopped, got ctx done signal")
+			return
+		}
+	}
+}
+
+// watchEvents will watch for events related to the Job resource
+func (jm *JobManager) watchEvents(ctx context.Context, lg log.Entry, health *WatchHealth, registryJob *JobData, listOptions metaV1.ListOptions, involvedObject v1.ObjectReference, namespace string) {
+
+	lg.Info("started the event watcher on job events")
+	watchData := WatchEvents{
+		ListOptions:       listOptions,
+		Namespace:         namespace,
+		Ctx:               ctx,
+		LogEntry:          lg,
+		InvolvedObject:    involvedObject,
+		ApplyCreationTime: health.applyCreation(),
+	}
+
+	eventChan := jm.eventManager.Watch(watchData)
+	go func() {
+		for {
+			select {
+			case event := <-eventChan:
+				registryJob.UpdateJobEvents(event)
+			case <-ctx.Done():
+				lg.Info("stopped the event watcher on job events")
+				return
+			}
+		}
+	}()
+}
+
+// AddNewJob add a new job under application settings
+func (jm *JobManager) AddNewJob(data ApplyEvent, applicationRegistry *RegistryRow, completions int32, backoffLimit int32, workQueue bool) *JobData {
+
+	log := applicationRegistry.Log()
+	jd := &JobData{
+		Metadata: MetaData{
+			Name:            data.ResourceName,
+			Namespace:       data.Namespace,
+			Annotations:     data.Annotations,
+			Labels:          data.Labels,
+			UID:             data.UID,
+			ResourceVersion: data.ResourceVersion,
+			Metrics:         GetMetricsDataFromAnnotations(data.Annotations),
+			Alerts:          GetAlertsDataFromAnnotations(data.Annotations),
+			DesiredState:    completions,
+		},
+		BackoffLimit:            backoffLimit,
+		WorkQueue:               workQueue,
+		Pods:                    make(map[string]DeploymenPod, 0),
+		Services:                make(map[string]ServicesData, 0),
+		ProgressDeadlineSeconds: GetProgressDeadlineApply(data.Annotations, jm.maxDeploymentTime),
+	}
+	applicationRegistry.DBSchema.Resources.Jobs[data.ResourceName] = jd
+
+	log.Info("job was associated to the application")
+
+	return jd
+
+}
+
+// getJobOwnerName returns the CronJob name when the job was spawned by a CronJob, so all the
+// scheduled runs are grouped under the same application. otherwise the job name is returned
+func getJobOwnerName(job *batchV1.Job) string {
+	for _, owner := range job.GetOwnerReferences() {
+		if owner.Kind == "CronJob" {
+			return owner.Name
+		}
+	}
+	return job.GetName()
+}
+
+// getJobCompletions returns the number of successful pods that the job needs
+func getJobCompletions(job *batchV1.Job) int32 {
+	if job.Spec.Completions == nil {
+		return defaultJobCompletions
+	}
+	return *job.Spec.Completions
+}
+
+// isWorkQueueJob returns true when the job pods work on a queue, a job without completions and with parallelism
+func isWorkQueueJob(job *batchV1.Job) bool {
+	return job.Spec.Completions == nil && job.Spec.Parallelism != nil && *job.Spec.Parallelism > 1
+}
+
+// getJobBackoffLimit returns the number of retries before the job is considered as failed
+func getJobBackoffLimit(job *batchV1.Job) int32 {
+	if job.Spec.BackoffLimit == nil {
+		return defaultJobBackoffLimit
+	}
+	return *job.Spec.BackoffLimit
+}
+
+// isJobCompleted returns true when the job has a complete or failed condition
+func isJobCompleted(job *batchV1.Job) bool {
+	for _, condition := range job.Status.Conditions {
+		if (condition.Type == batchV1.JobComplete || condition.Type == batchV1.JobFailed) && condition.Status == v1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
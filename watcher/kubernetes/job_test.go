@@ -0,0 +1,179 @@
+package kuberneteswatcher_test
+
+import (
+	"context"
+	kuberneteswatcher "statusbay/watcher/kubernetes"
+	"statusbay/watcher/kubernetes/common"
+	"statusbay/watcher/kubernetes/testutil"
+	"sync"
+	"testing"
+	"time"
+
+	batchV1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func createJobMock(client *fake.Clientset, name string, namespace string, owner string) *batchV1.Job {
+	completions := int32(2)
+	backoffLimit := int32(1)
+	job := &batchV1.Job{
+		Spec: batchV1.JobSpec{
+			Completions:  &completions,
+			BackoffLimit: &backoffLimit,
+			Selector: &metaV1.LabelSelector{
+				MatchLabels: map[string]string{
+					"controller-uid": "1234",
+				},
+			},
+		},
+		ObjectMeta: metaV1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Annotations: map[string]string{
+				"statusbay.io/report-deploy-by":      "foo@example.com",
+				"statusbay.io/report-slack-channels": "#channel",
+			},
+		},
+	}
+	if owner != "" {
+		job.OwnerReferences = []metaV1.OwnerReference{{Kind: "CronJob", Name: owner}}
+	}
+	job, _ = client.BatchV1().Jobs(namespace).Create(job)
+	return job
+}
+
+func createMockJobData(registryManager *kuberneteswatcher.RegistryManager, registryRow *kuberneteswatcher.RegistryRow, applyEvent kuberneteswatcher.ApplyEvent, progressDeadlineSeconds string, completions int32, backoffLimit int32, workQueue bool) *kuberneteswatcher.JobData {
+
+	maxDeploymentTime, _ := time.ParseDuration(progressDeadlineSeconds)
+	client := fake.NewSimpleClientset()
+	eventManager := NewEventsMock(client)
+	pvcManager := NewPvcManagerMock(client)
+	podManager := kuberneteswatcher.NewPodsManager(NewInformerManagerMock(client), eventManager, pvcManager, client, common.PodLogsConfig{})
+	jobManager := kuberneteswatcher.NewJobManager(NewInformerManagerMock(client), eventManager, registryManager, podManager, registryManager.LoadRunningApplies(), maxDeploymentTime)
+
+	return jobManager.AddNewJob(applyEvent, registryRow, completions, backoffLimit, workQueue)
+
+}
+
+func NewJobManagerMock(client *fake.Clientset) (*kuberneteswatcher.JobManager, *testutil.MockStorage) {
+	maxDeploymentTime, _ := time.ParseDuration("10m")
+	eventManager := NewEventsMock(client)
+	registryManager, storage := NewRegistryMock()
+	pvcManager := NewPvcManagerMock(client)
+	podManager := kuberneteswatcher.NewPodsManager(NewInformerManagerMock(client), eventManager, pvcManager, client, common.PodLogsConfig{})
+	runningApplies := registryManager.LoadRunningApplies()
+	jobManager := kuberneteswatcher.NewJobManager(NewInformerManagerMock(client), eventManager, registryManager, podManager, runningApplies, maxDeploymentTime)
+
+	var wg sync.WaitGroup
+	ctx := context.Background()
+
+	eventManager.Serve(ctx, &wg)
+	podManager.Serve(ctx, &wg)
+	jobManager.Serve(ctx, &wg)
+
+	return jobManager, storage
+}
+
+func TestJobWatch(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	_, storage := NewJobManagerMock(client)
+	namespace := "batch"
+
+	job := createJobMock(client, "migration-1585000000", namespace, "migration")
+	time.Sleep(time.Second)
+
+	job.Status.Active = 1
+	client.BatchV1().Jobs(namespace).Update(job)
+	time.Sleep(time.Second)
+
+	pod := &v1.Pod{
+		ObjectMeta: metaV1.ObjectMeta{
+			Name:   "migration-1585000000-abcde",
+			Labels: map[string]string{"controller-uid": "1234"},
+		},
+		Status: v1.PodStatus{Phase: v1.PodRunning},
+	}
+	client.CoreV1().Pods(namespace).Create(pod)
+	time.Sleep(time.Second * 2)
+
+	application := storage.MockWriteDeployment["1"]
+
+	t.Run("cronjob_application_name", func(t *testing.T) {
+		if application.Schema.Application != "migration" {
+			t.Fatalf("unexpected application name, got %s expected %s", application.Schema.Application, "migration")
+		}
+	})
+
+	t.Run("job_schema_data", func(t *testing.T) {
+		job, found := application.Schema.Resources.Jobs["migration-1585000000"]
+		if !found {
+			t.Fatalf("job not found in application resources")
+		}
+		if job.Metadata.DesiredState != 2 {
+			t.Fatalf("unexpected job completions, got %d expected %d", job.Metadata.DesiredState, 2)
+		}
+		if job.BackoffLimit != 1 {
+			t.Fatalf("unexpected job backoff limit, got %d expected %d", job.BackoffLimit, 1)
+		}
+		if len(job.Pods) != 1 {
+			t.Fatalf("unexpected job pods count, got %d expected %d", len(job.Pods), 1)
+		}
+	})
+
+}
+
+func TestJobFinish(t *testing.T) {
+
+	testCases := []struct {
+		name                string
+		workQueue           bool
+		status              batchV1.JobStatus
+		expectedStatus      common.DeploymentStatus
+		expectedDescription common.DeploymentStatusDescription
+	}{
+		{"successful", false, batchV1.JobStatus{Succeeded: 2}, common.ApplySuccessful, common.ApplyStatusDescriptionSuccessful},
+		{"complete_condition", false, batchV1.JobStatus{Succeeded: 1, Conditions: []batchV1.JobCondition{{Type: batchV1.JobComplete, Status: v1.ConditionTrue}}}, common.ApplySuccessful, common.ApplyStatusDescriptionSuccessful},
+		{"failed_pods_within_backoff_limit", false, batchV1.JobStatus{Succeeded: 1, Failed: 2, Active: 1}, common.ApplyStatusRunning, common.ApplyStatusDescriptionRunning},
+		{"backoff_limit", false, batchV1.JobStatus{Succeeded: 1, Failed: 2, Conditions: []batchV1.JobCondition{{Type: batchV1.JobFailed, Status: v1.ConditionTrue, Reason: "BackoffLimitExceeded"}}}, common.ApplyStatusFailed, common.ApplyStatusDescriptionBackoffLimit},
+		{"active_deadline", false, batchV1.JobStatus{Conditions: []batchV1.JobCondition{{Type: batchV1.JobFailed, Status: v1.ConditionTrue, Reason: "DeadlineExceeded"}}}, common.ApplyStatusFailed, common.ApplyStatusDescriptionJobDeadline},
+		{"work_queue_active", true, batchV1.JobStatus{Succeeded: 1, Active: 2}, common.ApplyStatusRunning, common.ApplyStatusDescriptionRunning},
+		{"work_queue_done", true, batchV1.JobStatus{Succeeded: 1}, common.ApplySuccessful, common.ApplyStatusDescriptionSuccessful},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			registry, storage := NewRegistryMock()
+			registryRow := registry.NewApplication("migration", "default", map[string]string{}, common.ApplyStatusRunning)
+
+			apply := kuberneteswatcher.ApplyEvent{
+				Event:        "create",
+				ApplyName:    "migration",
+				ResourceName: "migration-1585000000",
+				Namespace:    "default",
+				Kind:         "job",
+				Hash:         1234,
+				Annotations:  map[string]string{},
+				Labels:       map[string]string{},
+			}
+			data := createMockJobData(registry, registryRow, apply, "10m", 2, 1, tc.workQueue)
+			// Jobs that were created by a cron job are grouped by the cron job name and keep their own name
+			if data.GetName() != apply.ResourceName {
+				t.Fatalf("unexpected job name, got %s expected %s", data.GetName(), apply.ResourceName)
+			}
+			data.UpdateApplyStatus(tc.status)
+
+			time.Sleep(time.Second * 3)
+			if storage.MockWriteDeployment["1"].Status != tc.expectedStatus {
+				t.Fatalf("unexpected job apply status, got %s expected %s", storage.MockWriteDeployment["1"].Status, tc.expectedStatus)
+			}
+			if storage.MockWriteDeployment["1"].Schema.DeploymentDescription != tc.expectedDescription {
+				t.Fatalf("unexpected job apply description, got %s expected %s", storage.MockWriteDeployment["1"].Schema.DeploymentDescription, tc.expectedDescription)
+			}
+			if analyzed := storage.MockWriteDeployment["1"].Schema.FailureCauses != nil; analyzed != (tc.expectedStatus == common.ApplyStatusFailed) {
+				t.Fatalf("unexpected failure analysis, got %t expected %t", analyzed, tc.expectedStatus == common.ApplyStatusFailed)
+			}
+		})
+	}
+}
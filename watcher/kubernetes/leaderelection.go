@@ -0,0 +1,185 @@
+package kuberneteswatcher
+
+import (
+	"context"
+	"statusbay/serverutil"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+// LeaderElectionConfig describes the lease that is used to elect the active watcher replica
+type LeaderElectionConfig struct {
+	// Identity of the replica, usually the pod name
+	Identity string
+
+	// Namespace and Name of the lease object
+	Namespace string
+	Name      string
+
+	// LeaseDuration is the time that the standby replicas wait before taking over a lease that was not renewed
+	LeaseDuration time.Duration
+
+	// RenewDeadline is the time that the leader retries to renew the lease before it gives up the leadership
+	RenewDeadline time.Duration
+
+	// RetryPeriod is the time between the lease acquire/renew retries
+	RetryPeriod time.Duration
+}
+
+// LeaderElectionManager runs the watcher servers only while the replica holds the leader lease.
+// The other replicas are kept in standby until the lease is released or expired
+type LeaderElectionManager struct {
+	informerManagers []*InformerManager
+	client           kubernetes.Interface
+	config           LeaderElectionConfig
+	servers          func() []serverutil.Server
+
+	leader int32
+
+	// lock guards the running leader servers, they are stopped as soon as the leadership is lost
+	lock          sync.Mutex
+	runner        *serverutil.Runner
+	cancelServers context.CancelFunc
+
+	// stopWatcher is called after the leader servers were stopped when the leadership is lost
+	stopWatcher context.CancelFunc
+}
+
+// NewLeaderElectionManager creates new leader election manager. The servers function is called each
+// time that the replica becomes the leader, and should create the servers that run only on the leader.
+// The informers of all the watched clusters are warmed up while in standby, and the lease is held with the given client.
+// When the leadership is lost, stopWatcher is called to shut down the watcher gracefully
+func NewLeaderElectionManager(informerManagers []*InformerManager, kubernetesClientset kubernetes.Interface, config LeaderElectionConfig, servers func() []serverutil.Server, stopWatcher context.CancelFunc) *LeaderElectionManager {
+	return &LeaderElectionManager{
+		informerManagers: informerManagers,
+		client:           kubernetesClientset,
+		config:           config,
+		servers:          servers,
+		stopWatcher:      stopWatcher,
+	}
+}
+
+// IsLeader returns true when the replica holds the leader lease
+func (lm *LeaderElectionManager) IsLeader() bool {
+	return atomic.LoadInt32(&lm.leader) == 1
+}
+
+// Serve will start the leader election. When the leadership is lost, the watcher is stopped so the
+// process exits and the replica is restarted as a standby replica with a clean state
+func (lm *LeaderElectionManager) Serve(ctx context.Context, wg *sync.WaitGroup) {
+	wg.Add(1)
+
+	lg := log.WithFields(log.Fields{
+		"identity":  lm.config.Identity,
+		"lease":     lm.config.Name,
+		"namespace": lm.config.Namespace,
+	})
+
+	elector, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+		Lock: &resourcelock.LeaseLock{
+			LeaseMeta: metaV1.ObjectMeta{
+				Name:      lm.config.Name,
+				Namespace: lm.config.Namespace,
+			},
+			Client: lm.client.CoordinationV1(),
+			LockConfig: resourcelock.ResourceLockConfig{
+				Identity: lm.config.Identity,
+			},
+		},
+		LeaseDuration:   lm.config.LeaseDuration,
+		RenewDeadline:   lm.config.RenewDeadline,
+		RetryPeriod:     lm.config.RetryPeriod,
+		ReleaseOnCancel: true,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(leaderCtx context.Context) {
+				lm.startServers(lg, leaderCtx)
+			},
+			OnStoppedLeading: func() {
+				atomic.StoreInt32(&lm.leader, 0)
+				lm.stopServers(lg)
+			},
+			OnNewLeader: func(identity string) {
+				if identity != lm.config.Identity {
+					lg.WithField("leader", identity).Info("watcher is in standby, the leader lease is held by another replica")
+				}
+			},
+		},
+	})
+	if err != nil {
+		lg.WithError(err).Panic("invalid leader election configuration")
+	}
+
+	// Keep the informer caches synced while in standby, so the watcher starts quickly after taking over the lease.
+	// Each cluster is warmed up on its own, so a cluster that can't be reached doesn't delay the other clusters
+	for _, informerManager := range lm.informerManagers {
+		go func(informerManager *InformerManager) {
+			if err := informerManager.WarmUp(); err != nil {
+				lg.WithError(err).Warn("failed to warm up the informers")
+			}
+		}(informerManager)
+	}
+
+	go func() {
+		defer wg.Done()
+
+		// The leader servers were already stopped when Run returns
+		elector.Run(ctx)
+
+		if ctx.Err() != nil {
+			lg.Warn("leader election manager has been shut down")
+			return
+		}
+		lg.Error("leader lease was lost, stopping the watcher to restart as a standby replica")
+		lm.stopWatcher()
+	}()
+
+}
+
+// startServers starts the leader servers with a context that is canceled as soon as the leadership is lost
+func (lm *LeaderElectionManager) startServers(lg *log.Entry, leaderCtx context.Context) {
+	lm.lock.Lock()
+	defer lm.lock.Unlock()
+
+	// The leadership may be lost before the servers were started
+	if leaderCtx.Err() != nil {
+		return
+	}
+	lg.Info("leader lease acquired, starting the watcher")
+	atomic.StoreInt32(&lm.leader, 1)
+
+	ctx, cancelFn := context.WithCancel(leaderCtx)
+	lm.cancelServers = cancelFn
+	lm.runner = serverutil.RunAll(ctx, lm.servers())
+}
+
+// stopServers stops the leader servers and waits until they were stopped, so the servers don't report or save
+// applies after another replica took the lease
+func (lm *LeaderElectionManager) stopServers(lg *log.Entry) {
+	lm.lock.Lock()
+	defer lm.lock.Unlock()
+
+	if lm.cancelServers == nil {
+		return
+	}
+	lg.Warn("leader lease was lost, stopping the watcher")
+	lm.cancelServers()
+	lm.cancelServers = nil
+
+	stopped := make(chan struct{})
+	go func() {
+		lm.runner.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(lm.config.RenewDeadline):
+		lg.Warn("watcher servers were not stopped in time")
+	}
+}
@@ -0,0 +1,84 @@
+package kuberneteswatcher
+
+import (
+	"context"
+	"statusbay/serverutil"
+	"sync"
+	"testing"
+	"time"
+
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+type stoppableServerMock struct {
+	stopped chan struct{}
+}
+
+func (ssm *stoppableServerMock) Serve(ctx context.Context, wg *sync.WaitGroup) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		<-ctx.Done()
+		close(ssm.stopped)
+	}()
+}
+
+func TestLeaderElectionLostLease(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	ctx, cancelFn := context.WithCancel(context.Background())
+	defer cancelFn()
+	var wg sync.WaitGroup
+
+	config := LeaderElectionConfig{
+		Identity:      "replica-1",
+		Namespace:     "statusbay",
+		Name:          "statusbay-watcher",
+		LeaseDuration: time.Second * 2,
+		RenewDeadline: time.Second,
+		RetryPeriod:   time.Millisecond * 100,
+	}
+	server := &stoppableServerMock{stopped: make(chan struct{})}
+	watcherStopped := make(chan bool, 1)
+	lm := NewLeaderElectionManager([]*InformerManager{NewInformerManager(client, nil, 0, nil)}, client, config, func() []serverutil.Server {
+		return []serverutil.Server{server}
+	}, func() {
+		select {
+		case <-server.stopped:
+			watcherStopped <- true
+		default:
+			watcherStopped <- false
+		}
+	})
+
+	lm.Serve(ctx, &wg)
+	time.Sleep(time.Second)
+	if !lm.IsLeader() {
+		t.Fatalf("unexpected leader state, got %t expected %t", lm.IsLeader(), true)
+	}
+
+	// Another replica takes over the lease
+	lease, err := client.CoordinationV1().Leases(config.Namespace).Get(config.Name, metaV1.GetOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error getting the lease, %s", err)
+	}
+	holder := "replica-2"
+	renewTime := metaV1.NewMicroTime(time.Now().Add(time.Minute))
+	lease.Spec.HolderIdentity = &holder
+	lease.Spec.RenewTime = &renewTime
+	if _, err := client.CoordinationV1().Leases(config.Namespace).Update(lease); err != nil {
+		t.Fatalf("unexpected error updating the lease, %s", err)
+	}
+
+	select {
+	case serversStopped := <-watcherStopped:
+		if !serversStopped {
+			t.Fatalf("unexpected watcher stop before the leader servers were stopped")
+		}
+	case <-time.After(time.Second * 5):
+		t.Fatalf("leader lease was not lost")
+	}
+	if lm.IsLeader() {
+		t.Fatalf("unexpected leader state, got %t expected %t", lm.IsLeader(), false)
+	}
+}
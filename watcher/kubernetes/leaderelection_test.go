@@ -0,0 +1,78 @@
+package kuberneteswatcher_test
+
+import (
+	"context"
+	"statusbay/serverutil"
+	kuberneteswatcher "statusbay/watcher/kubernetes"
+	"sync"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+type MockLeaderServer struct {
+	started int
+	lock    sync.Mutex
+}
+
+func (mls *MockLeaderServer) Serve(ctx context.Context, wg *sync.WaitGroup) {
+	mls.lock.Lock()
+	defer mls.lock.Unlock()
+	mls.started++
+}
+
+func (mls *MockLeaderServer) startedCount() int {
+	mls.lock.Lock()
+	defer mls.lock.Unlock()
+	return mls.started
+}
+
+func NewLeaderElectionManagerMock(client *fake.Clientset, identity string, server *MockLeaderServer) *kuberneteswatcher.LeaderElectionManager {
+	config := kuberneteswatcher.LeaderElectionConfig{
+		Identity:      identity,
+		Namespace:     "statusbay",
+		Name:          "statusbay-watcher",
+		LeaseDuration: time.Second * 15,
+		RenewDeadline: time.Second * 10,
+		RetryPeriod:   time.Millisecond * 100,
+	}
+	return kuberneteswatcher.NewLeaderElectionManager([]*kuberneteswatcher.InformerManager{NewInformerManagerMock(client)}, client, config, func() []serverutil.Server {
+		return []serverutil.Server{server}
+	}, func() {})
+}
+
+func TestLeaderElection(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	ctx, cancelFn := context.WithCancel(context.Background())
+	defer cancelFn()
+	var wg sync.WaitGroup
+
+	leaderServer := &MockLeaderServer{}
+	leader := NewLeaderElectionManagerMock(client, "replica-1", leaderServer)
+	leader.Serve(ctx, &wg)
+	time.Sleep(time.Second)
+
+	standbyServer := &MockLeaderServer{}
+	standby := NewLeaderElectionManagerMock(client, "replica-2", standbyServer)
+	standby.Serve(ctx, &wg)
+	time.Sleep(time.Second)
+
+	t.Run("leader", func(t *testing.T) {
+		if !leader.IsLeader() {
+			t.Fatalf("unexpected leader state, got %t expected %t", leader.IsLeader(), true)
+		}
+		if leaderServer.startedCount() != 1 {
+			t.Fatalf("unexpected leader servers started count, got %d expected %d", leaderServer.startedCount(), 1)
+		}
+	})
+
+	t.Run("standby", func(t *testing.T) {
+		if standby.IsLeader() {
+			t.Fatalf("unexpected standby state, got %t expected %t", standby.IsLeader(), false)
+		}
+		if standbyServer.startedCount() != 0 {
+			t.Fatalf("unexpected standby servers started count, got %d expected %d", standbyServer.startedCount(), 0)
+		}
+	})
+}
@@ -23,6 +23,24 @@ const (
 
 	// annotationPrefixAllReporter prefix of all reporters integrations
 	annotationPrefixAllReporter = "report"
+
+	// annotationEnabled marks the resource to be tracked when the opt in filter is enabled
+	annotationEnabled = "enabled"
+
+	// annotationSuccessMinReadySeconds is the time that the pods should stay ready before the apply succeeds
+	annotationSuccessMinReadySeconds = "success-min-ready-seconds"
+
+	// annotationSuccessMinAvailablePercent is the percent of the desired pods that should be available
+	annotationSuccessMinAvailablePercent = "success-min-available-percent"
+
+	// annotationSuccessIgnoreContainers is a comma separated list of containers that don't affect the apply success
+	annotationSuccessIgnoreContainers = "success-ignore-containers"
+
+	// annotationSuccessMaxRestarts is the max restarts count of a container before the apply fails
+	annotationSuccessMaxRestarts = "success-max-restarts"
+
+	// defaultSuccessMinAvailablePercent all the desired pods should be available when the percent is not set
+	defaultSuccessMinAvailablePercent = 100
 )
 
 // GetMetadataByPrefix will return anitasion values key prefix
@@ -136,3 +154,59 @@ func GetApplicationName(annotations map[string]string, defaultValue string) stri
 	return applicationName
 
 }
+
+//GetSuccessCriteriaFromAnnotations returns the success criteria of the resource. nil is returned when none of the criteria
+//annotations was set, invalid values are skipped
+func GetSuccessCriteriaFromAnnotations(annotations map[string]string) *SuccessCriteria {
+
+	criteria := SuccessCriteria{
+		MinAvailablePercent: defaultSuccessMinAvailablePercent,
+		IgnoreContainers:    []string{},
+	}
+	found := false
+
+	if value := GetMetadata(annotations, fmt.Sprintf("%s/%s", annotationPrefix, annotationSuccessMinReadySeconds)); value != "" {
+		minReadySeconds, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || minReadySeconds < 0 {
+			log.WithField("value", value).Warn("invalid success min ready seconds annotation")
+		} else {
+			criteria.MinReadySeconds = minReadySeconds
+			found = true
+		}
+	}
+
+	if value := GetMetadata(annotations, fmt.Sprintf("%s/%s", annotationPrefix, annotationSuccessMinAvailablePercent)); value != "" {
+		percent, err := strconv.ParseInt(strings.TrimSuffix(value, "%"), 10, 32)
+		if err != nil || percent < 0 || percent > 100 {
+			log.WithField("value", value).Warn("invalid success min available percent annotation")
+		} else {
+			criteria.MinAvailablePercent = int32(percent)
+			found = true
+		}
+	}
+
+	if value := GetMetadata(annotations, fmt.Sprintf("%s/%s", annotationPrefix, annotationSuccessIgnoreContainers)); value != "" {
+		for _, container := range strings.Split(value, ",") {
+			if container = strings.TrimSpace(container); container != "" {
+				criteria.IgnoreContainers = append(criteria.IgnoreContainers, container)
+				found = true
+			}
+		}
+	}
+
+	if value := GetMetadata(annotations, fmt.Sprintf("%s/%s", annotationPrefix, annotationSuccessMaxRestarts)); value != "" {
+		maxRestarts, err := strconv.ParseInt(value, 10, 32)
+		if err != nil || maxRestarts < 0 {
+			log.WithField("value", value).Warn("invalid success max restarts annotation")
+		} else {
+			restarts := int32(maxRestarts)
+			criteria.MaxRestarts = &restarts
+			found = true
+		}
+	}
+
+	if !found {
+		return nil
+	}
+	return &criteria
+}
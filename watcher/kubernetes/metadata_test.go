@@ -165,3 +165,56 @@ func TestGetApplicationName(t *testing.T) {
 	})
 
 }
+
+func TestGetSuccessCriteriaFromAnnotations(t *testing.T) {
+
+	t.Run("without_success_criteria_annotations", func(t *testing.T) {
+		criteria := GetSuccessCriteriaFromAnnotations(map[string]string{})
+		if criteria != nil {
+			t.Fatalf("unexpected success criteria, got %v expected nil", criteria)
+		}
+	})
+
+	t.Run("all_success_criteria_annotations", func(t *testing.T) {
+		annotations := map[string]string{
+			fmt.Sprintf("%s/%s", annotationPrefix, annotationSuccessMinReadySeconds):     "30",
+			fmt.Sprintf("%s/%s", annotationPrefix, annotationSuccessMinAvailablePercent): "80%",
+			fmt.Sprintf("%s/%s", annotationPrefix, annotationSuccessIgnoreContainers):    "istio-proxy, log-shipper",
+			fmt.Sprintf("%s/%s", annotationPrefix, annotationSuccessMaxRestarts):         "2",
+		}
+		criteria := GetSuccessCriteriaFromAnnotations(annotations)
+		if criteria == nil {
+			t.Fatalf("unexpected nil success criteria")
+		}
+		if criteria.MinReadySeconds != 30 {
+			t.Fatalf("unexpected min ready seconds, got %d expected %d", criteria.MinReadySeconds, 30)
+		}
+		if criteria.MinAvailablePercent != 80 {
+			t.Fatalf("unexpected min available percent, got %d expected %d", criteria.MinAvailablePercent, 80)
+		}
+		if len(criteria.IgnoreContainers) != 2 || criteria.IgnoreContainers[1] != "log-shipper" {
+			t.Fatalf("unexpected ignored containers, got %v expected %v", criteria.IgnoreContainers, []string{"istio-proxy", "log-shipper"})
+		}
+		if criteria.MaxRestarts == nil || *criteria.MaxRestarts != 2 {
+			t.Fatalf("unexpected max restarts, got %v expected %d", criteria.MaxRestarts, 2)
+		}
+	})
+
+	t.Run("invalid_success_criteria_annotations", func(t *testing.T) {
+		annotations := map[string]string{
+			fmt.Sprintf("%s/%s", annotationPrefix, annotationSuccessMinAvailablePercent): "120",
+			fmt.Sprintf("%s/%s", annotationPrefix, annotationSuccessMaxRestarts):         "foo",
+			fmt.Sprintf("%s/%s", annotationPrefix, annotationSuccessMinReadySeconds):     "10",
+		}
+		criteria := GetSuccessCriteriaFromAnnotations(annotations)
+		if criteria == nil {
+			t.Fatalf("unexpected nil success criteria")
+		}
+		if criteria.MinAvailablePercent != defaultSuccessMinAvailablePercent {
+			t.Fatalf("unexpected min available percent, got %d expected %d", criteria.MinAvailablePercent, defaultSuccessMinAvailablePercent)
+		}
+		if criteria.MaxRestarts != nil {
+			t.Fatalf("unexpected max restarts, got %d expected nil", *criteria.MaxRestarts)
+		}
+	})
+}
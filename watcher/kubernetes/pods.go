@@ -3,6 +3,7 @@ package kuberneteswatcher
 import (
 	"context"
 	"fmt"
+	"statusbay/watcher/kubernetes/common"
 	"sync"
 	"time"
 
@@ -11,28 +12,40 @@ import (
 	v1 "k8s.io/api/core/v1"
 	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	eventwatch "k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/kubernetes"
 )
 
 // PodsManager defined pods manager struct
 type PodsManager struct {
-	client        kubernetes.Interface
-	eventManager  *EventsManager
-	pvcManager    *PvcManager
-	Watch         chan WatchData
-	podsFirstInit map[string]bool
-	mutex         *sync.RWMutex
+	informerManager *InformerManager
+	eventManager    *EventsManager
+	pvcManager      *PvcManager
+	Watch           chan WatchData
+	podsFirstInit   map[string]bool
+	mutex           *sync.RWMutex
+
+	// client fetches the logs of the failing containers
+	client       kubernetes.Interface
+	logsConfig   common.PodLogsConfig
+	logsCaptured map[types.UID]map[string]bool
+	redactor     *logRedactor
 }
 
 // NewPodsManager create new pods instance
-func NewPodsManager(kubernetesClientset kubernetes.Interface, eventManager *EventsManager, pvcManager *PvcManager) *PodsManager {
+func NewPodsManager(informerManager *InformerManager, eventManager *EventsManager, pvcManager *PvcManager, client kubernetes.Interface, logsConfig common.PodLogsConfig) *PodsManager {
 	return &PodsManager{
-		client:        kubernetesClientset,
-		eventManager:  eventManager,
-		pvcManager:    pvcManager,
-		podsFirstInit: map[string]bool{},
-		mutex:         &sync.RWMutex{},
-		Watch:         make(chan WatchData),
+		informerManager: informerManager,
+		eventManager:    eventManager,
+		pvcManager:      pvcManager,
+		podsFirstInit:   map[string]bool{},
+		mutex:           &sync.RWMutex{},
+		Watch:           make(chan WatchData),
+		client:          client,
+		logsConfig:      setPodLogsDefaults(logsConfig),
+		logsCaptured:    map[types.UID]map[string]bool{},
+		redactor:        newLogRedactor(logsConfig.RedactPatterns),
 	}
 }
 
@@ -82,14 +95,22 @@ func (pm *PodsManager) watch(watchData WatchData) {
 
 		lg.WithField("list_option", watchData.ListOptions).Debug("pod list options")
 
-		watcher, err := pm.client.CoreV1().Pods(watchData.Namespace).Watch(watchData.ListOptions)
-		if err != nil {
-			lg.WithError(err).WithField("list_option", watchData.ListOptions.String()).Error("error when trying to start watch on pods")
-			return
-		}
+		// reportedStates holds the container states that were recorded as pod events by this watch
+		reportedStates := map[string]bool{}
+
+		// watchedPods holds the uids of the pods that were not deleted, their captured logs marks are removed when the
+		// watch is stopped
+		watchedPods := map[types.UID]bool{}
+		defer func() {
+			for podUID := range watchedPods {
+				pm.forgetLogsCaptured(podUID)
+			}
+		}()
+
+		watcher := pm.informerManager.ResumableWatch(watchData.Ctx, *lg, watchData.WatchHealth, podsResource, watchData.Namespace, watchData.ListOptions)
 		for {
 			select {
-			case event, watch := <-watcher.ResultChan():
+			case event, watch := <-watcher:
 				if !watch {
 					lg.WithFields(log.Fields{
 						"list_options": watchData.ListOptions.String(),
@@ -114,12 +135,16 @@ func (pm *PodsManager) watch(watchData WatchData) {
 
 					pm.storePodFirstInit(pod.Name, true)
 					watchData.RegistryData.NewPod(pod)
-					eventListOptions := metaV1.ListOptions{FieldSelector: labels.SelectorFromSet(map[string]string{
+					eventFields := map[string]string{
 						"involvedObject.name": pod.GetName(),
 						"involvedObject.kind": "Pod",
-					}).String(),
 					}
-					go pm.watchEvents(watchData.Ctx, *podLog, watchData.RegistryData, eventListOptions, pod.Namespace, pod.GetName())
+					// Route the events by the pod uid, so events of an older pod with the same name are ignored
+					if pod.GetUID() != "" {
+						eventFields["involvedObject.uid"] = string(pod.GetUID())
+					}
+					eventListOptions := metaV1.ListOptions{FieldSelector: labels.SelectorFromSet(eventFields).String()}
+					go pm.watchEvents(watchData.Ctx, *podLog, watchData.WatchHealth, watchData.RegistryData, eventListOptions, newObjectReference("Pod", pod), pod.Namespace, pod.GetName())
 
 					for _, volume := range pod.Spec.Volumes {
 						pvc := volume.VolumeSource.PersistentVolumeClaim
@@ -137,6 +162,7 @@ func (pm *PodsManager) watch(watchData WatchData) {
 								Namespace:    pod.Namespace,
 								Pod:          pod.Name,
 								Ctx:          watchData.Ctx,
+								WatchHealth:  watchData.WatchHealth,
 							}
 						}
 					}
@@ -147,56 +173,41 @@ func (pm *PodsManager) watch(watchData WatchData) {
 					"count": len(pod.Status.ContainerStatuses),
 				}).Debug("list of pod status container statuses")
 
-				for _, container := range pod.Status.ContainerStatuses {
-
-					containerLog := podLog.WithFields(log.Fields{
-						"container_name": container.Name,
-						"container_id":   container.ContainerID,
-					})
-
-					if container.State.Waiting != nil {
-
-						message := container.State.Waiting.Reason
-						containerLog.WithField("message", message).Debug("container status is waiting")
-						if container.State.Waiting.Message != "" {
-							message = fmt.Sprintf("%s - %s", message, container.State.Waiting.Message)
-						}
+				// Init containers run before the pod containers, their failures are recorded the same way
+				for _, container := range pod.Status.InitContainerStatuses {
+					pm.updateContainerStatus(*podLog, watchData.RegistryData, reportedStates, pod, container)
+				}
 
-						eventMessage := EventMessages{
-							Message: message,
-							Time:    time.Now().UnixNano(),
-						}
-						watchData.RegistryData.UpdatePodEvents(pod.GetName(), "", eventMessage)
-						status = container.State.Waiting.Reason
+				for _, container := range pod.Status.ContainerStatuses {
+					if reason := pm.updateContainerStatus(*podLog, watchData.RegistryData, reportedStates, pod, container); reason != "" {
+						status = reason
 					}
+				}
 
-					if container.State.Terminated != nil {
-
-						message := container.State.Terminated.Reason
-						containerLog.WithField("message", message).Debug("container status is terminated")
-						if container.State.Terminated.Message != "" {
-							message = fmt.Sprintf("%s - %s", message, container.State.Terminated.Message)
-						}
+				for _, container := range pod.Status.EphemeralContainerStatuses {
+					pm.updateContainerStatus(*podLog, watchData.RegistryData, reportedStates, pod, container)
+				}
 
-						eventMessage := EventMessages{
-							Message:             message,
-							Time:                container.State.Terminated.StartedAt.UnixNano(),
-							ReportingController: container.State.Terminated.ContainerID,
-						}
-						watchData.RegistryData.UpdatePodEvents(pod.GetName(), "", eventMessage)
-						status = container.State.Terminated.Reason
-					}
+				// A pod that is blocked by an init container shows the blocking init step
+				if initStatus, blocked := initContainersStatus(pod); blocked {
+					status = initStatus
 				}
 
 				if pod.GetDeletionTimestamp() != nil {
-					status = "Terminated"
+					status = podStatusTerminated
 				}
 				podLog.WithField("status", status).Debug("pod status")
 				watchData.RegistryData.UpdatePod(pod, status)
 
+				if event.Type == eventwatch.Deleted {
+					delete(watchedPods, pod.GetUID())
+					pm.forgetLogsCaptured(pod.GetUID())
+				} else {
+					watchedPods[pod.GetUID()] = true
+				}
+
 			case <-watchData.Ctx.Done():
 				watchData.LogEntry.Info("pod watcher was stopped, got ctx done signal")
-				watcher.Stop()
 				return
 			}
 		}
@@ -204,16 +215,110 @@ func (pm *PodsManager) watch(watchData WatchData) {
 
 }
 
+// updateContainerStatus records the waiting and terminated states of a pod container as pod events, and returns the
+// reason of the container state, empty when the container is running. Each state is recorded once, reported holds
+// the states that were already recorded by the watch
+func (pm *PodsManager) updateContainerStatus(podLog log.Entry, registryData RegistryData, reported map[string]bool, pod *v1.Pod, container v1.ContainerStatus) string {
+
+	reason := ""
+	containerLog := podLog.WithFields(log.Fields{
+		"container_name": container.Name,
+		"container_id":   container.ContainerID,
+	})
+	podKey := fmt.Sprintf("%s/%s/%s/%s", pod.GetNamespace(), pod.GetName(), pod.GetUID(), container.Name)
+
+	if container.State.Waiting != nil {
+
+		message := container.State.Waiting.Reason
+		containerLog.WithField("message", message).Debug("container status is waiting")
+		if container.State.Waiting.Message != "" {
+			message = fmt.Sprintf("%s - %s", message, container.State.Waiting.Message)
+		}
+
+		eventMessage := EventMessages{
+			Message: message,
+			Time:    time.Now().UnixNano(),
+			Reason:  container.State.Waiting.Reason,
+			Type:    waitingEventType(container.State.Waiting.Reason),
+		}
+		// A waiting state is recorded again only after the container was restarted
+		key := fmt.Sprintf("%s/waiting/%d/%s", podKey, container.RestartCount, message)
+		recordContainerEvent(registryData, reported, key, pod.GetName(), eventMessage)
+		reason = container.State.Waiting.Reason
+	}
+
+	if container.State.Terminated != nil {
+
+		containerLog.WithField("message", container.State.Terminated.Reason).Debug("container status is terminated")
+		key := fmt.Sprintf("%s/terminated/%s/%d", podKey, container.State.Terminated.ContainerID, container.State.Terminated.StartedAt.UnixNano())
+		recordContainerEvent(registryData, reported, key, pod.GetName(), terminatedEventMessages(container.State.Terminated))
+		reason = container.State.Terminated.Reason
+	}
+
+	// The last termination keeps the reason of a restarted container, for example OOMKilled. It is the same container
+	// run as the terminated state that was recorded before the restart
+	if container.LastTerminationState.Terminated != nil {
+
+		terminated := container.LastTerminationState.Terminated
+		key := fmt.Sprintf("%s/terminated/%s/%d", podKey, terminated.ContainerID, terminated.StartedAt.UnixNano())
+		recordContainerEvent(registryData, reported, key, pod.GetName(), terminatedEventMessages(terminated))
+	}
+
+	pm.captureContainerLogs(*containerLog, registryData, pod, container)
+	return reason
+}
+
+// recordContainerEvent adds the container event to the pod events when the event was not recorded before
+func recordContainerEvent(registryData RegistryData, reported map[string]bool, key, podName string, event EventMessages) {
+	if reported[key] {
+		return
+	}
+	reported[key] = true
+	registryData.UpdatePodEvents(podName, "", event)
+}
+
+// terminatedEventMessages returns the pod event of a terminated container run
+func terminatedEventMessages(terminated *v1.ContainerStateTerminated) EventMessages {
+	message := terminated.Reason
+	if terminated.Message != "" {
+		message = fmt.Sprintf("%s - %s", message, terminated.Message)
+	}
+
+	eventType := v1.EventTypeNormal
+	if terminated.ExitCode != 0 {
+		eventType = v1.EventTypeWarning
+	}
+	return EventMessages{
+		Message:             message,
+		Time:                terminated.StartedAt.UnixNano(),
+		ReportingController: terminated.ContainerID,
+		Reason:              terminated.Reason,
+		Type:                eventType,
+	}
+}
+
+// waitingEventType returns the event type of a waiting container, a container that is being created is not a warning
+func waitingEventType(reason string) string {
+	switch reason {
+	case "", "ContainerCreating", "PodInitializing":
+		return v1.EventTypeNormal
+	}
+	return v1.EventTypeWarning
+}
+
 // watchEvents will start watch on pod event messages changes
-func (pm *PodsManager) watchEvents(ctx context.Context, lg log.Entry, registryData RegistryData, listOptions metaV1.ListOptions, namespace, podName string) {
+func (pm *PodsManager) watchEvents(ctx context.Context, lg log.Entry, health *WatchHealth, registryData RegistryData, listOptions metaV1.ListOptions, involvedObject v1.ObjectReference, namespace, podName string) {
 
 	lg.Info("starting to watch pod events")
 
 	watchData := WatchEvents{
-		ListOptions: listOptions,
-		Namespace:   namespace,
-		Ctx:         ctx,
-		LogEntry:    lg,
+		ListOptions:       listOptions,
+		Namespace:         namespace,
+		Ctx:               ctx,
+		LogEntry:          lg,
+		WatchHealth:       health,
+		InvolvedObject:    involvedObject,
+		ApplyCreationTime: health.applyCreation(),
 	}
 	eventChan := pm.eventManager.Watch(watchData)
 	go func() {
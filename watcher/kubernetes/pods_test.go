@@ -29,9 +29,9 @@ func createPodMock(client *fake.Clientset, name string, status v1.PodStatus, del
 func NewPodManagerMock() (*fake.Clientset, *kuberneteswatcher.PodsManager) {
 
 	client := fake.NewSimpleClientset()
-	eventManager := kuberneteswatcher.NewEventsManager(client)
+	eventManager := kuberneteswatcher.NewEventsManager(NewInformerManagerMock(client), common.EventsConfig{})
 	pvcManager := NewPvcManagerMock(client)
-	podManager := kuberneteswatcher.NewPodsManager(client, eventManager, pvcManager)
+	podManager := kuberneteswatcher.NewPodsManager(NewInformerManagerMock(client), eventManager, pvcManager, client, common.PodLogsConfig{})
 
 	var wg sync.WaitGroup
 	ctx := context.Background()
@@ -182,15 +182,115 @@ func TestPodWatchEvent(t *testing.T) {
 
 	createPodMock(client, "nginx", v1.PodStatus{Phase: v1.PodRunning}, nil)
 	time.Sleep(time.Second)
-	event1 := &v1.Event{Message: "message", ObjectMeta: metav1.ObjectMeta{Name: "a", CreationTimestamp: metav1.Time{Time: time.Now()}}}
-	event2 := &v1.Event{Message: "message", ObjectMeta: metav1.ObjectMeta{Name: "b", CreationTimestamp: metav1.Time{Time: time.Now()}}}
+	involvedObject := v1.ObjectReference{Kind: "Pod", Name: "nginx"}
+	event1 := &v1.Event{Message: "message", ObjectMeta: metav1.ObjectMeta{Name: "a", CreationTimestamp: metav1.Time{Time: time.Now()}}, InvolvedObject: involvedObject}
+	event2 := &v1.Event{Message: "message", ObjectMeta: metav1.ObjectMeta{Name: "b", CreationTimestamp: metav1.Time{Time: time.Now()}}, InvolvedObject: involvedObject}
 	client.CoreV1().Events("pe").Create(event1)
 	client.CoreV1().Events("pe").Create(event2)
 
 	time.Sleep(time.Second)
 	pods := storageMock.MockWriteDeployment["1"].Schema.Resources.Deployments["resourceName"].Pods
 
-	if len(*pods["nginx"].Events) != 2 {
-		t.Fatalf("unexpected watch pod events count, got %d expected %d", len(*pods["nginx"].Events), 2)
+	// Both events have the same message, the repeated event is merged into a single entry
+	if len(*pods["nginx"].Events) != 1 {
+		t.Fatalf("unexpected watch pod events count, got %d expected %d", len(*pods["nginx"].Events), 1)
 	}
+	if (*pods["nginx"].Events)[0].Count != 2 {
+		t.Fatalf("unexpected watch pod event occurrences, got %d expected %d", (*pods["nginx"].Events)[0].Count, 2)
+	}
+}
+
+func TestPodWatchInitContainers(t *testing.T) {
+	registry, storageMock := NewRegistryMock()
+
+	registryRow := registry.NewApplication("nginx", "default", map[string]string{}, common.ApplyStatusRunning)
+
+	apply := kuberneteswatcher.ApplyEvent{
+		Event:        "create",
+		ApplyName:    "application",
+		ResourceName: "resourceName",
+		Namespace:    "default",
+		Kind:         "deployment",
+		Hash:         1234,
+		Annotations:  map[string]string{},
+		Labels:       map[string]string{},
+	}
+
+	registryDeploymentData := createMockDeploymentData(registry, registryRow, apply, "10m")
+
+	lg := log.WithField("test", "TestPodWatchInitContainers")
+	ctx := context.Background()
+
+	client, podManager := NewPodManagerMock()
+
+	podManager.Watch <- kuberneteswatcher.WatchData{
+		RegistryData: registryDeploymentData,
+		ListOptions:  metav1.ListOptions{},
+		Namespace:    "pe",
+		Ctx:          ctx,
+		LogEntry:     *lg,
+	}
+	time.Sleep(time.Second)
+
+	createPodMock(client, "nginx", v1.PodStatus{
+		Phase: v1.PodPending,
+		InitContainerStatuses: []v1.ContainerStatus{
+			{
+				Name:         "config",
+				State:        v1.ContainerState{Terminated: &v1.ContainerStateTerminated{Reason: "Completed"}},
+				RestartCount: 0,
+			},
+			{
+				Name:                 "migrations",
+				State:                v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: "CrashLoopBackOff", Message: "back-off restarting failed container"}},
+				LastTerminationState: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{Reason: "Error", ExitCode: 3}},
+				RestartCount:         4,
+			},
+		},
+		ContainerStatuses: []v1.ContainerStatus{
+			{
+				Name:  "nginx",
+				State: v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: "PodInitializing"}},
+			},
+		},
+	}, nil)
+	time.Sleep(time.Second * 2)
+
+	pod := storageMock.MockWriteDeployment["1"].Schema.Resources.Deployments["resourceName"].Pods["nginx"]
+
+	t.Run("pod_status", func(t *testing.T) {
+		expected := "Init:CrashLoopBackOff (migrations 2/2)"
+		if *pod.Phase != expected {
+			t.Fatalf("unexpected nginx pod status, got %s expected %s", *pod.Phase, expected)
+		}
+	})
+
+	t.Run("containers", func(t *testing.T) {
+		if len(pod.Containers) != 3 {
+			t.Fatalf("unexpected containers count, got %d expected %d", len(pod.Containers), 3)
+		}
+		migrations := pod.Containers[1]
+		if migrations.Name != "migrations" || migrations.Type != kuberneteswatcher.ContainerTypeInit {
+			t.Fatalf("unexpected second container, got %s (%s) expected migrations (%s)", migrations.Name, migrations.Type, kuberneteswatcher.ContainerTypeInit)
+		}
+		if migrations.Reason != "CrashLoopBackOff" || migrations.RestartCount != 4 {
+			t.Fatalf("unexpected migrations container status, got %+v", migrations)
+		}
+		if migrations.LastTermination == nil || migrations.LastTermination.Reason != "Error" || migrations.LastTermination.ExitCode != 3 {
+			t.Fatalf("unexpected migrations container last termination, got %+v", migrations.LastTermination)
+		}
+		if pod.Containers[2].Type != kuberneteswatcher.ContainerTypeApp {
+			t.Fatalf("unexpected nginx container type, got %s expected %s", pod.Containers[2].Type, kuberneteswatcher.ContainerTypeApp)
+		}
+	})
+
+	t.Run("init_container_events", func(t *testing.T) {
+		expected := "CrashLoopBackOff - back-off restarting failed container"
+		for _, event := range *pod.Events {
+			if event.Message == expected {
+				return
+			}
+		}
+		t.Fatalf("expected init container event %q", expected)
+	})
 }
@@ -6,9 +6,9 @@ import (
 
 	log "github.com/sirupsen/logrus"
 	coreV1 "k8s.io/api/core/v1"
+	v1 "k8s.io/api/core/v1"
 	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
-	"k8s.io/client-go/kubernetes"
 )
 
 // WatchPvcData Holds the data to  be sent to the pvc Watch channel
@@ -19,25 +19,26 @@ type WatchPvcData struct {
 	Namespace    string
 	Pod          string
 	Ctx          context.Context
+	WatchHealth  *WatchHealth
 }
 
 // PvcManager manages the Pvc
 type PvcManager struct {
-	eventManager  *EventsManager
-	client        kubernetes.Interface
-	Watch         chan WatchPvcData
-	pvcsFirstInit map[string]bool
-	mutex         *sync.RWMutex
+	eventManager    *EventsManager
+	informerManager *InformerManager
+	Watch           chan WatchPvcData
+	pvcsFirstInit   map[string]bool
+	mutex           *sync.RWMutex
 }
 
 // NewPvcManager creates a new pvc manager objects
-func NewPvcManager(kubernetesClientset kubernetes.Interface, eventManager *EventsManager) *PvcManager {
+func NewPvcManager(informerManager *InformerManager, eventManager *EventsManager) *PvcManager {
 	return &PvcManager{
-		client:        kubernetesClientset,
-		eventManager:  eventManager,
-		pvcsFirstInit: map[string]bool{},
-		mutex:         &sync.RWMutex{},
-		Watch:         make(chan WatchPvcData),
+		informerManager: informerManager,
+		eventManager:    eventManager,
+		pvcsFirstInit:   map[string]bool{},
+		mutex:           &sync.RWMutex{},
+		Watch:           make(chan WatchPvcData),
 	}
 }
 
@@ -81,14 +82,10 @@ func (pm *PvcManager) watch(watchPvcData WatchPvcData) {
 		watchPvcData.LogEntry.Info("started pvc watcher")
 		watchPvcData.LogEntry.WithField("list_options", watchPvcData.ListOptions).Debug("started pvc watcher with the following list options")
 
-		watcher, err := pm.client.CoreV1().PersistentVolumeClaims(watchPvcData.Namespace).Watch(watchPvcData.ListOptions)
-		if err != nil {
-			watchPvcData.LogEntry.WithError(err).WithField("list_options", watchPvcData.ListOptions.String()).Error("an error occurred while trying to start the pvc watcher")
-			return
-		}
+		watcher := pm.informerManager.ResumableWatch(watchPvcData.Ctx, watchPvcData.LogEntry, watchPvcData.WatchHealth, persistentVolumeClaimsResource, watchPvcData.Namespace, watchPvcData.ListOptions)
 		for {
 			select {
-			case event, watch := <-watcher.ResultChan():
+			case event, watch := <-watcher:
 				if !watch {
 					watchPvcData.LogEntry.WithField("object", event.Object).Warn("pvc watcher was stopped. channel was closed")
 					return
@@ -113,12 +110,11 @@ func (pm *PvcManager) watch(watchPvcData WatchPvcData) {
 						"involvedObject.name": pvc.GetName(),
 						"involvedObject.kind": "PersistentVolumeClaim"}).String()}
 
-					pm.watchEvents(watchPvcData.Ctx, *lg, watchPvcData.RegistryData, eventListOptions, pvc.Namespace, watchPvcData.Pod, pvc.GetName())
+					pm.watchEvents(watchPvcData.Ctx, *lg, watchPvcData.WatchHealth, watchPvcData.RegistryData, eventListOptions, newObjectReference("PersistentVolumeClaim", pvc), pvc.Namespace, watchPvcData.Pod, pvc.GetName())
 				}
 
 			case <-watchPvcData.Ctx.Done():
 				watchPvcData.LogEntry.Info("pvc watcher was stopped. Got ctx done signal")
-				watcher.Stop()
 				return
 			}
 		}
@@ -127,15 +123,18 @@ func (pm *PvcManager) watch(watchPvcData WatchPvcData) {
 }
 
 // watchEvents will watch all the pvc events tracked from the pvc watcher
-func (pm *PvcManager) watchEvents(ctx context.Context, lg log.Entry, registryData RegistryData, listOptions metaV1.ListOptions, namespace, podName, pvcName string) {
+func (pm *PvcManager) watchEvents(ctx context.Context, lg log.Entry, health *WatchHealth, registryData RegistryData, listOptions metaV1.ListOptions, involvedObject v1.ObjectReference, namespace, podName, pvcName string) {
 
 	lg.Info("started watching on pvc events")
 
 	watchPvcData := WatchEvents{
-		ListOptions: listOptions,
-		Namespace:   namespace,
-		Ctx:         ctx,
-		LogEntry:    lg,
+		ListOptions:       listOptions,
+		Namespace:         namespace,
+		Ctx:               ctx,
+		LogEntry:          lg,
+		WatchHealth:       health,
+		InvolvedObject:    involvedObject,
+		ApplyCreationTime: health.applyCreation(),
 	}
 
 	eventChan := pm.eventManager.Watch(watchPvcData)
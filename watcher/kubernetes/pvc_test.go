@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	kuberneteswatcher "statusbay/watcher/kubernetes"
+	"statusbay/watcher/kubernetes/common"
 	"sync"
 	"testing"
 	"time"
@@ -30,6 +31,10 @@ func (mrd *MockRegistryData) UpdatePod(pod *v1.Pod, status string) error {
 	return errors.New("Implement me")
 }
 
+func (mrd *MockRegistryData) UpdatePodLogs(podName string, containerName string, logs kuberneteswatcher.ContainerLogs) error {
+	return errors.New("Implement me")
+}
+
 func (mrd *MockRegistryData) NewPod(pod *v1.Pod) error {
 	return errors.New("Implement me")
 }
@@ -42,6 +47,22 @@ func (mrd *MockRegistryData) UpdateServiceEvents(name string, event kuberneteswa
 	return errors.New("Implement me")
 }
 
+func (mrd *MockRegistryData) UpdateServiceEndpoints(name string, endpoints *v1.Endpoints) error {
+	return errors.New("Implement me")
+}
+
+func (mrd *MockRegistryData) NewServiceIngress(serviceName string, ingressName string) error {
+	return errors.New("Implement me")
+}
+
+func (mrd *MockRegistryData) UpdateServiceIngressEvents(serviceName string, ingressName string, event kuberneteswatcher.EventMessages) error {
+	return errors.New("Implement me")
+}
+
+func (mrd *MockRegistryData) UpdateServiceSelector(name string, change kuberneteswatcher.ServiceSelectorChange) error {
+	return errors.New("Implement me")
+}
+
 func (mrd *MockRegistryData) GetName() string {
 	return "www"
 }
@@ -61,8 +82,8 @@ func createPvcMock(client *fake.Clientset, namespace, volumeName, pvcName string
 
 // NewPvcManagerMock creates a pvcManager mock object.
 func NewPvcManagerMock(client *fake.Clientset) *kuberneteswatcher.PvcManager {
-	eventManager := kuberneteswatcher.NewEventsManager(client)
-	pvcManager := kuberneteswatcher.NewPvcManager(client, eventManager)
+	eventManager := kuberneteswatcher.NewEventsManager(NewInformerManagerMock(client), common.EventsConfig{})
+	pvcManager := kuberneteswatcher.NewPvcManager(NewInformerManagerMock(client), eventManager)
 
 	// Start the pvcManger
 	var wg sync.WaitGroup
@@ -95,9 +116,10 @@ func TestPvcWatch(t *testing.T) {
 	createPvcMock(client, namespace, "www", pvcName)
 	time.Sleep(2 * time.Second)
 
-	event1 := &v1.Event{Message: "message number 1", ObjectMeta: metaV1.ObjectMeta{Name: "www", CreationTimestamp: metaV1.Time{Time: time.Now()}}}
-	event2 := &v1.Event{Message: "message number 2", ObjectMeta: metaV1.ObjectMeta{Name: "www2", CreationTimestamp: metaV1.Time{Time: time.Now()}}}
-	event3 := &v1.Event{Message: "message number 3", ObjectMeta: metaV1.ObjectMeta{Name: "www3", CreationTimestamp: metaV1.Time{Time: time.Now()}}}
+	involvedObject := v1.ObjectReference{Kind: "PersistentVolumeClaim", Name: pvcName}
+	event1 := &v1.Event{Message: "message number 1", ObjectMeta: metaV1.ObjectMeta{Name: "www", CreationTimestamp: metaV1.Time{Time: time.Now()}}, InvolvedObject: involvedObject}
+	event2 := &v1.Event{Message: "message number 2", ObjectMeta: metaV1.ObjectMeta{Name: "www2", CreationTimestamp: metaV1.Time{Time: time.Now()}}, InvolvedObject: involvedObject}
+	event3 := &v1.Event{Message: "message number 3", ObjectMeta: metaV1.ObjectMeta{Name: "www3", CreationTimestamp: metaV1.Time{Time: time.Now()}}, InvolvedObject: involvedObject}
 	client.CoreV1().Events(namespace).Create(event1)
 	client.CoreV1().Events(namespace).Create(event2)
 	client.CoreV1().Events(namespace).Create(event3)
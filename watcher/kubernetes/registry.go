@@ -13,19 +13,49 @@ import (
 
 	log "github.com/sirupsen/logrus"
 	appsV1 "k8s.io/api/apps/v1"
+	autoscalingV2beta2 "k8s.io/api/autoscaling/v2beta2"
+	batchV1 "k8s.io/api/batch/v1"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
 	eventwatch "k8s.io/apimachinery/pkg/watch"
 )
 
 const (
 	// applyVersionFormat describe the format of apply versions
 	applyVersionFormat = "%s-%s-%s-%s"
+
+	// resourceVersionFormat describe the format of the watchers resource versions
+	resourceVersionFormat = "%s-%s"
+
+	// defaultCheckFinishInterval is how often the apply status is checked when the interval is not configured
+	defaultCheckFinishInterval = time.Second * 2
+)
+
+var (
+	// errBackoffLimitExceeded returned when one of the apply jobs failed more times than the job backoff limit
+	errBackoffLimitExceeded = errors.New("job has reached the specified backoff limit")
+
+	// errJobDeadlineExceeded returned when one of the apply jobs was active longer than the job active deadline
+	errJobDeadlineExceeded = errors.New("job was active longer than the specified active deadline")
+
+	// errCustomResourceFailed returned when one of the apply custom resources matched the failed status rule
+	errCustomResourceFailed = errors.New("custom resource status matched the failed rule")
+
+	// errSuccessCriteriaFailed returned when a container of the apply restarted more than the success criteria max restarts
+	errSuccessCriteriaFailed = errors.New("container restarted more than the success criteria max restarts")
+
+	// errAwaitingPodDeletion returned when the progress deadline of an OnDelete resource passed before its pods were deleted
+	errAwaitingPodDeletion = errors.New("pods are awaiting a manual deletion")
 )
 
 type Resources struct {
-	Deployments  map[string]*DeploymentData  `json:"Deployments"`
-	Daemonsets   map[string]*DaemonsetData   `json:"Daemonsets"`
-	Statefulsets map[string]*StatefulsetData `json:"Statefulsets"`
+	Deployments     map[string]*DeploymentData     `json:"Deployments"`
+	Daemonsets      map[string]*DaemonsetData      `json:"Daemonsets"`
+	Statefulsets    map[string]*StatefulsetData    `json:"Statefulsets"`
+	Jobs            map[string]*JobData            `json:"Jobs"`
+	CustomResources map[string]*CustomResourceData `json:"CustomResources"`
+	TrafficSwitches map[string]*TrafficSwitchData  `json:"TrafficSwitches"`
 }
 
 //DBSchema is a struct that save as json in given storage
@@ -38,6 +68,16 @@ type DBSchema struct {
 	DeployBy              string                             `json:"DeployBy"`
 	DeploymentDescription common.DeploymentStatusDescription `json:"DeploymentDescription"`
 	Resources             Resources                          `json:"Resources"`
+	WatchHealth           *WatchHealth                       `json:"WatchHealth"`
+	Rollback              *ApplyRollback                     `json:"Rollback"`
+	SpecDiffs             []SpecDiff                         `json:"SpecDiffs"`
+	FailureCauses         []common.FailureCause              `json:"FailureCauses"`
+	RolloutPhases         []RolloutPhase                     `json:"RolloutPhases"`
+
+	// Supersedes is the apply ID of the running apply that this apply replaced, SupersededBy is the apply ID
+	// of the apply that replaced this apply
+	Supersedes   string `json:"Supersedes"`
+	SupersededBy string `json:"SupersededBy"`
 }
 
 // ApplyEvent describe the new Kubernetes apply details for create/skip/delete new application
@@ -50,6 +90,24 @@ type ApplyEvent struct {
 	Hash         uint64
 	Annotations  map[string]string
 	Labels       map[string]string
+
+	// LegacyHash is the hash of the spec in the previous hash scheme, 0 when the scheme didn't change the hash of the kind
+	LegacyHash uint64
+
+	// Replicas is the desired replicas count, nil for resources without replicas
+	Replicas *int32
+
+	// UID and ResourceVersion of the applied resource
+	UID             types.UID
+	ResourceVersion string
+}
+
+// legacyHash returns the hash of the apply in the previous hash scheme
+func (data ApplyEvent) legacyHash() uint64 {
+	if data.LegacyHash == 0 {
+		return data.Hash
+	}
+	return data.LegacyHash
 }
 
 // RegistryRow defined row data of deployment
@@ -71,33 +129,48 @@ type RegistryManager struct {
 	registryData                map[string]*RegistryRow
 	saveInterval                time.Duration
 	checkFinishDelay            time.Duration
+	checkFinishInterval         time.Duration
 	collectDataAfterApplyFinish time.Duration
 	saveLock                    *sync.Mutex
 	applyLock                   *sync.Mutex
 	storage                     Storage
 	reporter                    *ReporterManager
 	lastDeploymentHistory       map[string]time.Time
+	resourceVersions            map[string]string
+	savedResourceVersions       map[string]string
+	resourceVersionsLock        *sync.Mutex
+	filter                      *ApplyFilter
+	notifyScaling               bool
 }
 
 // NewRegistryManager create new schema registry instance
-func NewRegistryManager(saveInterval time.Duration, checkFinishDelay time.Duration, collectDataAfterApplyFinish time.Duration, storage Storage, reporter *ReporterManager, clusterName string) *RegistryManager {
+func NewRegistryManager(saveInterval time.Duration, checkFinishDelay time.Duration, checkFinishInterval time.Duration, collectDataAfterApplyFinish time.Duration, storage Storage, reporter *ReporterManager, clusterName string, filter *ApplyFilter, notifyScaling bool) *RegistryManager {
 	if clusterName == "" {
 		log.Panic("cluster name is a mandatory field")
 		os.Exit(1)
 	}
+	if checkFinishInterval <= 0 {
+		checkFinishInterval = defaultCheckFinishInterval
+	}
 
 	return &RegistryManager{
 		clusterName:                 clusterName,
+		filter:                      filter,
+		notifyScaling:               notifyScaling,
 		saveInterval:                saveInterval,
 		checkFinishDelay:            checkFinishDelay,
+		checkFinishInterval:         checkFinishInterval,
 		collectDataAfterApplyFinish: collectDataAfterApplyFinish,
 		storage:                     storage,
 		reporter:                    reporter,
 
 		registryData:          make(map[string]*RegistryRow),
 		lastDeploymentHistory: make(map[string]time.Time),
+		resourceVersions:      make(map[string]string),
+		savedResourceVersions: make(map[string]string),
 		saveLock:              &sync.Mutex{},
 		applyLock:             &sync.Mutex{},
+		resourceVersionsLock:  &sync.Mutex{},
 	}
 }
 
@@ -123,14 +196,36 @@ func (dr *RegistryManager) Serve(ctx context.Context, wg *sync.WaitGroup) {
 // LoadRunningApps TODO:: fix me
 func (dr *RegistryManager) LoadRunningApplies() []*RegistryRow {
 	rows := []*RegistryRow{}
-	apps, _ := dr.storage.GetAppliesByStatus(common.ApplyStatusRunning)
-	log.WithField("count", len(apps)).Info("loading running job from database")
+	apps, _ := dr.storage.GetAppliesByStatus(common.ApplyStatusRunning, dr.clusterName)
+	log.WithFields(log.Fields{
+		"count":   len(apps),
+		"cluster": dr.clusterName,
+	}).Info("loading running job from database")
 
 	for applyID, appSchema := range apps {
 
 		encodedID := generateID(appSchema.Application, appSchema.Namespace, dr.clusterName)
 		ctx, cancelFn := context.WithCancel(context.Background())
 
+		// Applies that were saved before the watch health was tracked
+		if appSchema.WatchHealth == nil {
+			appSchema.WatchHealth = &WatchHealth{}
+		}
+		appSchema.WatchHealth.setApplyCreationTime(appSchema.CreationTimestamp)
+
+		// Applies that were saved before the traffic switches were tracked
+		if appSchema.Resources.TrafficSwitches == nil {
+			appSchema.Resources.TrafficSwitches = make(map[string]*TrafficSwitchData)
+		}
+
+		// Applies that were saved before the jobs and the custom resources were tracked
+		if appSchema.Resources.Jobs == nil {
+			appSchema.Resources.Jobs = make(map[string]*JobData)
+		}
+		if appSchema.Resources.CustomResources == nil {
+			appSchema.Resources.CustomResources = make(map[string]*CustomResourceData)
+		}
+
 		row := RegistryRow{
 			applyID:  applyID,
 			ctx:      ctx,
@@ -141,7 +236,7 @@ func (dr *RegistryManager) LoadRunningApplies() []*RegistryRow {
 		}
 		// update reload time to calculate progress dead line correctly the deployment
 		row.reloadRestartTime = time.Now().Unix()
-		go row.isFinish(dr.checkFinishDelay)
+		go row.isFinish(dr.checkFinishDelay, dr.checkFinishInterval)
 		dr.registryData[encodedID] = &row
 
 		rows = append(rows, &row)
@@ -156,8 +251,12 @@ func (dr *RegistryManager) NewApplyEvent(data ApplyEvent) *RegistryRow {
 	defer dr.applyLock.Unlock()
 
 	var appRegistry *RegistryRow
+	var specChanges []SpecChange
 
-	if data.Event == fmt.Sprintf("%v", eventwatch.Deleted) {
+	// A deleted resource is handled before the filter, the resource may no longer match the filter (for example when
+	// its annotation was removed) and its applied version and running apply still need to be cleaned
+	deleted := data.Event == fmt.Sprintf("%v", eventwatch.Deleted)
+	if deleted {
 		// Check if the resource already detected in StatusBasy
 		appRegistry = dr.Get(data.ApplyName, data.Namespace, data.Event)
 
@@ -176,10 +275,39 @@ func (dr *RegistryManager) NewApplyEvent(data ApplyEvent) *RegistryRow {
 			lg.Info("apply was canceled, got delete event")
 			go runningApply.Stop(common.ApplyCanceled, common.ApplyStatusDescriptionCanceled)
 		}
-	} else {
+	}
+
+	// Filtered resources are not tracked, and never create an apply
+	if match, reason := dr.filter.Match(data); !match {
+		log.WithFields(log.Fields{
+			"resource_name": data.ResourceName,
+			"namespace":     data.Namespace,
+			"cluster":       dr.clusterName,
+			"resource_kind": data.Kind,
+			"reason":        reason,
+		}).Debug("resource was filtered")
+		return nil
+	}
+
+	if !deleted {
 
 		appRegistry = dr.Get(data.ApplyName, data.Namespace, "")
-		if !dr.updateAppliesVersionHistory(data.ResourceName, data.Namespace, data.Kind, data.Hash) {
+		newVersion := dr.updateAppliesVersionHistory(data.ResourceName, data.Namespace, data.Kind, data.Hash, data.legacyHash())
+
+		// The replicas are not part of the apply hash, so a scale without a spec change is recorded
+		// as a scale event of the application instead of a new apply
+		if data.Replicas != nil {
+			previousReplicas, scaled := dr.updateAppliedReplicas(data.ResourceName, data.Namespace, data.Kind, *data.Replicas)
+			if scaled && !newVersion {
+				dr.newScaleEvent(data, previousReplicas)
+				return nil
+			}
+			if scaled {
+				specChanges = append(specChanges, replicasSpecChange(previousReplicas, *data.Replicas))
+			}
+		}
+
+		if !newVersion {
 			log.WithFields(log.Fields{
 				"resource_name": data.ResourceName,
 				"namespace":     data.Namespace,
@@ -188,23 +316,69 @@ func (dr *RegistryManager) NewApplyEvent(data ApplyEvent) *RegistryRow {
 			}).Debug("resource already deployed")
 			return nil
 		}
+
+		// A new version of a resource that the running apply already tracks is a new apply, the running apply
+		// is closed as superseded. An apply that already finished and only collects data is left to finish. Other
+		// resources are added to the apply, also when it only collects data
+		if appRegistry != nil && appRegistry.hasResource(data.Kind, data.ResourceName) {
+			appRegistry = dr.supersede(appRegistry, data)
+		}
 	}
 
 	// If apply not found in memory (first event of the apply), we needs to create new application apply
 	// for create new strcut that include all the resources
 	if appRegistry == nil {
 		status := common.ApplyStatusRunning
-		if data.Event == fmt.Sprintf("%v", eventwatch.Deleted) {
+		if deleted {
 			status = common.ApplyStatusDeleted
 		}
 
 		appRegistry = dr.NewApplication(data.ApplyName, data.Namespace, data.Annotations, status)
 	}
 
+	// The spec diff of the resource starts from the replicas change, the pod template changes are added by the resource manager
+	if !deleted {
+		appRegistry.setSpecDiff(data.Kind, data.ResourceName, specChanges)
+	}
+
 	return appRegistry
 
 }
 
+// supersede starts a new apply instead of the given apply. The given apply keeps its registry row until it is
+// saved, and is stopped as superseded when it is still running
+func (dr *RegistryManager) supersede(previous *RegistryRow, data ApplyEvent) *RegistryRow {
+
+	encodedID := generateID(data.ApplyName, data.Namespace, dr.clusterName)
+	delete(dr.registryData, encodedID)
+	dr.registryData[fmt.Sprintf("superseded-%s-%s", previous.GetApplyID(), encodedID)] = previous
+
+	appRegistry := dr.NewApplication(data.ApplyName, data.Namespace, data.Annotations, common.ApplyStatusRunning)
+
+	// The apply ID is generated from the creation time, so both applies must not be created in the same second
+	if appRegistry.DBSchema.CreationTimestamp <= previous.DBSchema.CreationTimestamp {
+		appRegistry.DBSchema.CreationTimestamp = previous.DBSchema.CreationTimestamp + 1
+		appRegistry.DBSchema.WatchHealth.setApplyCreationTime(appRegistry.DBSchema.CreationTimestamp)
+	}
+
+	lg := previous.Log()
+	if previous.beforeFinish {
+		lg.WithField("next_apply_id", appRegistry.GetApplyID()).Info("apply finished before a new version was applied, starting a new apply")
+		return appRegistry
+	}
+
+	previous.DBSchema.SupersededBy = appRegistry.GetApplyID()
+	appRegistry.DBSchema.Supersedes = previous.GetApplyID()
+	lg.WithFields(log.Fields{
+		"resource_name": data.ResourceName,
+		"resource_kind": data.Kind,
+		"superseded_by": appRegistry.GetApplyID(),
+	}).Info("apply was superseded, a new version was applied")
+	go previous.Stop(common.ApplyStatusSuperseded, common.ApplyStatusDescriptionSuperseded)
+
+	return appRegistry
+}
+
 // NewApplication will creates a new deployment row
 func (dr *RegistryManager) NewApplication(appName string, namespace string, annotations map[string]string, status common.DeploymentStatus) *RegistryRow {
 
@@ -233,7 +407,13 @@ func (dr *RegistryManager) NewApplication(appName string, namespace string, anno
 				Deployments:  make(map[string]*DeploymentData),
 				Daemonsets:   make(map[string]*DaemonsetData),
 				Statefulsets: make(map[string]*StatefulsetData),
+				Jobs:         make(map[string]*JobData),
+
+				CustomResources: make(map[string]*CustomResourceData),
+				TrafficSwitches: make(map[string]*TrafficSwitchData),
 			},
+			WatchHealth: &WatchHealth{applyCreationTime: time.Unix(deployTime, 0)},
+			SpecDiffs:   []SpecDiff{},
 		},
 	}
 
@@ -250,7 +430,7 @@ func (dr *RegistryManager) NewApplication(appName string, namespace string, anno
 
 	lg.Info("new application created in registry")
 
-	go row.isFinish(dr.checkFinishDelay)
+	go row.isFinish(dr.checkFinishDelay, dr.checkFinishInterval)
 	return &row
 
 }
@@ -283,6 +463,85 @@ func (wbr *RegistryRow) Log() log.Entry {
 	return *lg
 }
 
+// SetRollback marks the apply as a rollback of the given resource to an earlier revision
+func (wbr *RegistryRow) SetRollback(kind, resourceName string, revision int64) {
+	lg := wbr.Log()
+	lg.WithFields(log.Fields{
+		"resource_kind": kind,
+		"resource_name": resourceName,
+		"revision":      revision,
+	}).Info("apply was detected as a rollback")
+
+	wbr.DBSchema.Rollback = &ApplyRollback{
+		Kind:         kind,
+		ResourceName: resourceName,
+		Revision:     revision,
+	}
+}
+
+// setSpecDiff replaces the spec changes of the resource in the apply
+func (wbr *RegistryRow) setSpecDiff(kind, resourceName string, changes []SpecChange) {
+	if changes == nil {
+		changes = []SpecChange{}
+	}
+	for i, diff := range wbr.DBSchema.SpecDiffs {
+		if diff.Kind == kind && diff.ResourceName == resourceName {
+			wbr.DBSchema.SpecDiffs[i].Changes = changes
+			return
+		}
+	}
+	wbr.DBSchema.SpecDiffs = append(wbr.DBSchema.SpecDiffs, SpecDiff{
+		Kind:         kind,
+		ResourceName: resourceName,
+		Changes:      changes,
+	})
+}
+
+// AddSpecChanges adds changes to the spec diff of the resource in the apply
+func (wbr *RegistryRow) AddSpecChanges(kind, resourceName string, changes []SpecChange) {
+	for i, diff := range wbr.DBSchema.SpecDiffs {
+		if diff.Kind == kind && diff.ResourceName == resourceName {
+			wbr.DBSchema.SpecDiffs[i].Changes = append(wbr.DBSchema.SpecDiffs[i].Changes, changes...)
+			return
+		}
+	}
+	wbr.setSpecDiff(kind, resourceName, changes)
+}
+
+// hasResource returns true when the resource is already tracked by the apply, every resource of the apply has a spec diff.
+// Applies that were saved before the spec diffs were tracked are checked by their resources
+func (wbr *RegistryRow) hasResource(kind, resourceName string) bool {
+	for _, diff := range wbr.DBSchema.SpecDiffs {
+		if diff.Kind == kind && diff.ResourceName == resourceName {
+			return true
+		}
+	}
+
+	var found bool
+	resources := wbr.DBSchema.Resources
+	switch kind {
+	case "deployment":
+		_, found = resources.Deployments[resourceName]
+	case "daemonset":
+		_, found = resources.Daemonsets[resourceName]
+	case "statefulset":
+		_, found = resources.Statefulsets[resourceName]
+	case "job":
+		_, found = resources.Jobs[resourceName]
+	default:
+		_, found = resources.CustomResources[resourceName]
+	}
+	return found
+}
+
+// getSupersededByURI returns the uri of the apply that replaced the apply, empty when the apply was not superseded
+func (wbr *RegistryRow) getSupersededByURI() string {
+	if wbr.DBSchema.SupersededBy == "" {
+		return ""
+	}
+	return fmt.Sprintf("application/%s", wbr.DBSchema.SupersededBy)
+}
+
 // GetApplyID generate a uniqe for a specific apply
 func (wbr *RegistryRow) GetApplyID() string {
 
@@ -336,14 +595,26 @@ func (wbr *RegistryRow) isDeploymentFinish() (bool, error) {
 	countOfRunningReplicas := 0
 	var desiredStateCount int32
 	var readyReplicasCount int32
-	for _, deployment := range wbr.DBSchema.Resources.Deployments {
+	criteriaMet := true
+	for name, deployment := range wbr.DBSchema.Resources.Deployments {
 		desiredStateCount = desiredStateCount + deployment.Deployment.DesiredState
+		var deploymentReadyReplicas int32
 		for _, replica := range deployment.Replicaset {
 			if replica.Status.Replicas > 0 {
 				countOfRunningReplicas = countOfRunningReplicas + 1
 			}
-			readyReplicasCount = readyReplicasCount + replica.Status.ReadyReplicas
+			deploymentReadyReplicas = deploymentReadyReplicas + replica.Status.ReadyReplicas
+		}
+		// The success criteria decide when the deployment pods are ready instead of the replicasets ready replicas
+		if criteria := deployment.Deployment.SuccessCriteria; criteria != nil {
+			met, err := wbr.checkSuccessCriteria("deployment", name, criteria, deployment.Pods, deployment.Deployment.DesiredState)
+			if err != nil {
+				return isFinished, err
+			}
+			criteriaMet = criteriaMet && met
+			deploymentReadyReplicas = deployment.Deployment.DesiredState
 		}
+		readyReplicasCount = readyReplicasCount + deploymentReadyReplicas
 		if wbr.isWithinProgressDeadline(deployment.ProgressDeadlineSeconds) {
 			lg.WithFields(log.Fields{
 				"progress_deadline_seconds": deployment.ProgressDeadlineSeconds,
@@ -360,7 +631,7 @@ func (wbr *RegistryRow) isDeploymentFinish() (bool, error) {
 	}).Info("deployment status")
 
 	deploymentsNum := len(wbr.DBSchema.Resources.Deployments)
-	if deploymentsNum == countOfRunningReplicas && desiredStateCount == readyReplicasCount || wbr.status == common.ApplyStatusDeleted {
+	if deploymentsNum == countOfRunningReplicas && desiredStateCount == readyReplicasCount && criteriaMet || wbr.status == common.ApplyStatusDeleted {
 		lg.WithFields(log.Fields{
 			"replicaset_count":     countOfRunningReplicas,
 			"desired_state_count":  desiredStateCount,
@@ -374,6 +645,9 @@ func (wbr *RegistryRow) isDeploymentFinish() (bool, error) {
 }
 
 //isDaemonSetFinish  a DaemonSet is finished if: DesiredNumberScheduled == CurrentNumberScheduled AND DesiredNumberScheduled == UpdatedNumberScheduled
+/* A DaemonSet with the OnDelete update strategy replaces its pods only after they are deleted, when the progress deadline
+passed and all of its pods are ready but not updated the apply is awaiting a manual pod deletion instead of failed.
+*/
 func (wbr *RegistryRow) isDaemonSetFinish() (bool, error) {
 	lg := wbr.Log()
 	isFinished := false
@@ -384,12 +658,26 @@ func (wbr *RegistryRow) isDaemonSetFinish() (bool, error) {
 	totalDesiredPods := int32(0)
 	totalUpdatedPodsOnNodes := int32(0)
 	totalCurrentPods := int32(0)
-	for _, daemonset := range wbr.DBSchema.Resources.Daemonsets {
+	awaitingPodDeletion := false
+	criteriaMet := true
+	for name, daemonset := range wbr.DBSchema.Resources.Daemonsets {
 		totalDesiredPods = totalDesiredPods + daemonset.Status.DesiredNumberScheduled
-		totalUpdatedPodsOnNodes = totalUpdatedPodsOnNodes + daemonset.Status.DesiredNumberScheduled
+		totalUpdatedPodsOnNodes = totalUpdatedPodsOnNodes + daemonset.Status.UpdatedNumberScheduled
 		totalCurrentPods = totalCurrentPods + daemonset.Status.CurrentNumberScheduled
 
+		if criteria := daemonset.Metadata.SuccessCriteria; criteria != nil {
+			met, err := wbr.checkSuccessCriteria("daemonset", name, criteria, daemonset.Pods, daemonset.Status.DesiredNumberScheduled)
+			if err != nil {
+				return isFinished, err
+			}
+			criteriaMet = criteriaMet && met
+		}
+
 		if wbr.isWithinProgressDeadline(daemonset.ProgressDeadlineSeconds) {
+			if daemonset.isAwaitingPodDeletion() {
+				awaitingPodDeletion = true
+				continue
+			}
 			lg.WithFields(log.Fields{
 				"progress_deadline_seconds": daemonset.ProgressDeadlineSeconds,
 				"deploy_time":               wbr.getDeploymentDiff(daemonset.ProgressDeadlineSeconds),
@@ -400,9 +688,10 @@ func (wbr *RegistryRow) isDaemonSetFinish() (bool, error) {
 	lg.WithFields(log.Fields{
 		"total_daemonsets_desired_pods": totalDesiredPods,
 		"current_pods_count":            totalCurrentPods,
+		"updated_pods_count":            totalUpdatedPodsOnNodes,
 		"total_daemonsets":              len(wbr.DBSchema.Resources.Daemonsets),
 	}).Debug("daemonset status")
-	if totalDesiredPods == totalCurrentPods && totalDesiredPods == totalUpdatedPodsOnNodes || wbr.status == common.ApplyStatusDeleted {
+	if totalDesiredPods == totalCurrentPods && totalDesiredPods == totalUpdatedPodsOnNodes && criteriaMet || wbr.status == common.ApplyStatusDeleted {
 		lg.WithFields(log.Fields{
 			"total_daemonsets_desired_pods": totalDesiredPods,
 			"current_pods_count":            totalCurrentPods,
@@ -412,13 +701,20 @@ func (wbr *RegistryRow) isDaemonSetFinish() (bool, error) {
 		isFinished = true
 		return isFinished, nil
 	}
+	if awaitingPodDeletion {
+		lg.WithField("updated_pods_count", totalUpdatedPodsOnNodes).Warn("daemonset is awaiting manual pod deletion")
+		return isFinished, errAwaitingPodDeletion
+	}
 	return isFinished, nil
 }
 
 // isStatefulSetFinish defines when a deployment of Statefulset id done.
 /* In order to finish a successful deployment you will have to have the following terms:
 - Total Pods defined in statefulset yaml should be equal to ready pods running.
-- Counts of pods which are committed to the state should be equal to running pods running.
+- Counts of pods on the updated revision should be equal to the running pods that are expected to be updated.
+With a rolling update partition only the pods with an ordinal at or above the partition are expected to be updated.
+With the OnDelete update strategy the pods are updated only after they are deleted, when the progress deadline passed and
+all of the pods are ready but not updated the apply is awaiting a manual pod deletion instead of failed.
 */
 func (wbr *RegistryRow) isStatefulSetFinish() (bool, error) {
 	lg := wbr.Log()
@@ -427,17 +723,39 @@ func (wbr *RegistryRow) isStatefulSetFinish() (bool, error) {
 		isFinished = true
 		return isFinished, nil
 	}
-	var countOfPodsInState int32
+	var countOfUpdatedPods int32
+	var countOfExpectedUpdatedPods int32
+	allUpdated := true
 	var countOfRunningPods int32
 	var totalDesiredPods int32
 	var readyPodsCount int32
-	for _, statefulset := range wbr.DBSchema.Resources.Statefulsets {
-		totalDesiredPods = statefulset.Statefulset.DesiredState
+	awaitingPodDeletion := false
+	criteriaMet := true
+	for name, statefulset := range wbr.DBSchema.Resources.Statefulsets {
+		totalDesiredPods = totalDesiredPods + statefulset.Statefulset.DesiredState
 		countOfRunningPods = countOfRunningPods + statefulset.Status.Replicas
-		readyPodsCount = readyPodsCount + statefulset.Status.ReadyReplicas
-		countOfPodsInState = int32(len(statefulset.Pods))
+
+		// The success criteria decide when the statefulset pods are ready instead of the statefulset ready replicas
+		if criteria := statefulset.Statefulset.SuccessCriteria; criteria != nil {
+			met, err := wbr.checkSuccessCriteria("statefulset", name, criteria, statefulset.Pods, statefulset.Statefulset.DesiredState)
+			if err != nil {
+				return isFinished, err
+			}
+			criteriaMet = criteriaMet && met
+			readyPodsCount = readyPodsCount + statefulset.Statefulset.DesiredState
+		} else {
+			readyPodsCount = readyPodsCount + statefulset.Status.ReadyReplicas
+		}
+		// The pods map holds the old revision pods as well, the statefulset status counts only the updated revision pods
+		countOfUpdatedPods = countOfUpdatedPods + statefulset.Status.UpdatedReplicas
+		countOfExpectedUpdatedPods = countOfExpectedUpdatedPods + statefulset.expectedUpdatedReplicas()
+		allUpdated = allUpdated && statefulset.Status.UpdatedReplicas >= statefulset.expectedUpdatedReplicas()
 
 		if wbr.isWithinProgressDeadline(statefulset.ProgressDeadlineSeconds) {
+			if statefulset.isAwaitingPodDeletion() {
+				awaitingPodDeletion = true
+				continue
+			}
 			lg.WithFields(log.Fields{
 				"progress_deadline_seconds": statefulset.ProgressDeadlineSeconds,
 				"deploy_time":               wbr.getDeploymentDiff(statefulset.ProgressDeadlineSeconds),
@@ -446,27 +764,208 @@ func (wbr *RegistryRow) isStatefulSetFinish() (bool, error) {
 		}
 	}
 	lg.WithFields(log.Fields{
-		"total_statefulsets_desired_pods":  totalDesiredPods,
-		"total_statefulsets_in_state_pods": countOfPodsInState,
-		"current_pods_count":               countOfRunningPods,
-		"total_statefulsets":               len(wbr.DBSchema.Resources.Statefulsets),
+		"total_statefulsets_desired_pods": totalDesiredPods,
+		"updated_pods_count":              countOfUpdatedPods,
+		"expected_updated_pods_count":     countOfExpectedUpdatedPods,
+		"current_pods_count":              countOfRunningPods,
+		"total_statefulsets":              len(wbr.DBSchema.Resources.Statefulsets),
 	}).Info("statefulset status")
-	if totalDesiredPods == readyPodsCount && countOfPodsInState == countOfRunningPods || wbr.status == common.ApplyStatusDeleted {
+	if totalDesiredPods == readyPodsCount && allUpdated && criteriaMet || wbr.status == common.ApplyStatusDeleted {
 		lg.WithFields(log.Fields{
-			"total_statefulset_desired_pods":   totalDesiredPods,
-			"total_statefulsets_in_state_pods": countOfPodsInState,
-			"current_pods_count":               countOfRunningPods,
-			"total_statefulsets":               len(wbr.DBSchema.Resources.Statefulsets),
+			"total_statefulset_desired_pods": totalDesiredPods,
+			"updated_pods_count":             countOfUpdatedPods,
+			"current_pods_count":             countOfRunningPods,
+			"total_statefulsets":             len(wbr.DBSchema.Resources.Statefulsets),
 		}).Info("statefulset has finished successfully")
 		// Wating few minutes to collect more event after deployment finished
 		isFinished = true
 		return isFinished, nil
 	}
+	if awaitingPodDeletion {
+		lg.WithField("updated_pods_count", countOfUpdatedPods).Warn("statefulset is awaiting manual pod deletion")
+		return isFinished, errAwaitingPodDeletion
+	}
+	return isFinished, nil
+}
+
+// checkSuccessCriteria evaluates the success criteria of a resource. It returns false while not enough pods are available,
+// and errSuccessCriteriaFailed when one of the containers restarted more than the tolerated restarts
+func (wbr *RegistryRow) checkSuccessCriteria(kind, name string, criteria *SuccessCriteria, pods map[string]DeploymenPod, desired int32) (bool, error) {
+	baseLog := wbr.Log()
+	lg := baseLog.WithFields(log.Fields{"kind": kind, "name": name})
+	if reason := criteria.exceededRestartsReason(pods); reason != "" {
+		lg.WithField("reason", reason).Error("resource failed due to success criteria")
+		return false, errSuccessCriteriaFailed
+	}
+	if reason := criteria.unavailableReason(pods, desired, time.Now()); reason != "" {
+		lg.WithField("reason", reason).Debug("resource didn't meet the success criteria yet")
+		return false, nil
+	}
+	return true, nil
+}
+
+// hasPartitionedRollout returns true when one of the apply statefulsets updates only the pods above a partition
+func (wbr *RegistryRow) hasPartitionedRollout() bool {
+	for _, statefulset := range wbr.DBSchema.Resources.Statefulsets {
+		if statefulset.Partition > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// isJobFinish defines when a Job apply is done.
+/* A job apply finished successfully when the job is complete, the count of succeeded pods is equal to the job completions
+or, for a work queue job, one of the pods succeeded and none of the pods are active.
+A job apply failed when the job has the failed condition, the backoff limit or the active deadline was exceeded.
+*/
+func (wbr *RegistryRow) isJobFinish() (bool, error) {
+	lg := wbr.Log()
+	isFinished := false
+	if len(wbr.DBSchema.Resources.Jobs) == 0 {
+		isFinished = true
+		return isFinished, nil
+	}
+	var totalCompletions int32
+	var totalSucceeded int32
+	completedJobs := 0
+	for _, job := range wbr.DBSchema.Resources.Jobs {
+		totalCompletions = totalCompletions + job.Metadata.DesiredState
+		totalSucceeded = totalSucceeded + job.Status.Succeeded
+
+		// The job controller sets the failed condition when the backoff limit or the active deadline was exceeded
+		if failed, reason := job.failedCondition(); failed {
+			lg.WithFields(log.Fields{
+				"job":           job.GetName(),
+				"failed_pods":   job.Status.Failed,
+				"backoff_limit": job.BackoffLimit,
+				"reason":        reason,
+			}).Error("job failed")
+			if reason == jobDeadlineExceededReason {
+				return isFinished, errJobDeadlineExceeded
+			}
+			return isFinished, errBackoffLimitExceeded
+		}
+		if job.isComplete() {
+			completedJobs = completedJobs + 1
+		}
+
+		if wbr.isWithinProgressDeadline(job.ProgressDeadlineSeconds) {
+			lg.WithFields(log.Fields{
+				"progress_deadline_seconds": job.ProgressDeadlineSeconds,
+				"deploy_time":               wbr.getDeploymentDiff(job.ProgressDeadlineSeconds),
+			}).Error("job failed due to progress deadline")
+			return isFinished, errors.New("ProgressDeadLine has passed")
+		}
+	}
+	lg.WithFields(log.Fields{
+		"total_jobs_completions": totalCompletions,
+		"total_jobs_succeeded":   totalSucceeded,
+		"completed_jobs":         completedJobs,
+		"total_jobs":             len(wbr.DBSchema.Resources.Jobs),
+	}).Info("job status")
+	if completedJobs == len(wbr.DBSchema.Resources.Jobs) || wbr.status == common.ApplyStatusDeleted {
+		lg.WithFields(log.Fields{
+			"total_jobs_completions": totalCompletions,
+			"total_jobs_succeeded":   totalSucceeded,
+			"total_jobs":             len(wbr.DBSchema.Resources.Jobs),
+		}).Info("job has finished successfully")
+		isFinished = true
+		return isFinished, nil
+	}
+	return isFinished, nil
+}
+
+// isCustomResourceFinish defines when a custom resource apply is done.
+/* A custom resource apply finished successfully when all the custom resources status matched the succeeded rule.
+A custom resource apply failed when one of the custom resources status matched the failed rule.
+*/
+func (wbr *RegistryRow) isCustomResourceFinish() (bool, error) {
+	lg := wbr.Log()
+	isFinished := false
+	if len(wbr.DBSchema.Resources.CustomResources) == 0 {
+		isFinished = true
+		return isFinished, nil
+	}
+	succeeded := 0
+	for _, customResource := range wbr.DBSchema.Resources.CustomResources {
+		if customResource.Phase == CustomResourcePhaseFailed {
+			lg.WithFields(log.Fields{
+				"custom_resource": customResource.GetName(),
+				"kind":            customResource.Kind,
+			}).Error("custom resource failed due to status rule")
+			return isFinished, errCustomResourceFailed
+		}
+		if customResource.Phase == CustomResourcePhaseSucceeded {
+			succeeded = succeeded + 1
+		}
+
+		if wbr.isWithinProgressDeadline(customResource.ProgressDeadlineSeconds) {
+			lg.WithFields(log.Fields{
+				"progress_deadline_seconds": customResource.ProgressDeadlineSeconds,
+				"deploy_time":               wbr.getDeploymentDiff(customResource.ProgressDeadlineSeconds),
+			}).Error("custom resource failed due to progress deadline")
+			return isFinished, errors.New("ProgressDeadLine has passed")
+		}
+	}
+	lg.WithFields(log.Fields{
+		"total_custom_resources_succeeded": succeeded,
+		"total_custom_resources":           len(wbr.DBSchema.Resources.CustomResources),
+	}).Info("custom resource status")
+	if succeeded == len(wbr.DBSchema.Resources.CustomResources) || wbr.status == common.ApplyStatusDeleted {
+		lg.WithFields(log.Fields{
+			"total_custom_resources_succeeded": succeeded,
+			"total_custom_resources":           len(wbr.DBSchema.Resources.CustomResources),
+		}).Info("custom resource has finished successfully")
+		isFinished = true
+		return isFinished, nil
+	}
+	return isFinished, nil
+}
+
+// isTrafficSwitchFinish defines when a traffic switch apply is done.
+/* A traffic switch apply finished successfully when the endpoints of all the switched services flipped to the selected pods.
+A traffic switch apply failed when the endpoints didn't flip until the progress deadline.
+*/
+func (wbr *RegistryRow) isTrafficSwitchFinish() (bool, error) {
+	lg := wbr.Log()
+	isFinished := false
+	if len(wbr.DBSchema.Resources.TrafficSwitches) == 0 {
+		isFinished = true
+		return isFinished, nil
+	}
+	flipped := 0
+	for _, trafficSwitch := range wbr.DBSchema.Resources.TrafficSwitches {
+		if trafficSwitch.Switch.EndpointsFlipped > 0 {
+			flipped = flipped + 1
+			continue
+		}
+
+		if wbr.isWithinProgressDeadline(trafficSwitch.ProgressDeadlineSeconds) {
+			lg.WithFields(log.Fields{
+				"progress_deadline_seconds": trafficSwitch.ProgressDeadlineSeconds,
+				"deploy_time":               wbr.getDeploymentDiff(trafficSwitch.ProgressDeadlineSeconds),
+			}).Error("traffic switch failed due to progress deadline")
+			return isFinished, errors.New("ProgressDeadLine has passed")
+		}
+	}
+	lg.WithFields(log.Fields{
+		"total_traffic_switches_flipped": flipped,
+		"total_traffic_switches":         len(wbr.DBSchema.Resources.TrafficSwitches),
+	}).Info("traffic switch status")
+	if flipped == len(wbr.DBSchema.Resources.TrafficSwitches) || wbr.status == common.ApplyStatusDeleted {
+		lg.WithFields(log.Fields{
+			"total_traffic_switches_flipped": flipped,
+			"total_traffic_switches":         len(wbr.DBSchema.Resources.TrafficSwitches),
+		}).Info("traffic switch has finished successfully")
+		isFinished = true
+		return isFinished, nil
+	}
 	return isFinished, nil
 }
 
 // isFinish will check (by interval number) when the deployment finished by replicaset status
-func (wbr *RegistryRow) isFinish(checkFinishDelay time.Duration) {
+func (wbr *RegistryRow) isFinish(checkFinishDelay time.Duration, checkFinishInterval time.Duration) {
 	ctx, cancelFn := context.WithCancel(context.Background())
 	defer cancelFn()
 	lg := wbr.Log()
@@ -474,6 +973,9 @@ func (wbr *RegistryRow) isFinish(checkFinishDelay time.Duration) {
 		"deployment_count":   len(wbr.DBSchema.Resources.Deployments),
 		"daemonsets_count":   len(wbr.DBSchema.Resources.Daemonsets),
 		"statefulsets_count": len(wbr.DBSchema.Resources.Statefulsets),
+		"jobs_count":         len(wbr.DBSchema.Resources.Jobs),
+		"custom_resources":   len(wbr.DBSchema.Resources.CustomResources),
+		"traffic_switches":   len(wbr.DBSchema.Resources.TrafficSwitches),
 		"applied_by":         wbr.DBSchema.DeployBy,
 		"check_delay":        checkFinishDelay,
 	}).Debug("starting to watch on registry row to check if all resources status")
@@ -485,23 +987,55 @@ func (wbr *RegistryRow) isFinish(checkFinishDelay time.Duration) {
 	}
 	for {
 		select {
-		case <-time.After(time.Second * 2):
-			if wbr.finish {
+		case <-time.After(checkFinishInterval):
+			// The apply was stopped by a cancel or a newer apply
+			if wbr.finish || wbr.beforeFinish {
 				return
 			}
 			isDepFinished, depErr := wbr.isDeploymentFinish()
 			isDsFinished, dsErr := wbr.isDaemonSetFinish()
 			isSsFinished, ssErr := wbr.isStatefulSetFinish()
-			if dsErr != nil || depErr != nil || ssErr != nil {
-				wbr.Stop(common.ApplyStatusFailed, common.ApplyStatusDescriptionProgressDeadline)
+			isJobFinished, jobErr := wbr.isJobFinish()
+			isCrFinished, crErr := wbr.isCustomResourceFinish()
+			isTsFinished, tsErr := wbr.isTrafficSwitchFinish()
+
+			// OnDelete resources that are waiting for their pods to be deleted don't fail the apply
+			awaitingPodDeletion := dsErr == errAwaitingPodDeletion || ssErr == errAwaitingPodDeletion
+			if dsErr == errAwaitingPodDeletion {
+				isDsFinished, dsErr = true, nil
+			}
+			if ssErr == errAwaitingPodDeletion {
+				isSsFinished, ssErr = true, nil
+			}
+			if dsErr != nil || depErr != nil || ssErr != nil || jobErr != nil || crErr != nil || tsErr != nil {
+				description := common.ApplyStatusDescriptionProgressDeadline
+				if jobErr == errBackoffLimitExceeded {
+					description = common.ApplyStatusDescriptionBackoffLimit
+				} else if crErr == errCustomResourceFailed {
+					description = common.ApplyStatusDescriptionCustomResourceFailed
+				} else if jobErr == errJobDeadlineExceeded {
+					description = common.ApplyStatusDescriptionJobDeadline
+				} else if depErr == errSuccessCriteriaFailed || dsErr == errSuccessCriteriaFailed || ssErr == errSuccessCriteriaFailed {
+					description = common.ApplyStatusDescriptionSuccessCriteria
+				}
+				wbr.Stop(common.ApplyStatusFailed, description)
 				lg.WithFields(log.Fields{
 					"deployment_error":  depErr,
 					"daemonset_error":   dsErr,
 					"statefulset_error": ssErr,
+					"job_error":         jobErr,
+					"custom_error":      crErr,
+					"traffic_error":     tsErr,
 				}).Error("isFinish function watcher had an error")
 				return
-			} else if isDepFinished && isDsFinished && isSsFinished {
-				wbr.Stop(common.ApplySuccessful, common.ApplyStatusDescriptionSuccessful)
+			} else if isDepFinished && isDsFinished && isSsFinished && isJobFinished && isCrFinished && isTsFinished {
+				if awaitingPodDeletion {
+					wbr.Stop(common.ApplyStatusAwaitingPodDeletion, common.ApplyStatusDescriptionAwaitingPodDeletion)
+				} else if wbr.hasPartitionedRollout() {
+					wbr.Stop(common.ApplySuccessful, common.ApplyStatusDescriptionPartitioned)
+				} else {
+					wbr.Stop(common.ApplySuccessful, common.ApplyStatusDescriptionSuccessful)
+				}
 				return
 			}
 		case <-ctx.Done():
@@ -520,6 +1054,14 @@ func (wbr *RegistryRow) Stop(status common.DeploymentStatus, message common.Depl
 	wbr.beforeFinish = true
 	time.Sleep(wbr.collectDataAfterDeploymentFinish)
 	wbr.DBSchema.DeploymentDescription = message
+
+	wbr.DBSchema.RolloutPhases = rolloutPhases(time.Unix(wbr.DBSchema.CreationTimestamp, 0), wbr.DBSchema.Resources)
+
+	// The failure causes are analyzed after the data collection, so the latest events are included
+	if status == common.ApplyStatusFailed {
+		wbr.DBSchema.FailureCauses = analyzeFailure(wbr.DBSchema.Resources)
+		lg.WithField("failure_causes", len(wbr.DBSchema.FailureCauses)).Info("apply failure was analyzed")
+	}
 	wbr.finish = true
 	wbr.status = status
 	wbr.cancelFn()
@@ -532,7 +1074,7 @@ func (dd *DeploymentData) UpdateDeploymentStatus(status appsV1.DeploymentStatus)
 
 // UpdateDeploymentEvents will append events to deployment
 func (dd *DeploymentData) UpdateDeploymentEvents(event EventMessages) {
-	dd.Events = append(dd.Events, event)
+	dd.Events = appendEvent(dd.Events, event)
 }
 
 // InitReplicaset create new list of replicaset
@@ -550,7 +1092,7 @@ func (dd *DeploymentData) UpdateReplicasetEvents(name string, event EventMessage
 	if _, found := dd.Replicaset[name]; !found {
 		return errors.New("replicaset not found")
 	}
-	*dd.Replicaset[name].Events = append(*dd.Replicaset[name].Events, event)
+	*dd.Replicaset[name].Events = appendEvent(*dd.Replicaset[name].Events, event)
 
 	return nil
 }
@@ -572,38 +1114,58 @@ func NewPodToPods(pods map[string]DeploymenPod, pod *v1.Pod) error {
 	}
 	phase := string(pod.Status.Phase)
 	pods[pod.GetName()] = DeploymenPod{
-		Phase:  &phase,
-		Events: &[]EventMessages{},
-		Pvcs:   map[string][]EventMessages{},
+		Phase:             &phase,
+		CreationTimestamp: pod.GetCreationTimestamp().Time,
+		NodeName:          pod.Spec.NodeName,
+		PodIP:             pod.Status.PodIP,
+		QOSClass:          string(pod.Status.QOSClass),
+		Events:            &[]EventMessages{},
+		Pvcs:              map[string][]EventMessages{},
+		Logs:              map[string]ContainerLogs{},
+		Timeline:          newPodTimeline(pod),
+		Containers:        podContainerStatuses(pod),
 	}
 	return nil
 }
 
-// UpdatePodEvents will add event to pod events list
+// UpdatePodEvents will add event to pod events list, a repeated event is merged into the existing event
 func UpdatePodEvents(pods map[string]DeploymenPod, podName string, pvcName string, event EventMessages) error {
 	if _, found := pods[podName]; !found {
 		log.WithField("pod", podName).Warn("pod does not exist in pod list")
 		return errors.New("pod does not exist in pod list")
 	}
-	// Validate that we not inset duplicated events
-	for _, saveEvent := range *pods[podName].Events {
-		if saveEvent.Message == event.Message && saveEvent.Time == event.Time {
-			return nil
-		}
+	if pvcName == "" && pods[podName].Timeline != nil {
+		pods[podName].Timeline.updateEvent(event)
 	}
 
 	if pvcName != "" {
 		if _, found := pods[podName].Pvcs[pvcName]; !found {
 			pods[podName].Pvcs[pvcName] = []EventMessages{}
 		}
-		pods[podName].Pvcs[pvcName] = append(pods[podName].Pvcs[pvcName], event)
+		pods[podName].Pvcs[pvcName] = appendEvent(pods[podName].Pvcs[pvcName], event)
 	} else {
-		*pods[podName].Events = append(*pods[podName].Events, event)
+		*pods[podName].Events = appendEvent(*pods[podName].Events, event)
 	}
 
 	return nil
 }
 
+// UpdatePodLogs will set the log tail of a pod container
+func UpdatePodLogs(pods map[string]DeploymenPod, podName string, containerName string, logs ContainerLogs) error {
+	pod, found := pods[podName]
+	if !found {
+		log.WithField("pod", podName).Warn("pod does not exist in pod list")
+		return errors.New("pod does not exist in pod list")
+	}
+	// Pods of applies that were saved before the logs were collected don't have a logs map
+	if pod.Logs == nil {
+		pod.Logs = map[string]ContainerLogs{}
+		pods[podName] = pod
+	}
+	pod.Logs[containerName] = logs
+	return nil
+}
+
 // UpdatePodStatus will change pod status
 func UpdatePodStatus(pods map[string]DeploymenPod, pod *v1.Pod, status string) error {
 	if _, found := pods[pod.GetName()]; !found {
@@ -611,6 +1173,20 @@ func UpdatePodStatus(pods map[string]DeploymenPod, pod *v1.Pod, status string) e
 		return errors.New("pod does not exist in pod list")
 	}
 	*pods[pod.GetName()].Phase = status
+
+	// Pods of applies that were saved before the timeline was collected don't have a timeline
+	podData := pods[pod.GetName()]
+	if podData.Timeline == nil {
+		podData.Timeline = newPodTimeline(pod)
+	} else {
+		podData.Timeline.update(pod)
+	}
+	// The node and the ip are assigned after the pod was created
+	podData.NodeName = pod.Spec.NodeName
+	podData.PodIP = pod.Status.PodIP
+	podData.QOSClass = string(pod.Status.QOSClass)
+	podData.Containers = podContainerStatuses(pod)
+	pods[pod.GetName()] = podData
 	return nil
 }
 
@@ -621,7 +1197,9 @@ func newService(services map[string]ServicesData, service *v1.Service) error {
 		return errors.New("service already exists in services list")
 	}
 	services[service.GetName()] = ServicesData{
-		Events: &[]EventMessages{},
+		Events:    &[]EventMessages{},
+		Endpoints: []EndpointsReadiness{},
+		Ingresses: map[string]IngressData{},
 	}
 	return nil
 }
@@ -632,13 +1210,102 @@ func updateServiceEvents(services map[string]ServicesData, name string, event Ev
 		log.WithField("service", name).Warn("service does not exist in services list")
 		return errors.New("service does not exist in services list")
 	}
-	// Validate that we not inset duplicated events
-	for _, saveEvent := range *services[name].Events {
-		if saveEvent.Message == event.Message && saveEvent.Time == event.Time {
-			return nil
-		}
+	*services[name].Events = appendEvent(*services[name].Events, event)
+	return nil
+}
+
+// updateServiceEndpoints adds a sample of the service endpoints addresses, the sample is added only when the counts were changed
+func updateServiceEndpoints(services map[string]ServicesData, name string, endpoints *v1.Endpoints) error {
+	service, found := services[name]
+	if !found {
+		log.WithField("service", name).Warn("service does not exist in services list")
+		return errors.New("service does not exist in services list")
+	}
+	// The flip of the last selector change is checked on every change, the addresses counts may not change in a flip
+	if last := len(service.SelectorChanges) - 1; last >= 0 {
+		updateEndpointsFlipped(&service.SelectorChanges[last], endpoints)
+	}
+	ready, notReady := endpointsAddresses(endpoints)
+	if last := len(service.Endpoints) - 1; last >= 0 && service.Endpoints[last].Ready == ready && service.Endpoints[last].NotReady == notReady {
+		return nil
+	}
+	service.Endpoints = append(service.Endpoints, EndpointsReadiness{
+		Time:     time.Now().UnixNano(),
+		Ready:    ready,
+		NotReady: notReady,
+	})
+	services[name] = service
+	return nil
+}
+
+// newServiceIngress adds an ingress that routes to the service
+func newServiceIngress(services map[string]ServicesData, serviceName string, ingressName string) error {
+	service, found := services[serviceName]
+	if !found {
+		log.WithField("service", serviceName).Warn("service does not exist in services list")
+		return errors.New("service does not exist in services list")
+	}
+	// Services of applies that were saved before the ingresses were collected don't have an ingresses map
+	if service.Ingresses == nil {
+		service.Ingresses = map[string]IngressData{}
+		services[serviceName] = service
+	}
+	if _, found := service.Ingresses[ingressName]; found {
+		return errors.New("ingress already exists in service ingresses list")
+	}
+	service.Ingresses[ingressName] = IngressData{
+		Events: &[]EventMessages{},
 	}
-	*services[name].Events = append(*services[name].Events, event)
+	return nil
+}
+
+// updateServiceIngressEvents add event to an ingress of the service
+func updateServiceIngressEvents(services map[string]ServicesData, serviceName string, ingressName string, event EventMessages) error {
+	ingress, found := services[serviceName].Ingresses[ingressName]
+	if !found {
+		log.WithFields(log.Fields{
+			"service": serviceName,
+			"ingress": ingressName,
+		}).Warn("ingress does not exist in service ingresses list")
+		return errors.New("ingress does not exist in service ingresses list")
+	}
+	*ingress.Events = appendEvent(*ingress.Events, event)
+	return nil
+}
+
+// updateServiceSelector adds a selector change of the service
+func updateServiceSelector(services map[string]ServicesData, name string, change ServiceSelectorChange) error {
+	service, found := services[name]
+	if !found {
+		log.WithField("service", name).Warn("service does not exist in services list")
+		return errors.New("service does not exist in services list")
+	}
+	service.SelectorChanges = append(service.SelectorChanges, change)
+	services[name] = service
+	return nil
+}
+
+// updateHorizontalPodAutoscaler sets the horizontal pod autoscaler replicas and conditions, the collected events are kept
+func updateHorizontalPodAutoscaler(hpas map[string]HorizontalPodAutoscalerData, hpa *autoscalingV2beta2.HorizontalPodAutoscaler) map[string]HorizontalPodAutoscalerData {
+	// Applies that were saved before the horizontal pod autoscalers were collected don't have a horizontal pod autoscalers map
+	if hpas == nil {
+		hpas = map[string]HorizontalPodAutoscalerData{}
+	}
+	events := &[]EventMessages{}
+	if existing, found := hpas[hpa.GetName()]; found {
+		events = existing.Events
+	}
+	hpas[hpa.GetName()] = newHorizontalPodAutoscalerData(hpa, events)
+	return hpas
+}
+
+// updateHorizontalPodAutoscalerEvents add horizontal pod autoscaler event
+func updateHorizontalPodAutoscalerEvents(hpas map[string]HorizontalPodAutoscalerData, name string, event EventMessages) error {
+	if _, found := hpas[name]; !found {
+		log.WithField("hpa", name).Warn("horizontal pod autoscaler does not exist in horizontal pod autoscalers list")
+		return errors.New("horizontal pod autoscaler does not exist in horizontal pod autoscalers list")
+	}
+	*hpas[name].Events = appendEvent(*hpas[name].Events, event)
 	return nil
 }
 
@@ -664,6 +1331,11 @@ func (dd *DeploymentData) UpdatePodEvents(podName string, pvcName string, event
 	return UpdatePodEvents(dd.Pods, podName, pvcName, event)
 }
 
+// UpdatePodLogs will set the log tail of a deployment pod container
+func (dd *DeploymentData) UpdatePodLogs(podName string, containerName string, logs ContainerLogs) error {
+	return UpdatePodLogs(dd.Pods, podName, containerName, logs)
+}
+
 // NewService will set new service to deployment row
 func (dd *DeploymentData) NewService(service *v1.Service) error {
 	return newService(dd.Services, service)
@@ -674,6 +1346,36 @@ func (dd *DeploymentData) UpdateServiceEvents(name string, event EventMessages)
 	return updateServiceEvents(dd.Services, name, event)
 }
 
+// UpdateServiceEndpoints will add the endpoints addresses of a deployment service
+func (dd *DeploymentData) UpdateServiceEndpoints(name string, endpoints *v1.Endpoints) error {
+	return updateServiceEndpoints(dd.Services, name, endpoints)
+}
+
+// NewServiceIngress will add an ingress to a deployment service
+func (dd *DeploymentData) NewServiceIngress(serviceName string, ingressName string) error {
+	return newServiceIngress(dd.Services, serviceName, ingressName)
+}
+
+// UpdateServiceIngressEvents will set event to an ingress of a deployment service
+func (dd *DeploymentData) UpdateServiceIngressEvents(serviceName string, ingressName string, event EventMessages) error {
+	return updateServiceIngressEvents(dd.Services, serviceName, ingressName, event)
+}
+
+// UpdateServiceSelector will add a selector change to a deployment service
+func (dd *DeploymentData) UpdateServiceSelector(name string, change ServiceSelectorChange) error {
+	return updateServiceSelector(dd.Services, name, change)
+}
+
+// UpdateHorizontalPodAutoscaler will set the horizontal pod autoscaler of the deployment
+func (dd *DeploymentData) UpdateHorizontalPodAutoscaler(hpa *autoscalingV2beta2.HorizontalPodAutoscaler) {
+	dd.HorizontalPodAutoscalers = updateHorizontalPodAutoscaler(dd.HorizontalPodAutoscalers, hpa)
+}
+
+// UpdateHorizontalPodAutoscalerEvents will set event to the horizontal pod autoscaler
+func (dd *DeploymentData) UpdateHorizontalPodAutoscalerEvents(name string, event EventMessages) error {
+	return updateHorizontalPodAutoscalerEvents(dd.HorizontalPodAutoscalers, name, event)
+}
+
 // ################# END DeploymentData #################
 
 // ################# Start DaemonsetData #################
@@ -698,9 +1400,14 @@ func (dsd *DaemonsetData) UpdatePodEvents(podName string, pvcName string, event
 	return UpdatePodEvents(dsd.Pods, podName, pvcName, event)
 }
 
+// UpdatePodLogs will set the log tail of a daemonset pod container
+func (dsd *DaemonsetData) UpdatePodLogs(podName string, containerName string, logs ContainerLogs) error {
+	return UpdatePodLogs(dsd.Pods, podName, containerName, logs)
+}
+
 // UpdateDaemonsetEvents will add event to a daemonset
 func (dsd *DaemonsetData) UpdateDaemonsetEvents(event EventMessages) {
-	dsd.Events = append(dsd.Events, event)
+	dsd.Events = appendEvent(dsd.Events, event)
 }
 
 // UpdateApplyStatus will update a daemonsets status
@@ -708,6 +1415,33 @@ func (dsd *DaemonsetData) UpdateApplyStatus(status appsV1.DaemonSetStatus) {
 	dsd.Status = status
 }
 
+// UpdateNode sets the rollout state of the node. A pod that was not updated doesn't replace the updated pod of
+// the node, for example an old pod that is still terminating
+func (dsd *DaemonsetData) UpdateNode(name string, node DaemonsetNode) {
+	// Applies that were saved before the nodes were collected don't have a nodes map
+	if dsd.Nodes == nil {
+		dsd.Nodes = map[string]DaemonsetNode{}
+	}
+	if existing, found := dsd.Nodes[name]; found && existing.Pod != node.Pod && existing.Updated && !node.Updated {
+		return
+	}
+	dsd.Nodes[name] = node
+}
+
+// isAwaitingPodDeletion returns true when the OnDelete daemonset pods are ready but were not deleted to be updated
+func (dsd *DaemonsetData) isAwaitingPodDeletion() bool {
+	return dsd.UpdateStrategy == string(appsV1.OnDeleteDaemonSetStrategyType) &&
+		dsd.Status.NumberReady == dsd.Status.DesiredNumberScheduled &&
+		dsd.Status.UpdatedNumberScheduled < dsd.Status.DesiredNumberScheduled
+}
+
+// DeleteNodePod removes the node rollout state when its pod was deleted
+func (dsd *DaemonsetData) DeleteNodePod(name string, podName string) {
+	if existing, found := dsd.Nodes[name]; found && existing.Pod == podName {
+		delete(dsd.Nodes, name)
+	}
+}
+
 // NewService will set new service to deployment row
 func (dsd *DaemonsetData) NewService(service *v1.Service) error {
 	return newService(dsd.Services, service)
@@ -718,6 +1452,26 @@ func (dsd *DaemonsetData) UpdateServiceEvents(name string, event EventMessages)
 	return updateServiceEvents(dsd.Services, name, event)
 }
 
+// UpdateServiceEndpoints will add the endpoints addresses of a daemonset service
+func (dsd *DaemonsetData) UpdateServiceEndpoints(name string, endpoints *v1.Endpoints) error {
+	return updateServiceEndpoints(dsd.Services, name, endpoints)
+}
+
+// NewServiceIngress will add an ingress to a daemonset service
+func (dsd *DaemonsetData) NewServiceIngress(serviceName string, ingressName string) error {
+	return newServiceIngress(dsd.Services, serviceName, ingressName)
+}
+
+// UpdateServiceIngressEvents will set event to an ingress of a daemonset service
+func (dsd *DaemonsetData) UpdateServiceIngressEvents(serviceName string, ingressName string, event EventMessages) error {
+	return updateServiceIngressEvents(dsd.Services, serviceName, ingressName, event)
+}
+
+// UpdateServiceSelector will add a selector change to a daemonset service
+func (dsd *DaemonsetData) UpdateServiceSelector(name string, change ServiceSelectorChange) error {
+	return updateServiceSelector(dsd.Services, name, change)
+}
+
 // ################# END DaemonsetData #################
 
 // ################# START StatefulsetData #################
@@ -737,6 +1491,11 @@ func (ssd *StatefulsetData) UpdatePodEvents(podName string, pvcName string, even
 	return UpdatePodEvents(ssd.Pods, podName, pvcName, event)
 }
 
+// UpdatePodLogs will set the log tail of a statefulset pod container
+func (ssd *StatefulsetData) UpdatePodLogs(podName string, containerName string, logs ContainerLogs) error {
+	return UpdatePodLogs(ssd.Pods, podName, containerName, logs)
+}
+
 // UpdatePod will set pod events to statefulset
 func (ssd *StatefulsetData) UpdatePod(pod *v1.Pod, status string) error {
 	return UpdatePodStatus(ssd.Pods, pod, status)
@@ -744,7 +1503,7 @@ func (ssd *StatefulsetData) UpdatePod(pod *v1.Pod, status string) error {
 
 // UpdateStatefulsetEvents will append events to StatefulsetEvents list
 func (ssd *StatefulsetData) UpdateStatefulsetEvents(event EventMessages) {
-	ssd.Events = append(ssd.Events, event)
+	ssd.Events = appendEvent(ssd.Events, event)
 }
 
 // UpdateApplyStatus will update a statefulset status
@@ -752,6 +1511,25 @@ func (ssd *StatefulsetData) UpdateApplyStatus(status appsV1.StatefulSetStatus) {
 	ssd.Status = status
 }
 
+// expectedUpdatedReplicas returns the number of pods that the rollout updates, with a partition only the pods with an
+// ordinal at or above the partition are updated
+func (ssd *StatefulsetData) expectedUpdatedReplicas() int32 {
+	if ssd.Partition <= 0 {
+		return ssd.Status.Replicas
+	}
+	if ssd.Partition >= ssd.Status.Replicas {
+		return 0
+	}
+	return ssd.Status.Replicas - ssd.Partition
+}
+
+// isAwaitingPodDeletion returns true when the OnDelete statefulset pods are ready but were not deleted to be updated
+func (ssd *StatefulsetData) isAwaitingPodDeletion() bool {
+	return ssd.UpdateStrategy == string(appsV1.OnDeleteStatefulSetStrategyType) &&
+		ssd.Status.ReadyReplicas == ssd.Statefulset.DesiredState &&
+		ssd.Status.UpdatedReplicas < ssd.Status.Replicas
+}
+
 // NewService will set new service to deployment row
 func (ssd *StatefulsetData) NewService(service *v1.Service) error {
 	return newService(ssd.Services, service)
@@ -762,14 +1540,342 @@ func (ssd *StatefulsetData) UpdateServiceEvents(name string, event EventMessages
 	return updateServiceEvents(ssd.Services, name, event)
 }
 
+// UpdateServiceEndpoints will add the endpoints addresses of a statefulset service
+func (ssd *StatefulsetData) UpdateServiceEndpoints(name string, endpoints *v1.Endpoints) error {
+	return updateServiceEndpoints(ssd.Services, name, endpoints)
+}
+
+// NewServiceIngress will add an ingress to a statefulset service
+func (ssd *StatefulsetData) NewServiceIngress(serviceName string, ingressName string) error {
+	return newServiceIngress(ssd.Services, serviceName, ingressName)
+}
+
+// UpdateServiceIngressEvents will set event to an ingress of a statefulset service
+func (ssd *StatefulsetData) UpdateServiceIngressEvents(serviceName string, ingressName string, event EventMessages) error {
+	return updateServiceIngressEvents(ssd.Services, serviceName, ingressName, event)
+}
+
+// UpdateServiceSelector will add a selector change to a statefulset service
+func (ssd *StatefulsetData) UpdateServiceSelector(name string, change ServiceSelectorChange) error {
+	return updateServiceSelector(ssd.Services, name, change)
+}
+
+// UpdateHorizontalPodAutoscaler will set the horizontal pod autoscaler of the statefulset
+func (ssd *StatefulsetData) UpdateHorizontalPodAutoscaler(hpa *autoscalingV2beta2.HorizontalPodAutoscaler) {
+	ssd.HorizontalPodAutoscalers = updateHorizontalPodAutoscaler(ssd.HorizontalPodAutoscalers, hpa)
+}
+
+// UpdateHorizontalPodAutoscalerEvents will set event to the horizontal pod autoscaler
+func (ssd *StatefulsetData) UpdateHorizontalPodAutoscalerEvents(name string, event EventMessages) error {
+	return updateHorizontalPodAutoscalerEvents(ssd.HorizontalPodAutoscalers, name, event)
+}
+
 // ################# END StatefulsetData #################
 
+// ################# START JobData #################
+
+// GetName get the Job name
+func (jd *JobData) GetName() string {
+	return jd.Metadata.Name
+}
+
+// NewPod Attach a new pod to the Job row
+func (jd *JobData) NewPod(pod *v1.Pod) error {
+	return NewPodToPods(jd.Pods, pod)
+}
+
+// UpdatePodEvents will set pod events
+func (jd *JobData) UpdatePodEvents(podName string, pvcName string, event EventMessages) error {
+	return UpdatePodEvents(jd.Pods, podName, pvcName, event)
+}
+
+// UpdatePodLogs will set the log tail of a job pod container
+func (jd *JobData) UpdatePodLogs(podName string, containerName string, logs ContainerLogs) error {
+	return UpdatePodLogs(jd.Pods, podName, containerName, logs)
+}
+
+// UpdatePod will set pod events to job
+func (jd *JobData) UpdatePod(pod *v1.Pod, status string) error {
+	return UpdatePodStatus(jd.Pods, pod, status)
+}
+
+// UpdateJobEvents will append events to job events list
+func (jd *JobData) UpdateJobEvents(event EventMessages) {
+	jd.Events = appendEvent(jd.Events, event)
+}
+
+// UpdateApplyStatus will update a job status
+func (jd *JobData) UpdateApplyStatus(status batchV1.JobStatus) {
+	jd.Status = status
+}
+
+// failedCondition returns true and the failure reason when the job controller marked the job as failed
+func (jd *JobData) failedCondition() (bool, string) {
+	for _, condition := range jd.Status.Conditions {
+		if condition.Type == batchV1.JobFailed && condition.Status == v1.ConditionTrue {
+			return true, condition.Reason
+		}
+	}
+	return false, ""
+}
+
+// isComplete returns true when the job controller marked the job as complete or the job pods succeeded. A work queue
+// job is complete once one of its pods succeeded and none of its pods are active
+func (jd *JobData) isComplete() bool {
+	for _, condition := range jd.Status.Conditions {
+		if condition.Type == batchV1.JobComplete && condition.Status == v1.ConditionTrue {
+			return true
+		}
+	}
+	if jd.WorkQueue {
+		return jd.Status.Succeeded > 0 && jd.Status.Active == 0
+	}
+	return jd.Status.Succeeded >= jd.Metadata.DesiredState
+}
+
+// NewService will set new service to job row
+func (jd *JobData) NewService(service *v1.Service) error {
+	return newService(jd.Services, service)
+}
+
+// UpdateServiceEvents will set event to job
+func (jd *JobData) UpdateServiceEvents(name string, event EventMessages) error {
+	return updateServiceEvents(jd.Services, name, event)
+}
+
+// UpdateServiceEndpoints will add the endpoints addresses of a job service
+func (jd *JobData) UpdateServiceEndpoints(name string, endpoints *v1.Endpoints) error {
+	return updateServiceEndpoints(jd.Services, name, endpoints)
+}
+
+// NewServiceIngress will add an ingress to a job service
+func (jd *JobData) NewServiceIngress(serviceName string, ingressName string) error {
+	return newServiceIngress(jd.Services, serviceName, ingressName)
+}
+
+// UpdateServiceIngressEvents will set event to an ingress of a job service
+func (jd *JobData) UpdateServiceIngressEvents(serviceName string, ingressName string, event EventMessages) error {
+	return updateServiceIngressEvents(jd.Services, serviceName, ingressName, event)
+}
+
+// UpdateServiceSelector will add a selector change to a job service
+func (jd *JobData) UpdateServiceSelector(name string, change ServiceSelectorChange) error {
+	return updateServiceSelector(jd.Services, name, change)
+}
+
+// ################# END JobData #################
+
+// ################# START CustomResourceData #################
+
+// GetName get the custom resource name
+func (crd *CustomResourceData) GetName() string {
+	return crd.Metadata.Name
+}
+
+// NewPod Attach a new pod to the custom resource row
+func (crd *CustomResourceData) NewPod(pod *v1.Pod) error {
+	return NewPodToPods(crd.Pods, pod)
+}
+
+// UpdatePodEvents will set pod events
+func (crd *CustomResourceData) UpdatePodEvents(podName string, pvcName string, event EventMessages) error {
+	return UpdatePodEvents(crd.Pods, podName, pvcName, event)
+}
+
+// UpdatePodLogs will set the log tail of a custom resource pod container
+func (crd *CustomResourceData) UpdatePodLogs(podName string, containerName string, logs ContainerLogs) error {
+	return UpdatePodLogs(crd.Pods, podName, containerName, logs)
+}
+
+// UpdatePod will set pod events to custom resource
+func (crd *CustomResourceData) UpdatePod(pod *v1.Pod, status string) error {
+	return UpdatePodStatus(crd.Pods, pod, status)
+}
+
+// UpdateCustomResourceEvents will append events to custom resource events list
+func (crd *CustomResourceData) UpdateCustomResourceEvents(event EventMessages) {
+	crd.Events = appendEvent(crd.Events, event)
+}
+
+// UpdateApplyStatus will update the custom resource status and phase
+func (crd *CustomResourceData) UpdateApplyStatus(resource *unstructured.Unstructured, resourceConfig common.CustomResourceConfig) {
+	status, _, _ := unstructured.NestedMap(resource.Object, "status")
+	crd.Status = status
+	crd.Phase = GetCustomResourcePhase(resource, resourceConfig)
+}
+
+// NewService will set new service to custom resource row
+func (crd *CustomResourceData) NewService(service *v1.Service) error {
+	return newService(crd.Services, service)
+}
+
+// UpdateServiceEvents will set event to custom resource
+func (crd *CustomResourceData) UpdateServiceEvents(name string, event EventMessages) error {
+	return updateServiceEvents(crd.Services, name, event)
+}
+
+// UpdateServiceEndpoints will add the endpoints addresses of a custom resource service
+func (crd *CustomResourceData) UpdateServiceEndpoints(name string, endpoints *v1.Endpoints) error {
+	return updateServiceEndpoints(crd.Services, name, endpoints)
+}
+
+// NewServiceIngress will add an ingress to a custom resource service
+func (crd *CustomResourceData) NewServiceIngress(serviceName string, ingressName string) error {
+	return newServiceIngress(crd.Services, serviceName, ingressName)
+}
+
+// UpdateServiceIngressEvents will set event to an ingress of a custom resource service
+func (crd *CustomResourceData) UpdateServiceIngressEvents(serviceName string, ingressName string, event EventMessages) error {
+	return updateServiceIngressEvents(crd.Services, serviceName, ingressName, event)
+}
+
+// UpdateServiceSelector will add a selector change to a custom resource service
+func (crd *CustomResourceData) UpdateServiceSelector(name string, change ServiceSelectorChange) error {
+	return updateServiceSelector(crd.Services, name, change)
+}
+
+// ################# END CustomResourceData #################
+
+// ################# START TrafficSwitchData #################
+
+// GetName return the service name of the traffic switch
+func (tsd *TrafficSwitchData) GetName() string {
+	return tsd.Metadata.Name
+}
+
+// UpdateTrafficSwitchEvents will set event to the traffic switch service
+func (tsd *TrafficSwitchData) UpdateTrafficSwitchEvents(event EventMessages) {
+	tsd.Events = appendEvent(tsd.Events, event)
+}
+
+// UpdateTrafficSwitchEndpoints adds a sample of the service endpoints addresses and checks if the endpoints flipped
+// to the selected pods
+func (tsd *TrafficSwitchData) UpdateTrafficSwitchEndpoints(endpoints *v1.Endpoints) {
+	updateEndpointsFlipped(&tsd.Switch, endpoints)
+	ready, notReady := endpointsAddresses(endpoints)
+	if last := len(tsd.Endpoints) - 1; last >= 0 && tsd.Endpoints[last].Ready == ready && tsd.Endpoints[last].NotReady == notReady {
+		return
+	}
+	tsd.Endpoints = append(tsd.Endpoints, EndpointsReadiness{
+		Time:     time.Now().UnixNano(),
+		Ready:    ready,
+		NotReady: notReady,
+	})
+}
+
+// ################# END TrafficSwitchData #################
+
+// ################# START WatchHealth #################
+
+// watchRestarted marks that one of the apply watches was interrupted and re-established
+func (wh *WatchHealth) watchRestarted(err error) {
+	if wh == nil {
+		return
+	}
+	wh.lock.Lock()
+	defer wh.lock.Unlock()
+	wh.Restarts++
+	wh.Incomplete = true
+	wh.LastRestartTime = time.Now().Unix()
+	if err != nil {
+		wh.LastError = err.Error()
+	}
+}
+
+// setApplyCreationTime sets the creation time of the apply, from its unix timestamp
+func (wh *WatchHealth) setApplyCreationTime(timestamp int64) {
+	wh.lock.Lock()
+	defer wh.lock.Unlock()
+	wh.applyCreationTime = time.Unix(timestamp, 0)
+}
+
+// applyCreation returns the creation time of the apply, zero when it is unknown
+func (wh *WatchHealth) applyCreation() time.Time {
+	if wh == nil {
+		return time.Time{}
+	}
+	wh.lock.Lock()
+	defer wh.lock.Unlock()
+	return wh.applyCreationTime
+}
+
+// watchOverflowed marks that one of the apply watches dropped changes because it was too slow
+func (wh *WatchHealth) watchOverflowed() {
+	if wh == nil {
+		return
+	}
+	wh.lock.Lock()
+	defer wh.lock.Unlock()
+	wh.Overflows++
+}
+
+// IsIncomplete returns true when some changes of the apply resources may be missing
+func (wh *WatchHealth) IsIncomplete() bool {
+	if wh == nil {
+		return false
+	}
+	wh.lock.Lock()
+	defer wh.lock.Unlock()
+	return wh.Incomplete
+}
+
+// ################# END WatchHealth #################
+
+// LoadResourceVersion returns the last resource version that was handled by the watcher of the resource.
+// Empty string is returned when the resource version was never saved
+func (dr *RegistryManager) LoadResourceVersion(resource string) string {
+	dr.resourceVersionsLock.Lock()
+	defer dr.resourceVersionsLock.Unlock()
+
+	if resourceVersion, found := dr.resourceVersions[resource]; found {
+		return resourceVersion
+	}
+
+	resourceVersion, err := dr.storage.GetResourceVersion(fmt.Sprintf(resourceVersionFormat, resource, dr.clusterName))
+	if err != nil {
+		log.WithError(err).WithField("resource", resource).Warn("could not load the last resource version")
+		return ""
+	}
+	dr.resourceVersions[resource] = resourceVersion
+	dr.savedResourceVersions[resource] = resourceVersion
+	return resourceVersion
+}
+
+// UpdateResourceVersion sets the last resource version that was handled by the watcher of the resource.
+// The resource version is saved to the storage in the next save interval
+func (dr *RegistryManager) UpdateResourceVersion(resource, resourceVersion string) {
+	if resourceVersion == "" {
+		return
+	}
+	dr.resourceVersionsLock.Lock()
+	dr.resourceVersions[resource] = resourceVersion
+	dr.resourceVersionsLock.Unlock()
+}
+
+// saveResourceVersions saves the resource versions that were changed since the last save
+func (dr *RegistryManager) saveResourceVersions() {
+	dr.resourceVersionsLock.Lock()
+	defer dr.resourceVersionsLock.Unlock()
+
+	for resource, resourceVersion := range dr.resourceVersions {
+		if dr.savedResourceVersions[resource] == resourceVersion {
+			continue
+		}
+		if err := dr.storage.UpdateResourceVersion(fmt.Sprintf(resourceVersionFormat, resource, dr.clusterName), resourceVersion); err != nil {
+			continue
+		}
+		dr.savedResourceVersions[resource] = resourceVersion
+	}
+}
+
 // save will save all the row list to the storage
 func (dr *RegistryManager) save() {
 
 	dr.saveLock.Lock()
 	defer dr.saveLock.Unlock()
 
+	dr.saveResourceVersions()
+
 	var wg sync.WaitGroup
 	wg.Add(len(dr.registryData))
 	deleteRows := []string{}
@@ -788,13 +1894,14 @@ func (dr *RegistryManager) save() {
 				switch data.status {
 				case common.ApplyStatusRunning:
 					dr.reporter.DeploymentStarted <- common.DeploymentReport{
-						To:          data.DBSchema.ReportTo,
-						DeployBy:    data.DBSchema.DeployBy,
-						Name:        data.DBSchema.Application,
-						URI:         data.GetURI(),
-						Status:      data.status,
-						LogEntry:    data.Log(),
-						ClusterName: dr.clusterName,
+						To:               data.DBSchema.ReportTo,
+						DeployBy:         data.DBSchema.DeployBy,
+						Name:             data.DBSchema.Application,
+						URI:              data.GetURI(),
+						Status:           data.status,
+						LogEntry:         data.Log(),
+						ClusterName:      dr.clusterName,
+						RollbackRevision: data.DBSchema.Rollback.GetRevision(),
 					}
 				case common.ApplyStatusDeleted:
 					dr.reporter.DeploymentDeleted <- common.DeploymentReport{
@@ -822,13 +1929,17 @@ func (dr *RegistryManager) save() {
 
 				if data.status != common.ApplyStatusDeleted {
 					dr.reporter.DeploymentFinished <- common.DeploymentReport{
-						To:          data.DBSchema.ReportTo,
-						DeployBy:    data.DBSchema.DeployBy,
-						Name:        data.DBSchema.Application,
-						URI:         data.GetURI(),
-						Status:      data.status,
-						LogEntry:    data.Log(),
-						ClusterName: dr.clusterName,
+						To:               data.DBSchema.ReportTo,
+						DeployBy:         data.DBSchema.DeployBy,
+						Name:             data.DBSchema.Application,
+						URI:              data.GetURI(),
+						Status:           data.status,
+						LogEntry:         data.Log(),
+						ClusterName:      dr.clusterName,
+						DataIncomplete:   data.DBSchema.WatchHealth.IsIncomplete(),
+						RollbackRevision: data.DBSchema.Rollback.GetRevision(),
+						FailureCauses:    data.DBSchema.FailureCauses,
+						SupersededBy:     data.getSupersededByURI(),
 					}
 				}
 
@@ -853,8 +1964,13 @@ func generateID(name, namespace, cluster string) string {
 }
 
 // updateAppliesVersionHistory updates a new version of hash kind
-func (dr *RegistryManager) updateAppliesVersionHistory(name, namespace, resourceName string, hash uint64) bool {
-	return dr.storage.UpdateAppliesVersionHistory(fmt.Sprintf(applyVersionFormat, resourceName, namespace, name, dr.clusterName), hash)
+func (dr *RegistryManager) updateAppliesVersionHistory(name, namespace, resourceName string, hash uint64, legacyHash uint64) bool {
+	return dr.storage.UpdateAppliesVersionHistory(fmt.Sprintf(applyVersionFormat, resourceName, namespace, name, dr.clusterName), hash, legacyHash)
+}
+
+// updateAppliedReplicas updates the replicas count of the applied version, and returns the previous replicas count
+func (dr *RegistryManager) updateAppliedReplicas(name, namespace, resourceName string, replicas int32) (int32, bool) {
+	return dr.storage.UpdateAppliedReplicas(fmt.Sprintf(applyVersionFormat, resourceName, namespace, name, dr.clusterName), replicas)
 }
 
 // deleteAppliedVersion delete apply version
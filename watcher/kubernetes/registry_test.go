@@ -3,6 +3,7 @@ package kuberneteswatcher_test
 import (
 	"context"
 	"fmt"
+	"reflect"
 	notifierCommon "statusbay/notifiers/common"
 	kuberneteswatcher "statusbay/watcher/kubernetes"
 	"statusbay/watcher/kubernetes/common"
@@ -20,11 +21,12 @@ func NewRegistryMock() (*kuberneteswatcher.RegistryManager, *testutil.MockStorag
 
 	saveInterval, _ := time.ParseDuration("1s")
 	checkFinishDelay := 10 * time.Microsecond
+	checkFinishInterval := 100 * time.Millisecond
 	collectDataAfterApplyFinish := 10 * time.Microsecond
 
 	storageMock := testutil.NewMockStorage()
 	reporter := kuberneteswatcher.NewReporter([]notifierCommon.Notifier{})
-	registry := kuberneteswatcher.NewRegistryManager(saveInterval, checkFinishDelay, collectDataAfterApplyFinish, storageMock, reporter, "mock-cluster")
+	registry := kuberneteswatcher.NewRegistryManager(saveInterval, checkFinishDelay, checkFinishInterval, collectDataAfterApplyFinish, storageMock, reporter, "mock-cluster", nil, false)
 
 	var wg sync.WaitGroup
 	ctx := context.Background()
@@ -223,8 +225,8 @@ func TestDeploymentData(t *testing.T) {
 	})
 
 	t.Run("update_deployment_events", func(t *testing.T) {
-		event1 := kuberneteswatcher.EventMessages{}
-		event2 := kuberneteswatcher.EventMessages{}
+		event1 := kuberneteswatcher.EventMessages{Message: "message1"}
+		event2 := kuberneteswatcher.EventMessages{Message: "message2"}
 		data.UpdateDeploymentEvents(event1)
 		data.UpdateDeploymentEvents(event2)
 
@@ -233,6 +235,15 @@ func TestDeploymentData(t *testing.T) {
 
 		}
 
+		// A repeated event is merged into the existing event
+		data.UpdateDeploymentEvents(event1)
+		if len(data.Events) != 2 {
+			t.Fatalf("unexpected deployment event count after a repeated event, got %d expected %d", len(data.Events), 2)
+		}
+		if data.Events[0].Count != 2 {
+			t.Fatalf("unexpected repeated event count, got %d expected %d", data.Events[0].Count, 2)
+		}
+
 	})
 
 	t.Run("init_replicaset", func(t *testing.T) {
@@ -247,8 +258,8 @@ func TestDeploymentData(t *testing.T) {
 
 	t.Run("update_replicaset_events", func(t *testing.T) {
 		data.InitReplicaset("replica")
-		data.UpdateReplicasetEvents("replica", kuberneteswatcher.EventMessages{})
-		data.UpdateReplicasetEvents("replica", kuberneteswatcher.EventMessages{})
+		data.UpdateReplicasetEvents("replica", kuberneteswatcher.EventMessages{Message: "message1"})
+		data.UpdateReplicasetEvents("replica", kuberneteswatcher.EventMessages{Message: "message2"})
 
 		if len(*data.Replicaset["replica"].Events) != 2 {
 			t.Fatalf("unexpected replicaset event count, got %d expected %d", len(*data.Replicaset["replica"].Events), 2)
@@ -439,7 +450,7 @@ func TestDeploymentFinishSuccessful(t *testing.T) {
 
 	data.UpdateReplicasetStatus("replicaset-name", replicasetStatus)
 
-	time.Sleep(time.Second * 10)
+	time.Sleep(time.Second * 3)
 	if storage.MockWriteDeployment["1"].Status != common.ApplySuccessful {
 		t.Errorf("unexpected deployment status, got %s expected %s", storage.MockWriteDeployment["1"].Status, common.ApplySuccessful)
 	}
@@ -470,7 +481,7 @@ func TestDeploymentFinishProgressDeadLine(t *testing.T) {
 
 	data.UpdateReplicasetStatus("replicaset-name", replicasetStatus)
 
-	time.Sleep(time.Second * 8)
+	time.Sleep(time.Second * 4)
 	if storage.MockWriteDeployment["1"].Status != common.ApplyStatusFailed {
 		t.Fatalf("unexpected deployment status, got %s expected %s", storage.MockWriteDeployment["1"].Status, common.ApplyStatusFailed)
 	}
@@ -494,3 +505,364 @@ func TestGetApplyID(t *testing.T) {
 
 	}
 }
+
+func TestResourceVersionSave(t *testing.T) {
+
+	registry, storage := NewRegistryMock()
+	storage.MockResourceVersions["jobs.batch-mock-cluster"] = "7"
+
+	t.Run("load_saved_resource_version", func(t *testing.T) {
+		resourceVersion := registry.LoadResourceVersion("jobs.batch")
+		if resourceVersion != "7" {
+			t.Fatalf("unexpected resource version, got %s expected %s", resourceVersion, "7")
+		}
+	})
+
+	t.Run("save_resource_version", func(t *testing.T) {
+		registry.UpdateResourceVersion("deployments.apps", "12")
+		time.Sleep(time.Second * 2)
+		resourceVersion := storage.MockResourceVersions["deployments.apps-mock-cluster"]
+		if resourceVersion != "12" {
+			t.Fatalf("unexpected saved resource version, got %s expected %s", resourceVersion, "12")
+		}
+	})
+}
+
+func TestScaleEvent(t *testing.T) {
+
+	registry, storageMock := NewRegistryMock()
+
+	replicas := int32(2)
+	apply := kuberneteswatcher.ApplyEvent{
+		Event:        "ADDED",
+		ApplyName:    "nginx",
+		ResourceName: "nginx",
+		Namespace:    "pe",
+		Kind:         "deployment",
+		Hash:         1234,
+		Annotations:  map[string]string{},
+		Labels:       map[string]string{},
+		Replicas:     &replicas,
+	}
+
+	if row := registry.NewApplyEvent(apply); row == nil {
+		t.Fatalf("expected a new apply on the first event")
+	}
+
+	scaledReplicas := int32(5)
+	apply.Event = "MODIFIED"
+	apply.Replicas = &scaledReplicas
+
+	t.Run("scale_without_spec_change", func(t *testing.T) {
+		if row := registry.NewApplyEvent(apply); row != nil {
+			t.Fatalf("unexpected apply on scale event")
+		}
+		if len(storageMock.MockScaleEvents) != 1 {
+			t.Fatalf("unexpected scale events count, got %d expected %d", len(storageMock.MockScaleEvents), 1)
+		}
+		event := storageMock.MockScaleEvents[0]
+		if event.FromReplicas != 2 || event.ToReplicas != 5 {
+			t.Fatalf("unexpected scale event replicas, got %d -> %d expected %d -> %d", event.FromReplicas, event.ToReplicas, 2, 5)
+		}
+	})
+
+	t.Run("same_replicas", func(t *testing.T) {
+		if row := registry.NewApplyEvent(apply); row != nil {
+			t.Fatalf("unexpected apply when nothing was changed")
+		}
+		if len(storageMock.MockScaleEvents) != 1 {
+			t.Fatalf("unexpected scale events count, got %d expected %d", len(storageMock.MockScaleEvents), 1)
+		}
+	})
+	t.Run("scale_with_spec_change", func(t *testing.T) {
+		specReplicas := int32(3)
+		apply.Hash = 5678
+		apply.Replicas = &specReplicas
+
+		row := registry.NewApplyEvent(apply)
+		if row == nil {
+			t.Fatalf("expected a new apply on spec change")
+		}
+		if len(storageMock.MockScaleEvents) != 1 {
+			t.Fatalf("unexpected scale events count, got %d expected %d", len(storageMock.MockScaleEvents), 1)
+		}
+
+		expected := []kuberneteswatcher.SpecDiff{{
+			Kind:         "deployment",
+			ResourceName: "nginx",
+			Changes: []kuberneteswatcher.SpecChange{
+				{Type: kuberneteswatcher.SpecChangeReplicas, Action: kuberneteswatcher.SpecChangeModified, Name: "replicas", Previous: "5", Current: "3"},
+			},
+		}}
+		if !reflect.DeepEqual(row.DBSchema.SpecDiffs, expected) {
+			t.Fatalf("unexpected spec diff, got %+v expected %+v", row.DBSchema.SpecDiffs, expected)
+		}
+	})
+}
+
+func TestLegacyHashScheme(t *testing.T) {
+
+	registry, storageMock := NewRegistryMock()
+
+	// Hashes that were saved before the hash scheme was versioned included the replicas
+	storageMock.MockDeploymentHistory["deployment-pe-nginx-mock-cluster"] = 1111
+	storageMock.MockDeploymentHistory["deployment-pe-redis-mock-cluster"] = 2222
+
+	replicas := int32(2)
+	apply := kuberneteswatcher.ApplyEvent{
+		Event:        "MODIFIED",
+		ApplyName:    "nginx",
+		ResourceName: "nginx",
+		Namespace:    "pe",
+		Kind:         "deployment",
+		Hash:         1234,
+		LegacyHash:   1111,
+		Annotations:  map[string]string{},
+		Labels:       map[string]string{},
+		Replicas:     &replicas,
+	}
+
+	t.Run("re_seed_unchanged_spec", func(t *testing.T) {
+		if row := registry.NewApplyEvent(apply); row != nil {
+			t.Fatalf("unexpected apply when the legacy hash was not changed")
+		}
+		if storageMock.MockDeploymentHistory["deployment-pe-nginx-mock-cluster"] != 1234 {
+			t.Fatalf("unexpected re-seeded hash, got %d expected %d", storageMock.MockDeploymentHistory["deployment-pe-nginx-mock-cluster"], 1234)
+		}
+		if storageMock.MockHashSchemes["deployment-pe-nginx-mock-cluster"] != kuberneteswatcher.ApplyHashScheme {
+			t.Fatalf("unexpected hash scheme, got %d expected %d", storageMock.MockHashSchemes["deployment-pe-nginx-mock-cluster"], kuberneteswatcher.ApplyHashScheme)
+		}
+		if row := registry.NewApplyEvent(apply); row != nil {
+			t.Fatalf("unexpected apply after the hash was re-seeded")
+		}
+	})
+
+	t.Run("changed_spec", func(t *testing.T) {
+		apply.ApplyName = "redis"
+		apply.ResourceName = "redis"
+		if row := registry.NewApplyEvent(apply); row == nil {
+			t.Fatalf("expected a new apply when the legacy hash was changed")
+		}
+	})
+}
+
+func TestSupersededApply(t *testing.T) {
+
+	registry, storageMock := NewRegistryMock()
+
+	apply := kuberneteswatcher.ApplyEvent{
+		Event:        "ADDED",
+		ApplyName:    "nginx",
+		ResourceName: "nginx",
+		Namespace:    "pe",
+		Kind:         "deployment",
+		Hash:         1234,
+		Annotations:  map[string]string{},
+		Labels:       map[string]string{},
+	}
+
+	previous := registry.NewApplyEvent(apply)
+	if previous == nil {
+		t.Fatalf("expected a new apply on the first event")
+	}
+
+	apply.Event = "MODIFIED"
+	apply.Hash = 5678
+	current := registry.NewApplyEvent(apply)
+
+	t.Run("new_apply", func(t *testing.T) {
+		if current == nil || current == previous {
+			t.Fatalf("expected a new apply when the running apply resource was changed")
+		}
+		if current.GetApplyID() == previous.GetApplyID() {
+			t.Fatalf("unexpected apply id, the new apply has the superseded apply id %s", current.GetApplyID())
+		}
+		if current.DBSchema.Supersedes != previous.GetApplyID() {
+			t.Fatalf("unexpected supersedes apply id, got %s expected %s", current.DBSchema.Supersedes, previous.GetApplyID())
+		}
+		if previous.DBSchema.SupersededBy != current.GetApplyID() {
+			t.Fatalf("unexpected superseded by apply id, got %s expected %s", previous.DBSchema.SupersededBy, current.GetApplyID())
+		}
+	})
+
+	t.Run("other_resource", func(t *testing.T) {
+		service := apply
+		service.Kind = "service"
+		if row := registry.NewApplyEvent(service); row != current {
+			t.Fatalf("expected the resource to be added to the running apply")
+		}
+	})
+
+	t.Run("superseded_status", func(t *testing.T) {
+		time.Sleep(time.Second * 2)
+		statuses := map[common.DeploymentStatus]int{}
+		for _, row := range storageMock.MockWriteDeployment {
+			statuses[row.Status]++
+		}
+		if statuses[common.ApplyStatusSuperseded] != 1 {
+			t.Fatalf("unexpected superseded applies count, got %d expected %d", statuses[common.ApplyStatusSuperseded], 1)
+		}
+	})
+}
+
+func TestFinishedApplyCollectingData(t *testing.T) {
+
+	storageMock := testutil.NewMockStorage()
+	reporter := kuberneteswatcher.NewReporter([]notifierCommon.Notifier{})
+	registry := kuberneteswatcher.NewRegistryManager(time.Second, 10*time.Microsecond, 100*time.Millisecond, 2*time.Second, storageMock, reporter, "mock-cluster", nil, false)
+
+	var wg sync.WaitGroup
+	ctx, cancelFn := context.WithCancel(context.Background())
+	defer cancelFn()
+	registry.Serve(ctx, &wg)
+	reporter.Serve(ctx, &wg)
+
+	apply := kuberneteswatcher.ApplyEvent{
+		Event:        "ADDED",
+		ApplyName:    "nginx",
+		ResourceName: "nginx",
+		Namespace:    "pe",
+		Kind:         "deployment",
+		Hash:         1234,
+		Annotations:  map[string]string{},
+		Labels:       map[string]string{},
+	}
+	finished := registry.NewApplyEvent(apply)
+	if finished == nil {
+		t.Fatalf("expected a new apply on the first event")
+	}
+
+	// The apply finished and collects data until the collect data duration is over
+	go finished.Stop(common.ApplySuccessful, common.ApplyStatusDescriptionSuccessful)
+	time.Sleep(time.Millisecond * 100)
+
+	t.Run("other_resource", func(t *testing.T) {
+		canary := apply
+		canary.ResourceName = "nginx-canary"
+		if row := registry.NewApplyEvent(canary); row != finished {
+			t.Fatalf("expected the resource to be added to the apply that collects data")
+		}
+	})
+
+	t.Run("new_version", func(t *testing.T) {
+		modified := apply
+		modified.Event = "MODIFIED"
+		modified.Hash = 5678
+		current := registry.NewApplyEvent(modified)
+		if current == nil || current == finished {
+			t.Fatalf("expected a new apply when the resource of the apply that collects data was changed")
+		}
+		if current.DBSchema.Supersedes != "" || finished.DBSchema.SupersededBy != "" {
+			t.Fatalf("unexpected superseded apply, an apply that collects data already finished")
+		}
+	})
+}
+
+func TestLoadRunningAppliesSavedBeforeResources(t *testing.T) {
+
+	registry, storageMock := NewRegistryMock()
+
+	// Applies that were saved before the jobs and the custom resources were tracked don't have their maps
+	storageMock.MockRunningApplies["1"] = kuberneteswatcher.DBSchema{
+		Application: "migration",
+		Namespace:   "default",
+		Resources: kuberneteswatcher.Resources{
+			Deployments: map[string]*kuberneteswatcher.DeploymentData{},
+		},
+	}
+
+	rows := registry.LoadRunningApplies()
+	if len(rows) != 1 {
+		t.Fatalf("unexpected running applies count, got %d expected %d", len(rows), 1)
+	}
+	if rows[0].DBSchema.Resources.Jobs == nil || rows[0].DBSchema.Resources.CustomResources == nil {
+		t.Fatalf("unexpected nil resources maps of a loaded apply")
+	}
+
+	apply := kuberneteswatcher.ApplyEvent{
+		Event:        "create",
+		ApplyName:    "migration",
+		ResourceName: "migration-1585000000",
+		Namespace:    "default",
+		Kind:         "job",
+		Annotations:  map[string]string{},
+		Labels:       map[string]string{},
+	}
+	createMockJobData(registry, rows[0], apply, "10m", 1, 6, false)
+	if _, found := rows[0].DBSchema.Resources.Jobs["migration-1585000000"]; !found {
+		t.Fatalf("the job was not added to the loaded apply")
+	}
+}
+
+func TestSupersededApplySavedBeforeSpecDiffs(t *testing.T) {
+
+	registry, storageMock := NewRegistryMock()
+
+	// Applies that were saved before the spec diffs were tracked have only their resources
+	storageMock.MockRunningApplies["1"] = kuberneteswatcher.DBSchema{
+		Application: "nginx",
+		Namespace:   "pe",
+		Resources: kuberneteswatcher.Resources{
+			Deployments: map[string]*kuberneteswatcher.DeploymentData{
+				"nginx": {Deployment: kuberneteswatcher.MetaData{Name: "nginx", Namespace: "pe"}},
+			},
+		},
+	}
+	rows := registry.LoadRunningApplies()
+	if len(rows) != 1 {
+		t.Fatalf("unexpected running applies count, got %d expected %d", len(rows), 1)
+	}
+	loaded := rows[0]
+
+	apply := kuberneteswatcher.ApplyEvent{
+		Event:        "MODIFIED",
+		ApplyName:    "nginx",
+		ResourceName: "nginx",
+		Namespace:    "pe",
+		Kind:         "deployment",
+		Hash:         5678,
+		Annotations:  map[string]string{},
+		Labels:       map[string]string{},
+	}
+	current := registry.NewApplyEvent(apply)
+
+	if current == nil || current == loaded {
+		t.Fatalf("expected a new apply when the loaded apply resource was changed")
+	}
+	if loaded.DBSchema.SupersededBy != current.GetApplyID() {
+		t.Fatalf("unexpected superseded by apply id, got %s expected %s", loaded.DBSchema.SupersededBy, current.GetApplyID())
+	}
+}
+
+func TestDeletedFilteredResource(t *testing.T) {
+
+	storageMock := testutil.NewMockStorage()
+	reporter := kuberneteswatcher.NewReporter([]notifierCommon.Notifier{})
+	filter, _ := kuberneteswatcher.NewApplyFilter(common.FilterConfig{OptIn: true})
+	registry := kuberneteswatcher.NewRegistryManager(time.Second, 10*time.Microsecond, 100*time.Millisecond, 10*time.Microsecond, storageMock, reporter, "mock-cluster", filter, false)
+
+	apply := kuberneteswatcher.ApplyEvent{
+		Event:        "ADDED",
+		ApplyName:    "nginx",
+		ResourceName: "nginx",
+		Namespace:    "pe",
+		Kind:         "deployment",
+		Hash:         1234,
+		Annotations:  map[string]string{"statusbay.io/enabled": "true"},
+		Labels:       map[string]string{},
+	}
+	if registry.NewApplyEvent(apply) == nil {
+		t.Fatalf("expected a new apply of the opted in resource")
+	}
+
+	// The annotation was removed before the resource was deleted
+	apply.Event = "DELETED"
+	apply.Annotations = map[string]string{}
+	if row := registry.NewApplyEvent(apply); row != nil {
+		t.Fatalf("unexpected apply of the filtered resource")
+	}
+
+	if _, found := storageMock.MockDeploymentHistory["deployment-pe-nginx-mock-cluster"]; found {
+		t.Fatalf("the applied version of the deleted resource was not deleted")
+	}
+}
@@ -7,10 +7,9 @@ import (
 	log "github.com/sirupsen/logrus"
 
 	appsV1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
 	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
-
-	"k8s.io/client-go/kubernetes"
 )
 
 // WatchReplica defined replicaset watch received message
@@ -19,27 +18,28 @@ type WatchReplica struct {
 	DesiredReplicas int32
 	ListOptions     metaV1.ListOptions
 
-	Registry  *DeploymentData
-	Namespace string
-	Ctx       context.Context
+	Registry    *DeploymentData
+	Namespace   string
+	Ctx         context.Context
+	WatchHealth *WatchHealth
 }
 
 // ReplicaSetManager defined replicaset manager struct
 type ReplicaSetManager struct {
-	eventManager *EventsManager
-	Watch        chan WatchReplica
-	client       kubernetes.Interface
-	podsManager  *PodsManager
+	eventManager    *EventsManager
+	Watch           chan WatchReplica
+	informerManager *InformerManager
+	podsManager     *PodsManager
 	int64
 }
 
 // NewReplicasetManager create new replicaset instance
-func NewReplicasetManager(kubernetesClientset kubernetes.Interface, eventManager *EventsManager, podsManager *PodsManager) *ReplicaSetManager {
+func NewReplicasetManager(informerManager *InformerManager, eventManager *EventsManager, podsManager *PodsManager) *ReplicaSetManager {
 	return &ReplicaSetManager{
-		client:       kubernetesClientset,
-		eventManager: eventManager,
-		podsManager:  podsManager,
-		Watch:        make(chan WatchReplica),
+		informerManager: informerManager,
+		eventManager:    eventManager,
+		podsManager:     podsManager,
+		Watch:           make(chan WatchReplica),
 	}
 }
 
@@ -73,16 +73,11 @@ func (rm *ReplicaSetManager) watch(replicaData WatchReplica) {
 		//List of replicaset changes events
 		firstInit := map[string]bool{}
 
-		watcher, err := rm.client.AppsV1().ReplicaSets(replicaData.Namespace).Watch(replicaData.ListOptions)
-
-		if err != nil {
-			replicaData.LogEntry.WithField("list_option", replicaData.ListOptions).WithError(err).Error("error occured trying to start watch on replicasets")
-			return
-		}
+		watcher := rm.informerManager.ResumableWatch(replicaData.Ctx, replicaData.LogEntry, replicaData.WatchHealth, replicasetsResource, replicaData.Namespace, replicaData.ListOptions)
 
 		for {
 			select {
-			case event, watch := <-watcher.ResultChan():
+			case event, watch := <-watcher:
 				if !watch {
 					replicaData.LogEntry.Warn("replicaset watch was stopped, channel was closed")
 
@@ -118,6 +113,7 @@ func (rm *ReplicaSetManager) watch(replicaData WatchReplica) {
 							Namespace:    replicaData.Namespace,
 							Ctx:          replicaData.Ctx,
 							LogEntry:     *lg,
+							WatchHealth:  replicaData.WatchHealth,
 						}
 
 					} else {
@@ -131,7 +127,7 @@ func (rm *ReplicaSetManager) watch(replicaData WatchReplica) {
 					}).String(),
 					}
 
-					rm.watchEvents(replicaData.Ctx, *lg, replicaData.Registry, eventListOptions, replicaset.GetName(), replicaData.Namespace)
+					rm.watchEvents(replicaData.Ctx, *lg, replicaData.WatchHealth, replicaData.Registry, eventListOptions, newObjectReference("ReplicaSet", replicaset), replicaset.GetName(), replicaData.Namespace)
 
 				}
 
@@ -139,7 +135,6 @@ func (rm *ReplicaSetManager) watch(replicaData WatchReplica) {
 
 			case <-replicaData.Ctx.Done():
 				replicaData.LogEntry.Debug("replicaset watch was stopped, got ctx done signal")
-				watcher.Stop()
 				return
 			}
 		}
@@ -148,14 +143,17 @@ func (rm *ReplicaSetManager) watch(replicaData WatchReplica) {
 }
 
 // watchEvents will start watch on replicaset event messages changes
-func (rm *ReplicaSetManager) watchEvents(ctx context.Context, lg log.Entry, registryDeployment *DeploymentData, listOptions metaV1.ListOptions, replicasetName, namespace string) {
+func (rm *ReplicaSetManager) watchEvents(ctx context.Context, lg log.Entry, health *WatchHealth, registryDeployment *DeploymentData, listOptions metaV1.ListOptions, involvedObject v1.ObjectReference, replicasetName, namespace string) {
 
 	lg.Info("start watching replicaset events")
 	watchData := WatchEvents{
-		ListOptions: listOptions,
-		Namespace:   namespace,
-		Ctx:         ctx,
-		LogEntry:    lg,
+		ListOptions:       listOptions,
+		Namespace:         namespace,
+		Ctx:               ctx,
+		LogEntry:          lg,
+		WatchHealth:       health,
+		InvolvedObject:    involvedObject,
+		ApplyCreationTime: health.applyCreation(),
 	}
 
 	eventChan := rm.eventManager.Watch(watchData)
@@ -34,10 +34,10 @@ func createReplicasetMock(client *fake.Clientset, name string, specSelector *met
 
 func NewReplicasetMock(client *fake.Clientset) *kuberneteswatcher.ReplicaSetManager {
 
-	eventManager := kuberneteswatcher.NewEventsManager(client)
+	eventManager := kuberneteswatcher.NewEventsManager(NewInformerManagerMock(client), common.EventsConfig{})
 	pvcManager := NewPvcManagerMock(client)
-	podManager := kuberneteswatcher.NewPodsManager(client, eventManager, pvcManager)
-	replicasetManager := kuberneteswatcher.NewReplicasetManager(client, eventManager, podManager)
+	podManager := kuberneteswatcher.NewPodsManager(NewInformerManagerMock(client), eventManager, pvcManager, client, common.PodLogsConfig{})
+	replicasetManager := kuberneteswatcher.NewReplicasetManager(NewInformerManagerMock(client), eventManager, podManager)
 
 	var wg sync.WaitGroup
 	ctx := context.Background()
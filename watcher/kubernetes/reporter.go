@@ -20,6 +20,9 @@ type ReporterManager struct {
 	// Received channel when deployment finish
 	DeploymentFinished chan common.DeploymentReport
 
+	// Received channel when deployment scaled without a rollout
+	DeploymentScaled chan common.ScaleReport
+
 	// available ways to notify about changes in the deployment stages
 	availableNotifiers []notifierCommon.Notifier
 }
@@ -32,6 +35,7 @@ func NewReporter(availableNotifiers []notifierCommon.Notifier) *ReporterManager
 		DeploymentStarted:  make(chan common.DeploymentReport),
 		DeploymentDeleted:  make(chan common.DeploymentReport),
 		DeploymentFinished: make(chan common.DeploymentReport),
+		DeploymentScaled:   make(chan common.ScaleReport),
 	}
 }
 
@@ -52,6 +56,8 @@ func (re *ReporterManager) Serve(ctx context.Context, wg *sync.WaitGroup) {
 				re.deploymentDeleted(request)
 			case request := <-re.DeploymentFinished:
 				re.deploymentFinish(request)
+			case request := <-re.DeploymentScaled:
+				re.deploymentScaled(request)
 			case <-ctx.Done():
 				log.Warn("reporter has been shut down")
 				wg.Done()
@@ -83,3 +89,10 @@ func (re *ReporterManager) deploymentFinish(message common.DeploymentReport) {
 		notifier.ReportEnded(message)
 	}
 }
+
+// deploymentScaled will send slack message to channel/user when the deployment is scaled
+func (re *ReporterManager) deploymentScaled(message common.ScaleReport) {
+	for _, notifier := range re.availableNotifiers {
+		notifier.ReportScaled(message)
+	}
+}
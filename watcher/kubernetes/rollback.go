@@ -0,0 +1,181 @@
+package kuberneteswatcher
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+
+	appsV1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// revisionAnnotation is the revision of a deployment and its replicasets, set by the deployment controller
+	revisionAnnotation = "deployment.kubernetes.io/revision"
+
+	// revisionHistoryAnnotation is the list of the previous revisions of a replicaset that was reused by a rollback
+	revisionHistoryAnnotation = "deployment.kubernetes.io/revision-history"
+)
+
+// ApplyRollback describes an apply that restored the pod template of an earlier revision
+type ApplyRollback struct {
+	Kind         string `json:"Kind"`
+	ResourceName string `json:"ResourceName"`
+	Revision     int64  `json:"Revision"`
+}
+
+// GetRevision returns the rollback revision, 0 when the apply is not a rollback
+func (ar *ApplyRollback) GetRevision() int64 {
+	if ar == nil {
+		return 0
+	}
+	return ar.Revision
+}
+
+// replicaSetRollbackRevision returns the revision that the deployment was rolled back to, when the deployment pod template
+// matches the template of an earlier replicaset of the deployment.
+// The deployment revision annotation is the revision before the apply, the matching replicaset may already be reused by
+// the deployment controller, in this case the rollback revision is the last revision in the replicaset history
+func replicaSetRollbackRevision(deployment *appsV1.Deployment, replicasets []*appsV1.ReplicaSet) (int64, bool) {
+
+	currentRevision, err := strconv.ParseInt(deployment.GetAnnotations()[revisionAnnotation], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	for _, replicaset := range replicasets {
+		owner := metaV1.GetControllerOf(replicaset)
+		if owner == nil || owner.UID != deployment.GetUID() || !equalIgnoreHash(replicaset.Spec.Template, deployment.Spec.Template) {
+			continue
+		}
+
+		revision, err := strconv.ParseInt(replicaset.GetAnnotations()[revisionAnnotation], 10, 64)
+		if err != nil {
+			return 0, false
+		}
+		if revision < currentRevision {
+			return revision, true
+		}
+		if revision > currentRevision {
+			history := strings.Split(replicaset.GetAnnotations()[revisionHistoryAnnotation], ",")
+			if previousRevision, err := strconv.ParseInt(history[len(history)-1], 10, 64); err == nil {
+				return previousRevision, true
+			}
+		}
+		return 0, false
+	}
+	return 0, false
+}
+
+// revisionsRecord holds the controller revisions of a daemonset or a statefulset as they were seen before its next apply
+type revisionsRecord struct {
+	// current is the name of the revision that matched the pod template
+	current string
+
+	// numbers holds the revision number of each revision name
+	numbers map[string]int64
+}
+
+// newRevisionsRecord records the revision numbers of the owner controller revisions and the revision that matches the pod template
+func newRevisionsRecord(ownerUID types.UID, template v1.PodTemplateSpec, revisions []appsV1.ControllerRevision) *revisionsRecord {
+	record := &revisionsRecord{numbers: map[string]int64{}}
+	for _, revision := range ownedControllerRevisions(ownerUID, revisions) {
+		record.numbers[revision.GetName()] = revision.Revision
+	}
+	if matched := matchControllerRevision(ownerUID, template, revisions); matched != nil {
+		record.current = matched.GetName()
+	}
+	return record
+}
+
+// controllerRevisionRollbackRevision returns the revision that the daemonset or the statefulset was rolled back to, when the pod template
+// matches the template of a controller revision that was older than another controller revision of the owner.
+// The controller bumps the number of a reused revision to the latest number, so the number of the matched revision is
+// taken from the record of the revisions before the apply. Without a record the current revision numbers are compared
+func controllerRevisionRollbackRevision(ownerUID types.UID, template v1.PodTemplateSpec, revisions []appsV1.ControllerRevision, record *revisionsRecord) (int64, bool) {
+
+	matched := matchControllerRevision(ownerUID, template, revisions)
+	if matched == nil {
+		return 0, false
+	}
+
+	matchedRevision := matched.Revision
+	if record != nil {
+		if record.current == matched.GetName() {
+			return 0, false
+		}
+		if number, found := record.numbers[matched.GetName()]; found {
+			matchedRevision = number
+		}
+	}
+
+	latestRevision := int64(0)
+	for _, revision := range ownedControllerRevisions(ownerUID, revisions) {
+		if revision.GetName() != matched.GetName() && revision.Revision > latestRevision {
+			latestRevision = revision.Revision
+		}
+	}
+
+	if matchedRevision < latestRevision {
+		return matchedRevision, true
+	}
+	return 0, false
+}
+
+// matchControllerRevision returns the controller revision of the owner that matches the pod template
+func matchControllerRevision(ownerUID types.UID, template v1.PodTemplateSpec, revisions []appsV1.ControllerRevision) *appsV1.ControllerRevision {
+
+	templateData, err := json.Marshal(template)
+	if err != nil {
+		return nil
+	}
+	var currentTemplate map[string]interface{}
+	if err := json.Unmarshal(templateData, &currentTemplate); err != nil {
+		return nil
+	}
+
+	for _, revision := range ownedControllerRevisions(ownerUID, revisions) {
+		if revisionTemplate, ok := getControllerRevisionTemplate(*revision); ok && reflect.DeepEqual(revisionTemplate, currentTemplate) {
+			return revision
+		}
+	}
+	return nil
+}
+
+// ownedControllerRevisions returns the controller revisions that are controlled by the owner
+func ownedControllerRevisions(ownerUID types.UID, revisions []appsV1.ControllerRevision) []*appsV1.ControllerRevision {
+	owned := []*appsV1.ControllerRevision{}
+	for i := range revisions {
+		if owner := metaV1.GetControllerOf(&revisions[i]); owner != nil && owner.UID == ownerUID {
+			owned = append(owned, &revisions[i])
+		}
+	}
+	return owned
+}
+
+// getControllerRevisionTemplate returns the pod template that is saved in the controller revision patch
+func getControllerRevisionTemplate(revision appsV1.ControllerRevision) (map[string]interface{}, bool) {
+	var patch struct {
+		Spec struct {
+			Template map[string]interface{} `json:"template"`
+		} `json:"spec"`
+	}
+	if err := json.Unmarshal(revision.Data.Raw, &patch); err != nil || patch.Spec.Template == nil {
+		return nil, false
+	}
+	delete(patch.Spec.Template, "$patch")
+	return patch.Spec.Template, true
+}
+
+// equalIgnoreHash compares two pod templates, ignoring the pod template hash label that is added to the replicaset template
+func equalIgnoreHash(template1, template2 v1.PodTemplateSpec) bool {
+	t1 := template1.DeepCopy()
+	t2 := template2.DeepCopy()
+	delete(t1.Labels, appsV1.DefaultDeploymentUniqueLabelKey)
+	delete(t2.Labels, appsV1.DefaultDeploymentUniqueLabelKey)
+	return apiequality.Semantic.DeepEqual(t1, t2)
+}
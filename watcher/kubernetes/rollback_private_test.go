@@ -0,0 +1,210 @@
+package kuberneteswatcher
+
+import (
+	"encoding/json"
+	"testing"
+
+	appsV1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func podTemplateMock(image string) v1.PodTemplateSpec {
+	return v1.PodTemplateSpec{
+		ObjectMeta: metaV1.ObjectMeta{Labels: map[string]string{"app": "nginx"}},
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{{Name: "nginx", Image: image}},
+		},
+	}
+}
+
+func ownerReferencesMock(uid types.UID) []metaV1.OwnerReference {
+	controller := true
+	return []metaV1.OwnerReference{{UID: uid, Controller: &controller}}
+}
+
+func replicasetRevisionMock(ownerUID types.UID, image, revision, history string) *appsV1.ReplicaSet {
+	template := podTemplateMock(image)
+	template.Labels[appsV1.DefaultDeploymentUniqueLabelKey] = image
+	return &appsV1.ReplicaSet{
+		ObjectMeta: metaV1.ObjectMeta{
+			OwnerReferences: ownerReferencesMock(ownerUID),
+			Annotations: map[string]string{
+				revisionAnnotation:        revision,
+				revisionHistoryAnnotation: history,
+			},
+		},
+		Spec: appsV1.ReplicaSetSpec{Template: template},
+	}
+}
+
+func controllerRevisionMock(ownerUID types.UID, image string, revision int64) appsV1.ControllerRevision {
+	template, _ := json.Marshal(podTemplateMock(image))
+	var templateData map[string]interface{}
+	json.Unmarshal(template, &templateData)
+	templateData["$patch"] = "replace"
+	data, _ := json.Marshal(map[string]interface{}{"spec": map[string]interface{}{"template": templateData}})
+
+	return appsV1.ControllerRevision{
+		ObjectMeta: metaV1.ObjectMeta{Name: string(ownerUID) + "-" + image, OwnerReferences: ownerReferencesMock(ownerUID)},
+		Data:       runtime.RawExtension{Raw: data},
+		Revision:   revision,
+	}
+}
+
+func TestReplicaSetRollbackRevision(t *testing.T) {
+
+	replicasets := []*appsV1.ReplicaSet{
+		replicasetRevisionMock("deployment-uid", "nginx:1", "1", ""),
+		replicasetRevisionMock("deployment-uid", "nginx:2", "2", ""),
+		replicasetRevisionMock("deployment-uid", "nginx:3", "5", "3"),
+		replicasetRevisionMock("other-uid", "nginx:4", "1", ""),
+	}
+
+	testCases := []struct {
+		name             string
+		image            string
+		expectedRollback bool
+		expectedRevision int64
+	}{
+		{"rollback", "nginx:1", true, 1},
+		{"reused_replicaset", "nginx:3", true, 3},
+		{"current_template", "nginx:2", false, 0},
+		{"new_template", "nginx:5", false, 0},
+		{"other_deployment_template", "nginx:4", false, 0},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			deployment := &appsV1.Deployment{
+				ObjectMeta: metaV1.ObjectMeta{
+					UID:         "deployment-uid",
+					Annotations: map[string]string{revisionAnnotation: "2"},
+				},
+				Spec: appsV1.DeploymentSpec{Template: podTemplateMock(test.image)},
+			}
+			revision, isRollback := replicaSetRollbackRevision(deployment, replicasets)
+			if isRollback != test.expectedRollback || revision != test.expectedRevision {
+				t.Fatalf("unexpected rollback, got %t (revision %d) expected %t (revision %d)", isRollback, revision, test.expectedRollback, test.expectedRevision)
+			}
+		})
+	}
+}
+
+func TestControllerRevisionRollbackRevision(t *testing.T) {
+
+	revisions := []appsV1.ControllerRevision{
+		controllerRevisionMock("daemonset-uid", "nginx:1", 1),
+		controllerRevisionMock("daemonset-uid", "nginx:2", 2),
+		controllerRevisionMock("other-uid", "nginx:3", 1),
+	}
+
+	testCases := []struct {
+		name             string
+		image            string
+		expectedRollback bool
+		expectedRevision int64
+	}{
+		{"rollback", "nginx:1", true, 1},
+		{"latest_revision", "nginx:2", false, 0},
+		{"new_template", "nginx:4", false, 0},
+		{"other_owner_revision", "nginx:3", false, 0},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			revision, isRollback := controllerRevisionRollbackRevision("daemonset-uid", podTemplateMock(test.image), revisions, nil)
+			if isRollback != test.expectedRollback || revision != test.expectedRevision {
+				t.Fatalf("unexpected rollback, got %t (revision %d) expected %t (revision %d)", isRollback, revision, test.expectedRollback, test.expectedRevision)
+			}
+		})
+	}
+}
+
+func TestControllerRevisionManagerListsInformerCache(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	for _, revision := range []appsV1.ControllerRevision{
+		controllerRevisionMock("daemonset-uid", "nginx:1", 1),
+		controllerRevisionMock("daemonset-uid", "nginx:2", 2),
+	} {
+		revision.Namespace = "default"
+		revision.Labels = map[string]string{"app": "nginx"}
+		client.AppsV1().ControllerRevisions("default").Create(&revision)
+	}
+	cr := NewControllerRevisionManager(NewInformerManager(client, nil, 0, nil), nil)
+	listRequests := func() int {
+		lists := 0
+		for _, action := range client.Actions() {
+			if action.GetVerb() == "list" && action.GetResource().Resource == "controllerrevisions" {
+				lists++
+			}
+		}
+		return lists
+	}
+
+	revision, isRollback := cr.GetRollbackRevision("default", "daemonset-uid", map[string]string{"app": "nginx"}, podTemplateMock("nginx:1"))
+	if !isRollback || revision != 1 {
+		t.Fatalf("unexpected rollback, got %t (revision %d) expected %t (revision %d)", isRollback, revision, true, 1)
+	}
+	informerLists := listRequests()
+
+	// The revisions are read from the informer cache once it was synced
+	if _, found := cr.GetPreviousTemplate("default", "daemonset-uid", map[string]string{"app": "nginx"}, podTemplateMock("nginx:3")); !found {
+		t.Fatalf("expected the previous template of the daemonset")
+	}
+	cr.RecordRevisions("default", "other-uid", map[string]string{"app": "nginx"}, podTemplateMock("nginx:3"))
+	if lists := listRequests(); lists != informerLists {
+		t.Fatalf("unexpected controller revisions list requests, got %d expected %d", lists, informerLists)
+	}
+}
+
+func TestControllerRevisionRollbackRevisionRecord(t *testing.T) {
+
+	// The revisions before the apply
+	record := newRevisionsRecord("daemonset-uid", podTemplateMock("nginx:2"), []appsV1.ControllerRevision{
+		controllerRevisionMock("daemonset-uid", "nginx:1", 1),
+		controllerRevisionMock("daemonset-uid", "nginx:2", 2),
+	})
+
+	// The controller bumps the number of a reused revision, and creates a new revision for a new template
+	bumped := []appsV1.ControllerRevision{
+		controllerRevisionMock("daemonset-uid", "nginx:1", 3),
+		controllerRevisionMock("daemonset-uid", "nginx:2", 2),
+	}
+	created := []appsV1.ControllerRevision{
+		controllerRevisionMock("daemonset-uid", "nginx:1", 1),
+		controllerRevisionMock("daemonset-uid", "nginx:2", 2),
+		controllerRevisionMock("daemonset-uid", "nginx:3", 3),
+	}
+
+	testCases := []struct {
+		name             string
+		image            string
+		revisions        []appsV1.ControllerRevision
+		expectedRollback bool
+		expectedRevision int64
+	}{
+		{"bumped_revision_rollback", "nginx:1", bumped, true, 1},
+		{"recorded_current_revision", "nginx:2", bumped, false, 0},
+		{"new_revision", "nginx:3", created, false, 0},
+		{"new_template", "nginx:4", bumped, false, 0},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			revision, isRollback := controllerRevisionRollbackRevision("daemonset-uid", podTemplateMock(test.image), test.revisions, record)
+			if isRollback != test.expectedRollback || revision != test.expectedRevision {
+				t.Fatalf("unexpected rollback, got %t (revision %d) expected %t (revision %d)", isRollback, revision, test.expectedRollback, test.expectedRevision)
+			}
+		})
+	}
+
+	t.Run("bumped_revision_without_record", func(t *testing.T) {
+		if _, isRollback := controllerRevisionRollbackRevision("daemonset-uid", podTemplateMock("nginx:1"), bumped, nil); isRollback {
+			t.Fatalf("unexpected rollback of the latest revision without a record")
+		}
+	})
+}
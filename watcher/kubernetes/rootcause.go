@@ -0,0 +1,301 @@
+package kuberneteswatcher
+
+import (
+	"fmt"
+	"sort"
+	"statusbay/watcher/kubernetes/common"
+	"strings"
+	"time"
+)
+
+const (
+	// FailureQuotaExceeded pods could not be created because the namespace resource quota was exceeded
+	FailureQuotaExceeded = "QuotaExceeded"
+
+	// FailurePVCPending persistent volume claims of the pods were not bound
+	FailurePVCPending = "PVCPending"
+
+	// FailureUnschedulable pods could not be scheduled on any node
+	FailureUnschedulable = "Unschedulable"
+
+	// FailureImagePull the image of a container could not be pulled
+	FailureImagePull = "ImagePullBackOff"
+
+	// FailureOOMKilled a container was killed after it exceeded its memory limit
+	FailureOOMKilled = "OOMKilled"
+
+	// FailureProbe a container failed its liveness, readiness or startup probe
+	FailureProbe = "ProbeFailed"
+
+	// FailureCrashLoop a container is restarted repeatedly after it exited
+	FailureCrashLoop = "CrashLoopBackOff"
+
+	// FailureEndpointsNotReady pods were ready but were never added to the endpoints of their services
+	FailureEndpointsNotReady = "EndpointsNotReady"
+
+	// FailureIngressBackend an ingress routes to a service that is missing or has no endpoints
+	FailureIngressBackend = "IngressBackendMissing"
+
+	// FailureSuccessCriteria the pods didn't meet the success criteria annotations of their resource
+	FailureSuccessCriteria = "SuccessCriteria"
+)
+
+// failureRule matches event messages and container states to a failure cause
+type failureRule struct {
+	reason      string
+	description string
+	patterns    []string
+}
+
+// failureRules are ordered by their rank, a cause that usually leads to the causes after it is ranked first.
+// A message is matched to the first rule that one of its patterns is found in the message, case insensitive
+var failureRules = []failureRule{
+	{FailureQuotaExceeded, "The namespace resource quota was exceeded, pods could not be created", []string{"exceeded quota", "failed quota"}},
+	{FailurePVCPending, "Persistent volume claims are pending, pods could not start", []string{"unbound immediate PersistentVolumeClaims", "no persistent volumes available for this claim", "waiting for a volume to be created", "failed to provision volume", "storageclass.storage.k8s.io"}},
+	{FailureUnschedulable, "Pods could not be scheduled on any node", []string{"nodes are available", "Insufficient cpu", "Insufficient memory", "didn't tolerate"}},
+	{FailureImagePull, "The container image could not be pulled", []string{"ImagePullBackOff", "ErrImagePull", "Failed to pull image", "Back-off pulling image", "InvalidImageName"}},
+	{FailureOOMKilled, "Containers were killed after they exceeded their memory limit", []string{"OOMKilled"}},
+	{FailureProbe, "Containers failed their health probes", []string{"Liveness probe failed", "Readiness probe failed", "Startup probe failed"}},
+	{FailureCrashLoop, "Containers exited and are restarted repeatedly", []string{"CrashLoopBackOff", "Back-off restarting failed container"}},
+	// Matched by the services endpoints addresses and not by a message
+	{FailureEndpointsNotReady, "Pods were ready but were never added to the service endpoints", nil},
+	{FailureIngressBackend, "Ingresses route to a service backend that is missing or has no endpoints", []string{"does not have any active endpoint", "no endpoints available for service", "could not find service", "backend service not found"}},
+	// Matched by the resources success criteria and not by a message
+	{FailureSuccessCriteria, "Pods didn't meet the success criteria of their resource", nil},
+}
+
+// unschedulableDetails describes the scheduling failures in the unschedulable cause details
+var unschedulableDetails = []struct {
+	pattern string
+	detail  string
+}{
+	{"insufficient cpu", "insufficient cpu"},
+	{"insufficient memory", "insufficient memory"},
+	{"taint", "node taints"},
+	{"node selector", "node selector"},
+	{"affinity", "node affinity"},
+}
+
+// failureAnalyzer collects the failure causes of the apply resources
+type failureAnalyzer struct {
+	causes      map[string]*common.FailureCause
+	messageTime map[string]int64
+}
+
+// analyzeFailure returns the ranked failure causes that were found in the collected events and container states of the resources
+func analyzeFailure(resources Resources) []common.FailureCause {
+	fa := &failureAnalyzer{
+		causes:      map[string]*common.FailureCause{},
+		messageTime: map[string]int64{},
+	}
+
+	for name, deployment := range resources.Deployments {
+		fa.addEvents(deployment.Events, "", fmt.Sprintf("deployment/%s", name))
+		for replicasetName, replicaset := range deployment.Replicaset {
+			if replicaset.Events != nil {
+				fa.addEvents(*replicaset.Events, "", fmt.Sprintf("replicaset/%s", replicasetName))
+			}
+		}
+		fa.addPods(deployment.Pods)
+		fa.addServices(deployment.Services, deployment.Pods)
+		fa.addSuccessCriteria(deployment.Deployment.SuccessCriteria, deployment.Pods, deployment.Deployment.DesiredState, fmt.Sprintf("deployment/%s", name))
+	}
+	for name, daemonset := range resources.Daemonsets {
+		fa.addEvents(daemonset.Events, "", fmt.Sprintf("daemonset/%s", name))
+		fa.addPods(daemonset.Pods)
+		fa.addServices(daemonset.Services, daemonset.Pods)
+		fa.addSuccessCriteria(daemonset.Metadata.SuccessCriteria, daemonset.Pods, daemonset.Status.DesiredNumberScheduled, fmt.Sprintf("daemonset/%s", name))
+	}
+	for name, statefulset := range resources.Statefulsets {
+		fa.addEvents(statefulset.Events, "", fmt.Sprintf("statefulset/%s", name))
+		fa.addPods(statefulset.Pods)
+		fa.addServices(statefulset.Services, statefulset.Pods)
+		fa.addSuccessCriteria(statefulset.Statefulset.SuccessCriteria, statefulset.Pods, statefulset.Statefulset.DesiredState, fmt.Sprintf("statefulset/%s", name))
+	}
+	for name, job := range resources.Jobs {
+		fa.addEvents(job.Events, "", fmt.Sprintf("job/%s", name))
+		fa.addPods(job.Pods)
+		fa.addServices(job.Services, job.Pods)
+	}
+	for name, customResource := range resources.CustomResources {
+		fa.addEvents(customResource.Events, "", fmt.Sprintf("%s/%s", strings.ToLower(customResource.Kind), name))
+		fa.addPods(customResource.Pods)
+		fa.addServices(customResource.Services, customResource.Pods)
+	}
+
+	return fa.rankedCauses()
+}
+
+// addPods adds the pods events, the pods persistent volume claims events and the pods container states
+func (fa *failureAnalyzer) addPods(pods map[string]DeploymenPod) {
+	for podName, pod := range pods {
+		if pod.Phase != nil {
+			fa.addMessage(*pod.Phase, 0, podName, "")
+		}
+		if pod.Events != nil {
+			fa.addEvents(*pod.Events, podName, "")
+		}
+		for pvcName, events := range pod.Pvcs {
+			fa.addEvents(events, podName, fmt.Sprintf("pvc/%s", pvcName))
+		}
+	}
+}
+
+// addServices adds the services events, the services ingresses events and the services that the ready pods were
+// never added to their endpoints
+func (fa *failureAnalyzer) addServices(services map[string]ServicesData, pods map[string]DeploymenPod) {
+	podsReady := hasReadyPod(pods)
+	for serviceName, service := range services {
+		if service.Events != nil {
+			fa.addEvents(*service.Events, "", fmt.Sprintf("service/%s", serviceName))
+		}
+		for ingressName, ingress := range service.Ingresses {
+			if ingress.Events != nil {
+				fa.addEvents(*ingress.Events, "", fmt.Sprintf("ingress/%s", ingressName))
+			}
+		}
+		if podsReady && neverReady(service.Endpoints) {
+			message := fmt.Sprintf("service %s has no ready endpoints", serviceName)
+			fa.addCause(failureRuleByReason(FailureEndpointsNotReady), message, 0, "", fmt.Sprintf("service/%s", serviceName))
+		}
+	}
+}
+
+// addSuccessCriteria adds the resource when its pods exceeded the max restarts or were not available as its success
+// criteria require
+func (fa *failureAnalyzer) addSuccessCriteria(criteria *SuccessCriteria, pods map[string]DeploymenPod, desired int32, resource string) {
+	if criteria == nil {
+		return
+	}
+	reason := criteria.exceededRestartsReason(pods)
+	if reason == "" {
+		reason = criteria.unavailableReason(pods, desired, time.Now())
+	}
+	if reason == "" {
+		return
+	}
+	fa.addCause(failureRuleByReason(FailureSuccessCriteria), fmt.Sprintf("%s: %s", resource, reason), 0, "", resource)
+}
+
+// hasReadyPod returns true when one of the pods was ready
+func hasReadyPod(pods map[string]DeploymenPod) bool {
+	for _, pod := range pods {
+		if pod.Timeline != nil && pod.Timeline.Ready > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// neverReady returns true when the endpoints were collected and none of the samples had a ready address
+func neverReady(endpoints []EndpointsReadiness) bool {
+	if len(endpoints) == 0 {
+		return false
+	}
+	for _, sample := range endpoints {
+		if sample.Ready > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// addEvents adds the events of a pod or a resource
+func (fa *failureAnalyzer) addEvents(events []EventMessages, podName, resource string) {
+	for _, event := range events {
+		fa.addMessage(event.Message, event.lastSeen(), podName, resource)
+	}
+}
+
+// addMessage matches the message to a failure cause, and adds the pod and the resource to the cause
+func (fa *failureAnalyzer) addMessage(message string, time int64, podName, resource string) {
+	rule, found := matchFailureRule(message)
+	if !found {
+		return
+	}
+	fa.addCause(rule, message, time, podName, resource)
+}
+
+// addCause adds the pod and the resource to the cause of the rule, the latest message of the cause is kept
+func (fa *failureAnalyzer) addCause(rule failureRule, message string, time int64, podName, resource string) {
+	cause, found := fa.causes[rule.reason]
+	if !found {
+		cause = &common.FailureCause{
+			Reason:      rule.reason,
+			Description: rule.description,
+			Details:     []string{},
+			Pods:        []string{},
+			Resources:   []string{},
+		}
+		fa.causes[rule.reason] = cause
+		fa.messageTime[rule.reason] = time
+		cause.Message = message
+	}
+	if time > fa.messageTime[rule.reason] {
+		fa.messageTime[rule.reason] = time
+		cause.Message = message
+	}
+
+	if podName != "" {
+		cause.Pods = appendUnique(cause.Pods, podName)
+	}
+	if resource != "" {
+		cause.Resources = appendUnique(cause.Resources, resource)
+	}
+	if rule.reason == FailureUnschedulable {
+		lowerMessage := strings.ToLower(message)
+		for _, detail := range unschedulableDetails {
+			if strings.Contains(lowerMessage, detail.pattern) {
+				cause.Details = appendUnique(cause.Details, detail.detail)
+			}
+		}
+	}
+}
+
+// rankedCauses returns the collected causes ordered by the failure rules rank
+func (fa *failureAnalyzer) rankedCauses() []common.FailureCause {
+	causes := []common.FailureCause{}
+	for _, rule := range failureRules {
+		cause, found := fa.causes[rule.reason]
+		if !found {
+			continue
+		}
+		sort.Strings(cause.Pods)
+		sort.Strings(cause.Resources)
+		causes = append(causes, *cause)
+	}
+	return causes
+}
+
+// matchFailureRule returns the first failure rule that matches the message
+func matchFailureRule(message string) (failureRule, bool) {
+	lowerMessage := strings.ToLower(message)
+	for _, rule := range failureRules {
+		for _, pattern := range rule.patterns {
+			if strings.Contains(lowerMessage, strings.ToLower(pattern)) {
+				return rule, true
+			}
+		}
+	}
+	return failureRule{}, false
+}
+
+// failureRuleByReason returns the failure rule of the reason
+func failureRuleByReason(reason string) failureRule {
+	for _, rule := range failureRules {
+		if rule.reason == reason {
+			return rule
+		}
+	}
+	return failureRule{reason: reason}
+}
+
+// appendUnique appends the value when it doesn't exist in the list
+func appendUnique(values []string, value string) []string {
+	for _, existing := range values {
+		if existing == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
@@ -0,0 +1,105 @@
+package kuberneteswatcher
+
+import (
+	"reflect"
+	"statusbay/watcher/kubernetes/common"
+	"testing"
+)
+
+func podMock(phase string, events ...EventMessages) DeploymenPod {
+	return DeploymenPod{
+		Phase:  &phase,
+		Events: &events,
+		Pvcs:   map[string][]EventMessages{},
+	}
+}
+
+func TestAnalyzeFailure(t *testing.T) {
+
+	pvcPod := podMock("Pending", EventMessages{Message: "0/3 nodes are available: 3 pod has unbound immediate PersistentVolumeClaims.", Time: 1})
+	pvcPod.Pvcs["data"] = []EventMessages{{Message: "storageclass.storage.k8s.io \"fast\" not found", Time: 2}}
+
+	resources := Resources{
+		Deployments: map[string]*DeploymentData{
+			"nginx": {
+				Events: []EventMessages{{Message: "Scaled up replica set nginx-1 to 3", Time: 1}},
+				Replicaset: map[string]Replicaset{
+					"nginx-1": {Events: &[]EventMessages{{Message: "Error creating: pods \"nginx-1-abc\" is forbidden: exceeded quota: compute, requested: cpu=1", Time: 3}}},
+				},
+				Pods: map[string]DeploymenPod{
+					"nginx-1-a": podMock("ImagePullBackOff", EventMessages{Message: "Failed to pull image \"nginx:404\": not found", Time: 1}),
+					"nginx-1-b": podMock("CrashLoopBackOff",
+						EventMessages{Message: "OOMKilled", Time: 1},
+						EventMessages{Message: "CrashLoopBackOff - back-off 10s restarting failed container", Time: 2},
+					),
+					"nginx-1-c": podMock("Running", EventMessages{Message: "Readiness probe failed: connection refused", Time: 1}),
+					"nginx-1-d": podMock("Pending", EventMessages{Message: "0/3 nodes are available: 1 Insufficient cpu, 2 node(s) had taints that the pod didn't tolerate.", Time: 1}),
+					"nginx-1-e": podMock("Running"),
+				},
+				Services: map[string]ServicesData{},
+			},
+		},
+		Statefulsets: map[string]*StatefulsetData{
+			"db": {
+				Pods:     map[string]DeploymenPod{"db-0": pvcPod},
+				Services: map[string]ServicesData{},
+			},
+		},
+	}
+
+	expected := []common.FailureCause{
+		{Reason: FailureQuotaExceeded, Description: failureRules[0].description, Details: []string{}, Message: "Error creating: pods \"nginx-1-abc\" is forbidden: exceeded quota: compute, requested: cpu=1", Pods: []string{}, Resources: []string{"replicaset/nginx-1"}},
+		{Reason: FailurePVCPending, Description: failureRules[1].description, Details: []string{}, Message: "storageclass.storage.k8s.io \"fast\" not found", Pods: []string{"db-0"}, Resources: []string{"pvc/data"}},
+		{Reason: FailureUnschedulable, Description: failureRules[2].description, Details: []string{"insufficient cpu", "node taints"}, Message: "0/3 nodes are available: 1 Insufficient cpu, 2 node(s) had taints that the pod didn't tolerate.", Pods: []string{"nginx-1-d"}, Resources: []string{}},
+		{Reason: FailureImagePull, Description: failureRules[3].description, Details: []string{}, Message: "Failed to pull image \"nginx:404\": not found", Pods: []string{"nginx-1-a"}, Resources: []string{}},
+		{Reason: FailureOOMKilled, Description: failureRules[4].description, Details: []string{}, Message: "OOMKilled", Pods: []string{"nginx-1-b"}, Resources: []string{}},
+		{Reason: FailureProbe, Description: failureRules[5].description, Details: []string{}, Message: "Readiness probe failed: connection refused", Pods: []string{"nginx-1-c"}, Resources: []string{}},
+		{Reason: FailureCrashLoop, Description: failureRules[6].description, Details: []string{}, Message: "CrashLoopBackOff - back-off 10s restarting failed container", Pods: []string{"nginx-1-b"}, Resources: []string{}},
+	}
+
+	causes := analyzeFailure(resources)
+	if !reflect.DeepEqual(causes, expected) {
+		t.Fatalf("unexpected failure causes, got %+v expected %+v", causes, expected)
+	}
+
+	if causes := analyzeFailure(Resources{}); len(causes) != 0 {
+		t.Fatalf("unexpected failure causes for empty resources, got %+v", causes)
+	}
+}
+
+func TestAnalyzeFailureServices(t *testing.T) {
+
+	readyPod := podMock("Running")
+	readyPod.Timeline = &PodTimeline{Ready: 1}
+
+	resources := Resources{
+		Deployments: map[string]*DeploymentData{
+			"web": {
+				Pods: map[string]DeploymenPod{"web-1": readyPod},
+				Services: map[string]ServicesData{
+					"web": {
+						Events:    &[]EventMessages{},
+						Endpoints: []EndpointsReadiness{{Time: 1, Ready: 0, NotReady: 1}, {Time: 2, Ready: 0, NotReady: 0}},
+						Ingresses: map[string]IngressData{
+							"web": {Events: &[]EventMessages{{Message: "Service \"default/web\" does not have any active Endpoint", Time: 3}}},
+						},
+					},
+					"api": {
+						Events:    &[]EventMessages{},
+						Endpoints: []EndpointsReadiness{{Time: 1, Ready: 0, NotReady: 1}, {Time: 2, Ready: 1, NotReady: 0}},
+					},
+				},
+			},
+		},
+	}
+
+	expected := []common.FailureCause{
+		{Reason: FailureEndpointsNotReady, Description: failureRuleByReason(FailureEndpointsNotReady).description, Details: []string{}, Message: "service web has no ready endpoints", Pods: []string{}, Resources: []string{"service/web"}},
+		{Reason: FailureIngressBackend, Description: failureRuleByReason(FailureIngressBackend).description, Details: []string{}, Message: "Service \"default/web\" does not have any active Endpoint", Pods: []string{}, Resources: []string{"ingress/web"}},
+	}
+
+	causes := analyzeFailure(resources)
+	if !reflect.DeepEqual(causes, expected) {
+		t.Fatalf("unexpected failure causes, got %+v expected %+v", causes, expected)
+	}
+}
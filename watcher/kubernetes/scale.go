@@ -0,0 +1,70 @@
+package kuberneteswatcher
+
+import (
+	"fmt"
+	"statusbay/watcher/kubernetes/common"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ScaleEvent describes a change of the resource replicas count without a change of the resource spec
+type ScaleEvent struct {
+	Application  string
+	Cluster      string
+	Namespace    string
+	Kind         string
+	ResourceName string
+	FromReplicas int32
+	ToReplicas   int32
+	Time         int64
+}
+
+// newScaleEvent saves the scale event in the application scaling history, and reports it when scaling notifications are enabled
+func (dr *RegistryManager) newScaleEvent(data ApplyEvent, previousReplicas int32) {
+
+	event := ScaleEvent{
+		Application:  data.ApplyName,
+		Cluster:      dr.clusterName,
+		Namespace:    data.Namespace,
+		Kind:         data.Kind,
+		ResourceName: data.ResourceName,
+		FromReplicas: previousReplicas,
+		ToReplicas:   *data.Replicas,
+		Time:         time.Now().Unix(),
+	}
+
+	lg := log.WithFields(log.Fields{
+		"application":   event.Application,
+		"namespace":     event.Namespace,
+		"cluster":       event.Cluster,
+		"resource_name": event.ResourceName,
+		"resource_kind": event.Kind,
+		"from_replicas": event.FromReplicas,
+		"to_replicas":   event.ToReplicas,
+	})
+	lg.Info("resource was scaled")
+
+	if err := dr.storage.CreateScaleEvent(event); err != nil {
+		lg.WithError(err).Error("failed to save the scale event")
+	}
+
+	if !dr.notifyScaling {
+		return
+	}
+
+	report := common.ScaleReport{
+		To:           GetMetadataByPrefix(data.Annotations, fmt.Sprintf("%s/%s-", annotationPrefix, annotationPrefixAllReporter)),
+		Name:         event.Application,
+		Kind:         event.Kind,
+		ResourceName: event.ResourceName,
+		FromReplicas: event.FromReplicas,
+		ToReplicas:   event.ToReplicas,
+		LogEntry:     *lg,
+		ClusterName:  event.Cluster,
+	}
+	// The report is sent in the background, the apply lock is held while the scale event is created
+	go func() {
+		dr.reporter.DeploymentScaled <- report
+	}()
+}
@@ -2,30 +2,32 @@ package kuberneteswatcher
 
 import (
 	"context"
+	"fmt"
 	"sync"
 
 	log "github.com/sirupsen/logrus"
 
 	v1 "k8s.io/api/core/v1"
+	networkingV1beta1 "k8s.io/api/networking/v1beta1"
 	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
-	"k8s.io/client-go/kubernetes"
+	eventwatch "k8s.io/apimachinery/pkg/watch"
 )
 
 // ServiceManager defined service manager struct
 type ServiceManager struct {
-	eventManager *EventsManager
-	client       kubernetes.Interface
-	Watch        chan WatchData
+	eventManager    *EventsManager
+	informerManager *InformerManager
+	Watch           chan WatchData
 
 	dashboardURL string
 }
 
 // NewServiceManager create new service instance
-func NewServiceManager(kubernetesClientset kubernetes.Interface, eventManager *EventsManager) *ServiceManager {
+func NewServiceManager(informerManager *InformerManager, eventManager *EventsManager) *ServiceManager {
 	return &ServiceManager{
-		client:       kubernetesClientset,
-		eventManager: eventManager,
+		informerManager: informerManager,
+		eventManager:    eventManager,
 
 		Watch: make(chan WatchData),
 	}
@@ -58,17 +60,18 @@ func (sm *ServiceManager) watch(watchData WatchData) {
 		watchData.LogEntry.Info("start watching service")
 		watchData.LogEntry.WithField("list_option", watchData.ListOptions).Debug("start watch on service with list options")
 
-		watcher, err := sm.client.CoreV1().Services(watchData.Namespace).Watch(watchData.ListOptions)
-
-		if err != nil {
-			watchData.LogEntry.WithError(err).Error("could not start watch on service")
-			return
-		}
+		watcher := sm.informerManager.ResumableWatch(watchData.Ctx, watchData.LogEntry, watchData.WatchHealth, servicesResource, watchData.Namespace, watchData.ListOptions)
 		firstInit := map[string]bool{}
+		selectors := map[string]map[string]string{}
+
+		// Ingresses don't have the service labels, all the namespace ingresses are watched and matched to the services by their backends
+		ingressWatcher := sm.informerManager.ResumableWatch(watchData.Ctx, watchData.LogEntry, watchData.WatchHealth, ingressesResource, watchData.Namespace, metaV1.ListOptions{TimeoutSeconds: watchData.ListOptions.TimeoutSeconds})
+		ingresses := map[string]*networkingV1beta1.Ingress{}
+		trackedIngresses := map[string]bool{}
 
 		for {
 			select {
-			case event, watch := <-watcher.ResultChan():
+			case event, watch := <-watcher:
 				if !watch {
 					watchData.LogEntry.Warn("service watcher was stopped, channel was closed")
 					return
@@ -78,6 +81,17 @@ func (sm *ServiceManager) watch(watchData WatchData) {
 					watchData.LogEntry.WithField("object", event.Object).Warn("failed to parse service watcher data")
 					continue
 				}
+				previousSelector, selectorFound := selectors[svc.GetName()]
+				selectors[svc.GetName()] = svc.Spec.Selector
+				if selectorFound && !labels.Equals(previousSelector, svc.Spec.Selector) {
+					change := newServiceSelectorChange(sm.informerManager, watchData.Namespace, previousSelector, svc.Spec.Selector)
+					watchData.LogEntry.WithFields(log.Fields{
+						"service":  svc.GetName(),
+						"workload": change.Workload,
+					}).Info("service selector was changed")
+					watchData.RegistryData.UpdateServiceSelector(svc.GetName(), change)
+				}
+
 				if _, found := firstInit[svc.GetName()]; !found {
 					firstInit[svc.GetName()] = true
 					eventListOptions := metaV1.ListOptions{FieldSelector: labels.SelectorFromSet(map[string]string{
@@ -86,12 +100,39 @@ func (sm *ServiceManager) watch(watchData WatchData) {
 					}).String(),
 					}
 					watchData.RegistryData.NewService(svc)
-					sm.watchEvents(watchData.Ctx, watchData.LogEntry, watchData.RegistryData, eventListOptions, svc.GetName(), watchData.Namespace)
+					sm.watchEvents(watchData.Ctx, watchData.LogEntry, watchData.WatchHealth, watchData.RegistryData, eventListOptions, newObjectReference("Service", svc), svc.GetName(), watchData.Namespace)
+					sm.watchEndpoints(watchData, svc.GetName())
+					for _, ingress := range ingresses {
+						if routesToService(ingress, svc.GetName()) {
+							sm.trackIngress(watchData, trackedIngresses, svc.GetName(), ingress)
+						}
+					}
+				}
+
+			case event, watch := <-ingressWatcher:
+				if !watch {
+					watchData.LogEntry.Debug("ingress watcher was stopped, channel was closed")
+					ingressWatcher = nil
+					continue
+				}
+				ingress, isOk := event.Object.(*networkingV1beta1.Ingress)
+				if !isOk {
+					watchData.LogEntry.WithField("object", event.Object).Warn("failed to parse ingress watcher data")
+					continue
+				}
+				if event.Type == eventwatch.Deleted {
+					delete(ingresses, ingress.GetName())
+					continue
+				}
+				ingresses[ingress.GetName()] = ingress
+				for serviceName := range firstInit {
+					if routesToService(ingress, serviceName) {
+						sm.trackIngress(watchData, trackedIngresses, serviceName, ingress)
+					}
 				}
 
 			case <-watchData.Ctx.Done():
 				watchData.LogEntry.Debug("service watcher was stopped, got ctx done signal")
-				watcher.Stop()
 				return
 			}
 		}
@@ -100,16 +141,102 @@ func (sm *ServiceManager) watch(watchData WatchData) {
 
 }
 
+// watchEndpoints will start watch on the service endpoints addresses changes
+func (sm *ServiceManager) watchEndpoints(watchData WatchData, serviceName string) {
+
+	listOptions := metaV1.ListOptions{
+		TimeoutSeconds: watchData.ListOptions.TimeoutSeconds,
+		FieldSelector: labels.SelectorFromSet(map[string]string{
+			"metadata.name": serviceName,
+		}).String(),
+	}
+	endpointsLog := watchData.LogEntry.WithField("service", serviceName)
+
+	go func() {
+
+		endpointsLog.Debug("start watching service endpoints")
+		watcher := sm.informerManager.ResumableWatch(watchData.Ctx, *endpointsLog, watchData.WatchHealth, endpointsResource, watchData.Namespace, listOptions)
+
+		for {
+			select {
+			case event, watch := <-watcher:
+				if !watch {
+					endpointsLog.Debug("endpoints watcher was stopped, channel was closed")
+					return
+				}
+				endpoints, isOk := event.Object.(*v1.Endpoints)
+				if !isOk {
+					endpointsLog.WithField("object", event.Object).Warn("failed to parse endpoints watcher data")
+					continue
+				}
+				// Deleted endpoints don't have addresses
+				if event.Type == eventwatch.Deleted {
+					endpoints = &v1.Endpoints{}
+				}
+				watchData.RegistryData.UpdateServiceEndpoints(serviceName, endpoints)
+			case <-watchData.Ctx.Done():
+				endpointsLog.Debug("endpoints watcher was stopped, got ctx done signal")
+				return
+			}
+		}
+	}()
+}
+
+// trackIngress adds the ingress to the service and starts watching the ingress events, once per service and ingress
+func (sm *ServiceManager) trackIngress(watchData WatchData, trackedIngresses map[string]bool, serviceName string, ingress *networkingV1beta1.Ingress) {
+	key := fmt.Sprintf("%s/%s", serviceName, ingress.GetName())
+	if trackedIngresses[key] {
+		return
+	}
+	trackedIngresses[key] = true
+
+	ingressLog := watchData.LogEntry.WithFields(log.Fields{
+		"service": serviceName,
+		"ingress": ingress.GetName(),
+	})
+	ingressLog.Info("ingress that routes to the service was found")
+	watchData.RegistryData.NewServiceIngress(serviceName, ingress.GetName())
+
+	eventChan := sm.eventManager.Watch(WatchEvents{
+		ListOptions: metaV1.ListOptions{FieldSelector: labels.SelectorFromSet(map[string]string{
+			"involvedObject.name": ingress.GetName(),
+			"involvedObject.kind": "Ingress",
+		}).String(),
+		},
+		Namespace:         watchData.Namespace,
+		Ctx:               watchData.Ctx,
+		LogEntry:          *ingressLog,
+		WatchHealth:       watchData.WatchHealth,
+		InvolvedObject:    newObjectReference("Ingress", ingress),
+		ApplyCreationTime: watchData.WatchHealth.applyCreation(),
+	})
+	go func() {
+
+		for {
+			select {
+			case event := <-eventChan:
+				watchData.RegistryData.UpdateServiceIngressEvents(serviceName, ingress.GetName(), event)
+			case <-watchData.Ctx.Done():
+				ingressLog.Info("stop watching on ingress events")
+				return
+			}
+		}
+	}()
+}
+
 // watchEvents will start watch on service event messages changes
-func (sm *ServiceManager) watchEvents(ctx context.Context, lg log.Entry, registryDeployment RegistryData, listOptions metaV1.ListOptions, serviceName, namespace string) {
+func (sm *ServiceManager) watchEvents(ctx context.Context, lg log.Entry, health *WatchHealth, registryDeployment RegistryData, listOptions metaV1.ListOptions, involvedObject v1.ObjectReference, serviceName, namespace string) {
 
 	lg.Info("initializing the event watcher on service events")
 
 	watchData := WatchEvents{
-		ListOptions: listOptions,
-		Namespace:   namespace,
-		Ctx:         ctx,
-		LogEntry:    lg,
+		ListOptions:       listOptions,
+		Namespace:         namespace,
+		Ctx:               ctx,
+		LogEntry:          lg,
+		WatchHealth:       health,
+		InvolvedObject:    involvedObject,
+		ApplyCreationTime: health.applyCreation(),
 	}
 
 	eventChan := sm.eventManager.Watch(watchData)
@@ -126,3 +253,37 @@ func (sm *ServiceManager) watchEvents(ctx context.Context, lg log.Entry, registr
 		}
 	}()
 }
+
+// routesToService returns true when the default backend or one of the paths of the ingress routes to the service
+func routesToService(ingress *networkingV1beta1.Ingress, serviceName string) bool {
+	if ingress.Spec.Backend != nil && ingress.Spec.Backend.ServiceName == serviceName {
+		return true
+	}
+	for _, rule := range ingress.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+		for _, path := range rule.HTTP.Paths {
+			if path.Backend.ServiceName == serviceName {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// endpointsAddresses returns the count of the ready and the not ready addresses of the endpoints. An address that
+// is listed in a few subsets (one for each ports set) is counted once
+func endpointsAddresses(endpoints *v1.Endpoints) (int, int) {
+	ready := map[string]bool{}
+	notReady := map[string]bool{}
+	for _, subset := range endpoints.Subsets {
+		for _, address := range subset.Addresses {
+			ready[address.IP] = true
+		}
+		for _, address := range subset.NotReadyAddresses {
+			notReady[address.IP] = true
+		}
+	}
+	return len(ready), len(notReady)
+}
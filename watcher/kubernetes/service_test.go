@@ -10,6 +10,7 @@ import (
 
 	log "github.com/sirupsen/logrus"
 	v1 "k8s.io/api/core/v1"
+	networkingV1beta1 "k8s.io/api/networking/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"k8s.io/client-go/kubernetes/fake"
@@ -29,7 +30,7 @@ func createServiceMock(client *fake.Clientset, name, namespace string) {
 func NewServiceManagerMockMock(client *fake.Clientset) *kuberneteswatcher.ServiceManager {
 
 	eventManager := NewEventsMock(client)
-	serviceManager := kuberneteswatcher.NewServiceManager(client, eventManager)
+	serviceManager := kuberneteswatcher.NewServiceManager(NewInformerManagerMock(client), eventManager)
 	return serviceManager
 
 }
@@ -91,3 +92,167 @@ func TestServiceWatch(t *testing.T) {
 	}
 
 }
+
+func TestServiceEndpointsAndIngresses(t *testing.T) {
+	registry, storageMock := NewRegistryMock()
+
+	registryRow := registry.NewApplication("nginx", "default", map[string]string{}, common.ApplyStatusRunning)
+	namespace := "default"
+	apply := kuberneteswatcher.ApplyEvent{
+		Event:        "create",
+		ApplyName:    "nginx-deployment",
+		ResourceName: "resourceName",
+		Namespace:    namespace,
+		Kind:         "deployment",
+		Hash:         1234,
+		Annotations:  map[string]string{},
+		Labels:       map[string]string{},
+	}
+
+	registryDeploymentData := createMockDeploymentData(registry, registryRow, apply, "10m")
+	lg := log.WithField("test", "TestServiceEndpointsAndIngresses")
+	ctx := context.Background()
+
+	client := fake.NewSimpleClientset()
+
+	serviceManager := NewServiceManagerMockMock(client)
+
+	var wg sync.WaitGroup
+
+	serviceManager.Serve(ctx, &wg)
+
+	serviceManager.Watch <- kuberneteswatcher.WatchData{
+		ListOptions:  metav1.ListOptions{},
+		RegistryData: registryDeploymentData,
+		Namespace:    namespace,
+		Ctx:          ctx,
+		LogEntry:     *lg,
+	}
+
+	// The ingress is created before the service, and is matched once the service is found
+	ingress := &networkingV1beta1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: namespace},
+		Spec: networkingV1beta1.IngressSpec{
+			Rules: []networkingV1beta1.IngressRule{{
+				IngressRuleValue: networkingV1beta1.IngressRuleValue{HTTP: &networkingV1beta1.HTTPIngressRuleValue{
+					Paths: []networkingV1beta1.HTTPIngressPath{{Path: "/", Backend: networkingV1beta1.IngressBackend{ServiceName: "service-1"}}},
+				}},
+			}},
+		},
+	}
+	client.NetworkingV1beta1().Ingresses(namespace).Create(ingress)
+
+	time.Sleep(time.Second)
+	createServiceMock(client, "service-1", namespace)
+	time.Sleep(time.Second)
+
+	endpoints := &v1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{Name: "service-1", Namespace: namespace},
+		Subsets: []v1.EndpointSubset{
+			{NotReadyAddresses: []v1.EndpointAddress{{IP: "10.0.0.1"}, {IP: "10.0.0.2"}}},
+		},
+	}
+	endpoints, _ = client.CoreV1().Endpoints(namespace).Create(endpoints)
+	time.Sleep(time.Second)
+
+	endpoints.Subsets = []v1.EndpointSubset{
+		{Addresses: []v1.EndpointAddress{{IP: "10.0.0.1"}}, NotReadyAddresses: []v1.EndpointAddress{{IP: "10.0.0.2"}}},
+		{Addresses: []v1.EndpointAddress{{IP: "10.0.0.1"}}},
+	}
+	client.CoreV1().Endpoints(namespace).Update(endpoints)
+
+	event := &v1.Event{Message: "Service \"default/service-1\" does not have any active Endpoint", Type: v1.EventTypeWarning, InvolvedObject: v1.ObjectReference{Kind: "Ingress", Name: "web"}, ObjectMeta: metav1.ObjectMeta{CreationTimestamp: metav1.Time{Time: time.Now()}}}
+	client.CoreV1().Events(namespace).Create(event)
+
+	time.Sleep(time.Second * 2)
+
+	service := storageMock.MockWriteDeployment["1"].Schema.Resources.Deployments["resourceName"].Services["service-1"]
+
+	expectedEndpoints := [][]int{{2, 0}, {1, 1}}
+	if len(service.Endpoints) != len(expectedEndpoints) {
+		t.Fatalf("unexpected endpoints samples count, got %d expected %d", len(service.Endpoints), len(expectedEndpoints))
+	}
+	for i, sample := range service.Endpoints {
+		if sample.NotReady != expectedEndpoints[i][0] || sample.Ready != expectedEndpoints[i][1] {
+			t.Fatalf("unexpected endpoints sample %d, got %+v", i, sample)
+		}
+	}
+
+	ingressData, found := service.Ingresses["web"]
+	if !found {
+		t.Fatalf("ingress web was not found in the service ingresses")
+	}
+	if len(*ingressData.Events) != 1 {
+		t.Fatalf("unexpected ingress event count, got %d expected %d", len(*ingressData.Events), 1)
+	}
+}
+
+func TestServiceSelectorChange(t *testing.T) {
+	registry, storageMock := NewRegistryMock()
+
+	registryRow := registry.NewApplication("nginx", "default", map[string]string{}, common.ApplyStatusRunning)
+	namespace := "default"
+	apply := kuberneteswatcher.ApplyEvent{
+		Event:        "create",
+		ApplyName:    "nginx-deployment",
+		ResourceName: "resourceName",
+		Namespace:    namespace,
+		Kind:         "deployment",
+		Hash:         1234,
+		Annotations:  map[string]string{},
+		Labels:       map[string]string{},
+	}
+
+	registryDeploymentData := createMockDeploymentData(registry, registryRow, apply, "10m")
+	lg := log.WithField("test", "TestServiceSelectorChange")
+	ctx := context.Background()
+
+	client := fake.NewSimpleClientset()
+	createWorkloadPodMock(client, namespace, "nginx-green", "green")
+
+	serviceManager := NewServiceManagerMockMock(client)
+
+	var wg sync.WaitGroup
+
+	serviceManager.Serve(ctx, &wg)
+
+	serviceManager.Watch <- kuberneteswatcher.WatchData{
+		ListOptions:  metav1.ListOptions{},
+		RegistryData: registryDeploymentData,
+		Namespace:    namespace,
+		Ctx:          ctx,
+		LogEntry:     *lg,
+	}
+
+	time.Sleep(time.Second)
+	service := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "service-1", Namespace: namespace},
+		Spec:       v1.ServiceSpec{Selector: map[string]string{"color": "blue"}},
+	}
+	service, _ = client.CoreV1().Services(namespace).Create(service)
+	time.Sleep(time.Second)
+
+	service.Spec.Selector = map[string]string{"color": "green"}
+	client.CoreV1().Services(namespace).Update(service)
+	time.Sleep(time.Second)
+
+	endpoints := &v1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{Name: "service-1", Namespace: namespace},
+		Subsets: []v1.EndpointSubset{
+			{Addresses: []v1.EndpointAddress{{IP: "10.0.0.1", TargetRef: &v1.ObjectReference{Kind: "Pod", Name: "nginx-green-pod"}}}},
+		},
+	}
+	client.CoreV1().Endpoints(namespace).Create(endpoints)
+	time.Sleep(time.Second * 2)
+
+	changes := storageMock.MockWriteDeployment["1"].Schema.Resources.Deployments["resourceName"].Services["service-1"].SelectorChanges
+	if len(changes) != 1 {
+		t.Fatalf("unexpected selector changes count, got %d expected %d", len(changes), 1)
+	}
+	if changes[0].Workload != "deployment/nginx-green" {
+		t.Fatalf("unexpected workload, got %s expected %s", changes[0].Workload, "deployment/nginx-green")
+	}
+	if changes[0].EndpointsFlipped == 0 {
+		t.Fatalf("expected the endpoints to be flipped")
+	}
+}
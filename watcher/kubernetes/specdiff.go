@@ -0,0 +1,313 @@
+package kuberneteswatcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+
+	appsV1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// SpecChangeImage is a change of a container image
+	SpecChangeImage = "image"
+
+	// SpecChangeEnv is a change of a container environment variable, the values are redacted
+	SpecChangeEnv = "env"
+
+	// SpecChangeResources is a change of a container resource request or limit
+	SpecChangeResources = "resources"
+
+	// SpecChangeProbe is a change of a container liveness or readiness probe
+	SpecChangeProbe = "probe"
+
+	// SpecChangeReplicas is a change of the resource replicas count
+	SpecChangeReplicas = "replicas"
+
+	// SpecChangeAnnotation is a change of a pod template annotation
+	SpecChangeAnnotation = "annotation"
+
+	// SpecChangeSelector is a change of a service selector label
+	SpecChangeSelector = "selector"
+
+	// SpecChangeAdded describes a value that doesn't exist in the previous version
+	SpecChangeAdded = "added"
+
+	// SpecChangeRemoved describes a value that doesn't exist in the current version
+	SpecChangeRemoved = "removed"
+
+	// SpecChangeModified describes a value that exists in both versions with a different content
+	SpecChangeModified = "modified"
+
+	// redactedValue replaces the environment variables values in the spec diff
+	redactedValue = "<redacted>"
+)
+
+// SpecChange describes a single change of a resource spec against the previous version
+type SpecChange struct {
+	Type      string `json:"Type"`
+	Action    string `json:"Action"`
+	Container string `json:"Container,omitempty"`
+	Name      string `json:"Name"`
+	Previous  string `json:"Previous"`
+	Current   string `json:"Current"`
+}
+
+// SpecDiff describes the changes of a resource spec in the apply
+type SpecDiff struct {
+	Kind         string       `json:"Kind"`
+	ResourceName string       `json:"ResourceName"`
+	Changes      []SpecChange `json:"Changes"`
+}
+
+// newSpecChange creates a spec change, the action is taken from the existence of the previous and the current values
+func newSpecChange(changeType, container, name string, previous, current *string) SpecChange {
+	change := SpecChange{
+		Type:      changeType,
+		Action:    SpecChangeModified,
+		Container: container,
+		Name:      name,
+	}
+	if previous == nil {
+		change.Action = SpecChangeAdded
+	} else {
+		change.Previous = *previous
+	}
+	if current == nil {
+		change.Action = SpecChangeRemoved
+	} else {
+		change.Current = *current
+	}
+	return change
+}
+
+// replicasSpecChange returns the change of the replicas count
+func replicasSpecChange(previous, current int32) SpecChange {
+	previousValue := strconv.Itoa(int(previous))
+	currentValue := strconv.Itoa(int(current))
+	return newSpecChange(SpecChangeReplicas, "", "replicas", &previousValue, &currentValue)
+}
+
+// podTemplateSpecChanges returns the changes of the current pod template against the previous pod template
+func podTemplateSpecChanges(previous, current v1.PodTemplateSpec) []SpecChange {
+	changes := []SpecChange{}
+
+	previousContainers := map[string]v1.Container{}
+	for _, container := range podContainers(previous.Spec) {
+		previousContainers[container.Name] = container
+	}
+
+	currentContainers := map[string]bool{}
+	for _, container := range podContainers(current.Spec) {
+		currentContainers[container.Name] = true
+		previousContainer, found := previousContainers[container.Name]
+		if !found {
+			changes = append(changes, containerSpecChanges(container.Name, v1.Container{}, container)...)
+			continue
+		}
+		changes = append(changes, containerSpecChanges(container.Name, previousContainer, container)...)
+	}
+
+	for _, container := range podContainers(previous.Spec) {
+		if !currentContainers[container.Name] {
+			changes = append(changes, containerSpecChanges(container.Name, container, v1.Container{})...)
+		}
+	}
+
+	changes = append(changes, mapSpecChanges(SpecChangeAnnotation, "", previous.Annotations, current.Annotations, false)...)
+	return changes
+}
+
+// podContainers returns the init containers and the containers of the pod
+func podContainers(spec v1.PodSpec) []v1.Container {
+	containers := make([]v1.Container, 0, len(spec.InitContainers)+len(spec.Containers))
+	containers = append(containers, spec.InitContainers...)
+	return append(containers, spec.Containers...)
+}
+
+// containerSpecChanges returns the image, environment variables, resources and probes changes of a container.
+// An empty container describes a container that doesn't exist in the version
+func containerSpecChanges(name string, previous, current v1.Container) []SpecChange {
+	changes := []SpecChange{}
+
+	if previous.Image != current.Image {
+		changes = append(changes, newSpecChange(SpecChangeImage, name, "image", optionalString(previous.Image), optionalString(current.Image)))
+	}
+
+	changes = append(changes, mapSpecChanges(SpecChangeEnv, name, envValues(previous.Env), envValues(current.Env), true)...)
+	changes = append(changes, mapSpecChanges(SpecChangeResources, name, resourceValues(previous.Resources), resourceValues(current.Resources), false)...)
+
+	probes := []struct {
+		name     string
+		previous *v1.Probe
+		current  *v1.Probe
+	}{
+		{"liveness", previous.LivenessProbe, current.LivenessProbe},
+		{"readiness", previous.ReadinessProbe, current.ReadinessProbe},
+	}
+	for _, probe := range probes {
+		if apiequality.Semantic.DeepEqual(probe.previous, probe.current) {
+			continue
+		}
+		changes = append(changes, newSpecChange(SpecChangeProbe, name, probe.name, probeValue(probe.previous), probeValue(probe.current)))
+	}
+
+	return changes
+}
+
+// mapSpecChanges returns the changes between two key/value maps, sorted by key.
+// When redact is set the values are not saved, only the action of the change
+func mapSpecChanges(changeType, container string, previous, current map[string]string, redact bool) []SpecChange {
+	keys := []string{}
+	for key := range previous {
+		keys = append(keys, key)
+	}
+	for key := range current {
+		if _, found := previous[key]; !found {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	changes := []SpecChange{}
+	for _, key := range keys {
+		previousValue, previousFound := previous[key]
+		currentValue, currentFound := current[key]
+		if previousFound && currentFound && previousValue == currentValue {
+			continue
+		}
+		if redact {
+			previousValue = redactedValue
+			currentValue = redactedValue
+		}
+
+		var previousRef, currentRef *string
+		if previousFound {
+			previousRef = &previousValue
+		}
+		if currentFound {
+			currentRef = &currentValue
+		}
+		changes = append(changes, newSpecChange(changeType, container, key, previousRef, currentRef))
+	}
+	return changes
+}
+
+// envValues returns the container environment variables by name, variables that are taken from a source are
+// compared by their source definition
+func envValues(env []v1.EnvVar) map[string]string {
+	values := map[string]string{}
+	for _, variable := range env {
+		value := variable.Value
+		if variable.ValueFrom != nil {
+			source, _ := json.Marshal(variable.ValueFrom)
+			value = string(source)
+		}
+		values[variable.Name] = value
+	}
+	return values
+}
+
+// resourceValues returns the container requests and limits, keyed by requests.<resource> and limits.<resource>
+func resourceValues(resources v1.ResourceRequirements) map[string]string {
+	values := map[string]string{}
+	for name, quantity := range resources.Requests {
+		values[fmt.Sprintf("requests.%s", name)] = quantity.String()
+	}
+	for name, quantity := range resources.Limits {
+		values[fmt.Sprintf("limits.%s", name)] = quantity.String()
+	}
+	return values
+}
+
+// probeValue returns the probe definition as json, nil when the probe is not defined
+func probeValue(probe *v1.Probe) *string {
+	if probe == nil {
+		return nil
+	}
+	data, err := json.Marshal(probe)
+	if err != nil {
+		return nil
+	}
+	value := string(data)
+	return &value
+}
+
+// optionalString returns nil for an empty value
+func optionalString(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
+
+// previousReplicaSetTemplate returns the pod template of the deployment replicaset with the deployment revision.
+// The deployment revision annotation is the revision before the apply, the template is not returned when it matches
+// the current deployment pod template
+func previousReplicaSetTemplate(deployment *appsV1.Deployment, replicasets []*appsV1.ReplicaSet) (*v1.PodTemplateSpec, bool) {
+	currentRevision, found := deployment.GetAnnotations()[revisionAnnotation]
+	if !found {
+		return nil, false
+	}
+
+	for _, replicaset := range replicasets {
+		owner := metaV1.GetControllerOf(replicaset)
+		if owner == nil || owner.UID != deployment.GetUID() || replicaset.GetAnnotations()[revisionAnnotation] != currentRevision {
+			continue
+		}
+		if equalIgnoreHash(replicaset.Spec.Template, deployment.Spec.Template) {
+			return nil, false
+		}
+		template := replicaset.Spec.Template.DeepCopy()
+		delete(template.Labels, appsV1.DefaultDeploymentUniqueLabelKey)
+		return template, true
+	}
+	return nil, false
+}
+
+// previousControllerRevisionTemplate returns the pod template of the latest controller revision of the daemonset or
+// the statefulset, the template is not returned when it matches the current pod template
+func previousControllerRevisionTemplate(ownerUID types.UID, template v1.PodTemplateSpec, revisions []appsV1.ControllerRevision) (*v1.PodTemplateSpec, bool) {
+	var latest *appsV1.ControllerRevision
+	for i := range revisions {
+		owner := metaV1.GetControllerOf(&revisions[i])
+		if owner == nil || owner.UID != ownerUID {
+			continue
+		}
+		if latest == nil || revisions[i].Revision > latest.Revision {
+			latest = &revisions[i]
+		}
+	}
+	if latest == nil {
+		return nil, false
+	}
+
+	previous, ok := getControllerRevisionTemplate(*latest)
+	if !ok {
+		return nil, false
+	}
+	templateData, err := json.Marshal(template)
+	if err != nil {
+		return nil, false
+	}
+	var currentTemplate map[string]interface{}
+	if err := json.Unmarshal(templateData, &currentTemplate); err != nil || reflect.DeepEqual(previous, currentTemplate) {
+		return nil, false
+	}
+
+	data, err := json.Marshal(previous)
+	if err != nil {
+		return nil, false
+	}
+	previousTemplate := &v1.PodTemplateSpec{}
+	if err := json.Unmarshal(data, previousTemplate); err != nil {
+		return nil, false
+	}
+	return previousTemplate, true
+}
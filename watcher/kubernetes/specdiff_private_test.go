@@ -0,0 +1,125 @@
+package kuberneteswatcher
+
+import (
+	"reflect"
+	"testing"
+
+	appsV1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPodTemplateSpecChanges(t *testing.T) {
+
+	previous := podTemplateMock("nginx:1")
+	previous.Annotations = map[string]string{"version": "1", "removed": "true"}
+	previous.Spec.Containers[0].Env = []v1.EnvVar{{Name: "TOKEN", Value: "secret-1"}, {Name: "REMOVED", Value: "value"}, {Name: "SAME", Value: "value"}}
+	previous.Spec.Containers[0].Resources = v1.ResourceRequirements{
+		Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("100m")},
+	}
+	previous.Spec.Containers = append(previous.Spec.Containers, v1.Container{Name: "sidecar", Image: "sidecar:1"})
+
+	current := podTemplateMock("nginx:2")
+	current.Annotations = map[string]string{"version": "2"}
+	current.Spec.Containers[0].Env = []v1.EnvVar{{Name: "TOKEN", Value: "secret-2"}, {Name: "ADDED", Value: "value"}, {Name: "SAME", Value: "value"}}
+	current.Spec.Containers[0].Resources = v1.ResourceRequirements{
+		Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("200m")},
+	}
+	current.Spec.Containers[0].ReadinessProbe = &v1.Probe{InitialDelaySeconds: 5}
+
+	expected := []SpecChange{
+		{Type: SpecChangeImage, Action: SpecChangeModified, Container: "nginx", Name: "image", Previous: "nginx:1", Current: "nginx:2"},
+		{Type: SpecChangeEnv, Action: SpecChangeAdded, Container: "nginx", Name: "ADDED", Current: redactedValue},
+		{Type: SpecChangeEnv, Action: SpecChangeRemoved, Container: "nginx", Name: "REMOVED", Previous: redactedValue},
+		{Type: SpecChangeEnv, Action: SpecChangeModified, Container: "nginx", Name: "TOKEN", Previous: redactedValue, Current: redactedValue},
+		{Type: SpecChangeResources, Action: SpecChangeModified, Container: "nginx", Name: "requests.cpu", Previous: "100m", Current: "200m"},
+		{Type: SpecChangeProbe, Action: SpecChangeAdded, Container: "nginx", Name: "readiness", Current: `{"initialDelaySeconds":5}`},
+		{Type: SpecChangeImage, Action: SpecChangeRemoved, Container: "sidecar", Name: "image", Previous: "sidecar:1"},
+		{Type: SpecChangeAnnotation, Action: SpecChangeRemoved, Name: "removed", Previous: "true"},
+		{Type: SpecChangeAnnotation, Action: SpecChangeModified, Name: "version", Previous: "1", Current: "2"},
+	}
+
+	changes := podTemplateSpecChanges(previous, current)
+	if !reflect.DeepEqual(changes, expected) {
+		t.Fatalf("unexpected spec changes, got %+v expected %+v", changes, expected)
+	}
+
+	if changes := podTemplateSpecChanges(current, current); len(changes) != 0 {
+		t.Fatalf("unexpected spec changes for the same template, got %+v", changes)
+	}
+}
+
+func TestPreviousReplicaSetTemplate(t *testing.T) {
+
+	replicasets := []*appsV1.ReplicaSet{
+		replicasetRevisionMock("deployment-uid", "nginx:1", "1", ""),
+		replicasetRevisionMock("deployment-uid", "nginx:2", "2", ""),
+		replicasetRevisionMock("other-uid", "nginx:3", "2", ""),
+	}
+
+	testCases := []struct {
+		name          string
+		image         string
+		expectedFound bool
+		expectedImage string
+	}{
+		{"new_template", "nginx:3", true, "nginx:2"},
+		{"rollback", "nginx:1", true, "nginx:2"},
+		{"same_template", "nginx:2", false, ""},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			deployment := &appsV1.Deployment{
+				ObjectMeta: metaV1.ObjectMeta{
+					UID:         "deployment-uid",
+					Annotations: map[string]string{revisionAnnotation: "2"},
+				},
+				Spec: appsV1.DeploymentSpec{Template: podTemplateMock(test.image)},
+			}
+			template, found := previousReplicaSetTemplate(deployment, replicasets)
+			if found != test.expectedFound {
+				t.Fatalf("unexpected previous template, got %t expected %t", found, test.expectedFound)
+			}
+			if found && template.Spec.Containers[0].Image != test.expectedImage {
+				t.Fatalf("unexpected previous image, got %s expected %s", template.Spec.Containers[0].Image, test.expectedImage)
+			}
+			if found && template.Labels[appsV1.DefaultDeploymentUniqueLabelKey] != "" {
+				t.Fatalf("unexpected pod template hash label in the previous template")
+			}
+		})
+	}
+}
+
+func TestPreviousControllerRevisionTemplate(t *testing.T) {
+
+	revisions := []appsV1.ControllerRevision{
+		controllerRevisionMock("daemonset-uid", "nginx:2", 2),
+		controllerRevisionMock("daemonset-uid", "nginx:1", 1),
+		controllerRevisionMock("other-uid", "nginx:3", 3),
+	}
+
+	testCases := []struct {
+		name          string
+		image         string
+		expectedFound bool
+		expectedImage string
+	}{
+		{"new_template", "nginx:3", true, "nginx:2"},
+		{"rollback", "nginx:1", true, "nginx:2"},
+		{"same_template", "nginx:2", false, ""},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			template, found := previousControllerRevisionTemplate("daemonset-uid", podTemplateMock(test.image), revisions)
+			if found != test.expectedFound {
+				t.Fatalf("unexpected previous template, got %t expected %t", found, test.expectedFound)
+			}
+			if found && template.Spec.Containers[0].Image != test.expectedImage {
+				t.Fatalf("unexpected previous image, got %s expected %s", template.Spec.Containers[0].Image, test.expectedImage)
+			}
+		})
+	}
+}
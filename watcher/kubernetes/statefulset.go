@@ -10,15 +10,16 @@ import (
 	"github.com/mitchellh/hashstructure"
 	log "github.com/sirupsen/logrus"
 	appsV1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
 	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
-	"k8s.io/client-go/kubernetes"
+	eventwatch "k8s.io/apimachinery/pkg/watch"
 )
 
 // StatefulsetManager Struct definition
 type StatefulsetManager struct {
-	// Kubernetes client interface
-	client kubernetes.Interface
+	// Informer manager will be owner to watch the Kubernetes resources
+	informerManager *InformerManager
 
 	// Event manager will be owner to start watch on deployment events
 	eventManager *EventsManager
@@ -29,6 +30,9 @@ type StatefulsetManager struct {
 	// ServiceManager will
 	serviceManager *ServiceManager
 
+	// Watches the horizontal pod autoscalers of the statefulset
+	hpaManager *HorizontalPodAutoscalerManager
+
 	// Holds the revisions of deamonset and statefulset
 	controllerRevManager ControllerRevision
 
@@ -40,12 +44,13 @@ type StatefulsetManager struct {
 }
 
 // NewStatefulsetManager creates a new instance to manage statefulset related resources
-func NewStatefulsetManager(k8sClient kubernetes.Interface, eventManager *EventsManager, registryManager *RegistryManager, serviceManager *ServiceManager, controllerRevisionManager ControllerRevision, runningApplies []*RegistryRow, maxDeploymentTime time.Duration) *StatefulsetManager {
+func NewStatefulsetManager(informerManager *InformerManager, eventManager *EventsManager, registryManager *RegistryManager, serviceManager *ServiceManager, hpaManager *HorizontalPodAutoscalerManager, controllerRevisionManager ControllerRevision, runningApplies []*RegistryRow, maxDeploymentTime time.Duration) *StatefulsetManager {
 	return &StatefulsetManager{
-		client:                k8sClient,
+		informerManager:       informerManager,
 		eventManager:          eventManager,
 		registryManager:       registryManager,
 		serviceManager:        serviceManager,
+		hpaManager:            hpaManager,
 		controllerRevManager:  controllerRevisionManager,
 		maxDeploymentTime:     int64(maxDeploymentTime.Seconds()),
 		initialRunningApplies: runningApplies,
@@ -79,7 +84,7 @@ func (ssm *StatefulsetManager) Serve(ctx context.Context, wg *sync.WaitGroup) {
 			}
 			app.Log().Logger.WithField("name", sData.GetName()).Debug("begining watching loaded running statefulset")
 			go func(app *RegistryRow, sData *StatefulsetData, listOptions metaV1.ListOptions) {
-				ssm.watchStatefulset(app.ctx, app.cancelFn, app.Log(), sData, listOptions, sData.Statefulset.Namespace, sData.ProgressDeadlineSeconds)
+				ssm.watchStatefulset(app.ctx, app.cancelFn, app.Log(), app.DBSchema.WatchHealth, sData, listOptions, sData.Statefulset.Namespace, sData.ProgressDeadlineSeconds)
 			}(app, sData, staefulsetWatchListOptions)
 		}
 	}
@@ -90,21 +95,20 @@ func (ssm *StatefulsetManager) Serve(ctx context.Context, wg *sync.WaitGroup) {
 }
 
 func (ssm *StatefulsetManager) watchStatefulsets(ctx context.Context) {
-	statefulsetsList, _ := ssm.client.AppsV1().StatefulSets("").List(metaV1.ListOptions{})
-	statefulsetWatchListOptions := metaV1.ListOptions{ResourceVersion: statefulsetsList.GetResourceVersion()}
-	watcher, err := ssm.client.AppsV1().StatefulSets("").Watch(statefulsetWatchListOptions)
-	if err != nil {
-		log.WithError(err).WithField("list_option", statefulsetWatchListOptions.String()).Error("could not start watching statefulset")
-		return
+	// Resume from the last handled resource version, so changes that were applied while the watcher was down are not missed
+	resourceVersion := ssm.registryManager.LoadResourceVersion(statefulsetsResource.GroupResource().String())
+	if resourceVersion == "" {
+		resourceVersion, _ = ssm.informerManager.ResourceVersion(statefulsetsResource)
 	}
+	statefulsetWatchListOptions := metaV1.ListOptions{ResourceVersion: resourceVersion}
+	watcher := ssm.informerManager.ResumableWatch(ctx, *log.WithField("resource", statefulsetsResource.String()), nil, statefulsetsResource, "", statefulsetWatchListOptions)
 	go func() {
-		log.WithField("resource_version", statefulsetsList.GetResourceVersion()).Info("statefulsets watcher started")
+		log.WithField("resource_version", resourceVersion).Info("statefulsets watcher started")
 		for {
 			select {
-			case event, watch := <-watcher.ResultChan():
+			case event, watch := <-watcher:
 				if !watch {
-					log.WithField("list_options", statefulsetWatchListOptions.String()).Info("statefulsets watcher was stopped, reopening the channel")
-					ssm.watchStatefulsets(ctx)
+					log.WithField("list_options", statefulsetWatchListOptions.String()).Info("statefulsets watcher was stopped, channel was closed")
 					return
 				}
 				statefulset, ok := event.Object.(*appsV1.StatefulSet)
@@ -113,6 +117,7 @@ func (ssm *StatefulsetManager) watchStatefulsets(ctx context.Context) {
 					log.WithField("object", event.Object).Warn("failed to parse statefulset watcher data")
 					continue
 				}
+				ssm.registryManager.UpdateResourceVersion(statefulsetsResource.GroupResource().String(), statefulset.GetResourceVersion())
 
 				log.WithFields(log.Fields{
 					"name":      statefulset.GetName(),
@@ -122,27 +127,53 @@ func (ssm *StatefulsetManager) watchStatefulsets(ctx context.Context) {
 
 				if common.IsSupportedEventType(event.Type) {
 
-					hash, _ := hashstructure.Hash(statefulset.Spec, nil)
+					// The replicas are excluded from the hash, a scale without a spec change is not a new apply
+					spec := statefulset.Spec
+					spec.Replicas = nil
+					hash, _ := hashstructure.Hash(spec, nil)
+					// Versions that were saved before the replicas were excluded are compared with the full spec hash
+					legacyHash, _ := hashstructure.Hash(statefulset.Spec, nil)
 					apply := ApplyEvent{
-						Event:        fmt.Sprintf("%v", event.Type),
-						ApplyName:    statefulsetName,
-						ResourceName: statefulset.GetName(),
-						Namespace:    statefulset.GetNamespace(),
-						Kind:         "statefulset",
-						Hash:         hash,
-						Annotations:  statefulset.GetAnnotations(),
-						Labels:       map[string]string{},
+						Event:           fmt.Sprintf("%v", event.Type),
+						ApplyName:       statefulsetName,
+						ResourceName:    statefulset.GetName(),
+						Namespace:       statefulset.GetNamespace(),
+						Kind:            "statefulset",
+						Hash:            hash,
+						LegacyHash:      legacyHash,
+						Annotations:     statefulset.GetAnnotations(),
+						Labels:          statefulset.GetLabels(),
+						UID:             statefulset.GetUID(),
+						ResourceVersion: statefulset.GetResourceVersion(),
+						Replicas:        statefulset.Spec.Replicas,
+					}
+
+					if event.Type == eventwatch.Deleted {
+						ssm.controllerRevManager.ForgetRevisions(statefulset.GetUID())
 					}
 
 					appRegistry := ssm.registryManager.NewApplyEvent(apply)
 					if appRegistry == nil {
+						// The revisions are recorded before the next apply, a rollback bumps the number of the reused revision
+						if event.Type != eventwatch.Deleted && statefulset.Spec.Selector != nil {
+							ssm.controllerRevManager.RecordRevisions(statefulset.GetNamespace(), statefulset.GetUID(), statefulset.Spec.Selector.MatchLabels, statefulset.Spec.Template)
+						}
 						continue
 					}
 
 					statefulsetLog := appRegistry.Log()
 					statefulsetLog.WithField("event", event.Type).Info("adding statefulset to apply registry")
 
-					registryApply := ssm.AddNewStatefulset(apply, appRegistry, *statefulset.Spec.Replicas)
+					registryApply := ssm.AddNewStatefulset(apply, appRegistry, *statefulset.Spec.Replicas, getStatefulsetUpdateStrategy(statefulset), getStatefulsetPartition(statefulset))
+
+					if event.Type != eventwatch.Deleted && statefulset.Spec.Selector != nil {
+						if revision, isRollback := ssm.controllerRevManager.GetRollbackRevision(statefulset.GetNamespace(), statefulset.GetUID(), statefulset.Spec.Selector.MatchLabels, statefulset.Spec.Template); isRollback {
+							appRegistry.SetRollback("statefulset", statefulset.GetName(), revision)
+						}
+						if previous, found := ssm.controllerRevManager.GetPreviousTemplate(statefulset.GetNamespace(), statefulset.GetUID(), statefulset.Spec.Selector.MatchLabels, statefulset.Spec.Template); found {
+							appRegistry.AddSpecChanges("statefulset", statefulset.GetName(), podTemplateSpecChanges(*previous, statefulset.Spec.Template))
+						}
+					}
 
 					statefulsetWatchListOptions := metaV1.ListOptions{
 						LabelSelector: labels.SelectorFromSet(statefulset.GetLabels()).String()}
@@ -151,6 +182,7 @@ func (ssm *StatefulsetManager) watchStatefulsets(ctx context.Context) {
 						appRegistry.ctx,
 						appRegistry.cancelFn,
 						appRegistry.Log(),
+						appRegistry.DBSchema.WatchHealth,
 						registryApply,
 						statefulsetWatchListOptions,
 						statefulset.GetNamespace(),
@@ -164,7 +196,6 @@ func (ssm *StatefulsetManager) watchStatefulsets(ctx context.Context) {
 				}
 			case <-ctx.Done():
 				log.Warn("statefulset watcher was stopped, got ctx done signal")
-				watcher.Stop()
 				return
 			}
 		}
@@ -172,22 +203,18 @@ func (ssm *StatefulsetManager) watchStatefulsets(ctx context.Context) {
 }
 
 //  watchStatefulset will watch a specific statefulset and its related resources (controller revision + pods)
-func (ssm *StatefulsetManager) watchStatefulset(ctx context.Context, cancelFn context.CancelFunc, lg log.Entry, registryStatefulset *StatefulsetData, listOptions metaV1.ListOptions, namespace string, maxWatchTime int64) {
+func (ssm *StatefulsetManager) watchStatefulset(ctx context.Context, cancelFn context.CancelFunc, lg log.Entry, health *WatchHealth, registryStatefulset *StatefulsetData, listOptions metaV1.ListOptions, namespace string, maxWatchTime int64) {
 
 	statefulsetLog := lg.WithField("statefulset_name", registryStatefulset.GetName())
 
 	statefulsetLog.Info("start watching statefulset")
 	statefulsetLog.WithField("list_option", listOptions.String()).Debug("list option for statefulset filtering")
 
-	watcher, err := ssm.client.AppsV1().StatefulSets(namespace).Watch(listOptions)
-	if err != nil {
-		statefulsetLog.WithError(err).Error("could not start statefulset watcher")
-		return
-	}
+	watcher := ssm.informerManager.ResumableWatch(ctx, *statefulsetLog, health, statefulsetsResource, namespace, listOptions)
 	firstInit := true
 	for {
 		select {
-		case event, watch := <-watcher.ResultChan():
+		case event, watch := <-watcher:
 			if !watch {
 				statefulsetLog.Warn("statefulset watcher was stopped, channel was closed")
 				cancelFn()
@@ -208,11 +235,11 @@ func (ssm *StatefulsetManager) watchStatefulset(ctx context.Context, cancelFn co
 				}
 
 				// Start watching on Events of statefulset
-				ssm.watchEvents(ctx, *statefulsetLog, registryStatefulset, eventListOptions, namespace)
+				ssm.watchEvents(ctx, *statefulsetLog, health, registryStatefulset, eventListOptions, registryStatefulset.Statefulset.objectReference("StatefulSet"), namespace)
 
 				// Use the Controller revision to find the pods with specific controller-revision-hash for the statefulset
 				ssm.controllerRevManager.WatchControllerRevisionPodsRetry(ctx, *statefulsetLog,
-					registryStatefulset,
+					registryStatefulset, health,
 					statefulset.ObjectMeta.Generation,
 					statefulset.Spec.Selector.MatchLabels,
 					"controller.kubernetes.io/hash",
@@ -227,27 +254,41 @@ func (ssm *StatefulsetManager) watchStatefulset(ctx context.Context, cancelFn co
 					Namespace:    statefulset.Namespace,
 					Ctx:          ctx,
 					LogEntry:     *statefulsetLog,
+					WatchHealth:  health,
+				}
+
+				// Start watching the horizontal pod autoscalers of statefulset
+				ssm.hpaManager.Watch <- WatchHorizontalPodAutoscaler{
+					ListOptions:  metaV1.ListOptions{TimeoutSeconds: &maxWatchTime},
+					RegistryData: registryStatefulset,
+					Namespace:    statefulset.Namespace,
+					TargetKind:   "StatefulSet",
+					TargetName:   statefulset.GetName(),
+					Ctx:          ctx,
+					LogEntry:     *statefulsetLog,
+					WatchHealth:  health,
 				}
 
 			}
 			registryStatefulset.UpdateApplyStatus(statefulset.Status)
 		case <-ctx.Done():
 			statefulsetLog.Debug("statefulset watcher was stopped, got ctx done signal")
-			watcher.Stop()
 			return
 		}
 	}
 }
 
 // watchEvents will watch for events relate d to the Statefulset Resources
-func (ssm *StatefulsetManager) watchEvents(ctx context.Context, lg log.Entry, registryStatefulset *StatefulsetData, listOptions metaV1.ListOptions, namespace string) {
+func (ssm *StatefulsetManager) watchEvents(ctx context.Context, lg log.Entry, health *WatchHealth, registryStatefulset *StatefulsetData, listOptions metaV1.ListOptions, involvedObject v1.ObjectReference, namespace string) {
 
 	lg.Info("started the event watcher on statefulset events")
 	watchData := WatchEvents{
-		ListOptions: listOptions,
-		Namespace:   namespace,
-		Ctx:         ctx,
-		LogEntry:    lg,
+		ListOptions:       listOptions,
+		Namespace:         namespace,
+		Ctx:               ctx,
+		LogEntry:          lg,
+		InvolvedObject:    involvedObject,
+		ApplyCreationTime: health.applyCreation(),
 	}
 
 	eventChan := ssm.eventManager.Watch(watchData)
@@ -265,22 +306,28 @@ func (ssm *StatefulsetManager) watchEvents(ctx context.Context, lg log.Entry, re
 }
 
 // AddNewStatefulset add a new statefulset under application settings
-func (ssm *StatefulsetManager) AddNewStatefulset(data ApplyEvent, applicationRegistry *RegistryRow, desiredState int32) *StatefulsetData {
+func (ssm *StatefulsetManager) AddNewStatefulset(data ApplyEvent, applicationRegistry *RegistryRow, desiredState int32, updateStrategy string, partition int32) *StatefulsetData {
 
 	log := applicationRegistry.Log()
 	dd := &StatefulsetData{
 		Statefulset: MetaData{
-			Name:         data.ApplyName,
-			Namespace:    data.Namespace,
-			Annotations:  data.Annotations,
-			Labels:       data.Labels,
-			Metrics:      GetMetricsDataFromAnnotations(data.Annotations),
-			Alerts:       GetAlertsDataFromAnnotations(data.Annotations),
-			DesiredState: desiredState,
+			Name:            data.ApplyName,
+			Namespace:       data.Namespace,
+			Annotations:     data.Annotations,
+			Labels:          data.Labels,
+			UID:             data.UID,
+			ResourceVersion: data.ResourceVersion,
+			Metrics:         GetMetricsDataFromAnnotations(data.Annotations),
+			Alerts:          GetAlertsDataFromAnnotations(data.Annotations),
+			SuccessCriteria: GetSuccessCriteriaFromAnnotations(data.Annotations),
+			DesiredState:    desiredState,
 		},
-		Pods:                    make(map[string]DeploymenPod, 0),
-		Services:                make(map[string]ServicesData, 0),
-		ProgressDeadlineSeconds: GetProgressDeadlineApply(data.Annotations, ssm.maxDeploymentTime),
+		Pods:                     make(map[string]DeploymenPod, 0),
+		Services:                 make(map[string]ServicesData, 0),
+		HorizontalPodAutoscalers: make(map[string]HorizontalPodAutoscalerData, 0),
+		ProgressDeadlineSeconds:  GetProgressDeadlineApply(data.Annotations, ssm.maxDeploymentTime),
+		UpdateStrategy:           updateStrategy,
+		Partition:                partition,
 	}
 	applicationRegistry.DBSchema.Resources.Statefulsets[data.ResourceName] = dd
 
@@ -289,3 +336,20 @@ func (ssm *StatefulsetManager) AddNewStatefulset(data ApplyEvent, applicationReg
 	return dd
 
 }
+
+// getStatefulsetUpdateStrategy returns the update strategy type of the statefulset, RollingUpdate when it is not set
+func getStatefulsetUpdateStrategy(statefulset *appsV1.StatefulSet) string {
+	if statefulset.Spec.UpdateStrategy.Type == "" {
+		return string(appsV1.RollingUpdateStatefulSetStrategyType)
+	}
+	return string(statefulset.Spec.UpdateStrategy.Type)
+}
+
+// getStatefulsetPartition returns the rolling update partition of the statefulset, 0 when all the pods are updated
+func getStatefulsetPartition(statefulset *appsV1.StatefulSet) int32 {
+	rollingUpdate := statefulset.Spec.UpdateStrategy.RollingUpdate
+	if statefulset.Spec.UpdateStrategy.Type == appsV1.OnDeleteStatefulSetStrategyType || rollingUpdate == nil || rollingUpdate.Partition == nil {
+		return 0
+	}
+	return *rollingUpdate.Partition
+}
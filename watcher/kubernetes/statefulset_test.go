@@ -54,22 +54,40 @@ func createStatefulSetMock(client *fake.Clientset, name string, namespace string
 	return statefulset
 }
 
+func createMockStatefulsetData(registryManager *kuberneteswatcher.RegistryManager, registryRow *kuberneteswatcher.RegistryRow, applyEvent kuberneteswatcher.ApplyEvent, progressDeadlineSeconds string, updateStrategy string, partition int32) *kuberneteswatcher.StatefulsetData {
+
+	maxDeploymentTime, _ := time.ParseDuration(progressDeadlineSeconds)
+	client := fake.NewSimpleClientset()
+	eventManager := NewEventsMock(client)
+	serviceManager := NewServiceManagerMockMock(client)
+	hpaManager := NewHorizontalPodAutoscalerManagerMock(client)
+	pvcManager := NewPvcManagerMock(client)
+	podManager := kuberneteswatcher.NewPodsManager(NewInformerManagerMock(client), eventManager, pvcManager, client, common.PodLogsConfig{})
+	controllerRevisionManager := NewControllerRevisionManagerMock(client, podManager)
+	statefulsetManager := kuberneteswatcher.NewStatefulsetManager(NewInformerManagerMock(client), eventManager, registryManager, serviceManager, hpaManager, controllerRevisionManager, registryManager.LoadRunningApplies(), maxDeploymentTime)
+
+	return statefulsetManager.AddNewStatefulset(applyEvent, registryRow, 3, updateStrategy, partition)
+
+}
+
 func NewStatefulSetManagerMock(client *fake.Clientset) (*kuberneteswatcher.StatefulsetManager, *testutil.MockStorage, *MockControllerRevisionManager) {
 	maxDeploymentTime, _ := time.ParseDuration("10m")
-	eventManager := kuberneteswatcher.NewEventsManager(client)
+	eventManager := kuberneteswatcher.NewEventsManager(NewInformerManagerMock(client), common.EventsConfig{})
 	registryManager, Mockstorage := NewRegistryMock()
 	serviceManager := NewServiceManagerMockMock(client)
+	hpaManager := NewHorizontalPodAutoscalerManagerMock(client)
 	pvcManager := NewPvcManagerMock(client)
-	podManager := kuberneteswatcher.NewPodsManager(client, eventManager, pvcManager)
+	podManager := kuberneteswatcher.NewPodsManager(NewInformerManagerMock(client), eventManager, pvcManager, client, common.PodLogsConfig{})
 	controllerRevisionManager := NewControllerRevisionManagerMock(client, podManager)
 	runningApplies := registryManager.LoadRunningApplies()
-	statefulsetManager := kuberneteswatcher.NewStatefulsetManager(client, eventManager, registryManager, serviceManager, controllerRevisionManager, runningApplies, maxDeploymentTime)
+	statefulsetManager := kuberneteswatcher.NewStatefulsetManager(NewInformerManagerMock(client), eventManager, registryManager, serviceManager, hpaManager, controllerRevisionManager, runningApplies, maxDeploymentTime)
 
 	var wg sync.WaitGroup
 	ctx := context.Background()
 
 	eventManager.Serve(ctx, &wg)
 	serviceManager.Serve(ctx, &wg)
+	hpaManager.Serve(ctx, &wg)
 	podManager.Serve(ctx, &wg)
 	statefulsetManager.Serve(ctx, &wg)
 
@@ -171,6 +189,9 @@ func TestStatefulsetWatch(t *testing.T) {
 		if len(application.Schema.Resources.Statefulsets["application"].Statefulset.Annotations) != 4 {
 			t.Fatalf("unexpected amount of Lables values for statefulset annotations field , got %d expected %d", len(application.Schema.Resources.Statefulsets["application"].Statefulset.Annotations), 4)
 		}
+		if application.Schema.Resources.Statefulsets["application"].UpdateStrategy != string(appsV1.RollingUpdateStatefulSetStrategyType) {
+			t.Fatalf("unexpected update strategy, got %s expected %s", application.Schema.Resources.Statefulsets["application"].UpdateStrategy, appsV1.RollingUpdateStatefulSetStrategyType)
+		}
 
 	})
 
@@ -180,3 +201,112 @@ func TestStatefulsetWatch(t *testing.T) {
 	// 	}
 	// })
 }
+
+func TestStatefulsetFinish(t *testing.T) {
+
+	testCases := []struct {
+		name                string
+		progressDeadline    string
+		updateStrategy      appsV1.StatefulSetUpdateStrategyType
+		partition           int32
+		status              appsV1.StatefulSetStatus
+		expectedStatus      common.DeploymentStatus
+		expectedDescription common.DeploymentStatusDescription
+	}{
+		{"partition", "10m", appsV1.RollingUpdateStatefulSetStrategyType, 2, appsV1.StatefulSetStatus{Replicas: 3, ReadyReplicas: 3, UpdatedReplicas: 1}, common.ApplySuccessful, common.ApplyStatusDescriptionPartitioned},
+		{"partition_not_updated", "10m", appsV1.RollingUpdateStatefulSetStrategyType, 2, appsV1.StatefulSetStatus{Replicas: 3, ReadyReplicas: 3}, common.ApplyStatusRunning, common.ApplyStatusDescriptionRunning},
+		{"on_delete", "1s", appsV1.OnDeleteStatefulSetStrategyType, 0, appsV1.StatefulSetStatus{Replicas: 3, ReadyReplicas: 3}, common.ApplyStatusAwaitingPodDeletion, common.ApplyStatusDescriptionAwaitingPodDeletion},
+		{"on_delete_updated", "10m", appsV1.OnDeleteStatefulSetStrategyType, 0, appsV1.StatefulSetStatus{Replicas: 3, ReadyReplicas: 3, UpdatedReplicas: 3}, common.ApplySuccessful, common.ApplyStatusDescriptionSuccessful},
+		{"on_delete_not_ready", "1s", appsV1.OnDeleteStatefulSetStrategyType, 0, appsV1.StatefulSetStatus{Replicas: 3, ReadyReplicas: 2}, common.ApplyStatusFailed, common.ApplyStatusDescriptionProgressDeadline},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			registry, storage := NewRegistryMock()
+			registryRow := registry.NewApplication("application", "default", map[string]string{}, common.ApplyStatusRunning)
+
+			apply := kuberneteswatcher.ApplyEvent{
+				Event:        "create",
+				ApplyName:    "application",
+				ResourceName: "application",
+				Namespace:    "default",
+				Kind:         "statefulset",
+				Hash:         1234,
+				Annotations:  map[string]string{},
+				Labels:       map[string]string{},
+			}
+			data := createMockStatefulsetData(registry, registryRow, apply, tc.progressDeadline, string(tc.updateStrategy), tc.partition)
+			// The pods of the old revision are collected as well, they don't count as updated pods
+			for i := 0; i < 3; i++ {
+				data.NewPod(&v1.Pod{ObjectMeta: metaV1.ObjectMeta{Name: fmt.Sprintf("application-%d", i)}})
+			}
+			data.UpdateApplyStatus(tc.status)
+
+			time.Sleep(time.Second * 4)
+			if storage.MockWriteDeployment["1"].Status != tc.expectedStatus {
+				t.Fatalf("unexpected statefulset apply status, got %s expected %s", storage.MockWriteDeployment["1"].Status, tc.expectedStatus)
+			}
+			if storage.MockWriteDeployment["1"].Schema.DeploymentDescription != tc.expectedDescription {
+				t.Fatalf("unexpected statefulset apply description, got %s expected %s", storage.MockWriteDeployment["1"].Schema.DeploymentDescription, tc.expectedDescription)
+			}
+		})
+	}
+}
+
+func TestStatefulsetFinishSuccessCriteria(t *testing.T) {
+
+	testCases := []struct {
+		name                string
+		annotations         map[string]string
+		readyPods           int
+		restartCount        int32
+		expectedStatus      common.DeploymentStatus
+		expectedDescription common.DeploymentStatusDescription
+	}{
+		{"min_available_percent", map[string]string{"statusbay.io/success-min-available-percent": "60"}, 2, 0, common.ApplySuccessful, common.ApplyStatusDescriptionSuccessful},
+		{"max_restarts_exceeded", map[string]string{"statusbay.io/success-max-restarts": "1"}, 3, 2, common.ApplyStatusFailed, common.ApplyStatusDescriptionSuccessCriteria},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			registry, storage := NewRegistryMock()
+			registryRow := registry.NewApplication("application", "default", map[string]string{}, common.ApplyStatusRunning)
+
+			apply := kuberneteswatcher.ApplyEvent{
+				Event:        "create",
+				ApplyName:    "application",
+				ResourceName: "application",
+				Namespace:    "default",
+				Kind:         "statefulset",
+				Hash:         1234,
+				Annotations:  tc.annotations,
+				Labels:       map[string]string{},
+			}
+			data := createMockStatefulsetData(registry, registryRow, apply, "10m", string(appsV1.RollingUpdateStatefulSetStrategyType), 0)
+			for i := 0; i < 3; i++ {
+				pod := &v1.Pod{
+					ObjectMeta: metaV1.ObjectMeta{Name: fmt.Sprintf("application-%d", i)},
+					Status: v1.PodStatus{
+						ContainerStatuses: []v1.ContainerStatus{{
+							Name:         "app",
+							Ready:        i < tc.readyPods,
+							RestartCount: tc.restartCount,
+							State:        v1.ContainerState{Running: &v1.ContainerStateRunning{StartedAt: metaV1.NewTime(time.Now().Add(-time.Minute))}},
+						}},
+					},
+				}
+				data.NewPod(pod)
+				data.UpdatePod(pod, "Running")
+			}
+			data.UpdateApplyStatus(appsV1.StatefulSetStatus{Replicas: 3, ReadyReplicas: int32(tc.readyPods), UpdatedReplicas: 3})
+
+			time.Sleep(time.Second * 4)
+			if storage.MockWriteDeployment["1"].Status != tc.expectedStatus {
+				t.Fatalf("unexpected statefulset apply status, got %s expected %s", storage.MockWriteDeployment["1"].Status, tc.expectedStatus)
+			}
+			if storage.MockWriteDeployment["1"].Schema.DeploymentDescription != tc.expectedDescription {
+				t.Fatalf("unexpected statefulset apply description, got %s expected %s", storage.MockWriteDeployment["1"].Schema.DeploymentDescription, tc.expectedDescription)
+			}
+		})
+	}
+}
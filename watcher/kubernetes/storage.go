@@ -9,13 +9,21 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
+// ApplyHashScheme is the version of the fields that the apply hash is computed from. Scheme 1 excludes the replicas
+// of deployments and statefulsets from the hash
+const ApplyHashScheme = 1
+
 // Storage interface
 type Storage interface {
 	CreateApply(data *RegistryRow, status common.DeploymentStatus) (string, error)
 	UpdateApply(applyID string, data *RegistryRow, status common.DeploymentStatus) (bool, error)
-	GetAppliesByStatus(status common.DeploymentStatus) (map[string]DBSchema, error)
-	UpdateAppliesVersionHistory(deploymentName string, hash uint64) bool
+	GetAppliesByStatus(status common.DeploymentStatus, cluster string) (map[string]DBSchema, error)
+	UpdateAppliesVersionHistory(deploymentName string, hash uint64, legacyHash uint64) bool
 	DeleteAppliedVersion(deploymentName string) bool
+	UpdateAppliedReplicas(deploymentName string, replicas int32) (int32, bool)
+	CreateScaleEvent(event ScaleEvent) error
+	GetResourceVersion(resource string) (string, error)
+	UpdateResourceVersion(resource string, resourceVersion string) error
 }
 
 // MySQLStorage ...
@@ -55,6 +63,7 @@ func (my *MySQLStorage) CreateApply(data *RegistryRow, status common.DeploymentS
 		Details:   string(deploymentDetails),
 		DeployBy:  data.DBSchema.DeployBy,
 		Time:      data.DBSchema.CreationTimestamp,
+		Rollback:  data.DBSchema.Rollback != nil,
 	}
 
 	if err := my.client.DB.Create(&apply).Error; err != nil {
@@ -83,9 +92,10 @@ func (my *MySQLStorage) UpdateApply(applyID string, data *RegistryRow, status co
 	}
 
 	if err := my.client.DB.Model(&state.TableKubernetes{}).Where("apply_id = ?", applyID).Updates(state.TableKubernetes{
-		Status:  string(status),
-		Details: string(applyDetails),
-		Time:    data.DBSchema.CreationTimestamp,
+		Status:   string(status),
+		Details:  string(applyDetails),
+		Time:     data.DBSchema.CreationTimestamp,
+		Rollback: data.DBSchema.Rollback != nil,
 	}).Error; err != nil {
 		my.logger.WithError(err).WithFields(log.Fields{
 			"apply_id": applyID,
@@ -97,15 +107,16 @@ func (my *MySQLStorage) UpdateApply(applyID string, data *RegistryRow, status co
 
 }
 
-// GetAppliesByStatus return lits of deployment by given status
-func (my *MySQLStorage) GetAppliesByStatus(status common.DeploymentStatus) (map[string]DBSchema, error) {
+// GetAppliesByStatus return lits of deployment by given status of the given cluster
+func (my *MySQLStorage) GetAppliesByStatus(status common.DeploymentStatus, cluster string) (map[string]DBSchema, error) {
 
 	appRow := &[]state.TableKubernetes{}
 	resources := map[string]DBSchema{}
 
-	if err := my.client.DB.Where(map[string]interface{}{"status": status}).Select("apply_id, details").Find(appRow).Error; err != nil {
+	if err := my.client.DB.Where(map[string]interface{}{"status": status, "cluster": cluster}).Select("apply_id, details").Find(appRow).Error; err != nil {
 		my.logger.WithError(err).WithFields(log.Fields{
-			"status": status,
+			"status":  status,
+			"cluster": cluster,
 		}).Error("error when trying to get applications by status")
 		return resources, err
 	}
@@ -125,8 +136,9 @@ func (my *MySQLStorage) GetAppliesByStatus(status common.DeploymentStatus) (map[
 
 }
 
-// UpdateAppliesVersionHistory Checks if we should create/update a new Apply hash
-func (my *MySQLStorage) UpdateAppliesVersionHistory(applyName string, hash uint64) bool {
+// UpdateAppliesVersionHistory Checks if we should create/update a new Apply hash. A hash that was saved with an older
+// hash scheme is compared with the legacy hash, and is re-seeded with the current scheme when the spec didn't change
+func (my *MySQLStorage) UpdateAppliesVersionHistory(applyName string, hash uint64, legacyHash uint64) bool {
 
 	row := state.TableDeploymentsHash{}
 
@@ -136,6 +148,7 @@ func (my *MySQLStorage) UpdateAppliesVersionHistory(applyName string, hash uint6
 			my.client.DB.Create(&state.TableDeploymentsHash{
 				Deployment: applyName,
 				Hash:       hash,
+				HashScheme: ApplyHashScheme,
 			})
 			my.logger.WithFields(log.Fields{
 				"apply_name": applyName,
@@ -144,6 +157,25 @@ func (my *MySQLStorage) UpdateAppliesVersionHistory(applyName string, hash uint6
 			return true
 		}
 		return false
+	} else if row.HashScheme < ApplyHashScheme {
+		my.client.DB.Model(&row).Where("deployment = ?", applyName).Updates(map[string]interface{}{
+			"hash":        hash,
+			"hash_scheme": ApplyHashScheme,
+		})
+		if row.Hash == legacyHash {
+			my.logger.WithFields(log.Fields{
+				"apply_name":  applyName,
+				"spec_hash":   hash,
+				"hash_scheme": row.HashScheme,
+			}).Info("apply hash version was re-seeded with the current hash scheme")
+			return false
+		}
+		my.logger.WithFields(log.Fields{
+			"apply_name":  applyName,
+			"spec_hash":   hash,
+			"hash_scheme": row.HashScheme,
+		}).Info("apply version updated")
+		return true
 	} else if row.Hash == hash {
 		my.logger.WithFields(log.Fields{
 			"apply_name": applyName,
@@ -166,5 +198,93 @@ func (my *MySQLStorage) DeleteAppliedVersion(applyName string) bool {
 	my.client.DB.Delete(&state.TableDeploymentsHash{
 		Deployment: applyName,
 	})
+	my.client.DB.Delete(&state.TableDeploymentsReplicas{
+		Deployment: applyName,
+	})
 	return true
 }
+
+// UpdateAppliedReplicas saves the replicas count of the apply, and returns the previous replicas count.
+// Returns true only when the replicas count was changed
+func (my *MySQLStorage) UpdateAppliedReplicas(applyName string, replicas int32) (int32, bool) {
+
+	row := state.TableDeploymentsReplicas{}
+
+	if err := my.client.DB.Where("deployment = ?", applyName).First(&row).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			my.client.DB.Create(&state.TableDeploymentsReplicas{
+				Deployment: applyName,
+				Replicas:   replicas,
+			})
+			my.logger.WithFields(log.Fields{
+				"apply_name": applyName,
+				"replicas":   replicas,
+			}).Debug("apply replicas not found in storage, creating one")
+		}
+		return 0, false
+	} else if row.Replicas == replicas {
+		return row.Replicas, false
+	}
+
+	my.client.DB.Model(&row).Where("deployment = ?", applyName).Update("replicas", replicas)
+	my.logger.WithFields(log.Fields{
+		"apply_name":        applyName,
+		"previous_replicas": row.Replicas,
+		"replicas":          replicas,
+	}).Info("apply replicas updated")
+	return row.Replicas, true
+}
+
+// CreateScaleEvent saves a new scale event in the application scaling history
+func (my *MySQLStorage) CreateScaleEvent(event ScaleEvent) error {
+
+	row := state.TableScaleEvents{
+		Name:         event.Application,
+		Cluster:      event.Cluster,
+		Namespace:    event.Namespace,
+		Kind:         event.Kind,
+		ResourceName: event.ResourceName,
+		FromReplicas: event.FromReplicas,
+		ToReplicas:   event.ToReplicas,
+		Time:         event.Time,
+	}
+	if err := my.client.DB.Create(&row).Error; err != nil {
+		my.logger.WithError(err).WithFields(log.Fields{
+			"name":          event.Application,
+			"resource_name": event.ResourceName,
+		}).Error("error when trying to create a new scale event")
+		return err
+	}
+	return nil
+}
+
+// GetResourceVersion returns the last resource version that was handled by the resource watcher
+func (my *MySQLStorage) GetResourceVersion(resource string) (string, error) {
+
+	row := state.TableResourceVersion{}
+	if err := my.client.DB.Where("resource = ?", resource).First(&row).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return "", nil
+		}
+		my.logger.WithError(err).WithField("resource", resource).Error("error when trying to get the resource version")
+		return "", err
+	}
+	return row.ResourceVersion, nil
+}
+
+// UpdateResourceVersion saves the last resource version that was handled by the resource watcher
+func (my *MySQLStorage) UpdateResourceVersion(resource string, resourceVersion string) error {
+
+	row := state.TableResourceVersion{
+		Resource:        resource,
+		ResourceVersion: resourceVersion,
+	}
+	if err := my.client.DB.Save(&row).Error; err != nil {
+		my.logger.WithError(err).WithFields(log.Fields{
+			"resource":         resource,
+			"resource_version": resourceVersion,
+		}).Error("error when trying to save the resource version")
+		return err
+	}
+	return nil
+}
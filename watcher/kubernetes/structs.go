@@ -2,21 +2,29 @@ package kuberneteswatcher
 
 import (
 	"context"
+	"sync"
 	"time"
 
 	log "github.com/sirupsen/logrus"
 	appsV1 "k8s.io/api/apps/v1"
+	batchV1 "k8s.io/api/batch/v1"
 	v1 "k8s.io/api/core/v1"
 
 	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 )
 
 type RegistryData interface {
 	UpdatePodEvents(podName string, pvcName string, event EventMessages) error
 	NewPod(pod *v1.Pod) error
 	UpdatePod(pod *v1.Pod, status string) error
+	UpdatePodLogs(podName string, containerName string, logs ContainerLogs) error
 	NewService(pod *v1.Service) error
 	UpdateServiceEvents(name string, event EventMessages) error
+	UpdateServiceEndpoints(name string, endpoints *v1.Endpoints) error
+	NewServiceIngress(serviceName string, ingressName string) error
+	UpdateServiceIngressEvents(serviceName string, ingressName string, event EventMessages) error
+	UpdateServiceSelector(name string, change ServiceSelectorChange) error
 	GetName() string
 }
 
@@ -27,6 +35,7 @@ type WatchData struct {
 	RegistryData RegistryData
 	Namespace    string
 	LogEntry     log.Entry
+	WatchHealth  *WatchHealth
 }
 
 type MessageDeploy struct {
@@ -48,14 +57,42 @@ type MetaData struct {
 	Metrics      []Metrics         `json:"Metrics"`
 	Alerts       []Alerts          `json:"Alerts"`
 	DesiredState int32             `json:"DesiredState"`
+
+	// UID and ResourceVersion of the applied resource, used to match the resource events to the apply
+	UID             types.UID `json:"UID"`
+	ResourceVersion string    `json:"ResourceVersion"`
+
+	// SuccessCriteria are the success criteria annotations of the resource, nil when none of them was set
+	SuccessCriteria *SuccessCriteria `json:"SuccessCriteria"`
 }
 
 // DeploymenPod struct  TODO ::
 type DeploymenPod struct {
 	Phase             *string                    `json:"Phase"`
 	CreationTimestamp time.Time                  `json:"CreationTimestamp"`
+	NodeName          string                     `json:"NodeName"`
+	PodIP             string                     `json:"PodIP"`
+	QOSClass          string                     `json:"QOSClass"`
 	Events            *[]EventMessages           `json:"Events"`
 	Pvcs              map[string][]EventMessages `json:"Pvcs"`
+	Logs              map[string]ContainerLogs   `json:"Logs"`
+	Timeline          *PodTimeline               `json:"Timeline"`
+	Containers        []PodContainerStatus       `json:"Containers"`
+}
+
+// ContainerLogs holds the log tail of a container that terminated with an error or is crash-looping
+type ContainerLogs struct {
+	Reason       string `json:"Reason"`
+	ExitCode     int32  `json:"ExitCode"`
+	RestartCount int32  `json:"RestartCount"`
+
+	// Current is the tail of the current container logs, Previous is the tail of the previous (restarted) container logs
+	Current  string `json:"Current"`
+	Previous string `json:"Previous"`
+
+	// Error is the last error of fetching the logs
+	Error string `json:"Error"`
+	Time  int64  `json:"Time"`
 }
 
 // EventMessages struct  TODO ::
@@ -64,6 +101,15 @@ type EventMessages struct {
 	Time                int64  `json:"Time"`
 	Action              string `json:"Action"`
 	ReportingController string `json:"ReportingController"`
+	Reason              string `json:"Reason"`
+
+	// Type is Normal or Warning
+	Type string `json:"Type"`
+
+	// Count is the number of occurrences of the event, repeated events are merged into a single entry
+	Count          int32 `json:"Count"`
+	FirstTimestamp int64 `json:"FirstTimestamp"`
+	LastTimestamp  int64 `json:"LastTimestamp"`
 }
 
 // Replicaset struct  TODO ::
@@ -81,6 +127,9 @@ type DeploymentData struct {
 	Pods                    map[string]DeploymenPod `json:"Pods"`
 	Services                map[string]ServicesData `json:"Services"`
 	ProgressDeadlineSeconds int64
+
+	// HorizontalPodAutoscalers are the horizontal pod autoscalers that scale the deployment
+	HorizontalPodAutoscalers map[string]HorizontalPodAutoscalerData `json:"HorizontalPodAutoscalers"`
 }
 
 // DaemonsetData
@@ -91,6 +140,12 @@ type DaemonsetData struct {
 	Pods                    map[string]DeploymenPod `json:"Pods"`
 	Services                map[string]ServicesData `json:"Services"`
 	ProgressDeadlineSeconds int64
+
+	// UpdateStrategy is the daemonset update strategy type, RollingUpdate or OnDelete
+	UpdateStrategy string `json:"UpdateStrategy"`
+
+	// Nodes holds the rollout state of the daemonset pod of each node, by the node name
+	Nodes map[string]DaemonsetNode `json:"Nodes"`
 }
 
 // StatefulsetData holds the data of Statefulset for the registry
@@ -101,11 +156,141 @@ type StatefulsetData struct {
 	Pods                    map[string]DeploymenPod  `json:"Pods"`
 	Services                map[string]ServicesData  `json:"Services"`
 	ProgressDeadlineSeconds int64
+
+	// UpdateStrategy is the statefulset update strategy type, RollingUpdate or OnDelete
+	UpdateStrategy string `json:"UpdateStrategy"`
+
+	// Partition is the rolling update partition, only the pods with an ordinal at or above the partition are updated
+	Partition int32 `json:"Partition"`
+
+	// HorizontalPodAutoscalers are the horizontal pod autoscalers that scale the statefulset
+	HorizontalPodAutoscalers map[string]HorizontalPodAutoscalerData `json:"HorizontalPodAutoscalers"`
+}
+
+// JobData holds the data of Job for the registry
+type JobData struct {
+	Metadata                MetaData                `json:"MetaData"`
+	Status                  batchV1.JobStatus       `json:"Status"`
+	Events                  []EventMessages         `json:"Events"`
+	Pods                    map[string]DeploymenPod `json:"Pods"`
+	Services                map[string]ServicesData `json:"Services"`
+	BackoffLimit            int32                   `json:"BackoffLimit"`
+	ProgressDeadlineSeconds int64
+
+	// WorkQueue is true for a job without completions and with parallelism, the job is complete when one of its pods succeeded
+	WorkQueue bool `json:"WorkQueue"`
+}
+
+// CustomResourceData holds the data of a custom resource for the registry
+type CustomResourceData struct {
+	Metadata                MetaData                `json:"MetaData"`
+	Group                   string                  `json:"Group"`
+	Version                 string                  `json:"Version"`
+	Resource                string                  `json:"Resource"`
+	Kind                    string                  `json:"Kind"`
+	Phase                   string                  `json:"Phase"`
+	Status                  map[string]interface{}  `json:"Status"`
+	Events                  []EventMessages         `json:"Events"`
+	Pods                    map[string]DeploymenPod `json:"Pods"`
+	Services                map[string]ServicesData `json:"Services"`
+	ProgressDeadlineSeconds int64
+}
+
+// TrafficSwitchData holds the data of a service selector change that was applied on its own
+type TrafficSwitchData struct {
+	Metadata                MetaData              `json:"MetaData"`
+	Switch                  ServiceSelectorChange `json:"Switch"`
+	Events                  []EventMessages       `json:"Events"`
+	Endpoints               []EndpointsReadiness  `json:"Endpoints"`
+	ProgressDeadlineSeconds int64
+}
+
+// WatchHealth holds the health of the apply watches. When one of the watches was interrupted,
+// the collected data of the apply may be incomplete
+type WatchHealth struct {
+	// Restarts is the count of the watches that were re-established after they were closed or failed
+	Restarts int `json:"Restarts"`
+
+	// Overflows is the count of the watches that dropped changes because they were not received in time
+	Overflows int `json:"Overflows"`
+
+	// Incomplete is true when some changes of the apply resources may be missing
+	Incomplete bool `json:"Incomplete"`
+
+	// LastError is the last error that interrupted one of the watches
+	LastError string `json:"LastError"`
+
+	// LastRestartTime is the last time that one of the watches was re-established
+	LastRestartTime int64 `json:"LastRestartTime"`
+
+	// applyCreationTime is the creation time of the apply, used to match the resource events to the apply
+	applyCreationTime time.Time
+
+	lock sync.Mutex
 }
 
 // ServicesData holds the data of services
 type ServicesData struct {
 	Events *[]EventMessages `json:"Events"`
+
+	// Endpoints are the ready and not ready addresses counts of the service endpoints, a sample is added on every change
+	Endpoints []EndpointsReadiness `json:"Endpoints"`
+
+	// Ingresses are the ingresses that route to the service
+	Ingresses map[string]IngressData `json:"Ingresses"`
+
+	// SelectorChanges are the changes of the service selector, for example a blue/green traffic switch
+	SelectorChanges []ServiceSelectorChange `json:"SelectorChanges"`
+}
+
+// ServiceSelectorChange describes a change of the service selector and the workload that the service targets after the change
+type ServiceSelectorChange struct {
+	Time             int64             `json:"Time"`
+	PreviousSelector map[string]string `json:"PreviousSelector"`
+	Selector         map[string]string `json:"Selector"`
+
+	// Workload is the workload of the selected pods, for example deployment/nginx-green
+	Workload string `json:"Workload"`
+
+	// Pods are the pods that were selected by the service at the time of the change
+	Pods []string `json:"Pods"`
+
+	// EndpointsFlipped is the time (unix nano) that all the ready endpoints addresses targeted the selected pods, 0 until then
+	EndpointsFlipped int64 `json:"EndpointsFlipped"`
+}
+
+// EndpointsReadiness is a sample of the service endpoints addresses
+type EndpointsReadiness struct {
+	Time     int64 `json:"Time"`
+	Ready    int   `json:"Ready"`
+	NotReady int   `json:"NotReady"`
+}
+
+// IngressData holds the data of an ingress that routes to a service
+type IngressData struct {
+	Events *[]EventMessages `json:"Events"`
+}
+
+// HorizontalPodAutoscalerData holds the replicas, conditions and events of a horizontal pod autoscaler that scales
+// the applied resource
+type HorizontalPodAutoscalerData struct {
+	MinReplicas     *int32                             `json:"MinReplicas"`
+	MaxReplicas     int32                              `json:"MaxReplicas"`
+	CurrentReplicas int32                              `json:"CurrentReplicas"`
+	DesiredReplicas int32                              `json:"DesiredReplicas"`
+	LastScaleTime   int64                              `json:"LastScaleTime"`
+	Conditions      []HorizontalPodAutoscalerCondition `json:"Conditions"`
+	Events          *[]EventMessages                   `json:"Events"`
+}
+
+// HorizontalPodAutoscalerCondition describes the latest state of a horizontal pod autoscaler condition,
+// for example ScalingActive is false when the target metrics are unavailable
+type HorizontalPodAutoscalerCondition struct {
+	Type               string `json:"Type"`
+	Status             string `json:"Status"`
+	Reason             string `json:"Reason"`
+	Message            string `json:"Message"`
+	LastTransitionTime int64  `json:"LastTransitionTime"`
 }
 
 //Metrics describe the metrics data integration
@@ -119,3 +304,18 @@ type Alerts struct {
 	Provider string `json:"Provider"`
 	Tags     string `json:"Tags"`
 }
+
+// SuccessCriteria describe when the pods of a resource are considered as available for the apply to succeed
+type SuccessCriteria struct {
+	// MinReadySeconds is the time that a pod should stay ready, 0 when the pod is available once it is ready
+	MinReadySeconds int64 `json:"MinReadySeconds"`
+
+	// MinAvailablePercent is the percent of the desired pods that should be available
+	MinAvailablePercent int32 `json:"MinAvailablePercent"`
+
+	// IgnoreContainers are containers (for example sidecars) that are ignored when the pod readiness and restarts are checked
+	IgnoreContainers []string `json:"IgnoreContainers"`
+
+	// MaxRestarts is the max restarts count of a pod container, nil when the restarts are not limited
+	MaxRestarts *int32 `json:"MaxRestarts"`
+}
@@ -0,0 +1,102 @@
+package kuberneteswatcher
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// podStatusTerminated is the status of a pod that is being deleted
+const podStatusTerminated = "Terminated"
+
+// requiredPods returns the number of desired pods that should be available, rounded up
+func (sc *SuccessCriteria) requiredPods(desired int32) int32 {
+	return (desired*sc.MinAvailablePercent + 99) / 100
+}
+
+// isIgnored returns true when the container is ignored by the success criteria
+func (sc *SuccessCriteria) isIgnored(containerName string) bool {
+	for _, ignored := range sc.IgnoreContainers {
+		if ignored == containerName {
+			return true
+		}
+	}
+	return false
+}
+
+// unavailableReason returns the reason that not enough pods are available, empty when the available pods meet the criteria
+func (sc *SuccessCriteria) unavailableReason(pods map[string]DeploymenPod, desired int32, now time.Time) string {
+	available := int32(0)
+	for _, pod := range pods {
+		if isActivePod(pod) && sc.isPodAvailable(pod, now) {
+			available++
+		}
+	}
+	required := sc.requiredPods(desired)
+	if available >= required {
+		return ""
+	}
+	if sc.MinReadySeconds > 0 {
+		return fmt.Sprintf("%d of %d desired pods were ready for %d seconds, %d are required", available, desired, sc.MinReadySeconds, required)
+	}
+	return fmt.Sprintf("%d of %d desired pods are ready, %d are required", available, desired, required)
+}
+
+// exceededRestartsReason returns the first container that restarted more than the max restarts, empty when the restarts are
+// within the limit
+func (sc *SuccessCriteria) exceededRestartsReason(pods map[string]DeploymenPod) string {
+	if sc.MaxRestarts == nil {
+		return ""
+	}
+	podNames := []string{}
+	for podName := range pods {
+		podNames = append(podNames, podName)
+	}
+	sort.Strings(podNames)
+
+	for _, podName := range podNames {
+		pod := pods[podName]
+		if !isActivePod(pod) {
+			continue
+		}
+		for _, container := range pod.Containers {
+			if sc.isIgnored(container.Name) || container.RestartCount <= *sc.MaxRestarts {
+				continue
+			}
+			return fmt.Sprintf("container %s of pod %s restarted %d times, %d restarts are tolerated", container.Name, podName, container.RestartCount, *sc.MaxRestarts)
+		}
+	}
+	return ""
+}
+
+// isPodAvailable returns true when the containers that are not ignored are ready, and the pod is ready for the min ready
+// seconds. The ready time of the pod is reset when one of its containers restarted
+func (sc *SuccessCriteria) isPodAvailable(pod DeploymenPod, now time.Time) bool {
+	readySince := int64(0)
+	if pod.Timeline != nil {
+		readySince = pod.Timeline.Ready
+	}
+	for _, container := range pod.Containers {
+		if container.Type != ContainerTypeApp || sc.isIgnored(container.Name) {
+			continue
+		}
+		if !container.Ready {
+			return false
+		}
+		if container.StartedAt > readySince {
+			readySince = container.StartedAt
+		}
+	}
+	if readySince == 0 {
+		return false
+	}
+	return now.Sub(time.Unix(0, readySince)) >= time.Duration(sc.MinReadySeconds)*time.Second
+}
+
+// isActivePod returns false when the pod was terminated
+func isActivePod(pod DeploymenPod) bool {
+	if pod.Phase != nil && *pod.Phase == podStatusTerminated {
+		return false
+	}
+	return pod.Timeline == nil || pod.Timeline.Terminated == 0
+}
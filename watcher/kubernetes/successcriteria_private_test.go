@@ -0,0 +1,92 @@
+package kuberneteswatcher
+
+import (
+	"testing"
+	"time"
+)
+
+func successCriteriaPodMock(phase string, ready int64, containers ...PodContainerStatus) DeploymenPod {
+	return DeploymenPod{
+		Phase:      &phase,
+		Timeline:   &PodTimeline{Ready: ready},
+		Containers: containers,
+	}
+}
+
+func TestSuccessCriteriaPodAvailable(t *testing.T) {
+
+	now := time.Now()
+	readyAt := now.Add(-time.Minute).UnixNano()
+	criteria := &SuccessCriteria{MinReadySeconds: 30, MinAvailablePercent: 100, IgnoreContainers: []string{"istio-proxy"}}
+
+	testCases := []struct {
+		name     string
+		pod      DeploymenPod
+		expected bool
+	}{
+		{"ready_for_min_ready_seconds", successCriteriaPodMock("Running", readyAt, PodContainerStatus{Name: "app", Type: ContainerTypeApp, Ready: true, StartedAt: readyAt}), true},
+		{"ready_less_than_min_ready_seconds", successCriteriaPodMock("Running", now.Add(-10*time.Second).UnixNano(), PodContainerStatus{Name: "app", Type: ContainerTypeApp, Ready: true}), false},
+		{"restarted_container", successCriteriaPodMock("Running", readyAt, PodContainerStatus{Name: "app", Type: ContainerTypeApp, Ready: true, StartedAt: now.Add(-5 * time.Second).UnixNano()}), false},
+		{"container_not_ready", successCriteriaPodMock("Running", readyAt, PodContainerStatus{Name: "app", Type: ContainerTypeApp}), false},
+		{"ignored_container_not_ready", successCriteriaPodMock("Running", 0,
+			PodContainerStatus{Name: "app", Type: ContainerTypeApp, Ready: true, StartedAt: readyAt},
+			PodContainerStatus{Name: "istio-proxy", Type: ContainerTypeApp},
+		), true},
+		{"never_ready", successCriteriaPodMock("Pending", 0), false},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			available := criteria.isPodAvailable(test.pod, now)
+			if available != test.expected {
+				t.Fatalf("unexpected pod availability, got %t expected %t", available, test.expected)
+			}
+		})
+	}
+}
+
+func TestSuccessCriteriaReasons(t *testing.T) {
+
+	now := time.Now()
+	readyAt := now.Add(-time.Minute).UnixNano()
+	maxRestarts := int32(1)
+	criteria := &SuccessCriteria{MinAvailablePercent: 50, IgnoreContainers: []string{"istio-proxy"}, MaxRestarts: &maxRestarts}
+
+	pods := map[string]DeploymenPod{
+		"nginx-a": successCriteriaPodMock("Running", readyAt,
+			PodContainerStatus{Name: "app", Type: ContainerTypeApp, Ready: true},
+			PodContainerStatus{Name: "istio-proxy", Type: ContainerTypeApp, RestartCount: 5},
+		),
+		"nginx-b": successCriteriaPodMock("Running", 0, PodContainerStatus{Name: "app", Type: ContainerTypeApp, RestartCount: 1}),
+		"nginx-c": successCriteriaPodMock(podStatusTerminated, readyAt, PodContainerStatus{Name: "app", Type: ContainerTypeApp, Ready: true, RestartCount: 3}),
+	}
+
+	t.Run("required_pods", func(t *testing.T) {
+		if required := criteria.requiredPods(3); required != 2 {
+			t.Fatalf("unexpected required pods, got %d expected %d", required, 2)
+		}
+	})
+
+	t.Run("available_pods", func(t *testing.T) {
+		if reason := criteria.unavailableReason(pods, 2, now); reason != "" {
+			t.Fatalf("unexpected unavailable reason, got %s expected empty", reason)
+		}
+		if reason := criteria.unavailableReason(pods, 4, now); reason == "" {
+			t.Fatalf("unexpected empty unavailable reason")
+		}
+	})
+
+	t.Run("restarts_within_limit", func(t *testing.T) {
+		if reason := criteria.exceededRestartsReason(pods); reason != "" {
+			t.Fatalf("unexpected exceeded restarts reason, got %s expected empty", reason)
+		}
+	})
+
+	t.Run("exceeded_restarts", func(t *testing.T) {
+		pods["nginx-b"].Containers[0].RestartCount = 2
+		expected := "container app of pod nginx-b restarted 2 times, 1 restarts are tolerated"
+		if reason := criteria.exceededRestartsReason(pods); reason != expected {
+			t.Fatalf("unexpected exceeded restarts reason, got %s expected %s", reason, expected)
+		}
+	})
+}
@@ -16,6 +16,11 @@ type MockStorage struct {
 	MockUpdateDeployment  map[string]MockStorageDeployment
 	MockWriteDeployment   map[string]MockStorageDeployment
 	MockDeploymentHistory map[string]uint64
+	MockHashSchemes       map[string]int
+	MockResourceVersions  map[string]string
+	MockAppliedReplicas   map[string]int32
+	MockScaleEvents       []kuberneteswatcher.ScaleEvent
+	MockRunningApplies    map[string]kuberneteswatcher.DBSchema
 	MockFile              string
 }
 
@@ -24,6 +29,11 @@ func NewMockStorage() *MockStorage {
 		MockUpdateDeployment:  map[string]MockStorageDeployment{},
 		MockWriteDeployment:   map[string]MockStorageDeployment{},
 		MockDeploymentHistory: map[string]uint64{},
+		MockHashSchemes:       map[string]int{},
+		MockResourceVersions:  map[string]string{},
+		MockAppliedReplicas:   map[string]int32{},
+		MockScaleEvents:       []kuberneteswatcher.ScaleEvent{},
+		MockRunningApplies:    map[string]kuberneteswatcher.DBSchema{},
 	}
 }
 func (m *MockStorage) CreateApply(data *kuberneteswatcher.RegistryRow, status common.DeploymentStatus) (string, error) {
@@ -49,26 +59,62 @@ func (m *MockStorage) UpdateApply(applyID string, data *kuberneteswatcher.Regist
 	return true, nil
 }
 
-func (m *MockStorage) GetAppliesByStatus(status common.DeploymentStatus) (map[string]kuberneteswatcher.DBSchema, error) {
+func (m *MockStorage) GetAppliesByStatus(status common.DeploymentStatus, cluster string) (map[string]kuberneteswatcher.DBSchema, error) {
 
+	if status == common.ApplyStatusRunning {
+		return m.MockRunningApplies, nil
+	}
 	return map[string]kuberneteswatcher.DBSchema{}, nil
 
 }
 
-func (m *MockStorage) UpdateAppliesVersionHistory(deploymentName string, hash uint64) bool {
+func (m *MockStorage) UpdateAppliesVersionHistory(deploymentName string, hash uint64, legacyHash uint64) bool {
 
-	if _, ok := m.MockDeploymentHistory[deploymentName]; ok {
+	previous, ok := m.MockDeploymentHistory[deploymentName]
+	scheme := m.MockHashSchemes[deploymentName]
+	m.MockDeploymentHistory[deploymentName] = hash
+	m.MockHashSchemes[deploymentName] = kuberneteswatcher.ApplyHashScheme
 
-		return false
+	if ok && scheme < kuberneteswatcher.ApplyHashScheme {
+		return previous != legacyHash
 	}
-
-	m.MockDeploymentHistory[deploymentName] = hash
-	return true
+	return !ok || previous != hash
 
 }
 
 func (m *MockStorage) DeleteAppliedVersion(deploymentName string) bool {
 
+	delete(m.MockDeploymentHistory, deploymentName)
+	delete(m.MockHashSchemes, deploymentName)
+	delete(m.MockAppliedReplicas, deploymentName)
 	return true
 
 }
+
+func (m *MockStorage) UpdateAppliedReplicas(deploymentName string, replicas int32) (int32, bool) {
+
+	previous, ok := m.MockAppliedReplicas[deploymentName]
+	m.MockAppliedReplicas[deploymentName] = replicas
+	return previous, ok && previous != replicas
+
+}
+
+func (m *MockStorage) CreateScaleEvent(event kuberneteswatcher.ScaleEvent) error {
+
+	m.MockScaleEvents = append(m.MockScaleEvents, event)
+	return nil
+
+}
+
+func (m *MockStorage) GetResourceVersion(resource string) (string, error) {
+
+	return m.MockResourceVersions[resource], nil
+
+}
+
+func (m *MockStorage) UpdateResourceVersion(resource string, resourceVersion string) error {
+
+	m.MockResourceVersions[resource] = resourceVersion
+	return nil
+
+}
@@ -0,0 +1,216 @@
+package kuberneteswatcher
+
+import (
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	// RolloutPhaseFirstPodScheduled is the time from the apply start until the first new pod was scheduled
+	RolloutPhaseFirstPodScheduled = "first_pod_scheduled"
+
+	// RolloutPhaseFirstPodReady is the time from the apply start until the first new pod was ready
+	RolloutPhaseFirstPodReady = "first_pod_ready"
+
+	// RolloutPhaseAllPodsReady is the time from the apply start until all the new pods were ready
+	RolloutPhaseAllPodsReady = "all_pods_ready"
+
+	// RolloutPhaseOldPodsTerminated is the time from the apply start until all the pods that existed before the apply were terminated
+	RolloutPhaseOldPodsTerminated = "old_pods_terminated"
+)
+
+// imagePulledMessages are the kubelet event messages of a container image that is ready to run
+var imagePulledMessages = []string{"Successfully pulled image", "already present on machine"}
+
+// PodTimeline holds the times (unix nano) that the pod reached each readiness step, 0 when the step was not reached
+type PodTimeline struct {
+	Created         int64 `json:"Created"`
+	Scheduled       int64 `json:"Scheduled"`
+	ImagePulled     int64 `json:"ImagePulled"`
+	Initialized     int64 `json:"Initialized"`
+	ContainersReady int64 `json:"ContainersReady"`
+	Ready           int64 `json:"Ready"`
+	Terminated      int64 `json:"Terminated"`
+}
+
+// RolloutPhase describes how long the apply took to reach a rollout milestone
+type RolloutPhase struct {
+	Name string `json:"Name"`
+
+	// Start and End of the phase in unix nano, End is 0 when the phase was not completed
+	Start int64 `json:"Start"`
+	End   int64 `json:"End"`
+
+	// DurationSeconds is the phase duration, 0 when the phase was not completed
+	DurationSeconds float64 `json:"DurationSeconds"`
+}
+
+// newPodTimeline creates the timeline of a pod
+func newPodTimeline(pod *v1.Pod) *PodTimeline {
+	timeline := &PodTimeline{}
+	if created := pod.GetCreationTimestamp(); !created.IsZero() {
+		timeline.Created = created.Time.UnixNano()
+	}
+	timeline.update(pod)
+	return timeline
+}
+
+// update sets the steps that the pod reached from the pod conditions, the first transition of each step is kept
+func (pt *PodTimeline) update(pod *v1.Pod) {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Status != v1.ConditionTrue {
+			continue
+		}
+		if condition.LastTransitionTime.IsZero() {
+			continue
+		}
+		transitionTime := condition.LastTransitionTime.Time.UnixNano()
+		switch condition.Type {
+		case v1.PodScheduled:
+			setFirstTime(&pt.Scheduled, transitionTime)
+		case v1.PodInitialized:
+			setFirstTime(&pt.Initialized, transitionTime)
+		case v1.ContainersReady:
+			setFirstTime(&pt.ContainersReady, transitionTime)
+		case v1.PodReady:
+			setFirstTime(&pt.Ready, transitionTime)
+		}
+	}
+
+	// A pod is terminated once it was deleted and none of its containers is running
+	if pod.GetDeletionTimestamp() != nil && pt.Terminated == 0 {
+		for _, container := range pod.Status.ContainerStatuses {
+			if container.State.Running != nil {
+				return
+			}
+		}
+		pt.Terminated = time.Now().UnixNano()
+	}
+}
+
+// updateEvent sets the image pulled step from the kubelet events, the latest pulled image of the pod containers is kept
+func (pt *PodTimeline) updateEvent(event EventMessages) {
+	for _, message := range imagePulledMessages {
+		if strings.Contains(event.Message, message) && event.Time > pt.ImagePulled {
+			pt.ImagePulled = event.Time
+			return
+		}
+	}
+}
+
+// setFirstTime sets the value when it was not set before
+func setFirstTime(value *int64, t int64) {
+	if *value == 0 && t > 0 {
+		*value = t
+	}
+}
+
+// rolloutPhases computes the rollout phases of the apply pods. Pods that were created before the apply start are old pods
+func rolloutPhases(start time.Time, resources Resources) []RolloutPhase {
+	startTime := start.UnixNano()
+
+	newPods := []*PodTimeline{}
+	oldPods := []*PodTimeline{}
+	for _, pods := range resourcesPods(resources) {
+		for _, pod := range pods {
+			if pod.Timeline == nil {
+				continue
+			}
+			if time.Unix(0, pod.Timeline.Created).Unix() >= start.Unix() {
+				newPods = append(newPods, pod.Timeline)
+			} else {
+				oldPods = append(oldPods, pod.Timeline)
+			}
+		}
+	}
+
+	firstScheduled, firstReady, allReady := int64(0), int64(0), int64(0)
+	for _, pod := range newPods {
+		firstScheduled = minTime(firstScheduled, pod.Scheduled)
+		firstReady = minTime(firstReady, pod.Ready)
+	}
+
+	// New pods that were terminated during the apply (for example replaced after a failure) are not expected to be ready
+	runningPods := []*PodTimeline{}
+	for _, pod := range newPods {
+		if pod.Terminated == 0 {
+			runningPods = append(runningPods, pod)
+		}
+	}
+	if len(runningPods) > 0 {
+		allReady = maxCompletedTime(runningPods, func(pod *PodTimeline) int64 { return pod.Ready })
+	}
+
+	phases := []RolloutPhase{
+		newRolloutPhase(RolloutPhaseFirstPodScheduled, startTime, firstScheduled),
+		newRolloutPhase(RolloutPhaseFirstPodReady, startTime, firstReady),
+		newRolloutPhase(RolloutPhaseAllPodsReady, startTime, allReady),
+	}
+	if len(oldPods) > 0 {
+		oldTerminated := maxCompletedTime(oldPods, func(pod *PodTimeline) int64 { return pod.Terminated })
+		phases = append(phases, newRolloutPhase(RolloutPhaseOldPodsTerminated, startTime, oldTerminated))
+	}
+	return phases
+}
+
+// resourcesPods returns the pods of all the apply resources
+func resourcesPods(resources Resources) []map[string]DeploymenPod {
+	pods := []map[string]DeploymenPod{}
+	for _, deployment := range resources.Deployments {
+		pods = append(pods, deployment.Pods)
+	}
+	for _, daemonset := range resources.Daemonsets {
+		pods = append(pods, daemonset.Pods)
+	}
+	for _, statefulset := range resources.Statefulsets {
+		pods = append(pods, statefulset.Pods)
+	}
+	for _, job := range resources.Jobs {
+		pods = append(pods, job.Pods)
+	}
+	for _, customResource := range resources.CustomResources {
+		pods = append(pods, customResource.Pods)
+	}
+	return pods
+}
+
+// newRolloutPhase creates a rollout phase, a phase that ended before the apply start is reported with 0 duration
+func newRolloutPhase(name string, start, end int64) RolloutPhase {
+	phase := RolloutPhase{
+		Name:  name,
+		Start: start,
+		End:   end,
+	}
+	if end > start {
+		phase.DurationSeconds = time.Duration(end - start).Seconds()
+	}
+	return phase
+}
+
+// minTime returns the earliest time that was reached, 0 values were not reached
+func minTime(current, t int64) int64 {
+	if t == 0 {
+		return current
+	}
+	if current == 0 || t < current {
+		return t
+	}
+	return current
+}
+
+// maxCompletedTime returns the latest time of the step, 0 when one of the pods didn't reach the step
+func maxCompletedTime(pods []*PodTimeline, step func(pod *PodTimeline) int64) int64 {
+	latest := int64(0)
+	for _, pod := range pods {
+		t := step(pod)
+		if t == 0 {
+			return 0
+		}
+		if t > latest {
+			latest = t
+		}
+	}
+	return latest
+}
@@ -0,0 +1,134 @@
+package kuberneteswatcher
+
+import (
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// timelinePodMock returns a pod that was created at the given time with the given true conditions
+func timelinePodMock(created time.Time, conditions map[v1.PodConditionType]time.Time) *v1.Pod {
+	pod := &v1.Pod{
+		ObjectMeta: metaV1.ObjectMeta{
+			Name:              "pod",
+			CreationTimestamp: metaV1.NewTime(created),
+		},
+	}
+	for conditionType, transitionTime := range conditions {
+		pod.Status.Conditions = append(pod.Status.Conditions, v1.PodCondition{
+			Type:               conditionType,
+			Status:             v1.ConditionTrue,
+			LastTransitionTime: metaV1.NewTime(transitionTime),
+		})
+	}
+	return pod
+}
+
+func TestPodTimelineUpdate(t *testing.T) {
+
+	start := time.Unix(1000, 0)
+	pod := timelinePodMock(start, map[v1.PodConditionType]time.Time{
+		v1.PodScheduled: start.Add(time.Second),
+	})
+
+	timeline := newPodTimeline(pod)
+	if timeline.Created != start.UnixNano() {
+		t.Fatalf("unexpected created time, got %d expected %d", timeline.Created, start.UnixNano())
+	}
+	if timeline.Scheduled != start.Add(time.Second).UnixNano() {
+		t.Fatalf("unexpected scheduled time, got %d expected %d", timeline.Scheduled, start.Add(time.Second).UnixNano())
+	}
+	if timeline.Ready != 0 {
+		t.Fatalf("unexpected ready time, got %d expected 0", timeline.Ready)
+	}
+
+	// The first transition of each step is kept
+	timeline.update(timelinePodMock(start, map[v1.PodConditionType]time.Time{
+		v1.PodScheduled: start.Add(10 * time.Second),
+		v1.PodReady:     start.Add(5 * time.Second),
+	}))
+	if timeline.Scheduled != start.Add(time.Second).UnixNano() {
+		t.Fatalf("unexpected scheduled time after update, got %d expected %d", timeline.Scheduled, start.Add(time.Second).UnixNano())
+	}
+	if timeline.Ready != start.Add(5*time.Second).UnixNano() {
+		t.Fatalf("unexpected ready time after update, got %d expected %d", timeline.Ready, start.Add(5*time.Second).UnixNano())
+	}
+
+	timeline.updateEvent(EventMessages{Message: "Pulling image \"nginx\"", Time: start.Add(2 * time.Second).UnixNano()})
+	if timeline.ImagePulled != 0 {
+		t.Fatalf("unexpected image pulled time, got %d expected 0", timeline.ImagePulled)
+	}
+	timeline.updateEvent(EventMessages{Message: "Successfully pulled image \"nginx\"", Time: start.Add(3 * time.Second).UnixNano()})
+	if timeline.ImagePulled != start.Add(3*time.Second).UnixNano() {
+		t.Fatalf("unexpected image pulled time, got %d expected %d", timeline.ImagePulled, start.Add(3*time.Second).UnixNano())
+	}
+
+	deleted := metaV1.NewTime(start.Add(20 * time.Second))
+	pod.DeletionTimestamp = &deleted
+	pod.Status.ContainerStatuses = []v1.ContainerStatus{{State: v1.ContainerState{Running: &v1.ContainerStateRunning{}}}}
+	timeline.update(pod)
+	if timeline.Terminated != 0 {
+		t.Fatalf("unexpected terminated time of a running pod, got %d expected 0", timeline.Terminated)
+	}
+	pod.Status.ContainerStatuses = []v1.ContainerStatus{{State: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{}}}}
+	timeline.update(pod)
+	if timeline.Terminated == 0 {
+		t.Fatalf("expected terminated time to be set")
+	}
+}
+
+func TestRolloutPhases(t *testing.T) {
+
+	start := time.Unix(1000, 0)
+	at := func(seconds int) int64 {
+		return start.Add(time.Duration(seconds) * time.Second).UnixNano()
+	}
+
+	testCases := []struct {
+		name     string
+		pods     map[string]DeploymenPod
+		expected map[string]float64
+	}{
+		{"completed", map[string]DeploymenPod{
+			"old":      {Timeline: &PodTimeline{Created: at(-100), Scheduled: at(-99), Ready: at(-90), Terminated: at(30)}},
+			"new-1":    {Timeline: &PodTimeline{Created: at(1), Scheduled: at(2), Ready: at(10)}},
+			"new-2":    {Timeline: &PodTimeline{Created: at(1), Scheduled: at(3), Ready: at(20)}},
+			"replaced": {Timeline: &PodTimeline{Created: at(1), Terminated: at(5)}},
+		}, map[string]float64{
+			RolloutPhaseFirstPodScheduled: 2,
+			RolloutPhaseFirstPodReady:     10,
+			RolloutPhaseAllPodsReady:      20,
+			RolloutPhaseOldPodsTerminated: 30,
+		}},
+		{"not_ready", map[string]DeploymenPod{
+			"new-1":       {Timeline: &PodTimeline{Created: at(1), Scheduled: at(2), Ready: at(10)}},
+			"new-2":       {Timeline: &PodTimeline{Created: at(1), Scheduled: at(3)}},
+			"no-timeline": {},
+		}, map[string]float64{
+			RolloutPhaseFirstPodScheduled: 2,
+			RolloutPhaseFirstPodReady:     10,
+			RolloutPhaseAllPodsReady:      0,
+		}},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			resources := Resources{
+				Deployments: map[string]*DeploymentData{
+					"foo": {Pods: test.pods},
+				},
+			}
+			phases := rolloutPhases(start, resources)
+			if len(phases) != len(test.expected) {
+				t.Fatalf("unexpected rollout phases length, got %d expected %d", len(phases), len(test.expected))
+			}
+			for _, phase := range phases {
+				if phase.DurationSeconds != test.expected[phase.Name] {
+					t.Fatalf("unexpected %s duration, got %v expected %v", phase.Name, phase.DurationSeconds, test.expected[phase.Name])
+				}
+			}
+		})
+	}
+}
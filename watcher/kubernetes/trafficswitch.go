@@ -0,0 +1,361 @@
+package kuberneteswatcher
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mitchellh/hashstructure"
+	log "github.com/sirupsen/logrus"
+
+	appsV1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	eventwatch "k8s.io/apimachinery/pkg/watch"
+)
+
+// TrafficSwitchManager detects service selector changes, for example a blue/green cutover, and records them as applies
+type TrafficSwitchManager struct {
+	// Informer manager will be owner to watch the services
+	informerManager *InformerManager
+
+	// Event manager will be owner to start watch on the switched services events
+	eventManager *EventsManager
+
+	// Registry manager will be owner to manage the running / new traffic switch applies
+	registryManager *RegistryManager
+
+	// Max watch time
+	maxDeploymentTime int64
+
+	// Initial Running Applies to load on start
+	initialRunningApplies []*RegistryRow
+}
+
+// NewTrafficSwitchManager creates a new traffic switch manager
+func NewTrafficSwitchManager(informerManager *InformerManager, eventManager *EventsManager, registryManager *RegistryManager, runningApplies []*RegistryRow, maxDeploymentTime time.Duration) *TrafficSwitchManager {
+	return &TrafficSwitchManager{
+		informerManager:       informerManager,
+		eventManager:          eventManager,
+		registryManager:       registryManager,
+		maxDeploymentTime:     int64(maxDeploymentTime.Seconds()),
+		initialRunningApplies: runningApplies,
+	}
+}
+
+// Serve will start watching on the services selectors
+func (tsm *TrafficSwitchManager) Serve(ctx context.Context, wg *sync.WaitGroup) {
+	wg.Add(1)
+
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				log.Warn("traffic switch manager has been shut down")
+				wg.Done()
+				return
+			}
+		}
+	}()
+
+	// Continue watching on running traffic switches from storage state
+	runningApps := tsm.initialRunningApplies
+	log.WithField("running_apps", len(runningApps)).Debug("loaded running applications in traffic switch manager")
+	for _, application := range runningApps {
+		app := application
+		for _, trafficSwitchData := range application.DBSchema.Resources.TrafficSwitches {
+			tsData := trafficSwitchData
+			app.Log().Logger.WithField("name", tsData.GetName()).Debug("begining watching loaded running traffic switch")
+			go tsm.watchTrafficSwitch(app.ctx, app.Log(), app.DBSchema.WatchHealth, tsData, tsData.Metadata.Namespace, tsData.ProgressDeadlineSeconds)
+		}
+	}
+	// we dont need that anymore
+	tsm.initialRunningApplies = nil
+
+	tsm.watchServices(ctx)
+}
+
+// watchServices start watch on all Kubernetes services. The services are listed when the watch starts, so only the
+// selector changes that were applied while the watcher is running are detected
+func (tsm *TrafficSwitchManager) watchServices(ctx context.Context) {
+
+	servicesLog := log.WithField("resource", servicesResource.String())
+	watcher := tsm.informerManager.ResumableWatch(ctx, *servicesLog, nil, servicesResource, "", metaV1.ListOptions{})
+	selectors := map[string]map[string]string{}
+	go func() {
+		servicesLog.Info("starting traffic switch watcher")
+		for {
+			select {
+			case event, watch := <-watcher:
+				if !watch {
+					servicesLog.Info("traffic switch watcher was stopped, channel was closed")
+					return
+				}
+				service, ok := event.Object.(*v1.Service)
+				if !ok {
+					servicesLog.WithField("object", event.Object).Warn("failed to parse service watcher data")
+					continue
+				}
+
+				key := fmt.Sprintf("%s/%s", service.GetNamespace(), service.GetName())
+				if event.Type == eventwatch.Deleted {
+					delete(selectors, key)
+					continue
+				}
+				previous, found := selectors[key]
+				selectors[key] = service.Spec.Selector
+				if event.Type != eventwatch.Modified || !found || labels.Equals(previous, service.Spec.Selector) {
+					continue
+				}
+
+				// Services without a selector have manually managed endpoints, there are no pods to switch to
+				if len(service.Spec.Selector) == 0 {
+					continue
+				}
+				tsm.newTrafficSwitch(service, previous)
+
+			case <-ctx.Done():
+				servicesLog.Warn("traffic switch watcher was stopped, got ctx done signal")
+				return
+			}
+		}
+	}()
+}
+
+// newTrafficSwitch records the service selector change as an apply of the service application, when the application
+// has a running apply the traffic switch is added to it
+func (tsm *TrafficSwitchManager) newTrafficSwitch(service *v1.Service, previous map[string]string) {
+
+	hash, _ := hashstructure.Hash(service.Spec.Selector, nil)
+	apply := ApplyEvent{
+		Event:           fmt.Sprintf("%v", eventwatch.Modified),
+		ApplyName:       GetApplicationName(service.GetAnnotations(), service.GetName()),
+		ResourceName:    service.GetName(),
+		Namespace:       service.GetNamespace(),
+		Kind:            "service",
+		Hash:            hash,
+		Annotations:     service.GetAnnotations(),
+		Labels:          service.GetLabels(),
+		UID:             service.GetUID(),
+		ResourceVersion: service.GetResourceVersion(),
+	}
+
+	// The selector change lists the selected pods, it's created before the apply so the apply isn't checked without its traffic switch
+	change := newServiceSelectorChange(tsm.informerManager, service.GetNamespace(), previous, service.Spec.Selector)
+	appRegistry := tsm.registryManager.NewApplyEvent(apply)
+	if appRegistry == nil {
+		return
+	}
+	switchLog := appRegistry.Log()
+
+	switchLog.WithFields(log.Fields{
+		"service":  service.GetName(),
+		"workload": change.Workload,
+	}).Info("adding service traffic switch to apply registry")
+
+	appRegistry.AddSpecChanges("service", service.GetName(), mapSpecChanges(SpecChangeSelector, "", previous, service.Spec.Selector, false))
+	registryData := tsm.AddNewTrafficSwitch(apply, appRegistry, change)
+
+	go tsm.watchTrafficSwitch(
+		appRegistry.ctx,
+		switchLog,
+		appRegistry.DBSchema.WatchHealth,
+		registryData,
+		service.GetNamespace(),
+		GetProgressDeadlineApply(service.GetAnnotations(), tsm.maxDeploymentTime))
+}
+
+// watchTrafficSwitch will watch the endpoints and the events of the switched service
+func (tsm *TrafficSwitchManager) watchTrafficSwitch(ctx context.Context, lg log.Entry, health *WatchHealth, registryData *TrafficSwitchData, namespace string, maxWatchTime int64) {
+
+	switchLog := lg.WithField("service", registryData.GetName())
+	switchLog.Info("start watching traffic switch")
+
+	eventChan := tsm.eventManager.Watch(WatchEvents{
+		ListOptions: metaV1.ListOptions{FieldSelector: labels.SelectorFromSet(map[string]string{
+			"involvedObject.name": registryData.GetName(),
+			"involvedObject.kind": "Service",
+		}).String(),
+			TimeoutSeconds: &maxWatchTime,
+		},
+		Namespace:         namespace,
+		Ctx:               ctx,
+		LogEntry:          *switchLog,
+		WatchHealth:       health,
+		InvolvedObject:    registryData.Metadata.objectReference("Service"),
+		ApplyCreationTime: health.applyCreation(),
+	})
+
+	listOptions := metaV1.ListOptions{
+		TimeoutSeconds: &maxWatchTime,
+		FieldSelector:  fields.OneTermEqualSelector("metadata.name", registryData.GetName()).String(),
+	}
+	watcher := tsm.informerManager.ResumableWatch(ctx, *switchLog, health, endpointsResource, namespace, listOptions)
+	for {
+		select {
+		case event, watch := <-watcher:
+			if !watch {
+				switchLog.Debug("traffic switch endpoints watcher was stopped, channel was closed")
+				watcher = nil
+				continue
+			}
+			endpoints, isOk := event.Object.(*v1.Endpoints)
+			if !isOk {
+				switchLog.WithField("object", event.Object).Warn("failed to parse endpoints watcher data")
+				continue
+			}
+			// Deleted endpoints don't have addresses
+			if event.Type == eventwatch.Deleted {
+				endpoints = &v1.Endpoints{}
+			}
+			registryData.UpdateTrafficSwitchEndpoints(endpoints)
+		case event := <-eventChan:
+			registryData.UpdateTrafficSwitchEvents(event)
+		case <-ctx.Done():
+			switchLog.Debug("traffic switch watcher was stopped, got ctx done signal")
+			return
+		}
+	}
+}
+
+// AddNewTrafficSwitch add a new traffic switch under application settings
+func (tsm *TrafficSwitchManager) AddNewTrafficSwitch(data ApplyEvent, applicationRegistry *RegistryRow, change ServiceSelectorChange) *TrafficSwitchData {
+
+	log := applicationRegistry.Log()
+	tsd := &TrafficSwitchData{
+		Metadata: MetaData{
+			Name:            data.ResourceName,
+			Namespace:       data.Namespace,
+			Annotations:     data.Annotations,
+			Labels:          data.Labels,
+			UID:             data.UID,
+			ResourceVersion: data.ResourceVersion,
+			Metrics:         GetMetricsDataFromAnnotations(data.Annotations),
+			Alerts:          GetAlertsDataFromAnnotations(data.Annotations),
+		},
+		Switch:                  change,
+		Events:                  make([]EventMessages, 0),
+		Endpoints:               make([]EndpointsReadiness, 0),
+		ProgressDeadlineSeconds: GetProgressDeadlineApply(data.Annotations, tsm.maxDeploymentTime),
+	}
+	applicationRegistry.DBSchema.Resources.TrafficSwitches[data.ResourceName] = tsd
+
+	log.WithField("workload", change.Workload).Info("traffic switch was associated to the application")
+
+	return tsd
+}
+
+// newServiceSelectorChange creates the selector change with the pods that the new selector selects and their workload
+func newServiceSelectorChange(informerManager *InformerManager, namespace string, previous, selector map[string]string) ServiceSelectorChange {
+	change := ServiceSelectorChange{
+		Time:             time.Now().UnixNano(),
+		PreviousSelector: previous,
+		Selector:         selector,
+		Pods:             []string{},
+	}
+	if len(selector) == 0 {
+		return change
+	}
+
+	objects, err := informerManager.List(podsResource, namespace, metaV1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(selector).String(),
+	})
+	if err != nil {
+		log.WithError(err).WithField("namespace", namespace).Warn("could not list the pods of the service selector")
+		return change
+	}
+
+	workloads := map[string]int{}
+	for _, obj := range objects {
+		pod, ok := obj.(*v1.Pod)
+		if !ok {
+			continue
+		}
+		change.Pods = append(change.Pods, pod.GetName())
+		if workload := podWorkload(informerManager, pod); workload != "" {
+			workloads[workload]++
+		}
+	}
+	sort.Strings(change.Pods)
+	change.Workload = mostCommonWorkload(workloads)
+	return change
+}
+
+// podWorkload returns the workload that owns the pod, for example deployment/nginx. The pods of a replicaset are
+// owned by the deployment of the replicaset
+func podWorkload(informerManager *InformerManager, pod *v1.Pod) string {
+	owner := metaV1.GetControllerOf(pod)
+	if owner == nil {
+		return ""
+	}
+	if owner.Kind == "ReplicaSet" {
+		objects, err := informerManager.List(replicasetsResource, pod.GetNamespace(), metaV1.ListOptions{
+			FieldSelector: fields.OneTermEqualSelector("metadata.name", owner.Name).String(),
+		})
+		if err == nil && len(objects) > 0 {
+			if replicaset, ok := objects[0].(*appsV1.ReplicaSet); ok {
+				if deployment := metaV1.GetControllerOf(replicaset); deployment != nil {
+					return fmt.Sprintf("%s/%s", strings.ToLower(deployment.Kind), deployment.Name)
+				}
+			}
+		}
+	}
+	return fmt.Sprintf("%s/%s", strings.ToLower(owner.Kind), owner.Name)
+}
+
+// mostCommonWorkload returns the workload that owns most of the pods, ties are broken by the workload name
+func mostCommonWorkload(workloads map[string]int) string {
+	names := []string{}
+	for name := range workloads {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	workload := ""
+	for _, name := range names {
+		if workload == "" || workloads[name] > workloads[workload] {
+			workload = name
+		}
+	}
+	return workload
+}
+
+// endpointsReadyPods returns the names of the pods that the ready addresses of the endpoints target
+func endpointsReadyPods(endpoints *v1.Endpoints) map[string]bool {
+	pods := map[string]bool{}
+	for _, subset := range endpoints.Subsets {
+		for _, address := range subset.Addresses {
+			if address.TargetRef != nil && address.TargetRef.Kind == "Pod" {
+				pods[address.TargetRef.Name] = true
+			}
+		}
+	}
+	return pods
+}
+
+// updateEndpointsFlipped sets the time that the endpoints flipped, once there is a ready address and all the ready
+// addresses target the pods that were selected by the change
+func updateEndpointsFlipped(change *ServiceSelectorChange, endpoints *v1.Endpoints) {
+	if change.EndpointsFlipped > 0 {
+		return
+	}
+	readyPods := endpointsReadyPods(endpoints)
+	if len(readyPods) == 0 {
+		return
+	}
+	selected := map[string]bool{}
+	for _, pod := range change.Pods {
+		selected[pod] = true
+	}
+	for pod := range readyPods {
+		if !selected[pod] {
+			return
+		}
+	}
+	change.EndpointsFlipped = time.Now().UnixNano()
+}
@@ -0,0 +1,57 @@
+package kuberneteswatcher
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestMostCommonWorkload(t *testing.T) {
+	testsCases := []struct {
+		name      string
+		workloads map[string]int
+		expected  string
+	}{
+		{"empty", map[string]int{}, ""},
+		{"single", map[string]int{"deployment/green": 3}, "deployment/green"},
+		{"most_pods", map[string]int{"deployment/blue": 1, "deployment/green": 3}, "deployment/green"},
+		{"tie", map[string]int{"deployment/green": 2, "deployment/blue": 2}, "deployment/blue"},
+	}
+
+	for _, test := range testsCases {
+		t.Run(test.name, func(t *testing.T) {
+			if workload := mostCommonWorkload(test.workloads); workload != test.expected {
+				t.Fatalf("unexpected workload, got %s expected %s", workload, test.expected)
+			}
+		})
+	}
+}
+
+func TestUpdateEndpointsFlipped(t *testing.T) {
+	address := func(pod string) v1.EndpointAddress {
+		return v1.EndpointAddress{IP: pod, TargetRef: &v1.ObjectReference{Kind: "Pod", Name: pod}}
+	}
+
+	testsCases := []struct {
+		name     string
+		ready    []v1.EndpointAddress
+		notReady []v1.EndpointAddress
+		flipped  bool
+	}{
+		{"no_addresses", nil, nil, false},
+		{"only_not_ready", nil, []v1.EndpointAddress{address("green-1")}, false},
+		{"old_pods_ready", []v1.EndpointAddress{address("green-1"), address("blue-1")}, nil, false},
+		{"selected_pods_ready", []v1.EndpointAddress{address("green-1")}, []v1.EndpointAddress{address("green-2")}, true},
+	}
+
+	for _, test := range testsCases {
+		t.Run(test.name, func(t *testing.T) {
+			change := ServiceSelectorChange{Pods: []string{"green-1", "green-2"}}
+			endpoints := &v1.Endpoints{Subsets: []v1.EndpointSubset{{Addresses: test.ready, NotReadyAddresses: test.notReady}}}
+			updateEndpointsFlipped(&change, endpoints)
+			if flipped := change.EndpointsFlipped > 0; flipped != test.flipped {
+				t.Fatalf("unexpected flipped result, got %t expected %t", flipped, test.flipped)
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package kuberneteswatcher_test
+
+import (
+	"context"
+	kuberneteswatcher "statusbay/watcher/kubernetes"
+	"statusbay/watcher/kubernetes/testutil"
+	"sync"
+	"testing"
+	"time"
+
+	appsV1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func createWorkloadPodMock(client *fake.Clientset, namespace, deploymentName, color string) {
+	isController := true
+	replicaset := &appsV1.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      deploymentName + "-rs",
+			Namespace: namespace,
+			OwnerReferences: []metav1.OwnerReference{
+				{Kind: "Deployment", Name: deploymentName, Controller: &isController},
+			},
+		},
+	}
+	client.AppsV1().ReplicaSets(namespace).Create(replicaset)
+
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      deploymentName + "-pod",
+			Namespace: namespace,
+			Labels:    map[string]string{"color": color},
+			OwnerReferences: []metav1.OwnerReference{
+				{Kind: "ReplicaSet", Name: replicaset.GetName(), Controller: &isController},
+			},
+		},
+	}
+	client.CoreV1().Pods(namespace).Create(pod)
+}
+
+func NewTrafficSwitchManagerMock(client *fake.Clientset) (*kuberneteswatcher.TrafficSwitchManager, *testutil.MockStorage) {
+	maxDeploymentTime, _ := time.ParseDuration("10m")
+	eventManager := NewEventsMock(client)
+	registryManager, storage := NewRegistryMock()
+	runningApplies := registryManager.LoadRunningApplies()
+	trafficSwitchManager := kuberneteswatcher.NewTrafficSwitchManager(NewInformerManagerMock(client), eventManager, registryManager, runningApplies, maxDeploymentTime)
+
+	var wg sync.WaitGroup
+	ctx := context.Background()
+
+	eventManager.Serve(ctx, &wg)
+	trafficSwitchManager.Serve(ctx, &wg)
+
+	return trafficSwitchManager, storage
+}
+
+func TestTrafficSwitchWatch(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	namespace := "default"
+
+	createWorkloadPodMock(client, namespace, "web-blue", "blue")
+	createWorkloadPodMock(client, namespace, "web-green", "green")
+	service := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "web",
+			Namespace: namespace,
+		},
+		Spec: v1.ServiceSpec{
+			Selector: map[string]string{"color": "blue"},
+		},
+	}
+	client.CoreV1().Services(namespace).Create(service)
+
+	_, storage := NewTrafficSwitchManagerMock(client)
+	time.Sleep(time.Second)
+
+	// Switch the traffic from the blue deployment to the green deployment
+	service.Spec.Selector = map[string]string{"color": "green"}
+	client.CoreV1().Services(namespace).Update(service)
+	time.Sleep(time.Second)
+
+	endpoints := &v1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "web",
+			Namespace: namespace,
+		},
+		Subsets: []v1.EndpointSubset{
+			{Addresses: []v1.EndpointAddress{
+				{IP: "10.0.0.2", TargetRef: &v1.ObjectReference{Kind: "Pod", Name: "web-green-pod"}},
+			}},
+		},
+	}
+	client.CoreV1().Endpoints(namespace).Create(endpoints)
+	time.Sleep(time.Second * 2)
+
+	application, found := storage.MockWriteDeployment["1"]
+	if !found {
+		t.Fatalf("traffic switch apply was not created")
+	}
+	trafficSwitch, found := application.Schema.Resources.TrafficSwitches["web"]
+	if !found {
+		t.Fatalf("traffic switch not found in application resources")
+	}
+
+	t.Run("selector", func(t *testing.T) {
+		if trafficSwitch.Switch.PreviousSelector["color"] != "blue" || trafficSwitch.Switch.Selector["color"] != "green" {
+			t.Fatalf("unexpected selector change, got %v to %v", trafficSwitch.Switch.PreviousSelector, trafficSwitch.Switch.Selector)
+		}
+	})
+
+	t.Run("workload", func(t *testing.T) {
+		if trafficSwitch.Switch.Workload != "deployment/web-green" {
+			t.Fatalf("unexpected workload, got %s expected %s", trafficSwitch.Switch.Workload, "deployment/web-green")
+		}
+		if len(trafficSwitch.Switch.Pods) != 1 || trafficSwitch.Switch.Pods[0] != "web-green-pod" {
+			t.Fatalf("unexpected pods, got %v", trafficSwitch.Switch.Pods)
+		}
+	})
+
+	t.Run("endpoints_flipped", func(t *testing.T) {
+		if trafficSwitch.Switch.EndpointsFlipped == 0 {
+			t.Fatalf("expected the endpoints to be flipped")
+		}
+		if len(trafficSwitch.Endpoints) != 1 || trafficSwitch.Endpoints[0].Ready != 1 {
+			t.Fatalf("unexpected endpoints samples, got %v", trafficSwitch.Endpoints)
+		}
+	})
+}